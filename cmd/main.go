@@ -7,10 +7,13 @@ import (
 	"io"
 	"log"
 	"os"
+	"path/filepath"
 	"time"
 
+	"github.com/i2y/d2mcp/internal/domain/entity"
 	"github.com/i2y/d2mcp/internal/infrastructure/d2"
 	"github.com/i2y/d2mcp/internal/infrastructure/mcp"
+	"github.com/i2y/d2mcp/internal/infrastructure/output"
 	"github.com/i2y/d2mcp/internal/presentation/handler"
 	"github.com/i2y/d2mcp/internal/usecase"
 )
@@ -36,6 +39,15 @@ func main() {
 		endpointPath      string
 		heartbeatInterval int
 		stateless         bool
+		historyDepth      int
+		store             string
+		workspaceDir      string
+		idleTTL           time.Duration
+		maxDiagrams       int
+		maxTotalSize      int64
+		outputDir         string
+		overwrite         string
+		inputDir          string
 	)
 	flag.StringVar(&transport, "transport", "sse", "Transport mode: stdio, sse, or streamable")
 	flag.StringVar(&addr, "addr", ":3000", "Address to listen on for SSE/Streamable HTTP transport (e.g., :3000)")
@@ -45,6 +57,17 @@ func main() {
 	flag.StringVar(&endpointPath, "endpoint-path", "/mcp", "Endpoint path for Streamable HTTP transport")
 	flag.IntVar(&heartbeatInterval, "heartbeat-interval", 30, "Heartbeat interval in seconds for Streamable HTTP")
 	flag.BoolVar(&stateless, "stateless", false, "Enable stateless mode for Streamable HTTP")
+	flag.IntVar(&historyDepth, "history-depth", 50, "Number of changes per diagram that can be undone (0 disables undo)")
+	flag.StringVar(&store, "store", "memory", "Diagram store: memory, or disk to keep diagrams in the workspace directory across restarts")
+	flag.StringVar(&workspaceDir, "workspace", "", "Workspace directory for -store disk (default ~/.d2mcp/workspace)")
+	flag.DurationVar(&idleTTL, "idle-ttl", 0, "Evict diagrams unchanged for this long, e.g. 24h (0 keeps them)")
+	flag.IntVar(&maxDiagrams, "max-diagrams", 0, "Maximum number of stored diagrams, evicting the least recently changed beyond it (0 means no limit)")
+	flag.Int64Var(&maxTotalSize, "max-total-size", 0, "Maximum total size in bytes of stored diagrams, evicting the least recently changed beyond it (0 means no limit)")
+	// The file access settings can also come from the environment, such as the env
+	// block of an MCP client configuration; flags take precedence.
+	flag.StringVar(&outputDir, "output-dir", os.Getenv("D2MCP_OUTPUT_DIR"), "Directory d2_save writes to; paths outside of it are rejected (env D2MCP_OUTPUT_DIR, default $TMPDIR/d2mcp_output)")
+	flag.StringVar(&overwrite, "overwrite", envOr("D2MCP_OVERWRITE", "always"), "What d2_save does with existing files: always (replace), never (fail), or rename (save under a numbered name) (env D2MCP_OVERWRITE)")
+	flag.StringVar(&inputDir, "input-dir", os.Getenv("D2MCP_INPUT_DIR"), "Directory d2_load_file reads .d2 files and their imports from; paths outside of it are rejected (env D2MCP_INPUT_DIR, default: loading files is disabled)")
 	flag.Parse()
 
 	// Validate transport mode.
@@ -53,6 +76,19 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Validate store.
+	if store != "memory" && store != "disk" {
+		fmt.Fprintf(os.Stderr, "Invalid store: %s. Must be 'memory' or 'disk'\n", store)
+		os.Exit(1)
+	}
+
+	// Validate overwrite policy.
+	overwritePolicy, err := output.ParseOverwritePolicy(overwrite)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid overwrite policy: %s. Must be 'never', 'always', or 'rename'\n", overwrite)
+		os.Exit(1)
+	}
+
 	// Set up logging based on transport mode
 	if transport == "stdio" {
 		// In STDIO mode, log to file to avoid any interference with stdio communication
@@ -80,11 +116,51 @@ func main() {
 	ctx := context.Background()
 
 	// Initialize repository.
-	oracleRepo := d2.NewD2OracleRepository()
+	repoOptions := []d2.OracleOption{
+		d2.WithHistoryDepth(historyDepth),
+		d2.WithIdleTTL(idleTTL),
+		d2.WithMaxDiagrams(maxDiagrams),
+		d2.WithMaxTotalSize(maxTotalSize),
+		d2.WithInputDir(inputDir),
+	}
+	var oracleRepo *d2.D2OracleRepository
+	switch store {
+	case "memory":
+		oracleRepo = d2.NewD2OracleRepository(repoOptions...)
+	case "disk":
+		if workspaceDir == "" {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				log.Fatalf("Failed to locate the default workspace: %v", err)
+			}
+			workspaceDir = filepath.Join(home, ".d2mcp", "workspace")
+		}
+
+		repo, err := d2.NewD2FileRepository(workspaceDir, repoOptions...)
+		if err != nil {
+			log.Fatalf("Failed to open workspace: %v", err)
+		}
+		oracleRepo = repo
+		log.Printf("Storing diagrams in %s", workspaceDir)
+	}
+
+	// Initialize output directory.
+	if outputDir == "" {
+		outputDir = filepath.Join(os.TempDir(), "d2mcp_output")
+	}
+	outputRoot, err := output.NewDir(outputDir, overwritePolicy)
+	if err != nil {
+		log.Fatalf("Failed to open output directory: %v", err)
+	}
+	log.Printf("Saving diagrams to %s (overwrite: %s)", outputRoot.Path(), overwritePolicy)
 
 	// Initialize usecases.
 	diagramUseCase := usecase.NewDiagramUseCase(oracleRepo)
 	oracleUseCase := usecase.NewOracleUseCase(oracleRepo)
+	boardUseCase := usecase.NewBoardUseCase(oracleRepo)
+	snapshotUseCase := usecase.NewSnapshotUseCase(oracleRepo)
+	diffUseCase := usecase.NewDiffUseCase(oracleRepo, oracleRepo)
+	branchUseCase := usecase.NewBranchUseCase(oracleRepo, oracleRepo)
 
 	// Initialize MCP server.
 	server, err := mcp.NewServer(ServerName, ServerVersion)
@@ -135,8 +211,13 @@ func main() {
 
 	// Initialize handlers.
 	createHandler := handler.NewCreateHandler(diagramUseCase)
-	exportHandler := handler.NewExportHandler(diagramUseCase)
-	saveHandler := handler.NewSaveHandler(diagramUseCase)
+	exportHandler := handler.NewExportHandler(diagramUseCase, diffUseCase)
+	saveHandler := handler.NewSaveHandler(diagramUseCase, diffUseCase, outputRoot)
+	listHandler := handler.NewListHandler(diagramUseCase)
+	deleteHandler := handler.NewDeleteHandler(diagramUseCase)
+	cloneHandler := handler.NewCloneHandler(diagramUseCase)
+	renameDiagramHandler := handler.NewRenameDiagramHandler(diagramUseCase)
+	loadFileHandler := handler.NewLoadFileHandler(oracleUseCase)
 
 	// Initialize Oracle handlers.
 	oracleCreateHandler := handler.NewOracleCreateHandler(oracleUseCase)
@@ -146,6 +227,42 @@ func main() {
 	oracleRenameHandler := handler.NewOracleRenameHandler(oracleUseCase)
 	oracleGetHandler := handler.NewOracleGetHandler(oracleUseCase)
 	oracleSerializeHandler := handler.NewOracleSerializeHandler(oracleUseCase)
+	oracleBatchHandler := handler.NewOracleBatchHandler(oracleUseCase)
+	undoHandler := handler.NewUndoHandler(oracleUseCase)
+	redoHandler := handler.NewRedoHandler(oracleUseCase)
+
+	// Initialize board handlers.
+	boardCreateHandler := handler.NewBoardCreateHandler(boardUseCase)
+	boardListHandler := handler.NewBoardListHandler(boardUseCase)
+	boardDeleteHandler := handler.NewBoardDeleteHandler(boardUseCase)
+	boardMoveHandler := handler.NewBoardMoveHandler(boardUseCase)
+
+	// Initialize snapshot handlers.
+	snapshotCreateHandler := handler.NewSnapshotCreateHandler(snapshotUseCase)
+	snapshotListHandler := handler.NewSnapshotListHandler(snapshotUseCase)
+	snapshotRestoreHandler := handler.NewSnapshotRestoreHandler(snapshotUseCase)
+	snapshotDeleteHandler := handler.NewSnapshotDeleteHandler(snapshotUseCase)
+	diffHandler := handler.NewDiffHandler(diffUseCase)
+
+	// Initialize branch handlers.
+	branchCreateHandler := handler.NewBranchCreateHandler(branchUseCase)
+	branchListHandler := handler.NewBranchListHandler(branchUseCase)
+	branchMergeHandler := handler.NewBranchMergeHandler(branchUseCase)
+
+	// Initialize resource handlers.
+	diagramsResourceHandler := handler.NewDiagramsResourceHandler(diagramUseCase)
+	sourceResourceHandler := handler.NewSourceResourceHandler(oracleUseCase)
+	svgResourceHandler := handler.NewSVGResourceHandler(diagramUseCase)
+	graphResourceHandler := handler.NewGraphResourceHandler(oracleUseCase)
+	pdfResourceHandler := handler.NewDocumentResourceHandler(diagramUseCase, entity.FormatPDF)
+	pptxResourceHandler := handler.NewDocumentResourceHandler(diagramUseCase, entity.FormatPPTX)
+	diagramResourceNotifier := handler.NewDiagramResourceNotifier(server, sourceResourceHandler)
+
+	// Initialize prompt handlers.
+	architecturePromptHandler := handler.NewArchitecturePromptHandler(oracleUseCase)
+	erdPromptHandler := handler.NewERDPromptHandler(oracleUseCase)
+	sequencePromptHandler := handler.NewSequencePromptHandler(oracleUseCase)
+	refinePromptHandler := handler.NewRefinePromptHandler(oracleUseCase)
 
 	// Register tools.
 	if err := server.RegisterTool(createHandler.GetTool(), createHandler.GetHandler()); err != nil {
@@ -157,6 +274,21 @@ func main() {
 	if err := server.RegisterTool(saveHandler.GetTool(), saveHandler.GetHandler()); err != nil {
 		log.Fatalf("Failed to register save tool: %v", err)
 	}
+	if err := server.RegisterTool(listHandler.GetTool(), listHandler.GetHandler()); err != nil {
+		log.Fatalf("Failed to register list tool: %v", err)
+	}
+	if err := server.RegisterTool(deleteHandler.GetTool(), deleteHandler.GetHandler()); err != nil {
+		log.Fatalf("Failed to register delete tool: %v", err)
+	}
+	if err := server.RegisterTool(cloneHandler.GetTool(), cloneHandler.GetHandler()); err != nil {
+		log.Fatalf("Failed to register clone tool: %v", err)
+	}
+	if err := server.RegisterTool(renameDiagramHandler.GetTool(), renameDiagramHandler.GetHandler()); err != nil {
+		log.Fatalf("Failed to register rename diagram tool: %v", err)
+	}
+	if err := server.RegisterTool(loadFileHandler.GetTool(), loadFileHandler.GetHandler()); err != nil {
+		log.Fatalf("Failed to register load file tool: %v", err)
+	}
 
 	// Register Oracle tools.
 	if err := server.RegisterTool(oracleCreateHandler.GetTool(), oracleCreateHandler.GetHandler()); err != nil {
@@ -180,6 +312,107 @@ func main() {
 	if err := server.RegisterTool(oracleSerializeHandler.GetTool(), oracleSerializeHandler.GetHandler()); err != nil {
 		log.Fatalf("Failed to register oracle serialize tool: %v", err)
 	}
+	if err := server.RegisterTool(oracleBatchHandler.GetTool(), oracleBatchHandler.GetHandler()); err != nil {
+		log.Fatalf("Failed to register oracle batch tool: %v", err)
+	}
+	if err := server.RegisterTool(undoHandler.GetTool(), undoHandler.GetHandler()); err != nil {
+		log.Fatalf("Failed to register undo tool: %v", err)
+	}
+	if err := server.RegisterTool(redoHandler.GetTool(), redoHandler.GetHandler()); err != nil {
+		log.Fatalf("Failed to register redo tool: %v", err)
+	}
+
+	// Register board tools.
+	if err := server.RegisterTool(boardCreateHandler.GetTool(), boardCreateHandler.GetHandler()); err != nil {
+		log.Fatalf("Failed to register board create tool: %v", err)
+	}
+	if err := server.RegisterTool(boardListHandler.GetTool(), boardListHandler.GetHandler()); err != nil {
+		log.Fatalf("Failed to register board list tool: %v", err)
+	}
+	if err := server.RegisterTool(boardDeleteHandler.GetTool(), boardDeleteHandler.GetHandler()); err != nil {
+		log.Fatalf("Failed to register board delete tool: %v", err)
+	}
+	if err := server.RegisterTool(boardMoveHandler.GetTool(), boardMoveHandler.GetHandler()); err != nil {
+		log.Fatalf("Failed to register board move tool: %v", err)
+	}
+
+	// Register snapshot tools.
+	if err := server.RegisterTool(snapshotCreateHandler.GetTool(), snapshotCreateHandler.GetHandler()); err != nil {
+		log.Fatalf("Failed to register snapshot create tool: %v", err)
+	}
+	if err := server.RegisterTool(snapshotListHandler.GetTool(), snapshotListHandler.GetHandler()); err != nil {
+		log.Fatalf("Failed to register snapshot list tool: %v", err)
+	}
+	if err := server.RegisterTool(snapshotRestoreHandler.GetTool(), snapshotRestoreHandler.GetHandler()); err != nil {
+		log.Fatalf("Failed to register snapshot restore tool: %v", err)
+	}
+	if err := server.RegisterTool(snapshotDeleteHandler.GetTool(), snapshotDeleteHandler.GetHandler()); err != nil {
+		log.Fatalf("Failed to register snapshot delete tool: %v", err)
+	}
+	if err := server.RegisterTool(diffHandler.GetTool(), diffHandler.GetHandler()); err != nil {
+		log.Fatalf("Failed to register diff tool: %v", err)
+	}
+
+	// Register branch tools.
+	if err := server.RegisterTool(branchCreateHandler.GetTool(), branchCreateHandler.GetHandler()); err != nil {
+		log.Fatalf("Failed to register branch create tool: %v", err)
+	}
+	if err := server.RegisterTool(branchListHandler.GetTool(), branchListHandler.GetHandler()); err != nil {
+		log.Fatalf("Failed to register branch list tool: %v", err)
+	}
+	if err := server.RegisterTool(branchMergeHandler.GetTool(), branchMergeHandler.GetHandler()); err != nil {
+		log.Fatalf("Failed to register branch merge tool: %v", err)
+	}
+
+	// Register resources.
+	if err := server.RegisterResource(diagramsResourceHandler.GetResource(), diagramsResourceHandler.GetHandler()); err != nil {
+		log.Fatalf("Failed to register diagrams resource: %v", err)
+	}
+	if err := server.RegisterResourceTemplate(sourceResourceHandler.GetResourceTemplate(), sourceResourceHandler.GetHandler()); err != nil {
+		log.Fatalf("Failed to register diagram source resource: %v", err)
+	}
+	if err := server.RegisterResourceTemplate(svgResourceHandler.GetResourceTemplate(), svgResourceHandler.GetHandler()); err != nil {
+		log.Fatalf("Failed to register diagram SVG resource: %v", err)
+	}
+	if err := server.RegisterResourceTemplate(graphResourceHandler.GetResourceTemplate(), graphResourceHandler.GetHandler()); err != nil {
+		log.Fatalf("Failed to register diagram graph resource: %v", err)
+	}
+	if err := server.RegisterResourceTemplate(pdfResourceHandler.GetResourceTemplate(), pdfResourceHandler.GetHandler()); err != nil {
+		log.Fatalf("Failed to register diagram PDF resource: %v", err)
+	}
+	if err := server.RegisterResourceTemplate(pptxResourceHandler.GetResourceTemplate(), pptxResourceHandler.GetHandler()); err != nil {
+		log.Fatalf("Failed to register diagram PPTX resource: %v", err)
+	}
+
+	// Register prompts.
+	if err := server.RegisterPrompt(architecturePromptHandler.GetPrompt(), architecturePromptHandler.GetHandler()); err != nil {
+		log.Fatalf("Failed to register architecture prompt: %v", err)
+	}
+	if err := server.RegisterPrompt(erdPromptHandler.GetPrompt(), erdPromptHandler.GetHandler()); err != nil {
+		log.Fatalf("Failed to register ERD prompt: %v", err)
+	}
+	if err := server.RegisterPrompt(sequencePromptHandler.GetPrompt(), sequencePromptHandler.GetHandler()); err != nil {
+		log.Fatalf("Failed to register sequence prompt: %v", err)
+	}
+	if err := server.RegisterPrompt(refinePromptHandler.GetPrompt(), refinePromptHandler.GetHandler()); err != nil {
+		log.Fatalf("Failed to register refine prompt: %v", err)
+	}
+
+	// Register each stored diagram as a resource and notify sessions following it after every change.
+	oracleRepo.OnChange(diagramResourceNotifier.DiagramChanged)
+	diagrams, err := oracleRepo.ListDiagrams(ctx)
+	if err != nil {
+		log.Fatalf("Failed to list stored diagrams: %v", err)
+	}
+	for _, diagram := range diagrams {
+		diagramResourceNotifier.DiagramChanged(diagram.ID)
+	}
+	oracleRepo.OnRemove(diagramResourceNotifier.DiagramRemoved)
+
+	// Evict idle diagrams in the background.
+	if idleTTL > 0 {
+		go oracleRepo.RunEviction(ctx, min(idleTTL, time.Minute))
+	}
 
 	// Start the server.
 	log.Printf("Starting %s v%s MCP server...", ServerName, ServerVersion)
@@ -187,3 +420,12 @@ func main() {
 		log.Fatalf("Server error: %v", err)
 	}
 }
+
+// envOr returns the value of the environment variable name, or fallback when it
+// is unset or empty.
+func envOr(name, fallback string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return fallback
+}
@@ -0,0 +1,35 @@
+package entity
+
+// BoardKind identifies how a board is nested in its parent.
+type BoardKind string
+
+const (
+	// BoardLayer is a board declared under layers. Layers start from a blank canvas.
+	BoardLayer BoardKind = "layer"
+	// BoardScenario is a board declared under scenarios. Scenarios inherit from their parent.
+	BoardScenario BoardKind = "scenario"
+	// BoardStep is a board declared under steps. Steps inherit from the previous step.
+	BoardStep BoardKind = "step"
+)
+
+// Keyword returns the D2 keyword under which boards of this kind are declared.
+func (k BoardKind) Keyword() string {
+	switch k {
+	case BoardLayer:
+		return "layers"
+	case BoardScenario:
+		return "scenarios"
+	case BoardStep:
+		return "steps"
+	default:
+		return ""
+	}
+}
+
+// Board represents a layer, scenario or step board of a diagram.
+type Board struct {
+	Name     string
+	Kind     BoardKind
+	Path     []string // Board names from the root board down to this board
+	Children []*Board
+}
@@ -0,0 +1,50 @@
+package entity
+
+import "time"
+
+// Branch is a stored diagram forked from another diagram, so that both can be
+// edited independently and the branch merged back later.
+type Branch struct {
+	ID        string // ID of the branch diagram
+	DiagramID string // ID of the diagram the branch was forked from
+	Base      string // D2 source at the fork point, moved forward by every merge
+	CreatedAt time.Time
+}
+
+// MergeConflictKind identifies why a change could not be merged.
+type MergeConflictKind string
+
+const (
+	// ConflictAttribute is an attribute set to different values on both sides.
+	ConflictAttribute MergeConflictKind = "attribute"
+	// ConflictDeleteModify is an element deleted on one side and modified on the other.
+	ConflictDeleteModify MergeConflictKind = "delete_modify"
+	// ConflictMissingEndpoint is a connection added on one side to an object deleted on the other.
+	ConflictMissingEndpoint MergeConflictKind = "missing_endpoint"
+	// ConflictBoard is a board added or deleted in the branch, which a merge cannot do.
+	ConflictBoard MergeConflictKind = "board"
+)
+
+// MergeConflict is a change that the merge could not apply on its own. A
+// delete/modify conflict without an attribute is about objects or connections
+// added inside or to a deleted element, and Target or Branch lists their IDs.
+type MergeConflict struct {
+	Kind      MergeConflictKind
+	BoardPath []string    // Board of the element; empty for the root board
+	ElementID string      // For board conflicts, the name of the board
+	Attribute string      // Name of the conflicting attribute, for attribute and delete/modify conflicts
+	Base      interface{} // Value at the fork point; nil when unset
+	Target    interface{} // Value in the diagram merged into; nil when unset or deleted
+	Branch    interface{} // Value in the branch; nil when unset or deleted
+	Message   string
+}
+
+// MergeResult represents the outcome of merging a branch.
+type MergeResult struct {
+	BranchID   string
+	DiagramID  string
+	Merged     bool  // False when conflicts stopped the merge and nothing was applied
+	Operations int   // Number of operations applied to the diagram
+	Revision   int64 // Revision of the diagram after the merge
+	Conflicts  []*MergeConflict
+}
@@ -1,11 +1,23 @@
 package entity
 
+import "time"
+
 // Diagram represents a D2 diagram entity.
 type Diagram struct {
 	ID      string
 	Content string
 	Format  ExportFormat
 	Theme   *Theme
+	Options *RenderOptions // Defaults applied whenever the diagram is exported
+}
+
+// DiagramInfo describes a stored diagram.
+type DiagramInfo struct {
+	ID       string
+	Elements int       // Objects and connections in the diagram's root board
+	Size     int       // Length of the D2 source in bytes
+	Revision int64     // Increased by one on every change
+	Modified time.Time // Time of the last change, or when the diagram was loaded from disk
 }
 
 // ExportFormat represents the output format for diagram export.
@@ -18,8 +30,80 @@ const (
 	FormatPNG ExportFormat = "png"
 	// FormatPDF represents PDF export format.
 	FormatPDF ExportFormat = "pdf"
+	// FormatPPTX represents PowerPoint export format with a slide per board.
+	FormatPPTX ExportFormat = "pptx"
+	// FormatAnimatedSVG represents an SVG that cycles through the diagram's steps.
+	FormatAnimatedSVG ExportFormat = "animated_svg"
+	// FormatGIF represents an animated GIF of the diagram's steps.
+	FormatGIF ExportFormat = "gif"
+)
+
+// LayoutEngine identifies a D2 layout engine.
+type LayoutEngine string
+
+const (
+	// LayoutDagre is D2's default hierarchical layout engine.
+	LayoutDagre LayoutEngine = "dagre"
+	// LayoutELK is the Eclipse Layout Kernel, better suited to container-heavy diagrams.
+	LayoutELK LayoutEngine = "elk"
+)
+
+// EdgeRouting identifies how edges are drawn between the shapes they connect.
+type EdgeRouting string
+
+const (
+	// RoutingOrthogonal draws edges as horizontal and vertical segments. ELK only.
+	RoutingOrthogonal EdgeRouting = "orthogonal"
+	// RoutingSplines draws edges as smooth curves along the routes of the layout engine.
+	RoutingSplines EdgeRouting = "splines"
+	// RoutingStraight draws every edge as a single straight line between its shapes.
+	RoutingStraight EdgeRouting = "straight"
 )
 
+// LayoutOptions controls how a diagram is laid out.
+type LayoutOptions struct {
+	Engine      LayoutEngine
+	Direction   string      // up, down, left or right; empty keeps the diagram's own direction
+	NodeSpacing int         // Spacing between nodes in pixels; 0 keeps the engine default
+	EdgeSpacing int         // Spacing between edges (dagre) or between edges and nodes (ELK); 0 keeps the engine default
+	Routing     EdgeRouting // Empty keeps the engine default: splines for dagre, orthogonal for ELK
+}
+
+// RenderOptions controls how a diagram is laid out and rendered.
+// Nil fields fall back to the diagram's stored defaults, then to D2's own defaults.
+type RenderOptions struct {
+	Theme     *Theme
+	DarkTheme *Theme   // Used when the viewer prefers a dark color scheme (SVG only)
+	Sketch    *bool    // Render with a hand-drawn look
+	Pad       *int     // Padding around the diagram in pixels
+	Center    *bool    // Center the diagram in the SVG viewport
+	Scale     *float64 // Fixed output scale; nil fits the diagram to its viewport
+	Layout    *LayoutOptions
+	Raster    *RasterOptions // Image size and converter for PNG output
+	TOC       *bool          // Start PDF output with a linked table of contents
+	Interval  *int           // Milliseconds each step is shown in animated_svg and gif output
+	Changes   *DiagramDiff   // Changes to highlight; applied at render time and never stored
+}
+
+// RasterBackend identifies how SVG output is converted to raster images.
+type RasterBackend string
+
+const (
+	// RasterNative rasterizes in-process without external tools.
+	RasterNative RasterBackend = "native"
+	// RasterExternal converts with rsvg-convert or ImageMagick, which must be installed.
+	RasterExternal RasterBackend = "external"
+)
+
+// RasterOptions controls the size of raster output.
+// Zero fields keep the diagram's natural size at 96 DPI.
+type RasterOptions struct {
+	Width   int     // Output width in pixels; with Height, the diagram is fit inside both
+	Height  int     // Output height in pixels
+	DPI     float64 // Resolution used when neither Width nor Height is set
+	Backend RasterBackend
+}
+
 // Theme represents a D2 diagram theme.
 type Theme struct {
 	ID   int
@@ -0,0 +1,54 @@
+package entity
+
+// DiagramVersion identifies one side of a diff: the current state of a stored
+// diagram, one of its snapshots, or D2 text that is not stored.
+type DiagramVersion struct {
+	DiagramID string
+	Snapshot  string // Name of a snapshot of DiagramID; empty for the current state
+	Content   string // D2 text, used when DiagramID is empty
+}
+
+// DiagramDiff lists the structural differences between two versions of a diagram.
+// The objects and edges are those of the root board; changes within layers,
+// scenarios and steps are listed under Boards.
+type DiagramDiff struct {
+	From           DiagramVersion
+	To             DiagramVersion
+	AddedObjects   []*GraphObject
+	RemovedObjects []*GraphObject
+	ChangedObjects []*ElementChange
+	AddedEdges     []*GraphEdge
+	RemovedEdges   []*GraphEdge
+	ChangedEdges   []*ElementChange
+	AddedBoards    []*Board     // Boards only the new version has, without their nested boards
+	RemovedBoards  []*Board     // Boards only the old version has, without their nested boards
+	Boards         []*BoardDiff // Changes within boards both versions have
+}
+
+// BoardDiff lists the changes within a layer, scenario or step that both versions
+// of a diagram have.
+type BoardDiff struct {
+	Path []string // Board names from the root board down to this board
+	Kind BoardKind
+	Diff *DiagramDiff // Changes to the objects and edges of this board only
+}
+
+// Empty reports whether the two versions are structurally the same.
+func (d *DiagramDiff) Empty() bool {
+	return len(d.AddedObjects) == 0 && len(d.RemovedObjects) == 0 && len(d.ChangedObjects) == 0 &&
+		len(d.AddedEdges) == 0 && len(d.RemovedEdges) == 0 && len(d.ChangedEdges) == 0 &&
+		len(d.AddedBoards) == 0 && len(d.RemovedBoards) == 0 && len(d.Boards) == 0
+}
+
+// ElementChange lists the attributes of an object or edge that differ between two versions.
+type ElementChange struct {
+	ID         string
+	Attributes []*AttributeChange
+}
+
+// AttributeChange is an attribute whose value differs between two versions.
+type AttributeChange struct {
+	Name   string
+	Before interface{} // nil when the attribute is not set in the old version
+	After  interface{} // nil when the attribute is not set in the new version
+}
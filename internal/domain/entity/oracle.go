@@ -31,12 +31,28 @@ type OracleResult struct {
 	Graph    *DiagramGraph     // The resulting graph state
 }
 
+// HistoryResult represents the outcome of an undo or redo
+type HistoryResult struct {
+	Changes   []string // Descriptions of the changes undone or redone, in the order applied
+	UndoDepth int      // Number of changes that can still be undone
+	RedoDepth int      // Number of changes that can still be redone
+}
+
 // DiagramGraph represents the internal graph structure
 type DiagramGraph struct {
 	ID      string
 	Content string
 	Objects map[string]*GraphObject
 	Edges   map[string]*GraphEdge
+	Boards  []*BoardGraph // Layers, scenarios and steps declared in this board, in order
+}
+
+// BoardGraph is the graph of a layer, scenario or step nested in a board.
+// Scenarios and steps include what they inherit.
+type BoardGraph struct {
+	Name  string
+	Kind  BoardKind
+	Graph *DiagramGraph
 }
 
 // GraphObject represents a shape in the diagram
@@ -56,3 +72,17 @@ type GraphEdge struct {
 	Label      string
 	Attributes map[string]interface{}
 }
+
+// Key returns the D2 key that declares the edge, such as a -> b.
+func (e *GraphEdge) Key() string {
+	arrow := "--"
+	switch srcArrow, dstArrow := e.Attributes["srcArrow"] == true, e.Attributes["dstArrow"] == true; {
+	case srcArrow && dstArrow:
+		arrow = "<->"
+	case srcArrow:
+		arrow = "<-"
+	case dstArrow:
+		arrow = "->"
+	}
+	return e.From + " " + arrow + " " + e.To
+}
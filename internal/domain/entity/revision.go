@@ -0,0 +1,16 @@
+package entity
+
+import "fmt"
+
+// RevisionConflictError reports that a diagram was changed since the revision a
+// request was based on. Revision 0 stands for a diagram that does not exist.
+type RevisionConflictError struct {
+	DiagramID string
+	Expected  int64
+	Actual    int64
+}
+
+// Error implements the error interface.
+func (e *RevisionConflictError) Error() string {
+	return fmt.Sprintf("revision conflict: diagram %s is at revision %d, not the expected revision %d; read it again and reapply the change", e.DiagramID, e.Actual, e.Expected)
+}
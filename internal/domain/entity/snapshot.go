@@ -0,0 +1,12 @@
+package entity
+
+import "time"
+
+// Snapshot is a named checkpoint of a stored diagram.
+type Snapshot struct {
+	DiagramID string
+	Name      string
+	Content   string // D2 source of the diagram when the snapshot was taken
+	CreatedAt time.Time
+	UpdatedAt time.Time // When the snapshot was last taken again under the same name
+}
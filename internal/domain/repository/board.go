@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/i2y/d2mcp/internal/domain/entity"
+)
+
+// BoardRepository defines operations for managing the layers, scenarios and steps of a diagram
+type BoardRepository interface {
+	// CreateBoard adds an empty board of the given kind under the board at parentPath
+	CreateBoard(ctx context.Context, diagramID string, parentPath []string, kind entity.BoardKind, name string) (*entity.Board, error)
+
+	// ListBoards returns the board tree below the root board
+	ListBoards(ctx context.Context, diagramID string) ([]*entity.Board, error)
+
+	// DeleteBoard removes a board and all boards nested in it
+	DeleteBoard(ctx context.Context, diagramID string, boardPath []string) error
+
+	// MoveBoard moves a board to a new position among its siblings of the same kind
+	MoveBoard(ctx context.Context, diagramID string, boardPath []string, index int) error
+}
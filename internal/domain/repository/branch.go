@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/i2y/d2mcp/internal/domain/entity"
+)
+
+// BranchRepository defines operations for forking diagrams and merging the forks back
+type BranchRepository interface {
+	// CreateBranch stores a copy of a diagram under branchID and records the fork point
+	CreateBranch(ctx context.Context, diagramID, branchID string) (*entity.Branch, error)
+
+	// GetBranch retrieves a branch by the ID of its diagram
+	GetBranch(ctx context.Context, branchID string) (*entity.Branch, error)
+
+	// ListBranches returns the branches forked from a diagram, oldest first
+	ListBranches(ctx context.Context, diagramID string) ([]*entity.Branch, error)
+
+	// MergeBranch applies operations to the diagram a branch was forked from as a
+	// single change, and moves the fork point of the branch to base
+	MergeBranch(ctx context.Context, branchID string, ops []entity.OracleOperation, base string) error
+}
@@ -10,11 +10,24 @@ import (
 // DiagramRepository defines the interface for diagram operations.
 type DiagramRepository interface {
 	// Render renders D2 text into a diagram with specified format.
-	Render(ctx context.Context, content string, format entity.ExportFormat, theme *entity.Theme) (io.Reader, error)
+	Render(ctx context.Context, content string, format entity.ExportFormat, opts *entity.RenderOptions) (io.Reader, error)
 
 	// Create creates a new diagram programmatically.
 	Create(ctx context.Context, diagram *entity.Diagram) error
 
 	// Export exports the diagram to the specified format.
-	Export(ctx context.Context, diagramID string, format entity.ExportFormat) (io.Reader, error)
+	// Non-nil fields of opts override the defaults stored with the diagram.
+	Export(ctx context.Context, diagramID string, format entity.ExportFormat, opts *entity.RenderOptions) (io.Reader, error)
+
+	// ListDiagrams describes all stored diagrams, sorted by ID.
+	ListDiagrams(ctx context.Context) ([]*entity.DiagramInfo, error)
+
+	// DeleteDiagram removes a diagram along with its history, snapshots and branch record.
+	DeleteDiagram(ctx context.Context, diagramID string) error
+
+	// CloneDiagram stores a copy of a diagram and its render defaults under newID.
+	CloneDiagram(ctx context.Context, diagramID, newID string) error
+
+	// RenameDiagram moves a diagram and everything stored with it to newID.
+	RenameDiagram(ctx context.Context, diagramID, newID string) error
 }
@@ -34,9 +34,33 @@ type OracleRepository interface {
 	// GetChildren retrieves child element IDs
 	GetChildren(ctx context.Context, diagramID string, boardPath []string, parentID string) ([]string, error)
 
+	// ExecuteBatch applies operations in order and commits them only if all succeed.
+	// The results do not include the graph state
+	ExecuteBatch(ctx context.Context, diagramID string, ops []entity.OracleOperation) ([]*entity.OracleResult, error)
+
+	// Undo reverts up to steps of the most recent changes to a diagram
+	Undo(ctx context.Context, diagramID string, steps int) (*entity.HistoryResult, error)
+
+	// Redo reapplies up to steps of the most recently undone changes to a diagram
+	Redo(ctx context.Context, diagramID string, steps int) (*entity.HistoryResult, error)
+
+	// GetGraph retrieves the objects and edges of the diagram's root board and nested boards
+	GetGraph(ctx context.Context, diagramID string) (*entity.DiagramGraph, error)
+
+	// ParseGraph compiles D2 text into the objects and edges of its boards without storing it
+	ParseGraph(ctx context.Context, content string) (*entity.DiagramGraph, error)
+
 	// LoadDiagram loads a diagram from D2 text
 	LoadDiagram(ctx context.Context, diagramID string, content string) error
 
+	// LoadFile loads a diagram from the D2 file at path inside the server's input
+	// directory, resolving its relative imports within root, or within the file's
+	// directory when root is empty
+	LoadFile(ctx context.Context, diagramID, path, root string) error
+
 	// SerializeDiagram converts the current graph state back to D2 text
 	SerializeDiagram(ctx context.Context, diagramID string) (string, error)
+
+	// SerializeBoard converts a single board of the diagram back to D2 text
+	SerializeBoard(ctx context.Context, diagramID string, boardPath []string) (string, error)
 }
@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/i2y/d2mcp/internal/domain/entity"
+)
+
+// Revision tracks the revision of one stored diagram across the repository calls
+// made for a request. Every change to a diagram increases its revision by one.
+type Revision struct {
+	DiagramID string
+	Expected  *int64 // When set, using the diagram at any other revision fails
+	Current   int64  // Revision the request last saw or produced
+}
+
+type revisionKey struct{}
+
+// WithRevision returns a context under which repositories check and record the
+// revision of rev.DiagramID.
+func WithRevision(ctx context.Context, rev *Revision) context.Context {
+	return context.WithValue(ctx, revisionKey{}, rev)
+}
+
+// RevisionFromContext returns the revision tracked for diagramID, or nil when the
+// context does not track that diagram.
+func RevisionFromContext(ctx context.Context, diagramID string) *Revision {
+	rev, _ := ctx.Value(revisionKey{}).(*Revision)
+	if rev == nil || rev.DiagramID != diagramID {
+		return nil
+	}
+	return rev
+}
+
+// Check records that the diagram is at current, failing with an
+// *entity.RevisionConflictError when the request expected another revision.
+func (rev *Revision) Check(current int64) error {
+	if rev.Expected != nil && *rev.Expected != current {
+		return &entity.RevisionConflictError{
+			DiagramID: rev.DiagramID,
+			Expected:  *rev.Expected,
+			Actual:    current,
+		}
+	}
+	rev.Current = current
+	return nil
+}
+
+// Advance records a change made by the request itself, so that later calls of the
+// same request expect the new revision.
+func (rev *Revision) Advance(current int64) {
+	rev.Current = current
+	if rev.Expected != nil {
+		rev.Expected = &current
+	}
+}
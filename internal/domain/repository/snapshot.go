@@ -0,0 +1,25 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/i2y/d2mcp/internal/domain/entity"
+)
+
+// SnapshotRepository defines operations for named checkpoints of stored diagrams
+type SnapshotRepository interface {
+	// CreateSnapshot saves the current state of a diagram under name, replacing any snapshot with the same name
+	CreateSnapshot(ctx context.Context, diagramID, name string) (*entity.Snapshot, error)
+
+	// ListSnapshots returns the snapshots of a diagram, oldest first
+	ListSnapshots(ctx context.Context, diagramID string) ([]*entity.Snapshot, error)
+
+	// GetSnapshot retrieves a snapshot
+	GetSnapshot(ctx context.Context, diagramID, name string) (*entity.Snapshot, error)
+
+	// RestoreSnapshot replaces the diagram with the state saved in a snapshot
+	RestoreSnapshot(ctx context.Context, diagramID, name string) (*entity.Snapshot, error)
+
+	// DeleteSnapshot removes a snapshot
+	DeleteSnapshot(ctx context.Context, diagramID, name string) error
+}
@@ -0,0 +1,91 @@
+package d2
+
+import (
+	"errors"
+	"fmt"
+	"image"
+
+	"oss.terrastruct.com/d2/d2renderers/d2animate"
+	"oss.terrastruct.com/d2/d2renderers/d2svg"
+	"oss.terrastruct.com/d2/d2target"
+
+	"github.com/i2y/d2mcp/internal/domain/entity"
+	"github.com/i2y/d2mcp/internal/infrastructure/raster"
+)
+
+// defaultInterval is how long each step is shown when no interval is given, in milliseconds.
+const defaultInterval = 1000
+
+// animationFrames returns the boards shown by an animation: the root board followed
+// by its steps in order. D2 already renders each step on top of the previous ones.
+func animationFrames(diagram *d2target.Diagram) ([]*d2target.Diagram, error) {
+	if len(diagram.Steps) == 0 {
+		return nil, errors.New("diagram has no steps to animate")
+	}
+	return append([]*d2target.Diagram{diagram}, diagram.Steps...), nil
+}
+
+// animationInterval returns the requested frame interval or the default.
+func animationInterval(opts *entity.RenderOptions) int {
+	if opts.Interval != nil {
+		return *opts.Interval
+	}
+	return defaultInterval
+}
+
+// renderAnimatedSVG renders the root board and its steps into a single SVG that
+// cycles through them with CSS animations.
+func renderAnimatedSVG(diagram *d2target.Diagram, renderOpts *d2svg.RenderOpts, opts *entity.RenderOptions) ([]byte, error) {
+	frames, err := animationFrames(diagram)
+	if err != nil {
+		return nil, err
+	}
+
+	// Boards rendered with a master ID become fragments sharing the wrapper's styles.
+	masterID, err := diagram.HashID(renderOpts.Salt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash diagram: %w", err)
+	}
+	frameOpts := *renderOpts
+	frameOpts.MasterID = masterID
+
+	svgs := make([][]byte, len(frames))
+	for i, frame := range frames {
+		svgs[i], err = d2svg.Render(frame, &frameOpts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render step %d: %w", i, err)
+		}
+	}
+
+	output, err := d2animate.Wrap(diagram, svgs, frameOpts, animationInterval(opts))
+	if err != nil {
+		return nil, fmt.Errorf("failed to animate SVG: %w", err)
+	}
+	return output, nil
+}
+
+// renderGIF rasterizes the root board and its steps into the frames of an animated GIF.
+func renderGIF(diagram *d2target.Diagram, renderOpts *d2svg.RenderOpts, opts *entity.RenderOptions) ([]byte, error) {
+	frames, err := animationFrames(diagram)
+	if err != nil {
+		return nil, err
+	}
+
+	rasterOpts := raster.Options{}
+	if opts.Raster != nil {
+		rasterOpts = raster.Options{Width: opts.Raster.Width, Height: opts.Raster.Height, DPI: opts.Raster.DPI}
+	}
+	images := make([]*image.RGBA, len(frames))
+	for i, frame := range frames {
+		svg, err := d2svg.Render(frame, renderOpts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render step %d: %w", i, err)
+		}
+		images[i], err = raster.Rasterize(svg, rasterOpts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to rasterize step %d: %w", i, err)
+		}
+	}
+
+	return raster.GIF(images, animationInterval(opts))
+}
@@ -0,0 +1,64 @@
+package d2
+
+import (
+	"context"
+	"image/gif"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/i2y/d2mcp/internal/domain/entity"
+)
+
+func TestD2Repository_RenderAnimatedSVG(t *testing.T) {
+	repo := NewD2Repository()
+	interval := 500
+
+	reader, err := repo.Render(context.Background(), multiBoardContent, entity.FormatAnimatedSVG, &entity.RenderOptions{Interval: &interval})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	svg, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("failed to read SVG: %v", err)
+	}
+
+	// One keyframe per frame: the root board and its two steps.
+	if keyframes := strings.Count(string(svg), "@keyframes d2Transition-"); keyframes != 3 {
+		t.Errorf("animated SVG has %d keyframes, want 3", keyframes)
+	}
+	if !strings.Contains(string(svg), " 1500ms infinite") {
+		t.Error("animated SVG does not cycle every 1500ms")
+	}
+}
+
+func TestD2Repository_RenderGIF(t *testing.T) {
+	repo := NewD2Repository()
+	interval := 250
+
+	reader, err := repo.Render(context.Background(), multiBoardContent, entity.FormatGIF, &entity.RenderOptions{Interval: &interval})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	anim, err := gif.DecodeAll(reader)
+	if err != nil {
+		t.Fatalf("failed to decode GIF: %v", err)
+	}
+	if len(anim.Image) != 3 {
+		t.Fatalf("GIF has %d frames, want 3", len(anim.Image))
+	}
+	for i, delay := range anim.Delay {
+		if delay != 25 {
+			t.Errorf("frame %d delay = %d, want 25", i, delay)
+		}
+	}
+}
+
+func TestD2Repository_RenderAnimationWithoutSteps(t *testing.T) {
+	repo := NewD2Repository()
+	for _, format := range []entity.ExportFormat{entity.FormatAnimatedSVG, entity.FormatGIF} {
+		if _, err := repo.Render(context.Background(), "a -> b", format, nil); err == nil {
+			t.Errorf("Render(%s) expected error for a diagram without steps", format)
+		}
+	}
+}
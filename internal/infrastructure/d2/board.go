@@ -0,0 +1,264 @@
+package d2
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"oss.terrastruct.com/d2/d2ast"
+	"oss.terrastruct.com/d2/d2format"
+	"oss.terrastruct.com/d2/d2graph"
+	"oss.terrastruct.com/d2/d2parser"
+
+	"github.com/i2y/d2mcp/internal/domain/entity"
+)
+
+// boardKinds lists the board kinds in the order D2 resolves board names.
+var boardKinds = []entity.BoardKind{entity.BoardLayer, entity.BoardScenario, entity.BoardStep}
+
+// CreateBoard adds an empty board of the given kind under the board at parentPath
+func (r *D2OracleRepository) CreateBoard(ctx context.Context, diagramID string, parentPath []string, kind entity.BoardKind, name string) (*entity.Board, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	change := fmt.Sprintf("create %s %s", kind, strings.Join(append(append([]string{}, parentPath...), name), "."))
+	err := r.editBoardAST(ctx, diagramID, change, func(ast *d2ast.Map) error {
+		parent, err := findBoardMap(ast, parentPath)
+		if err != nil {
+			return err
+		}
+
+		container := findChildMap(parent, kind.Keyword())
+		if container == nil {
+			node, err := newMapNode(kind.Keyword())
+			if err != nil {
+				return err
+			}
+			parent.Nodes = append(parent.Nodes, node)
+			container = node.MapKey.Value.Map
+		}
+		if findChildMap(container, name) != nil {
+			return fmt.Errorf("%s %s already exists", kind, name)
+		}
+
+		node, err := newMapNode(name)
+		if err != nil {
+			return err
+		}
+		container.Nodes = append(container.Nodes, node)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	path := append(append([]string{}, parentPath...), name)
+	return &entity.Board{
+		Name: name,
+		Kind: kind,
+		Path: path,
+	}, nil
+}
+
+// ListBoards returns the board tree below the root board
+func (r *D2OracleRepository) ListBoards(ctx context.Context, diagramID string) ([]*entity.Board, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	data, err := r.lookup(ctx, diagramID)
+	if err != nil {
+		return nil, err
+	}
+
+	return boardTree(data.graph, nil), nil
+}
+
+// DeleteBoard removes a board and all boards nested in it
+func (r *D2OracleRepository) DeleteBoard(ctx context.Context, diagramID string, boardPath []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	change := "delete board " + strings.Join(boardPath, ".")
+	return r.editBoardAST(ctx, diagramID, change, func(ast *d2ast.Map) error {
+		container, index, err := findBoardNode(ast, boardPath)
+		if err != nil {
+			return err
+		}
+
+		container.Nodes = append(container.Nodes[:index], container.Nodes[index+1:]...)
+		return nil
+	})
+}
+
+// MoveBoard moves a board to a new position among its siblings of the same kind
+func (r *D2OracleRepository) MoveBoard(ctx context.Context, diagramID string, boardPath []string, index int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	change := fmt.Sprintf("move board %s to position %d", strings.Join(boardPath, "."), index)
+	return r.editBoardAST(ctx, diagramID, change, func(ast *d2ast.Map) error {
+		container, from, err := findBoardNode(ast, boardPath)
+		if err != nil {
+			return err
+		}
+
+		// Only board declarations count towards the index; comments stay in place.
+		var positions []int
+		for i, n := range container.Nodes {
+			if n.MapKey != nil {
+				positions = append(positions, i)
+			}
+		}
+		if index >= len(positions) {
+			index = len(positions) - 1
+		}
+
+		node := container.Nodes[from]
+		nodes := append(container.Nodes[:from:from], container.Nodes[from+1:]...)
+		to := len(nodes)
+		if target := positions[index]; target < len(nodes) {
+			to = target
+		}
+		nodes = append(nodes[:to], append([]d2ast.MapNodeBox{node}, nodes[to:]...)...)
+		container.Nodes = nodes
+		return nil
+	})
+}
+
+// editBoardAST applies fn to a private copy of the diagram AST and commits the recompiled
+// result, recorded in the history as change. Callers must hold r.mu.
+func (r *D2OracleRepository) editBoardAST(ctx context.Context, diagramID string, change string, fn func(ast *d2ast.Map) error) error {
+	data, err := r.lookup(ctx, diagramID)
+	if err != nil {
+		return err
+	}
+
+	session := r.getOrCreateSession(diagramID, data.graph)
+
+	// Work on a freshly parsed AST so that a failed edit never touches the live graph.
+	source := data.content
+	if session.Graph != nil && session.Graph.AST != nil {
+		source = d2format.Format(session.Graph.AST)
+	}
+	ast, err := d2parser.Parse(data.imports.filePath(), strings.NewReader(source), nil)
+	if err != nil {
+		return fmt.Errorf("failed to parse diagram: %w", err)
+	}
+
+	if err := fn(ast); err != nil {
+		return err
+	}
+
+	newGraph, err := data.imports.compile(d2format.Format(ast))
+	if err != nil {
+		return fmt.Errorf("failed to compile diagram: %w", err)
+	}
+
+	r.commitGraph(ctx, diagramID, newGraph, change)
+	return nil
+}
+
+// boardTree converts the nested boards of graph into entities.
+func boardTree(graph *d2graph.Graph, parentPath []string) []*entity.Board {
+	var boards []*entity.Board
+	for _, kind := range boardKinds {
+		for _, child := range boardGraphs(graph, kind) {
+			path := append(append([]string{}, parentPath...), child.Name)
+			boards = append(boards, &entity.Board{
+				Name:     child.Name,
+				Kind:     kind,
+				Path:     path,
+				Children: boardTree(child, path),
+			})
+		}
+	}
+	return boards
+}
+
+// boardGraphs returns the boards of the given kind declared in graph.
+func boardGraphs(graph *d2graph.Graph, kind entity.BoardKind) []*d2graph.Graph {
+	switch kind {
+	case entity.BoardLayer:
+		return graph.Layers
+	case entity.BoardScenario:
+		return graph.Scenarios
+	case entity.BoardStep:
+		return graph.Steps
+	}
+	return nil
+}
+
+// findBoardMap returns the map declaring the board at boardPath.
+func findBoardMap(ast *d2ast.Map, boardPath []string) (*d2ast.Map, error) {
+	current := ast
+	for i := range boardPath {
+		container, index, err := findBoardNode(current, boardPath[i:i+1])
+		if err != nil {
+			return nil, fmt.Errorf("board %s not found", strings.Join(boardPath[:i+1], "."))
+		}
+
+		// Empty boards are formatted as a bare key, so give them a map to write into.
+		mk := container.Nodes[index].MapKey
+		if mk.Value.Map == nil {
+			node, err := newMapNode(boardPath[i])
+			if err != nil {
+				return nil, err
+			}
+			mk.Value = node.MapKey.Value
+		}
+		current = mk.Value.Map
+	}
+	return current, nil
+}
+
+// findBoardNode returns the layers, scenarios or steps map that declares the board at
+// boardPath, together with the index of the board's node in that map.
+func findBoardNode(ast *d2ast.Map, boardPath []string) (*d2ast.Map, int, error) {
+	parent, err := findBoardMap(ast, boardPath[:len(boardPath)-1])
+	if err != nil {
+		return nil, 0, err
+	}
+
+	name := boardPath[len(boardPath)-1]
+	for _, kind := range boardKinds {
+		container := findChildMap(parent, kind.Keyword())
+		if container == nil {
+			continue
+		}
+		for i, n := range container.Nodes {
+			if isKeyNamed(n.MapKey, name) {
+				return container, i, nil
+			}
+		}
+	}
+	return nil, 0, fmt.Errorf("board %s not found", strings.Join(boardPath, "."))
+}
+
+// findChildMap returns the map value of the key named name declared directly in m.
+func findChildMap(m *d2ast.Map, name string) *d2ast.Map {
+	for _, n := range m.Nodes {
+		if isKeyNamed(n.MapKey, name) && n.MapKey.Value.Map != nil {
+			return n.MapKey.Value.Map
+		}
+	}
+	return nil
+}
+
+// isKeyNamed reports whether mk is a plain, single-segment key with the given name.
+func isKeyNamed(mk *d2ast.Key, name string) bool {
+	if mk == nil || mk.Key == nil || len(mk.Edges) > 0 || len(mk.Key.Path) != 1 {
+		return false
+	}
+	return mk.Key.Path[0].Unbox().ScalarString() == name
+}
+
+// newMapNode creates an empty `name: {}` map node. The node is parsed rather than built
+// by hand so that it carries the source ranges d2format relies on to print nested maps.
+func newMapNode(name string) (d2ast.MapNodeBox, error) {
+	source := d2format.Format(d2ast.MakeKeyPath([]string{name})) + ": {\n}"
+	ast, err := d2parser.Parse("", strings.NewReader(source), nil)
+	if err != nil {
+		return d2ast.MapNodeBox{}, fmt.Errorf("invalid board name %q: %w", name, err)
+	}
+	return ast.Nodes[0], nil
+}
@@ -0,0 +1,83 @@
+package d2
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/i2y/d2mcp/internal/domain/entity"
+)
+
+func TestD2OracleRepository_BoardLifecycle(t *testing.T) {
+	repo := NewD2OracleRepository()
+	ctx := context.Background()
+
+	diagramID := "test-board-lifecycle"
+	err := repo.LoadDiagram(ctx, diagramID, "web -> api")
+	if err != nil {
+		t.Fatalf("LoadDiagram() error = %v", err)
+	}
+
+	// Create boards of every kind
+	for _, b := range []struct {
+		parent []string
+		kind   entity.BoardKind
+		name   string
+	}{
+		{nil, entity.BoardLayer, "network"},
+		{nil, entity.BoardScenario, "outage"},
+		{nil, entity.BoardStep, "1"},
+		{nil, entity.BoardStep, "2"},
+		{[]string{"network"}, entity.BoardLayer, "details"},
+	} {
+		if _, err := repo.CreateBoard(ctx, diagramID, b.parent, b.kind, b.name); err != nil {
+			t.Fatalf("CreateBoard(%s) error = %v", b.name, err)
+		}
+	}
+
+	// Duplicate boards are rejected
+	if _, err := repo.CreateBoard(ctx, diagramID, nil, entity.BoardLayer, "network"); err == nil {
+		t.Error("CreateBoard() should fail for a duplicate board")
+	}
+
+	// Nested boards are editable through the Oracle API
+	if _, err := repo.CreateElement(ctx, diagramID, []string{"network", "details"}, "router"); err != nil {
+		t.Fatalf("CreateElement() on nested layer error = %v", err)
+	}
+
+	boards, err := repo.ListBoards(ctx, diagramID)
+	if err != nil {
+		t.Fatalf("ListBoards() error = %v", err)
+	}
+	if len(boards) != 4 {
+		t.Fatalf("ListBoards() returned %d boards, want 4", len(boards))
+	}
+	if boards[0].Name != "network" || len(boards[0].Children) != 1 || boards[0].Children[0].Kind != entity.BoardLayer {
+		t.Errorf("ListBoards() network = %+v, want one nested layer", boards[0])
+	}
+
+	// Reorder steps
+	if err := repo.MoveBoard(ctx, diagramID, []string{"2"}, 0); err != nil {
+		t.Fatalf("MoveBoard() error = %v", err)
+	}
+	boards, _ = repo.ListBoards(ctx, diagramID)
+	if boards[2].Name != "2" || boards[3].Name != "1" {
+		t.Errorf("MoveBoard() steps order = %s, %s, want 2, 1", boards[2].Name, boards[3].Name)
+	}
+
+	// Delete a layer together with its nested boards
+	if err := repo.DeleteBoard(ctx, diagramID, []string{"network"}); err != nil {
+		t.Fatalf("DeleteBoard() error = %v", err)
+	}
+	serialized, err := repo.SerializeDiagram(ctx, diagramID)
+	if err != nil {
+		t.Fatalf("SerializeDiagram() error = %v", err)
+	}
+	if strings.Contains(serialized, "network") || strings.Contains(serialized, "router") {
+		t.Errorf("DeleteBoard() left the layer behind:\n%s", serialized)
+	}
+
+	if err := repo.DeleteBoard(ctx, diagramID, []string{"missing"}); err == nil {
+		t.Error("DeleteBoard() should fail for an unknown board")
+	}
+}
@@ -0,0 +1,117 @@
+package d2
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+	"strings"
+
+	"oss.terrastruct.com/d2/d2renderers/d2svg"
+	"oss.terrastruct.com/d2/d2target"
+
+	"github.com/i2y/d2mcp/internal/infrastructure/raster"
+)
+
+const (
+	// boardDPI is the default resolution boards are rasterized at for PDF pages and slides.
+	boardDPI = 2 * raster.DefaultDPI
+	// rootBoardTitle names the root board, following the D2 CLI's index.svg convention.
+	rootBoardTitle = "index"
+)
+
+// diagramBoard is a board of the diagram that gets its own page or slide.
+type diagramBoard struct {
+	diagram *d2target.Diagram
+	titles  []string // Board names from the root down to this board
+	paths   []string // D2 board paths of the same boards, as used by links, e.g. "root.layers.x"
+}
+
+// path returns the D2 board path of the board itself.
+func (b diagramBoard) path() string {
+	return b.paths[len(b.paths)-1]
+}
+
+// collectBoards flattens the board tree into page order: each board is followed by
+// its layers, scenarios and steps. Folder-only boards have no content of their own
+// and get no page.
+func collectBoards(diagram *d2target.Diagram, titles, paths []string, boards []diagramBoard) []diagramBoard {
+	if !diagram.IsFolderOnly {
+		boards = append(boards, diagramBoard{diagram: diagram, titles: titles, paths: paths})
+	}
+	children := []struct {
+		kind     string
+		diagrams []*d2target.Diagram
+	}{
+		{kind: "layers", diagrams: diagram.Layers},
+		{kind: "scenarios", diagrams: diagram.Scenarios},
+		{kind: "steps", diagrams: diagram.Steps},
+	}
+	for _, group := range children {
+		for _, child := range group.diagrams {
+			childTitles := append(append([]string{}, titles...), child.Name)
+			childPaths := append(append([]string{}, paths...), paths[len(paths)-1]+"."+group.kind+"."+child.Name)
+			boards = collectBoards(child, childTitles, childPaths, boards)
+		}
+	}
+	return boards
+}
+
+// rasterizeBoard renders a board and rasterizes it at the given resolution, returning
+// both the image and its PNG encoding.
+func rasterizeBoard(b diagramBoard, renderOpts *d2svg.RenderOpts, dpi float64) (*image.RGBA, []byte, error) {
+	svg, err := d2svg.Render(b.diagram, renderOpts)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to render board %q: %w", b.path(), err)
+	}
+	img, err := raster.Rasterize(svg, raster.Options{DPI: dpi})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to rasterize board %q: %w", b.path(), err)
+	}
+	var encoded bytes.Buffer
+	if err := png.Encode(&encoded, img); err != nil {
+		return nil, nil, fmt.Errorf("failed to encode board %q: %w", b.path(), err)
+	}
+	return img, encoded.Bytes(), nil
+}
+
+// linkedShape is the area of a shape with a link, relative to the board image.
+type linkedShape struct {
+	x, y, width, height float64
+	link                string
+}
+
+// linkedShapes locates the shapes with links on a board image that is imageWidth
+// wide, in the same unit as imageWidth.
+func linkedShapes(diagram *d2target.Diagram, renderOpts *d2svg.RenderOpts, imageWidth float64) []linkedShape {
+	topLeft, bottomRight := diagram.BoundingBox()
+	pad := float64(d2svg.DEFAULT_PADDING)
+	if renderOpts.Pad != nil {
+		pad = float64(*renderOpts.Pad)
+	}
+	naturalWidth := float64(bottomRight.X-topLeft.X) + 2*pad
+	if naturalWidth <= 0 {
+		return nil
+	}
+	factor := imageWidth / naturalWidth
+
+	var shapes []linkedShape
+	for _, shape := range diagram.Shapes {
+		if shape.Link == "" {
+			continue
+		}
+		shapes = append(shapes, linkedShape{
+			x:      (float64(shape.Pos.X-shape.StrokeWidth-topLeft.X) + pad) * factor,
+			y:      (float64(shape.Pos.Y-shape.StrokeWidth-topLeft.Y) + pad) * factor,
+			width:  float64(shape.Width+2*shape.StrokeWidth) * factor,
+			height: float64(shape.Height+2*shape.StrokeWidth) * factor,
+			link:   shape.Link,
+		})
+	}
+	return shapes
+}
+
+// isBoardLink reports whether a link points at a board of the same diagram.
+func isBoardLink(link string) bool {
+	return strings.HasPrefix(link, "root")
+}
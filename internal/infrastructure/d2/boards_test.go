@@ -0,0 +1,41 @@
+package d2
+
+import (
+	"strings"
+	"testing"
+
+	"oss.terrastruct.com/d2/d2target"
+)
+
+func TestCollectBoards(t *testing.T) {
+	root := &d2target.Diagram{
+		Layers: []*d2target.Diagram{
+			{Name: "folder", IsFolderOnly: true, Layers: []*d2target.Diagram{{Name: "inner"}}},
+		},
+		Scenarios: []*d2target.Diagram{{Name: "outage"}},
+		Steps:     []*d2target.Diagram{{Name: "one"}, {Name: "two"}},
+	}
+
+	boards := collectBoards(root, []string{rootBoardTitle}, []string{"root"}, nil)
+	want := []struct {
+		title string
+		path  string
+	}{
+		{title: "index", path: "root"},
+		{title: "index / folder / inner", path: "root.layers.folder.layers.inner"},
+		{title: "index / outage", path: "root.scenarios.outage"},
+		{title: "index / one", path: "root.steps.one"},
+		{title: "index / two", path: "root.steps.two"},
+	}
+	if len(boards) != len(want) {
+		t.Fatalf("collectBoards() returned %d boards, want %d", len(boards), len(want))
+	}
+	for i, w := range want {
+		if title := strings.Join(boards[i].titles, " / "); title != w.title {
+			t.Errorf("board %d title = %q, want %q", i, title, w.title)
+		}
+		if path := boards[i].path(); path != w.path {
+			t.Errorf("board %d path = %q, want %q", i, path, w.path)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package d2
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/i2y/d2mcp/internal/domain/entity"
+)
+
+// CreateBranch stores a copy of a diagram under branchID and records the fork point
+func (r *D2OracleRepository) CreateBranch(ctx context.Context, diagramID, branchID string) (*entity.Branch, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	data, err := r.lookup(ctx, diagramID)
+	if err != nil {
+		return nil, err
+	}
+	if _, exists := r.diagram(branchID); exists {
+		return nil, fmt.Errorf("diagram %s already exists", branchID)
+	}
+	if err := r.checkRevision(ctx, branchID, 0); err != nil {
+		return nil, err
+	}
+
+	branchData, err := copyDiagramData(data)
+	if err != nil {
+		return nil, err
+	}
+	r.diagrams[branchID] = branchData
+
+	branch := &entity.Branch{
+		ID:        branchID,
+		DiagramID: diagramID,
+		Base:      branchData.content,
+		CreatedAt: time.Now(),
+	}
+	r.branches[branchID] = branch
+	r.changed(ctx, branchID)
+
+	copied := *branch
+	return &copied, nil
+}
+
+// GetBranch retrieves a branch by the ID of its diagram
+func (r *D2OracleRepository) GetBranch(ctx context.Context, branchID string) (*entity.Branch, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	branch, exists := r.branches[branchID]
+	if !exists {
+		return nil, fmt.Errorf("branch %s not found", branchID)
+	}
+
+	copied := *branch
+	return &copied, nil
+}
+
+// ListBranches returns the branches forked from a diagram, oldest first
+func (r *D2OracleRepository) ListBranches(ctx context.Context, diagramID string) ([]*entity.Branch, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if _, err := r.lookup(ctx, diagramID); err != nil {
+		return nil, err
+	}
+
+	var branches []*entity.Branch
+	for _, branch := range r.branches {
+		if branch.DiagramID == diagramID {
+			copied := *branch
+			branches = append(branches, &copied)
+		}
+	}
+	sort.Slice(branches, func(i, j int) bool {
+		if !branches[i].CreatedAt.Equal(branches[j].CreatedAt) {
+			return branches[i].CreatedAt.Before(branches[j].CreatedAt)
+		}
+		return branches[i].ID < branches[j].ID
+	})
+	return branches, nil
+}
+
+// MergeBranch applies operations to the diagram a branch was forked from as a
+// single change, and moves the fork point of the branch to base. The merge can
+// be undone like any other change.
+func (r *D2OracleRepository) MergeBranch(ctx context.Context, branchID string, ops []entity.OracleOperation, base string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	branch, exists := r.branches[branchID]
+	if !exists {
+		return fmt.Errorf("branch %s not found", branchID)
+	}
+
+	if _, err := r.lookup(ctx, branch.DiagramID); err != nil {
+		return err
+	}
+	if len(ops) > 0 {
+		if _, err := r.executeBatch(ctx, branch.DiagramID, ops, "merge branch "+branchID); err != nil {
+			return err
+		}
+	}
+
+	branch.Base = base
+	r.persistDiagram(branchID)
+	return nil
+}
@@ -0,0 +1,73 @@
+package d2
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/i2y/d2mcp/internal/domain/entity"
+)
+
+func TestD2OracleRepository_BranchLifecycle(t *testing.T) {
+	repo := NewD2OracleRepository()
+	ctx := context.Background()
+
+	if err := repo.LoadDiagram(ctx, "main", "web -> api"); err != nil {
+		t.Fatalf("LoadDiagram() error = %v", err)
+	}
+
+	branch, err := repo.CreateBranch(ctx, "main", "feature")
+	if err != nil {
+		t.Fatalf("CreateBranch() error = %v", err)
+	}
+	if branch.DiagramID != "main" || !strings.Contains(branch.Base, "web -> api") {
+		t.Errorf("CreateBranch() = %+v", branch)
+	}
+	if _, err := repo.CreateBranch(ctx, "main", "feature"); err == nil {
+		t.Error("CreateBranch() with an existing ID should fail")
+	}
+	if _, err := repo.CreateBranch(ctx, "missing", "other"); err == nil {
+		t.Error("CreateBranch() of a missing diagram should fail")
+	}
+
+	// The branch is an independent diagram.
+	if _, err := repo.CreateElement(ctx, "feature", nil, "db"); err != nil {
+		t.Fatalf("CreateElement() error = %v", err)
+	}
+	if _, err := repo.GetObject(ctx, "main", nil, "db"); err == nil {
+		t.Error("editing the branch should not change the diagram")
+	}
+
+	branches, err := repo.ListBranches(ctx, "main")
+	if err != nil {
+		t.Fatalf("ListBranches() error = %v", err)
+	}
+	if len(branches) != 1 || branches[0].ID != "feature" {
+		t.Errorf("ListBranches() = %v", branches)
+	}
+
+	source, _ := repo.SerializeDiagram(ctx, "feature")
+	ops := []entity.OracleOperation{{Type: entity.OracleCreate, DiagramID: "main", Key: "db"}}
+	if err := repo.MergeBranch(ctx, "feature", ops, source); err != nil {
+		t.Fatalf("MergeBranch() error = %v", err)
+	}
+	if _, err := repo.GetObject(ctx, "main", nil, "db"); err != nil {
+		t.Errorf("MergeBranch() did not apply the operations: %v", err)
+	}
+	merged, _ := repo.GetBranch(ctx, "feature")
+	if merged.Base != source {
+		t.Errorf("MergeBranch() base = %q, want %q", merged.Base, source)
+	}
+
+	// The merge is a single change that can be undone.
+	if _, err := repo.Undo(ctx, "main", 1); err != nil {
+		t.Fatalf("Undo() error = %v", err)
+	}
+	if _, err := repo.GetObject(ctx, "main", nil, "db"); err == nil {
+		t.Error("Undo() should revert the merge")
+	}
+
+	if _, err := repo.GetBranch(ctx, "main"); err == nil {
+		t.Error("GetBranch() of a diagram that is not a branch should fail")
+	}
+}
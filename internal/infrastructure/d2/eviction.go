@@ -0,0 +1,208 @@
+package d2
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"sort"
+	"time"
+)
+
+// WithIdleTTL evicts diagrams that have not been changed for ttl. Zero keeps
+// idle diagrams.
+func WithIdleTTL(ttl time.Duration) OracleOption {
+	return func(r *D2OracleRepository) {
+		r.idleTTL = max(ttl, 0)
+	}
+}
+
+// WithMaxDiagrams limits how many diagrams are stored, evicting the least recently
+// changed ones beyond the limit. Zero means no limit.
+func WithMaxDiagrams(n int) OracleOption {
+	return func(r *D2OracleRepository) {
+		r.maxDiagrams = max(n, 0)
+	}
+}
+
+// WithMaxTotalSize limits the total size in bytes of the stored diagrams, counted
+// as their D2 source, snapshots and undo history, evicting the least recently
+// changed ones beyond the limit. Zero means no limit.
+func WithMaxTotalSize(size int64) OracleOption {
+	return func(r *D2OracleRepository) {
+		r.maxTotalSize = max(size, 0)
+	}
+}
+
+// RunEviction evicts idle diagrams every interval until ctx is done. The limits on
+// the number and size of diagrams are enforced after every change as well.
+func (r *D2OracleRepository) RunEviction(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.mu.Lock()
+			r.evictBeyondLimits("")
+			r.mu.Unlock()
+		}
+	}
+}
+
+// evictBeyondLimits evicts the diagrams that have been idle for longer than the
+// idle TTL, then the least recently changed diagrams until the count and size
+// limits are met. The diagram keep is never evicted, so a change cannot evict the
+// diagram it was made to. Callers must hold r.mu.
+func (r *D2OracleRepository) evictBeyondLimits(keep string) {
+	if r.idleTTL == 0 && r.maxDiagrams == 0 && r.maxTotalSize == 0 {
+		return
+	}
+
+	type candidate struct {
+		id       string
+		modified time.Time
+		size     int64
+	}
+	candidates := make([]candidate, 0, len(r.diagrams))
+	var total int64
+	for id := range r.diagrams {
+		c := candidate{id: id, modified: r.lastModified(id), size: r.diagramSize(id)}
+		candidates = append(candidates, c)
+		total += c.size
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].modified.Before(candidates[j].modified)
+	})
+
+	now := time.Now()
+	count := len(candidates)
+	for _, c := range candidates {
+		// Without a workspace to read them back from, diagrams other diagrams
+		// import are kept, since their importers would no longer compile.
+		if c.id == keep || (r.workspace == nil && len(r.importers(c.id)) > 0) {
+			continue
+		}
+
+		var reason string
+		switch {
+		case r.idleTTL > 0 && now.Sub(c.modified) > r.idleTTL:
+			reason = fmt.Sprintf("unchanged since %s", c.modified.Format(time.RFC3339))
+		case r.maxDiagrams > 0 && count > r.maxDiagrams:
+			reason = fmt.Sprintf("over the limit of %d diagrams", r.maxDiagrams)
+		case r.maxTotalSize > 0 && total > r.maxTotalSize:
+			reason = fmt.Sprintf("over the limit of %d bytes", r.maxTotalSize)
+		default:
+			// Candidates are in order of their last change, so the rest are newer.
+			if r.idleTTL == 0 {
+				return
+			}
+			continue
+		}
+
+		if r.evict(c.id, reason) {
+			count--
+			total -= c.size
+		}
+	}
+}
+
+// evict removes a diagram from memory. With a workspace, the diagram is saved
+// first and stays listed, to be read back when it is used again; its undo history
+// is lost. A diagram that cannot be saved is kept. Callers must hold r.mu.
+func (r *D2OracleRepository) evict(diagramID, reason string) bool {
+	if r.workspace == nil {
+		r.removeDiagram(diagramID)
+		log.Printf("Evicted diagram %s: %s", diagramID, reason)
+		return true
+	}
+
+	if err := r.saveDiagram(diagramID); err != nil {
+		log.Printf("Keeping diagram %s, which could not be saved before eviction: %v", diagramID, err)
+		return false
+	}
+	r.evicted[diagramID] = diagramInfo(diagramID, r.diagrams[diagramID])
+
+	// The fork point stays, so that the diagram's branches are still listed.
+	delete(r.diagrams, diagramID)
+	delete(r.snapshots, diagramID)
+	r.sessionMu.Lock()
+	delete(r.sessions, diagramID)
+	r.sessionMu.Unlock()
+
+	log.Printf("Evicted diagram %s to the workspace: %s", diagramID, reason)
+	return true
+}
+
+// reloadDiagram reads back a diagram evicted to the workspace. It counts towards
+// the limits again from the next change on, so that it cannot evict a diagram the
+// current request is still using. Callers must hold r.mu.
+func (r *D2OracleRepository) reloadDiagram(diagramID string) (*diagramData, bool) {
+	info, evicted := r.evicted[diagramID]
+	if !evicted {
+		return nil, false
+	}
+
+	// Dropped first, so that a diagram importing itself fails to compile rather
+	// than being read back over and over.
+	delete(r.evicted, diagramID)
+	if err := r.loadDiagram(url.PathEscape(diagramID)); err != nil {
+		log.Printf("Failed to read back evicted diagram %s: %v", diagramID, err)
+		r.evicted[diagramID] = info
+		return nil, false
+	}
+
+	data := r.diagrams[diagramID]
+	data.modified = info.Modified
+	return data, true
+}
+
+// removeDiagram drops a diagram and its session, snapshots and fork point from
+// memory and tells the remove listeners. Callers must hold r.mu.
+func (r *D2OracleRepository) removeDiagram(diagramID string) {
+	delete(r.diagrams, diagramID)
+	delete(r.dependencies, diagramID)
+	delete(r.snapshots, diagramID)
+	delete(r.branches, diagramID)
+
+	r.sessionMu.Lock()
+	delete(r.sessions, diagramID)
+	r.sessionMu.Unlock()
+
+	r.notifyRemove(diagramID)
+}
+
+// lastModified returns when a diagram was last changed, preferring the time of its
+// Oracle session. Callers must hold r.mu.
+func (r *D2OracleRepository) lastModified(diagramID string) time.Time {
+	modified := r.diagrams[diagramID].modified
+
+	r.sessionMu.RLock()
+	defer r.sessionMu.RUnlock()
+	if session, exists := r.sessions[diagramID]; exists && session.LastModified.After(modified) {
+		return session.LastModified
+	}
+	return modified
+}
+
+// diagramSize estimates the memory held for a diagram by the size of its D2 source,
+// snapshots and undo history. Callers must hold r.mu.
+func (r *D2OracleRepository) diagramSize(diagramID string) int64 {
+	size := int64(len(r.diagrams[diagramID].content))
+	for _, snapshot := range r.snapshots[diagramID] {
+		size += int64(len(snapshot.Content))
+	}
+
+	r.sessionMu.RLock()
+	defer r.sessionMu.RUnlock()
+	if session, exists := r.sessions[diagramID]; exists {
+		for _, entries := range [][]historyEntry{session.history, session.undone} {
+			for _, entry := range entries {
+				size += int64(len(entry.before) + len(entry.after))
+			}
+		}
+	}
+	return size
+}
@@ -0,0 +1,189 @@
+package d2
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/i2y/d2mcp/internal/domain/entity"
+)
+
+func TestD2OracleRepository_Eviction(t *testing.T) {
+	ctx := context.Background()
+
+	create := func(t *testing.T, repo *D2OracleRepository, id, content string) {
+		t.Helper()
+		if err := repo.Create(ctx, &entity.Diagram{ID: id, Content: content}); err != nil {
+			t.Fatalf("Create(%s) error = %v", id, err)
+		}
+	}
+	list := func(repo *D2OracleRepository) string {
+		diagrams, _ := repo.ListDiagrams(ctx)
+		return strings.Join(diagramIDs(diagrams), ",")
+	}
+
+	t.Run("max diagrams", func(t *testing.T) {
+		repo := NewD2OracleRepository(WithMaxDiagrams(2))
+		var removed []string
+		repo.OnRemove(func(diagramID string) {
+			removed = append(removed, diagramID)
+		})
+
+		create(t, repo, "a", "x")
+		create(t, repo, "b", "x")
+		// Changing a keeps it; b is now the least recently changed.
+		if _, err := repo.CreateElement(ctx, "a", nil, "y"); err != nil {
+			t.Fatalf("CreateElement() error = %v", err)
+		}
+		create(t, repo, "c", "x")
+
+		if got := list(repo); got != "a,c" {
+			t.Errorf("ListDiagrams() = %s, want a,c", got)
+		}
+		if strings.Join(removed, ",") != "b" {
+			t.Errorf("removed = %v, want [b]", removed)
+		}
+		if _, err := repo.ListSnapshots(ctx, "b"); err == nil {
+			t.Error("ListSnapshots() on an evicted diagram succeeded")
+		}
+	})
+
+	t.Run("max total size", func(t *testing.T) {
+		repo := NewD2OracleRepository(WithMaxTotalSize(20))
+		create(t, repo, "a", "first -> second")
+		create(t, repo, "b", "third -> fourth")
+
+		if got := list(repo); got != "b" {
+			t.Errorf("ListDiagrams() = %s, want b", got)
+		}
+
+		// The diagram being changed is kept even when it alone exceeds the limit.
+		create(t, repo, "c", strings.Repeat("node -> ", 10)+"end")
+		if got := list(repo); got != "c" {
+			t.Errorf("ListDiagrams() = %s, want c", got)
+		}
+	})
+
+	t.Run("idle TTL", func(t *testing.T) {
+		repo := NewD2OracleRepository(WithIdleTTL(time.Hour))
+		create(t, repo, "idle", "x")
+		create(t, repo, "active", "x")
+		repo.diagrams["idle"].modified = time.Now().Add(-2 * time.Hour)
+
+		runCtx, cancel := context.WithCancel(ctx)
+		done := make(chan struct{})
+		go func() {
+			repo.RunEviction(runCtx, time.Millisecond)
+			close(done)
+		}()
+		deadline := time.Now().Add(5 * time.Second)
+		for list(repo) != "active" && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+		cancel()
+		<-done
+
+		if got := list(repo); got != "active" {
+			t.Errorf("ListDiagrams() = %s, want active", got)
+		}
+	})
+
+	t.Run("disk store saves before evicting", func(t *testing.T) {
+		dir := t.TempDir()
+		repo, err := NewD2FileRepository(dir, WithMaxDiagrams(1))
+		if err != nil {
+			t.Fatalf("NewD2FileRepository() error = %v", err)
+		}
+		var removed []string
+		repo.OnRemove(func(diagramID string) {
+			removed = append(removed, diagramID)
+		})
+		create(t, repo, "a", "x")
+		if _, err := repo.CreateElement(ctx, "a", nil, "y"); err != nil {
+			t.Fatalf("CreateElement() error = %v", err)
+		}
+		if _, err := repo.CreateSnapshot(ctx, "a", "v1"); err != nil {
+			t.Fatalf("CreateSnapshot() error = %v", err)
+		}
+		create(t, repo, "b", "x")
+
+		if _, exists := repo.diagrams["a"]; exists {
+			t.Fatal("diagram a was not evicted from memory")
+		}
+		content, err := os.ReadFile(filepath.Join(dir, "a.d2"))
+		if err != nil || !strings.Contains(string(content), "y") {
+			t.Errorf("evicted diagram file = %q, %v", content, err)
+		}
+		// Evicted diagrams stay listed and are not reported as removed.
+		if got := list(repo); got != "a,b" {
+			t.Errorf("ListDiagrams() = %s, want a,b", got)
+		}
+		if len(removed) != 0 {
+			t.Errorf("removed = %v, want none", removed)
+		}
+
+		// Using an evicted diagram reads it back with its revision and snapshots.
+		graph, err := repo.GetGraph(ctx, "a")
+		if err != nil {
+			t.Fatalf("GetGraph() on an evicted diagram error = %v", err)
+		}
+		if graph.Objects["y"] == nil {
+			t.Errorf("read back diagram is missing y: %v", graph.Objects)
+		}
+		diagrams, _ := repo.ListDiagrams(ctx)
+		if diagrams[0].Revision != 2 {
+			t.Errorf("read back revision = %d, want 2", diagrams[0].Revision)
+		}
+		if snapshots, err := repo.ListSnapshots(ctx, "a"); err != nil || len(snapshots) != 1 {
+			t.Errorf("ListSnapshots() = %v, %v, want v1", snapshots, err)
+		}
+
+		// The next change evicts down to the limit again.
+		if _, err := repo.CreateElement(ctx, "a", nil, "z"); err != nil {
+			t.Fatalf("CreateElement() on a read back diagram error = %v", err)
+		}
+		if _, exists := repo.diagrams["b"]; exists {
+			t.Error("diagram b was not evicted after changing a")
+		}
+
+		// Diagrams import evicted diagrams, reading them back.
+		create(t, repo, "c", "...@b")
+		if got := list(repo); got != "a,b,c" {
+			t.Errorf("ListDiagrams() = %s, want a,b,c", got)
+		}
+
+		// Reopening with the limit loads the workspace and evicts down to it again,
+		// keeping the evicted diagrams readable.
+		reopened, err := NewD2FileRepository(dir, WithMaxDiagrams(1))
+		if err != nil {
+			t.Fatalf("NewD2FileRepository() reopen error = %v", err)
+		}
+		if len(reopened.diagrams) != 1 {
+			t.Errorf("diagrams in memory after reopen = %d, want 1", len(reopened.diagrams))
+		}
+		if got := list(reopened); got != "a,b,c" {
+			t.Errorf("ListDiagrams() after reopen = %s, want a,b,c", got)
+		}
+		for _, id := range []string{"a", "b", "c"} {
+			if _, err := reopened.GetGraph(ctx, id); err != nil {
+				t.Errorf("GetGraph(%s) after reopen error = %v", id, err)
+			}
+		}
+
+		// Evicted diagrams still keep the diagrams they import from being deleted.
+		if err := reopened.DeleteDiagram(ctx, "b"); err == nil {
+			t.Error("DeleteDiagram() of a diagram imported by an evicted diagram succeeded")
+		}
+
+		// Deleting an evicted diagram removes its files.
+		if err := reopened.DeleteDiagram(ctx, "c"); err != nil {
+			t.Fatalf("DeleteDiagram() error = %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(dir, "c.d2")); !os.IsNotExist(err) {
+			t.Errorf("deleted diagram file still exists: %v", err)
+		}
+	})
+}
@@ -0,0 +1,188 @@
+package d2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"oss.terrastruct.com/d2/d2compiler"
+	"oss.terrastruct.com/d2/d2graph"
+
+	"github.com/i2y/d2mcp/internal/infrastructure/sandbox"
+)
+
+// WithInputDir confines LoadFile to the directory dir: files are only loaded from
+// below it, and neither they nor their imports may leave it through ".." or
+// symlinks. Without an input directory, LoadFile fails.
+func WithInputDir(dir string) OracleOption {
+	return func(r *D2OracleRepository) {
+		r.inputDir = dir
+	}
+}
+
+// importRoot is the directory a diagram loaded from a file reads its imports from.
+// Imports cannot reach outside of it.
+type importRoot struct {
+	dir  string // Absolute path of the directory
+	path string // Slash-separated path of the diagram's file within dir
+	fs   fs.FS
+}
+
+// newImportRoot returns the import root for the file at path within dir.
+func newImportRoot(dir, path string) *importRoot {
+	return &importRoot{
+		dir:  dir,
+		path: path,
+		fs:   rootFS{dir: dir},
+	}
+}
+
+// rootFS is the file system below dir. Unlike os.DirFS, it opens every file
+// through an os.Root, so symlinks cannot lead out of dir.
+type rootFS struct {
+	dir string
+}
+
+// Open opens the file at name below the directory.
+func (f rootFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	root, err := os.OpenRoot(f.dir)
+	if err != nil {
+		return nil, err
+	}
+	// Open files stay usable after their root is closed.
+	defer root.Close()
+	return root.Open(filepath.FromSlash(name))
+}
+
+// compile compiles D2 source as the content of the root's file. A nil root
+// compiles the source on its own.
+func (root *importRoot) compile(content string) (*d2graph.Graph, error) {
+	if root == nil {
+		return compileSource(content)
+	}
+	return compileFile(content, root.path, root.fs)
+}
+
+// filePath returns the path of the root's file, or "" for a nil root.
+func (root *importRoot) filePath() string {
+	if root == nil {
+		return ""
+	}
+	return root.path
+}
+
+// compileSource compiles D2 source into a graph.
+func compileSource(content string) (*d2graph.Graph, error) {
+	return compileFile(content, "", nil)
+}
+
+// compileFile compiles D2 source as the content of the file at path in fsys, so
+// that its relative imports are read from fsys.
+func compileFile(content, path string, fsys fs.FS) (*d2graph.Graph, error) {
+	graph, _, err := d2compiler.Compile(path, strings.NewReader(content), &d2compiler.CompileOptions{
+		UTF16Pos: false,
+		FS:       fsys,
+	})
+	return graph, err
+}
+
+// recompileGraph compiles content in place of graph, reading imports from where
+// graph read them.
+func recompileGraph(graph *d2graph.Graph, content string) (*d2graph.Graph, error) {
+	return compileFile(content, graph.AST.Range.Path, graph.FS)
+}
+
+// LoadFile reads the D2 file at path and stores it as a diagram. Relative paths
+// are resolved against the input directory, and absolute paths must lie inside it.
+// Relative imports are resolved within root, which defaults to the file's
+// directory and must lie inside the input directory as well, and keep being
+// resolved from there as the diagram is edited and exported. Loading over an
+// existing diagram is recorded in its history, so it can be undone.
+func (r *D2OracleRepository) LoadFile(ctx context.Context, diagramID, path, root string) error {
+	if r.inputDir == "" {
+		return errors.New("loading files is disabled because the server has no input directory")
+	}
+	if filepath.Ext(path) != sourceExt {
+		return fmt.Errorf("%s is not a D2 file; only files ending in %s can be loaded", path, sourceExt)
+	}
+
+	inputDir, err := sandbox.Open(r.inputDir)
+	if err != nil {
+		return fmt.Errorf("invalid input directory: %w", err)
+	}
+	file, err := inputRelative(inputDir, path)
+	if err != nil {
+		return err
+	}
+	rootDir := filepath.Dir(file)
+	if root != "" {
+		if rootDir, err = inputRelative(inputDir, root); err != nil {
+			return err
+		}
+	}
+	rel := sandbox.Within(rootDir, file)
+	if rel == "" {
+		return fmt.Errorf("file %s is outside of %s", path, root)
+	}
+
+	// The import root may itself be a symlink, so it is resolved before being
+	// used as the boundary of the diagram's imports.
+	resolved, err := filepath.EvalSymlinks(filepath.Join(inputDir.Resolved(), rootDir))
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", inputDir.Abs(rootDir), err)
+	}
+	if sandbox.Within(inputDir.Resolved(), resolved) == "" {
+		return fmt.Errorf("%s resolves through a symlink to %s, which is outside the input directory %s", inputDir.Abs(rootDir), resolved, inputDir.Path())
+	}
+
+	imports := newImportRoot(resolved, filepath.ToSlash(rel))
+	content, err := fs.ReadFile(imports.fs, imports.path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s inside the input directory %s: %w", inputDir.Abs(file), inputDir.Path(), err)
+	}
+	graph, err := imports.compile(string(content))
+	if err != nil {
+		return fmt.Errorf("failed to compile %s: %w", inputDir.Abs(file), err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Loading again replaces the source but keeps the diagram's render defaults.
+	if existing, exists := r.diagram(diagramID); exists {
+		if err := r.checkRevision(ctx, diagramID, existing.revision); err != nil {
+			return err
+		}
+		existing.imports = imports
+		r.commitGraph(ctx, diagramID, graph, "load file "+filepath.Base(path))
+		return nil
+	}
+
+	if err := r.checkRevision(ctx, diagramID, 0); err != nil {
+		return err
+	}
+	r.diagrams[diagramID] = &diagramData{
+		content: string(content),
+		graph:   graph,
+		imports: imports,
+	}
+	r.changed(ctx, diagramID)
+	return nil
+}
+
+// inputRelative returns name as a clean path relative to the input directory,
+// rejecting names that leave it.
+func inputRelative(inputDir *sandbox.Dir, name string) (string, error) {
+	rel, err := inputDir.Relative(name)
+	if err != nil {
+		return "", fmt.Errorf("path %s is outside the input directory %s; the server only loads files below it, so use a path relative to it or an absolute path inside it", name, inputDir.Path())
+	}
+	return rel, nil
+}
@@ -0,0 +1,187 @@
+package d2
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/i2y/d2mcp/internal/domain/entity"
+)
+
+func TestD2OracleRepository_LoadFile(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	files := map[string]string{
+		"docs/arch.d2":            "...@shared/services\ndb: @db\nweb -> api\napi -> db",
+		"docs/shared/services.d2": "web: Web App\napi: API",
+		"docs/db.d2":              "shape: cylinder\nlabel: Postgres",
+		"common/theme.d2":         "vars: {accent: blue}",
+		"docs/themed.d2":          "...@../common/theme\nweb.style.fill: ${accent}",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	repo := NewD2OracleRepository(WithInputDir(dir))
+	if err := repo.LoadFile(ctx, "arch", filepath.Join(dir, "docs/arch.d2"), ""); err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+
+	obj, err := repo.GetObject(ctx, "arch", nil, "web")
+	if err != nil || obj.Label != "Web App" {
+		t.Errorf("GetObject(web) = %+v, %v", obj, err)
+	}
+	if obj, err := repo.GetObject(ctx, "arch", nil, "db"); err != nil || obj.Shape != "cylinder" {
+		t.Errorf("GetObject(db) = %+v, %v", obj, err)
+	}
+
+	// Imports keep resolving while the diagram is edited, undone and exported.
+	if _, err := repo.CreateElement(ctx, "arch", nil, "cache"); err != nil {
+		t.Fatalf("CreateElement() error = %v", err)
+	}
+	if _, err := repo.CreateBoard(ctx, "arch", nil, entity.BoardLayer, "detail"); err != nil {
+		t.Fatalf("CreateBoard() error = %v", err)
+	}
+	if _, err := repo.Undo(ctx, "arch", 2); err != nil {
+		t.Fatalf("Undo() error = %v", err)
+	}
+	reader, err := repo.Export(ctx, "arch", entity.FormatSVG, nil)
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	svg, _ := io.ReadAll(reader)
+	if !strings.Contains(string(svg), "Postgres") {
+		t.Error("exported SVG is missing the imported label")
+	}
+	source, _ := repo.SerializeDiagram(ctx, "arch")
+	if !strings.Contains(source, "@shared/services") {
+		t.Errorf("SerializeDiagram() = %q, want the import kept", source)
+	}
+
+	// Imports outside the file's directory need a root that contains them.
+	if err := repo.LoadFile(ctx, "themed", filepath.Join(dir, "docs/themed.d2"), ""); err == nil {
+		t.Error("LoadFile() importing a parent directory without a root succeeded")
+	}
+	if err := repo.LoadFile(ctx, "themed", filepath.Join(dir, "docs/themed.d2"), dir); err != nil {
+		t.Errorf("LoadFile() with root error = %v", err)
+	}
+	if err := repo.LoadFile(ctx, "outside", filepath.Join(dir, "common/theme.d2"), filepath.Join(dir, "docs")); err == nil {
+		t.Error("LoadFile() of a file outside of root succeeded")
+	}
+
+	// Relative paths are resolved against the input directory.
+	if err := repo.LoadFile(ctx, "relative", "docs/db.d2", ""); err != nil {
+		t.Errorf("LoadFile() with a relative path error = %v", err)
+	}
+}
+
+func TestD2OracleRepository_LoadFileConfinement(t *testing.T) {
+	base := t.TempDir()
+	input := filepath.Join(base, "input")
+	outside := filepath.Join(base, "outside")
+	ctx := context.Background()
+
+	files := map[string]string{
+		"input/main.d2":     "a -> b",
+		"input/notes.txt":   "a -> b",
+		"input/docs/doc.d2": "...@../main",
+		"input/leaky.d2":    "...@linked/secret",
+		"outside/secret.d2": "secret: Secret",
+		"secret.d2":         "secret: Secret",
+	}
+	for name, content := range files {
+		path := filepath.Join(base, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(outside, filepath.Join(input, "linked")); err != nil {
+		t.Skipf("symlinks unsupported: %v", err)
+	}
+	if err := os.Symlink(filepath.Join(outside, "secret.d2"), filepath.Join(input, "secret.d2")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := NewD2OracleRepository().LoadFile(ctx, "main", filepath.Join(input, "main.d2"), ""); err == nil || !strings.Contains(err.Error(), "disabled") {
+		t.Errorf("LoadFile() without an input directory error = %v, want it disabled", err)
+	}
+
+	repo := NewD2OracleRepository(WithInputDir(input))
+	if err := repo.LoadFile(ctx, "main", "main.d2", ""); err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	if err := repo.LoadFile(ctx, "doc", "docs/doc.d2", "."); err != nil {
+		t.Errorf("LoadFile() with the input directory as root error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		path string
+		root string
+		want string
+	}{
+		{name: "parent directory", path: "../secret.d2", want: "outside the input directory"},
+		{name: "inner parent directory", path: "docs/../../secret.d2", want: "outside the input directory"},
+		{name: "absolute path outside", path: filepath.Join(base, "secret.d2"), want: "outside the input directory"},
+		{name: "root outside", path: "main.d2", root: "/", want: "outside the input directory"},
+		{name: "root in the parent directory", path: "main.d2", root: "..", want: "outside the input directory"},
+		{name: "not a D2 file", path: "notes.txt", want: "not a D2 file"},
+		{name: "symlinked file", path: "secret.d2", want: "escapes"},
+		{name: "symlinked directory", path: "linked/secret.d2", want: "symlink"},
+		{name: "symlinked root", path: "linked/secret.d2", root: "linked", want: "symlink"},
+		{name: "import through a symlink", path: "leaky.d2", want: "escapes"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := repo.LoadFile(ctx, "escaped", tt.path, tt.root)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("LoadFile(%q, %q) error = %v, want it to contain %q", tt.path, tt.root, err, tt.want)
+			}
+		})
+	}
+	if _, err := repo.GetGraph(ctx, "escaped"); err == nil {
+		t.Error("a diagram was loaded from outside the input directory")
+	}
+}
+
+func TestD2FileRepository_LoadFilePersistence(t *testing.T) {
+	dir := t.TempDir()
+	workspace := filepath.Join(dir, "workspace")
+	ctx := context.Background()
+
+	if err := os.WriteFile(filepath.Join(dir, "main.d2"), []byte("...@parts\na -> b"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "parts.d2"), []byte("a: Imported"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	repo, err := NewD2FileRepository(workspace, WithInputDir(dir))
+	if err != nil {
+		t.Fatalf("NewD2FileRepository() error = %v", err)
+	}
+	if err := repo.LoadFile(ctx, "main", filepath.Join(dir, "main.d2"), ""); err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+
+	reopened, err := NewD2FileRepository(workspace)
+	if err != nil {
+		t.Fatalf("NewD2FileRepository() reopen error = %v", err)
+	}
+	if obj, err := reopened.GetObject(ctx, "main", nil, "a"); err != nil || obj.Label != "Imported" {
+		t.Errorf("GetObject() after reopen = %+v, %v", obj, err)
+	}
+}
@@ -0,0 +1,219 @@
+package d2
+
+import (
+	"fmt"
+	"sort"
+
+	"oss.terrastruct.com/d2/d2format"
+	"oss.terrastruct.com/d2/d2graph"
+	"oss.terrastruct.com/d2/d2oracle"
+
+	"github.com/i2y/d2mcp/internal/domain/entity"
+)
+
+// Styles of the change highlight overlay. Added elements are green, removed
+// elements are drawn back as faded red ghosts and modified elements are outlined.
+// Added and modified elements keep every style they set themselves.
+var (
+	addedStyles = map[string]string{
+		"style.stroke":       "#2e7d32",
+		"style.stroke-width": "3",
+		"style.fill":         "#e8f5e9",
+	}
+	removedStyles = map[string]string{
+		"style.stroke":      "#c62828",
+		"style.stroke-dash": "3",
+		"style.fill":        "#ffebee",
+		"style.opacity":     "0.4",
+	}
+	modifiedStyles = map[string]string{
+		"style.stroke":       "#ef6c00",
+		"style.stroke-width": "4",
+	}
+)
+
+// highlightChanges returns a copy of content styled to show the changes since an
+// earlier version of the diagram. Elements removed since then are added back so
+// they can be drawn as ghosts. Changes within layers, scenarios and steps are
+// highlighted on their own board.
+func highlightChanges(content string, imports *importRoot, changes *entity.DiagramDiff) (string, error) {
+	graph, err := imports.compile(content)
+	if err != nil {
+		return "", fmt.Errorf("failed to compile diagram: %w", err)
+	}
+
+	graph = highlightBoard(graph, nil, changes)
+	for _, board := range changes.Boards {
+		graph = highlightBoard(graph, board.Path, board.Diff)
+	}
+
+	return d2format.Format(graph.AST), nil
+}
+
+// highlightBoard styles the changes to the objects and edges of one board.
+func highlightBoard(graph *d2graph.Graph, boardPath []string, changes *entity.DiagramDiff) *d2graph.Graph {
+	// Removed objects come back first so removed edges can connect to them. They
+	// are sorted by ID, so containers are restored before their children.
+	for _, obj := range changes.RemovedObjects {
+		graph = restoreObject(graph, boardPath, obj)
+	}
+	for _, edge := range changes.RemovedEdges {
+		graph = restoreEdge(graph, boardPath, edge)
+	}
+
+	for _, obj := range changes.AddedObjects {
+		graph = highlightElement(graph, boardPath, obj.ID, addedStyles)
+	}
+	for _, change := range changes.ChangedObjects {
+		graph = highlightElement(graph, boardPath, change.ID, modifiedStyles)
+	}
+	for _, edge := range changes.AddedEdges {
+		graph = highlightElement(graph, boardPath, edge.ID, addedStyles)
+	}
+	for _, change := range changes.ChangedEdges {
+		graph = highlightElement(graph, boardPath, change.ID, modifiedStyles)
+	}
+	return graph
+}
+
+// restoreObject adds a removed object back with its old label and shape, styled as a ghost.
+func restoreObject(graph *d2graph.Graph, boardPath []string, obj *entity.GraphObject) *d2graph.Graph {
+	graph, key, ok := tryEdit(graph, func(g *d2graph.Graph) (*d2graph.Graph, string, error) {
+		return d2oracle.Create(g, boardPath, obj.ID)
+	})
+	if !ok {
+		return graph
+	}
+
+	attributes := map[string]string{}
+	for name, value := range removedStyles {
+		attributes[name] = value
+	}
+	if obj.Label != "" {
+		attributes["label"] = obj.Label
+	}
+	if obj.Shape != "" {
+		attributes["shape"] = obj.Shape
+	}
+	return styleElement(graph, boardPath, key, attributes)
+}
+
+// restoreEdge adds a removed edge back with its old label, styled as a ghost.
+func restoreEdge(graph *d2graph.Graph, boardPath []string, edge *entity.GraphEdge) *d2graph.Graph {
+	if edge.From == "" || edge.To == "" {
+		return graph
+	}
+
+	graph, key, ok := tryEdit(graph, func(g *d2graph.Graph) (*d2graph.Graph, string, error) {
+		return d2oracle.Create(g, boardPath, edge.Key())
+	})
+	if !ok {
+		return graph
+	}
+
+	attributes := map[string]string{}
+	for name, value := range removedStyles {
+		if name != "style.fill" {
+			attributes[name] = value
+		}
+	}
+	if edge.Label != "" {
+		attributes["label"] = edge.Label
+	}
+	return styleElement(graph, boardPath, key, attributes)
+}
+
+// highlightElement applies highlight styles to an existing object or edge, skipping
+// the styles it already sets itself, so that the render keeps the diagram's own
+// colors. An element with its own stroke cannot be outlined, so it gets a shadow
+// as the cue instead.
+func highlightElement(graph *d2graph.Graph, boardPath []string, key string, styles map[string]string) *d2graph.Graph {
+	style := elementStyle(graph, boardPath, key)
+	if style == nil {
+		return graph
+	}
+
+	own := map[string]*d2graph.Scalar{
+		"style.stroke":       style.Stroke,
+		"style.stroke-width": style.StrokeWidth,
+		"style.stroke-dash":  style.StrokeDash,
+		"style.fill":         style.Fill,
+		"style.opacity":      style.Opacity,
+		"style.shadow":       style.Shadow,
+	}
+	attributes := map[string]string{}
+	for name, value := range styles {
+		if own[name] == nil {
+			attributes[name] = value
+		}
+	}
+	if style.Stroke != nil && style.Shadow == nil {
+		attributes["style.shadow"] = "true"
+	}
+	return styleElement(graph, boardPath, key, attributes)
+}
+
+// elementStyle returns the style of the object or edge with the given key on the
+// board at boardPath, or nil when there is no such element.
+func elementStyle(graph *d2graph.Graph, boardPath []string, key string) *d2graph.Style {
+	graph, err := resolveBoard(graph, boardPath)
+	if err != nil {
+		return nil
+	}
+	for _, obj := range graph.Objects {
+		if obj.AbsID() == key {
+			return &obj.Style
+		}
+	}
+	for _, edge := range graph.Edges {
+		if edge.AbsID() == key {
+			return &edge.Style
+		}
+	}
+	return nil
+}
+
+// styleElement sets attributes on an object or edge. An attribute the element
+// does not support is skipped, so one unusual shape cannot stop the whole
+// diagram from rendering.
+func styleElement(graph *d2graph.Graph, boardPath []string, key string, attributes map[string]string) *d2graph.Graph {
+	names := make([]string, 0, len(attributes))
+	for name := range attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		value := attributes[name]
+		graph, _, _ = tryEdit(graph, func(g *d2graph.Graph) (*d2graph.Graph, string, error) {
+			updated, err := d2oracle.Set(g, boardPath, key+"."+name, nil, &value)
+			return updated, "", err
+		})
+	}
+	return graph
+}
+
+// tryEdit applies a d2oracle edit, returning the graph unchanged when the edit
+// fails. d2oracle edits the AST in place, so a failed edit is undone by
+// compiling the previous source again.
+func tryEdit(graph *d2graph.Graph, edit func(*d2graph.Graph) (*d2graph.Graph, string, error)) (result *d2graph.Graph, key string, ok bool) {
+	before := d2format.Format(graph.AST)
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			ok = false
+		}
+		if !ok {
+			if restored, err := recompileGraph(graph, before); err == nil {
+				result = restored
+			} else {
+				result = graph
+			}
+		}
+	}()
+
+	updated, key, err := edit(graph)
+	if err != nil {
+		return graph, "", false
+	}
+	return updated, key, true
+}
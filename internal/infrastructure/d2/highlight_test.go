@@ -0,0 +1,160 @@
+package d2
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/i2y/d2mcp/internal/domain/entity"
+)
+
+func TestHighlightChanges(t *testing.T) {
+	changes := &entity.DiagramDiff{
+		AddedObjects:   []*entity.GraphObject{{ID: "db"}, {ID: "queue"}},
+		RemovedObjects: []*entity.GraphObject{{ID: "old"}, {ID: "old.cache", Label: "Cache", Shape: "cylinder"}},
+		ChangedObjects: []*entity.ElementChange{{ID: "api"}, {ID: "web"}},
+		AddedEdges:     []*entity.GraphEdge{{ID: "(api -> db)[0]", From: "api", To: "db"}},
+		RemovedEdges: []*entity.GraphEdge{{
+			ID:         "(api -> old.cache)[0]",
+			From:       "api",
+			To:         "old.cache",
+			Label:      "reads",
+			Attributes: map[string]interface{}{"dstArrow": true},
+		}},
+	}
+
+	source := "web -> api\napi -> db\ndb: {shape: cylinder}\nqueue.style.fill: \"#fff3e0\"\nweb.style.stroke: \"#123456\""
+	content, err := highlightChanges(source, nil, changes)
+	if err != nil {
+		t.Fatalf("highlightChanges() error = %v", err)
+	}
+
+	graph, err := compileSource(content)
+	if err != nil {
+		t.Fatalf("highlighted source does not compile: %v\n%s", err, content)
+	}
+	highlighted := NewD2OracleRepository().graphToEntity(graph)
+
+	checks := []struct {
+		id        string
+		attribute string
+		want      string
+	}{
+		{"db", "stroke", addedStyles["style.stroke"]},
+		{"api", "stroke", modifiedStyles["style.stroke"]},
+		{"old", "opacity", removedStyles["style.opacity"]},
+		{"old.cache", "stroke", removedStyles["style.stroke"]},
+		{"old.cache", "shape", "cylinder"},
+		{"db", "fill", addedStyles["style.fill"]},
+		{"queue", "fill", "#fff3e0"},
+		{"queue", "stroke", addedStyles["style.stroke"]},
+		{"web", "stroke", "#123456"},
+		{"web", "stroke-width", modifiedStyles["style.stroke-width"]},
+		{"web", "shadow", "true"},
+	}
+	for _, check := range checks {
+		obj, ok := highlighted.Objects[check.id]
+		if !ok {
+			t.Errorf("highlighted diagram is missing %s:\n%s", check.id, content)
+			continue
+		}
+		got, _ := obj.Attributes[check.attribute].(string)
+		if got != check.want {
+			t.Errorf("%s %s = %q, want %q", check.id, check.attribute, got, check.want)
+		}
+	}
+
+	if edge, ok := highlighted.Edges["(api -> db)[0]"]; !ok || edge.Attributes["stroke"] != addedStyles["style.stroke"] {
+		t.Errorf("added edge not highlighted:\n%s", content)
+	}
+	removed, ok := highlighted.Edges["(api -> old.cache)[0]"]
+	if !ok || removed.Label != "reads" || removed.Attributes["stroke-dash"] != removedStyles["style.stroke-dash"] {
+		t.Errorf("removed edge not restored as a ghost:\n%s", content)
+	}
+}
+
+func TestHighlightChanges_Boards(t *testing.T) {
+	changes := &entity.DiagramDiff{
+		Boards: []*entity.BoardDiff{{
+			Path: []string{"net"},
+			Kind: entity.BoardLayer,
+			Diff: &entity.DiagramDiff{
+				AddedObjects:   []*entity.GraphObject{{ID: "firewall"}},
+				RemovedObjects: []*entity.GraphObject{{ID: "proxy", Label: "Proxy"}},
+				ChangedObjects: []*entity.ElementChange{{ID: "router"}},
+			},
+		}},
+	}
+
+	source := "web\nlayers: {\n  net: {\n    router -> firewall\n  }\n}\n"
+	content, err := highlightChanges(source, nil, changes)
+	if err != nil {
+		t.Fatalf("highlightChanges() error = %v", err)
+	}
+
+	graph, err := compileSource(content)
+	if err != nil {
+		t.Fatalf("highlighted source does not compile: %v\n%s", err, content)
+	}
+	highlighted := NewD2OracleRepository().graphToEntity(graph)
+
+	if len(highlighted.Objects) != 1 {
+		t.Errorf("highlights leaked into the root board:\n%s", content)
+	}
+	if len(highlighted.Boards) != 1 {
+		t.Fatalf("highlighted diagram has %d boards:\n%s", len(highlighted.Boards), content)
+	}
+	net := highlighted.Boards[0].Graph
+
+	checks := []struct {
+		id        string
+		attribute string
+		want      string
+	}{
+		{"firewall", "stroke", addedStyles["style.stroke"]},
+		{"router", "stroke", modifiedStyles["style.stroke"]},
+		{"proxy", "opacity", removedStyles["style.opacity"]},
+	}
+	for _, check := range checks {
+		obj, ok := net.Objects[check.id]
+		if !ok {
+			t.Errorf("board net is missing %s:\n%s", check.id, content)
+			continue
+		}
+		got, _ := obj.Attributes[check.attribute].(string)
+		if got != check.want {
+			t.Errorf("%s %s = %q, want %q", check.id, check.attribute, got, check.want)
+		}
+	}
+}
+
+func TestD2OracleRepository_ExportHighlightKeepsSource(t *testing.T) {
+	repo := NewD2OracleRepository()
+	ctx := context.Background()
+
+	diagramID := "test-highlight"
+	if err := repo.LoadDiagram(ctx, diagramID, "web -> api"); err != nil {
+		t.Fatalf("LoadDiagram() error = %v", err)
+	}
+	before, _ := repo.SerializeDiagram(ctx, diagramID)
+
+	reader, err := repo.Export(ctx, diagramID, entity.FormatSVG, &entity.RenderOptions{
+		Changes: &entity.DiagramDiff{AddedObjects: []*entity.GraphObject{{ID: "api"}}},
+	})
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	svg, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("reading export: %v", err)
+	}
+	if !strings.Contains(strings.ToLower(string(svg)), addedStyles["style.stroke"]) {
+		t.Error("Export() did not draw the highlight")
+	}
+
+	after, _ := repo.SerializeDiagram(ctx, diagramID)
+	if after != before {
+		t.Errorf("Export() with highlights changed the stored diagram:\n%s", after)
+	}
+}
@@ -0,0 +1,155 @@
+package d2
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/i2y/d2mcp/internal/domain/entity"
+)
+
+// defaultHistoryDepth is how many changes per diagram can be undone by default.
+const defaultHistoryDepth = 50
+
+// historyEntry is a change recorded in a session history, with the D2 source and
+// render defaults of the diagram before and after it.
+type historyEntry struct {
+	description   string
+	operations    []entity.OracleOperation
+	before        string
+	after         string
+	beforeOptions *entity.RenderOptions
+	afterOptions  *entity.RenderOptions
+}
+
+// record adds a change to the session history, dropping the oldest changes beyond
+// the history depth. A new change discards everything that could be redone.
+func (r *D2OracleRepository) record(session *OracleSession, entry historyEntry) {
+	session.undone = nil
+	if r.historyDepth == 0 {
+		return
+	}
+
+	session.history = append(session.history, entry)
+	session.Operations = append(session.Operations, entry.operations...)
+	if excess := len(session.history) - r.historyDepth; excess > 0 {
+		for _, dropped := range session.history[:excess] {
+			session.Operations = session.Operations[len(dropped.operations):]
+		}
+		session.history = append([]historyEntry(nil), session.history[excess:]...)
+	}
+}
+
+// Undo reverts up to steps of the most recent changes to a diagram
+func (r *D2OracleRepository) Undo(ctx context.Context, diagramID string, steps int) (*entity.HistoryResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	data, err := r.lookup(ctx, diagramID)
+	if err != nil {
+		return nil, err
+	}
+
+	session := r.getOrCreateSession(diagramID, data.graph)
+	if len(session.history) == 0 {
+		return nil, fmt.Errorf("nothing to undo in diagram %s", diagramID)
+	}
+
+	steps = min(steps, len(session.history))
+	entries := session.history[len(session.history)-steps:]
+	if err := r.restore(ctx, diagramID, session, entries[0].before, entries[0].beforeOptions); err != nil {
+		return nil, err
+	}
+
+	changes := make([]string, 0, steps)
+	for i := len(entries) - 1; i >= 0; i-- {
+		entry := entries[i]
+		session.Operations = session.Operations[:len(session.Operations)-len(entry.operations)]
+		session.undone = append(session.undone, entry)
+		changes = append(changes, entry.description)
+	}
+	session.history = session.history[:len(session.history)-steps]
+
+	return historyResult(session, changes), nil
+}
+
+// Redo reapplies up to steps of the most recently undone changes to a diagram
+func (r *D2OracleRepository) Redo(ctx context.Context, diagramID string, steps int) (*entity.HistoryResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	data, err := r.lookup(ctx, diagramID)
+	if err != nil {
+		return nil, err
+	}
+
+	session := r.getOrCreateSession(diagramID, data.graph)
+	if len(session.undone) == 0 {
+		return nil, fmt.Errorf("nothing to redo in diagram %s", diagramID)
+	}
+
+	steps = min(steps, len(session.undone))
+	entries := session.undone[len(session.undone)-steps:]
+	if err := r.restore(ctx, diagramID, session, entries[0].after, entries[0].afterOptions); err != nil {
+		return nil, err
+	}
+
+	changes := make([]string, 0, steps)
+	for i := len(entries) - 1; i >= 0; i-- {
+		entry := entries[i]
+		session.Operations = append(session.Operations, entry.operations...)
+		session.history = append(session.history, entry)
+		changes = append(changes, entry.description)
+	}
+	session.undone = session.undone[:len(session.undone)-steps]
+
+	return historyResult(session, changes), nil
+}
+
+// restore replaces the diagram with content and options without recording a change.
+// Callers must hold r.mu.
+func (r *D2OracleRepository) restore(ctx context.Context, diagramID string, session *OracleSession, content string, options *entity.RenderOptions) error {
+	data := r.diagrams[diagramID]
+	graph, err := data.imports.compile(content)
+	if err != nil {
+		return fmt.Errorf("failed to restore diagram: %w", err)
+	}
+
+	data.graph = graph
+	data.content = content
+	data.options = options
+	session.Graph = graph
+	session.LastModified = time.Now()
+
+	r.changed(ctx, diagramID)
+	return nil
+}
+
+// historyResult reports the changes an undo or redo applied and what remains.
+func historyResult(session *OracleSession, changes []string) *entity.HistoryResult {
+	return &entity.HistoryResult{
+		Changes:   changes,
+		UndoDepth: len(session.history),
+		RedoDepth: len(session.undone),
+	}
+}
+
+// describeOperation returns a short description of an operation for the history.
+func describeOperation(op *entity.OracleOperation) string {
+	target := op.Key
+	if len(op.BoardPath) > 0 {
+		target = fmt.Sprintf("%s in board %s", op.Key, strings.Join(op.BoardPath, "."))
+	}
+
+	switch {
+	case op.Type == entity.OracleSet && op.Value != nil:
+		return fmt.Sprintf("set %s to %s", target, *op.Value)
+	case op.Type == entity.OracleMove && op.NewKey != nil:
+		return fmt.Sprintf("move %s to %s", target, *op.NewKey)
+	case op.Type == entity.OracleRename && op.NewKey != nil:
+		return fmt.Sprintf("rename %s to %s", target, *op.NewKey)
+	default:
+		return fmt.Sprintf("%s %s", op.Type, target)
+	}
+}
@@ -0,0 +1,227 @@
+package d2
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/i2y/d2mcp/internal/domain/entity"
+)
+
+func TestD2OracleRepository_UndoRedo(t *testing.T) {
+	repo := NewD2OracleRepository()
+	ctx := context.Background()
+
+	diagramID := "test-undo-redo"
+	if err := repo.LoadDiagram(ctx, diagramID, "web -> api\ncloud"); err != nil {
+		t.Fatalf("LoadDiagram() error = %v", err)
+	}
+	original, err := repo.SerializeDiagram(ctx, diagramID)
+	if err != nil {
+		t.Fatalf("SerializeDiagram() error = %v", err)
+	}
+
+	if _, err := repo.MoveElement(ctx, diagramID, nil, "api", "cloud.api", false); err != nil {
+		t.Fatalf("Move() error = %v", err)
+	}
+	moved, err := repo.SerializeDiagram(ctx, diagramID)
+	if err != nil {
+		t.Fatalf("SerializeDiagram() error = %v", err)
+	}
+
+	result, err := repo.Undo(ctx, diagramID, 1)
+	if err != nil {
+		t.Fatalf("Undo() error = %v", err)
+	}
+	if len(result.Changes) != 1 || result.Changes[0] != "move api to cloud.api" {
+		t.Errorf("Undo() changes = %v", result.Changes)
+	}
+	if result.UndoDepth != 0 || result.RedoDepth != 1 {
+		t.Errorf("Undo() depths = %d/%d, want 0/1", result.UndoDepth, result.RedoDepth)
+	}
+	if got, _ := repo.SerializeDiagram(ctx, diagramID); got != original {
+		t.Errorf("Undo() did not restore the diagram:\n%s", got)
+	}
+
+	// The restored graph must be usable for further edits.
+	if _, err := repo.GetObject(ctx, diagramID, nil, "api"); err != nil {
+		t.Errorf("GetObject() after Undo() error = %v", err)
+	}
+
+	if _, err := repo.Redo(ctx, diagramID, 1); err != nil {
+		t.Fatalf("Redo() error = %v", err)
+	}
+	if got, _ := repo.SerializeDiagram(ctx, diagramID); got != moved {
+		t.Errorf("Redo() did not reapply the move:\n%s", got)
+	}
+
+	if _, err := repo.Redo(ctx, diagramID, 1); err == nil {
+		t.Error("Redo() should fail when nothing was undone")
+	}
+}
+
+func TestD2OracleRepository_UndoSteps(t *testing.T) {
+	repo := NewD2OracleRepository()
+	ctx := context.Background()
+
+	diagramID := "test-undo-steps"
+	if err := repo.LoadDiagram(ctx, diagramID, "a"); err != nil {
+		t.Fatalf("LoadDiagram() error = %v", err)
+	}
+	for _, key := range []string{"b", "c", "d"} {
+		if _, err := repo.CreateElement(ctx, diagramID, nil, key); err != nil {
+			t.Fatalf("CreateElement(%s) error = %v", key, err)
+		}
+	}
+
+	// Undoing more steps than recorded stops at the oldest change.
+	result, err := repo.Undo(ctx, diagramID, 10)
+	if err != nil {
+		t.Fatalf("Undo() error = %v", err)
+	}
+	want := []string{"create d", "create c", "create b"}
+	if strings.Join(result.Changes, ",") != strings.Join(want, ",") {
+		t.Errorf("Undo() changes = %v, want %v", result.Changes, want)
+	}
+	if got, _ := repo.SerializeDiagram(ctx, diagramID); strings.TrimSpace(got) != "a" {
+		t.Errorf("Undo() left %q, want a", got)
+	}
+
+	result, err = repo.Redo(ctx, diagramID, 2)
+	if err != nil {
+		t.Fatalf("Redo() error = %v", err)
+	}
+	want = []string{"create b", "create c"}
+	if strings.Join(result.Changes, ",") != strings.Join(want, ",") {
+		t.Errorf("Redo() changes = %v, want %v", result.Changes, want)
+	}
+	if result.UndoDepth != 2 || result.RedoDepth != 1 {
+		t.Errorf("Redo() depths = %d/%d, want 2/1", result.UndoDepth, result.RedoDepth)
+	}
+
+	// A new change discards the changes waiting to be redone.
+	if _, err := repo.CreateElement(ctx, diagramID, nil, "e"); err != nil {
+		t.Fatalf("CreateElement() error = %v", err)
+	}
+	if _, err := repo.Redo(ctx, diagramID, 1); err == nil {
+		t.Error("Redo() should fail after a new change")
+	}
+}
+
+func TestD2OracleRepository_UndoBatchAndReplace(t *testing.T) {
+	repo := NewD2OracleRepository()
+	ctx := context.Background()
+
+	diagramID := "test-undo-batch"
+	if err := repo.LoadDiagram(ctx, diagramID, "web -> api"); err != nil {
+		t.Fatalf("LoadDiagram() error = %v", err)
+	}
+	original, _ := repo.SerializeDiagram(ctx, diagramID)
+
+	shape := "cylinder"
+	if _, err := repo.ExecuteBatch(ctx, diagramID, []entity.OracleOperation{
+		{Type: entity.OracleCreate, Key: "db"},
+		{Type: entity.OracleSet, Key: "db.shape", Value: &shape},
+	}); err != nil {
+		t.Fatalf("ExecuteBatch() error = %v", err)
+	}
+	if err := repo.LoadDiagram(ctx, diagramID, "x -> y"); err != nil {
+		t.Fatalf("LoadDiagram() error = %v", err)
+	}
+
+	repo.sessionMu.RLock()
+	session := repo.sessions[diagramID]
+	repo.sessionMu.RUnlock()
+	if len(session.Operations) != 2 {
+		t.Errorf("Operations = %d, want 2", len(session.Operations))
+	}
+
+	// Replacing the whole diagram is one step, and a batch is undone as a whole.
+	if _, err := repo.Undo(ctx, diagramID, 1); err != nil {
+		t.Fatalf("Undo() error = %v", err)
+	}
+	if got, _ := repo.SerializeDiagram(ctx, diagramID); !strings.Contains(got, "shape: cylinder") {
+		t.Errorf("Undo() of the load did not restore the batch result:\n%s", got)
+	}
+	if _, err := repo.Undo(ctx, diagramID, 1); err != nil {
+		t.Fatalf("Undo() error = %v", err)
+	}
+	if got, _ := repo.SerializeDiagram(ctx, diagramID); got != original {
+		t.Errorf("Undo() of the batch left:\n%s", got)
+	}
+	if len(session.Operations) != 0 {
+		t.Errorf("Operations after undo = %d, want 0", len(session.Operations))
+	}
+	if _, err := repo.Undo(ctx, diagramID, 1); err == nil {
+		t.Error("Undo() should fail when the history is empty")
+	}
+}
+
+func TestD2OracleRepository_UndoReplaceOptions(t *testing.T) {
+	repo := NewD2OracleRepository()
+	ctx := context.Background()
+
+	diagramID := "test-undo-options"
+	elk := &entity.RenderOptions{Layout: &entity.LayoutOptions{Engine: entity.LayoutELK}}
+	if err := repo.Create(ctx, &entity.Diagram{ID: diagramID, Content: "a -> b", Options: elk}); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	dagre := &entity.RenderOptions{Layout: &entity.LayoutOptions{Engine: entity.LayoutDagre}}
+	if err := repo.Create(ctx, &entity.Diagram{ID: diagramID, Content: "x -> y", Options: dagre}); err != nil {
+		t.Fatalf("Create() replace error = %v", err)
+	}
+
+	engine := func() entity.LayoutEngine {
+		return repo.diagrams[diagramID].options.Layout.Engine
+	}
+	if _, err := repo.Undo(ctx, diagramID, 1); err != nil {
+		t.Fatalf("Undo() error = %v", err)
+	}
+	if got := engine(); got != entity.LayoutELK {
+		t.Errorf("layout engine after undo = %s, want elk", got)
+	}
+	if _, err := repo.Redo(ctx, diagramID, 1); err != nil {
+		t.Fatalf("Redo() error = %v", err)
+	}
+	if got := engine(); got != entity.LayoutDagre {
+		t.Errorf("layout engine after redo = %s, want dagre", got)
+	}
+}
+
+func TestD2OracleRepository_HistoryDepth(t *testing.T) {
+	repo := NewD2OracleRepository(WithHistoryDepth(2))
+	ctx := context.Background()
+
+	diagramID := "test-history-depth"
+	if err := repo.LoadDiagram(ctx, diagramID, "a"); err != nil {
+		t.Fatalf("LoadDiagram() error = %v", err)
+	}
+	for _, key := range []string{"b", "c", "d"} {
+		if _, err := repo.CreateElement(ctx, diagramID, nil, key); err != nil {
+			t.Fatalf("CreateElement(%s) error = %v", key, err)
+		}
+	}
+
+	result, err := repo.Undo(ctx, diagramID, 5)
+	if err != nil {
+		t.Fatalf("Undo() error = %v", err)
+	}
+	if len(result.Changes) != 2 {
+		t.Errorf("Undo() undid %d changes, want 2", len(result.Changes))
+	}
+	got, _ := repo.SerializeDiagram(ctx, diagramID)
+	if !strings.Contains(got, "b") || strings.Contains(got, "c") {
+		t.Errorf("Undo() left:\n%s", got)
+	}
+
+	disabled := NewD2OracleRepository(WithHistoryDepth(0))
+	if err := disabled.LoadDiagram(ctx, diagramID, "a"); err != nil {
+		t.Fatalf("LoadDiagram() error = %v", err)
+	}
+	if _, err := disabled.CreateElement(ctx, diagramID, nil, "b"); err != nil {
+		t.Fatalf("CreateElement() error = %v", err)
+	}
+	if _, err := disabled.Undo(ctx, diagramID, 1); err == nil {
+		t.Error("Undo() should fail when the history is disabled")
+	}
+}
@@ -0,0 +1,145 @@
+package d2
+
+import (
+	"context"
+	"fmt"
+
+	"oss.terrastruct.com/d2/d2graph"
+	"oss.terrastruct.com/d2/d2layouts/d2dagrelayout"
+	"oss.terrastruct.com/d2/d2layouts/d2elklayout"
+	"oss.terrastruct.com/d2/lib/geo"
+
+	"github.com/i2y/d2mcp/internal/domain/entity"
+)
+
+// newLayoutResolver returns a D2 layout resolver that applies the given layout settings
+// to whichever engine the diagram selects.
+func newLayoutResolver(opts *entity.LayoutOptions) func(engine string) (d2graph.LayoutGraph, error) {
+	if opts == nil {
+		opts = &entity.LayoutOptions{}
+	}
+
+	return func(engine string) (d2graph.LayoutGraph, error) {
+		var layout d2graph.LayoutGraph
+		switch entity.LayoutEngine(engine) {
+		case entity.LayoutDagre, "":
+			dagreOpts := d2dagrelayout.DefaultOpts
+			if opts.NodeSpacing > 0 {
+				dagreOpts.NodeSep = opts.NodeSpacing
+			}
+			if opts.EdgeSpacing > 0 {
+				dagreOpts.EdgeSep = opts.EdgeSpacing
+			}
+			layout = func(ctx context.Context, g *d2graph.Graph) error {
+				return d2dagrelayout.Layout(ctx, g, &dagreOpts)
+			}
+
+		case entity.LayoutELK:
+			elkOpts := d2elklayout.DefaultOpts
+			if opts.NodeSpacing > 0 {
+				elkOpts.NodeSpacing = opts.NodeSpacing
+			}
+			if opts.EdgeSpacing > 0 {
+				elkOpts.EdgeNodeSpacing = opts.EdgeSpacing
+			}
+			layout = func(ctx context.Context, g *d2graph.Graph) error {
+				return d2elklayout.Layout(ctx, g, &elkOpts)
+			}
+
+		default:
+			return nil, fmt.Errorf("unsupported layout engine: %s (use dagre or elk)", engine)
+		}
+
+		if opts.Routing == entity.RoutingOrthogonal && entity.LayoutEngine(engine) != entity.LayoutELK {
+			return nil, fmt.Errorf("orthogonal edge routing requires the elk layout engine")
+		}
+
+		return func(ctx context.Context, g *d2graph.Graph) error {
+			// A direction declared in the diagram itself always wins over the default.
+			if opts.Direction != "" && g.Root.Direction.Value == "" {
+				g.Root.Direction.Value = opts.Direction
+			}
+			if err := layout(ctx, g); err != nil {
+				return err
+			}
+			routeEdges(g, opts.Routing)
+			return nil
+		}, nil
+	}
+}
+
+// routeEdges redraws the edges laid out by an engine in the given routing style.
+// Orthogonal and empty routing keep the engine's own routes.
+func routeEdges(g *d2graph.Graph, routing entity.EdgeRouting) {
+	for _, edge := range g.Edges {
+		if len(edge.Route) < 2 {
+			continue
+		}
+
+		switch routing {
+		case entity.RoutingSplines:
+			if !edge.IsCurve {
+				edge.Route = splineRoute(edge.Route)
+				edge.IsCurve = true
+			}
+
+		case entity.RoutingStraight:
+			// Self-loops and edges into their own container have no straight line.
+			if edge.Src == edge.Dst || edge.Src.IsDescendantOf(edge.Dst) || edge.Dst.IsDescendantOf(edge.Src) {
+				continue
+			}
+			points := []*geo.Point{edge.Src.Box.Center(), edge.Dst.Box.Center()}
+			start, end := edge.TraceToShape(points, 0, 1)
+			edge.Route = points[start : end+1]
+			edge.IsCurve = false
+		}
+	}
+}
+
+// splineRoute turns a polyline into a smooth Catmull-Rom curve through the same
+// points, in the cubic Bézier form D2 draws curved routes in: the first point,
+// then two control points and an end point per segment.
+func splineRoute(points []*geo.Point) []*geo.Point {
+	at := func(i int) *geo.Point {
+		return points[max(0, min(i, len(points)-1))]
+	}
+
+	route := []*geo.Point{points[0]}
+	for i := 0; i+1 < len(points); i++ {
+		before, from, to, after := at(i-1), at(i), at(i+1), at(i+2)
+		route = append(route,
+			geo.NewPoint(from.X+(to.X-before.X)/6, from.Y+(to.Y-before.Y)/6),
+			geo.NewPoint(to.X-(after.X-from.X)/6, to.Y-(after.Y-from.Y)/6),
+			to,
+		)
+	}
+	return route
+}
+
+// mergeLayoutOptions returns defaults with every non-zero field of overrides applied on top.
+func mergeLayoutOptions(defaults, overrides *entity.LayoutOptions) *entity.LayoutOptions {
+	if overrides == nil {
+		return defaults
+	}
+	if defaults == nil {
+		return overrides
+	}
+
+	merged := *defaults
+	if overrides.Engine != "" {
+		merged.Engine = overrides.Engine
+	}
+	if overrides.Direction != "" {
+		merged.Direction = overrides.Direction
+	}
+	if overrides.NodeSpacing > 0 {
+		merged.NodeSpacing = overrides.NodeSpacing
+	}
+	if overrides.EdgeSpacing > 0 {
+		merged.EdgeSpacing = overrides.EdgeSpacing
+	}
+	if overrides.Routing != "" {
+		merged.Routing = overrides.Routing
+	}
+	return &merged
+}
@@ -0,0 +1,199 @@
+package d2
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"testing"
+
+	"github.com/i2y/d2mcp/internal/domain/entity"
+)
+
+var viewBoxPattern = regexp.MustCompile(`viewBox="[-\d.]+ [-\d.]+ ([\d.]+) ([\d.]+)"`)
+
+// renderSize renders content as SVG and returns the outer viewBox size.
+func renderSize(t *testing.T, render func() (io.Reader, error)) (float64, float64) {
+	t.Helper()
+
+	reader, err := render()
+	if err != nil {
+		t.Fatalf("render error = %v", err)
+	}
+	svg, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("failed to read SVG: %v", err)
+	}
+
+	match := viewBoxPattern.FindSubmatch(svg)
+	if match == nil {
+		t.Fatalf("SVG has no viewBox")
+	}
+	var width, height float64
+	fmt.Sscan(string(match[1]), &width)
+	fmt.Sscan(string(match[2]), &height)
+	return width, height
+}
+
+func TestD2Repository_RenderLayout(t *testing.T) {
+	repo := NewD2Repository()
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		content string
+		opts    *entity.RenderOptions
+		wantErr bool
+	}{
+		{
+			name:    "default engine",
+			content: "a -> b",
+		},
+		{
+			name:    "dagre with spacing",
+			content: "a -> b; a -> c",
+			opts:    &entity.RenderOptions{Layout: &entity.LayoutOptions{Engine: entity.LayoutDagre, NodeSpacing: 120, EdgeSpacing: 40}},
+		},
+		{
+			name:    "elk",
+			content: "group: { a -> b }\ngroup.b -> c",
+			opts:    &entity.RenderOptions{Layout: &entity.LayoutOptions{Engine: entity.LayoutELK, NodeSpacing: 100}},
+		},
+		{
+			name:    "engine from d2-config",
+			content: "vars: { d2-config: { layout-engine: elk } }\na -> b",
+		},
+		{
+			name:    "unknown engine",
+			content: "a -> b",
+			opts:    &entity.RenderOptions{Layout: &entity.LayoutOptions{Engine: "tala"}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := repo.Render(ctx, tt.content, entity.FormatSVG, tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Render() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestD2Repository_LayoutDirection(t *testing.T) {
+	repo := NewD2Repository()
+	ctx := context.Background()
+
+	for _, engine := range []entity.LayoutEngine{entity.LayoutDagre, entity.LayoutELK} {
+		t.Run(string(engine), func(t *testing.T) {
+			right := &entity.RenderOptions{Layout: &entity.LayoutOptions{Engine: engine, Direction: "right"}}
+			width, height := renderSize(t, func() (io.Reader, error) {
+				return repo.Render(ctx, "a -> b -> c", entity.FormatSVG, right)
+			})
+			if width <= height {
+				t.Errorf("direction right produced %vx%v, want a wide diagram", width, height)
+			}
+
+			// A direction declared in the source takes precedence.
+			width, height = renderSize(t, func() (io.Reader, error) {
+				return repo.Render(ctx, "direction: down\na -> b -> c", entity.FormatSVG, right)
+			})
+			if width >= height {
+				t.Errorf("declared direction down produced %vx%v, want a tall diagram", width, height)
+			}
+		})
+	}
+}
+
+func TestD2Repository_ExportLayoutDefaults(t *testing.T) {
+	repo := NewD2Repository()
+	ctx := context.Background()
+
+	err := repo.Create(ctx, &entity.Diagram{
+		ID:      "wide",
+		Content: "a -> b -> c",
+		Options: &entity.RenderOptions{Layout: &entity.LayoutOptions{Engine: entity.LayoutELK, Direction: "right"}},
+	})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	// The stored defaults apply when no options are given.
+	width, height := renderSize(t, func() (io.Reader, error) {
+		return repo.Export(ctx, "wide", entity.FormatSVG, nil)
+	})
+	if width <= height {
+		t.Errorf("stored direction right produced %vx%v, want a wide diagram", width, height)
+	}
+
+	// Per-export options override individual defaults.
+	down := &entity.RenderOptions{Layout: &entity.LayoutOptions{Direction: "down"}}
+	width, height = renderSize(t, func() (io.Reader, error) {
+		return repo.Export(ctx, "wide", entity.FormatSVG, down)
+	})
+	if width >= height {
+		t.Errorf("override direction down produced %vx%v, want a tall diagram", width, height)
+	}
+}
+
+var connectionPathPattern = regexp.MustCompile(`<path d="(M[^"]*)"[^>]*class="connection`)
+
+func TestD2Repository_EdgeRouting(t *testing.T) {
+	repo := NewD2Repository()
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		layout  *entity.LayoutOptions
+		want    *regexp.Regexp
+		wantErr bool
+	}{
+		{
+			name:   "elk splines",
+			layout: &entity.LayoutOptions{Engine: entity.LayoutELK, Routing: entity.RoutingSplines},
+			want:   regexp.MustCompile(`^M [\d.]+ [\d.]+( C( [\d.]+){6})+$`),
+		},
+		{
+			name:   "dagre straight",
+			layout: &entity.LayoutOptions{Engine: entity.LayoutDagre, Routing: entity.RoutingStraight},
+			want:   regexp.MustCompile(`^M [\d.]+ [\d.]+ L [\d.]+ [\d.]+$`),
+		},
+		{
+			name:   "elk straight",
+			layout: &entity.LayoutOptions{Engine: entity.LayoutELK, Routing: entity.RoutingStraight},
+			want:   regexp.MustCompile(`^M [\d.]+ [\d.]+ L [\d.]+ [\d.]+$`),
+		},
+		{
+			name:    "dagre orthogonal",
+			layout:  &entity.LayoutOptions{Engine: entity.LayoutDagre, Routing: entity.RoutingOrthogonal},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader, err := repo.Render(ctx, "a -> b -> c\na -> c", entity.FormatSVG, &entity.RenderOptions{Layout: tt.layout})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Render() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			svg, err := io.ReadAll(reader)
+			if err != nil {
+				t.Fatalf("failed to read SVG: %v", err)
+			}
+
+			paths := connectionPathPattern.FindAllSubmatch(svg, -1)
+			if len(paths) != 3 {
+				t.Fatalf("found %d connection paths, want 3", len(paths))
+			}
+			for _, path := range paths {
+				if !tt.want.Match(path[1]) {
+					t.Errorf("connection path %q does not match %s", path[1], tt.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package d2
+
+import (
+	"context"
+	"sort"
+
+	"github.com/i2y/d2mcp/internal/domain/entity"
+)
+
+// ListDiagrams describes all stored diagrams, including those evicted to the
+// workspace, sorted by ID.
+func (r *D2OracleRepository) ListDiagrams(ctx context.Context) ([]*entity.DiagramInfo, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	infos := make([]*entity.DiagramInfo, 0, len(r.diagrams)+len(r.evicted))
+	for id, data := range r.diagrams {
+		infos = append(infos, diagramInfo(id, data))
+	}
+	for _, info := range r.evicted {
+		evicted := *info
+		infos = append(infos, &evicted)
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].ID < infos[j].ID
+	})
+	return infos, nil
+}
+
+// DeleteDiagram removes a diagram along with its session, snapshots and branch
+// record, and deletes its files from the workspace. Branches forked from the
+// diagram are kept but can no longer be merged. Diagrams other diagrams import
+// cannot be deleted.
+func (r *D2OracleRepository) DeleteDiagram(ctx context.Context, diagramID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, err := r.lookup(ctx, diagramID); err != nil {
+		return err
+	}
+	if err := r.checkNotImported(diagramID, "delete"); err != nil {
+		return err
+	}
+
+	r.removeDiagram(diagramID)
+	if r.workspace != nil {
+		return r.workspace.remove(diagramID)
+	}
+	return nil
+}
+
+// RenameDiagram moves a diagram and its session, snapshots and branches to newID,
+// keeping its revision. Branches forked from the diagram follow it. Diagrams
+// other diagrams import cannot be renamed.
+func (r *D2OracleRepository) RenameDiagram(ctx context.Context, diagramID, newID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.checkRename(ctx, diagramID, newID); err != nil {
+		return err
+	}
+
+	r.sessionMu.Lock()
+	if session, exists := r.sessions[diagramID]; exists {
+		session.DiagramID = newID
+		r.sessions[newID] = session
+		delete(r.sessions, diagramID)
+	}
+	r.sessionMu.Unlock()
+
+	if snapshots, exists := r.snapshots[diagramID]; exists {
+		for _, snapshot := range snapshots {
+			snapshot.DiagramID = newID
+		}
+		r.snapshots[newID] = snapshots
+		delete(r.snapshots, diagramID)
+	}
+	if branch, exists := r.branches[diagramID]; exists {
+		branch.ID = newID
+		r.branches[newID] = branch
+		delete(r.branches, diagramID)
+	}
+
+	r.rename(diagramID, newID)
+
+	for id, branch := range r.branches {
+		if branch.DiagramID == diagramID {
+			branch.DiagramID = newID
+			r.persistDiagram(id)
+		}
+	}
+	if r.workspace != nil {
+		return r.workspace.remove(diagramID)
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package d2
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/i2y/d2mcp/internal/domain/entity"
+	"github.com/i2y/d2mcp/internal/domain/repository"
+)
+
+func TestD2OracleRepository_DiagramLifecycle(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	repo, err := NewD2FileRepository(dir)
+	if err != nil {
+		t.Fatalf("NewD2FileRepository() error = %v", err)
+	}
+	var removed []string
+	repo.OnRemove(func(diagramID string) {
+		removed = append(removed, diagramID)
+	})
+
+	sketch := true
+	if err := repo.Create(ctx, &entity.Diagram{ID: "arch", Content: "web -> api", Options: &entity.RenderOptions{Sketch: &sketch}}); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if _, err := repo.CreateElement(ctx, "arch", nil, "db"); err != nil {
+		t.Fatalf("CreateElement() error = %v", err)
+	}
+	if _, err := repo.CreateSnapshot(ctx, "arch", "first"); err != nil {
+		t.Fatalf("CreateSnapshot() error = %v", err)
+	}
+	if _, err := repo.CreateBranch(ctx, "arch", "arch-cache"); err != nil {
+		t.Fatalf("CreateBranch() error = %v", err)
+	}
+
+	// A clone is an independent diagram at its first revision.
+	revision := &repository.Revision{DiagramID: "copy"}
+	if err := repo.CloneDiagram(repository.WithRevision(ctx, revision), "arch", "copy"); err != nil {
+		t.Fatalf("CloneDiagram() error = %v", err)
+	}
+	if revision.Current != 1 {
+		t.Errorf("clone revision = %d, want 1", revision.Current)
+	}
+	if err := repo.CloneDiagram(ctx, "arch", "arch-cache"); err == nil {
+		t.Error("CloneDiagram() onto an existing diagram succeeded")
+	}
+	if _, err := repo.DeleteElement(ctx, "copy", nil, "db"); err != nil {
+		t.Fatalf("DeleteElement() error = %v", err)
+	}
+	if source, _ := repo.SerializeDiagram(ctx, "arch"); !strings.Contains(source, "db") {
+		t.Errorf("changing the clone changed the original: %q", source)
+	}
+	if options := repo.diagrams["copy"].options; options == nil || options.Sketch == nil || !*options.Sketch {
+		t.Errorf("clone render options = %+v", options)
+	}
+
+	// Renaming moves the history, snapshots and branches and keeps the revision.
+	expected := int64(2)
+	revision = &repository.Revision{DiagramID: "arch", Expected: &expected}
+	if err := repo.RenameDiagram(repository.WithRevision(ctx, revision), "arch", "system"); err != nil {
+		t.Fatalf("RenameDiagram() error = %v", err)
+	}
+	if revision.Current != 2 {
+		t.Errorf("renamed revision = %d, want 2", revision.Current)
+	}
+	if _, err := repo.SerializeDiagram(ctx, "arch"); err == nil {
+		t.Error("SerializeDiagram() on the old ID succeeded")
+	}
+	if snapshot, err := repo.GetSnapshot(ctx, "system", "first"); err != nil || snapshot.DiagramID != "system" {
+		t.Errorf("GetSnapshot() = %+v, %v", snapshot, err)
+	}
+	if branch, err := repo.GetBranch(ctx, "arch-cache"); err != nil || branch.DiagramID != "system" {
+		t.Errorf("GetBranch() = %+v, %v", branch, err)
+	}
+	if _, err := repo.Undo(ctx, "system", 1); err != nil {
+		t.Errorf("Undo() after rename error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "arch.d2")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("old workspace file still exists: %v", err)
+	}
+
+	// Deleting removes the diagram with everything stored for it.
+	if err := repo.DeleteDiagram(ctx, "system"); err != nil {
+		t.Fatalf("DeleteDiagram() error = %v", err)
+	}
+	if err := repo.DeleteDiagram(ctx, "system"); err == nil {
+		t.Error("DeleteDiagram() of a deleted diagram succeeded")
+	}
+	if _, exists := repo.snapshots["system"]; exists {
+		t.Error("snapshots of the deleted diagram were kept")
+	}
+	if _, exists := repo.sessions["system"]; exists {
+		t.Error("session of the deleted diagram was kept")
+	}
+	if strings.Join(removed, ",") != "arch,system" {
+		t.Errorf("removed = %v, want [arch system]", removed)
+	}
+
+	reopened, err := NewD2FileRepository(dir)
+	if err != nil {
+		t.Fatalf("NewD2FileRepository() reopen error = %v", err)
+	}
+	diagrams, _ := reopened.ListDiagrams(ctx)
+	if ids := diagramIDs(diagrams); strings.Join(ids, ",") != "arch-cache,copy" {
+		t.Errorf("ListDiagrams() after reopen = %v", ids)
+	}
+}
@@ -8,7 +8,6 @@ import (
 	"time"
 
 	"oss.terrastruct.com/d2/d2ast"
-	"oss.terrastruct.com/d2/d2compiler"
 	"oss.terrastruct.com/d2/d2format"
 	"oss.terrastruct.com/d2/d2graph"
 	"oss.terrastruct.com/d2/d2oracle"
@@ -24,23 +23,93 @@ type OracleSession struct {
 	AST          *d2ast.Map
 	LastModified time.Time
 	Operations   []entity.OracleOperation // History tracking
+
+	history []historyEntry // Changes that can be undone, oldest first
+	undone  []historyEntry // Changes that can be redone, most recently undone last
 }
 
 // D2OracleRepository extends D2Repository with Oracle capabilities
 type D2OracleRepository struct {
 	*D2Repository
-	sessions  map[string]*OracleSession
-	sessionMu sync.RWMutex
+	sessions     map[string]*OracleSession
+	sessionMu    sync.RWMutex
+	historyDepth int
+	snapshots    map[string]map[string]*entity.Snapshot // Snapshots by diagram ID and name, guarded by mu
+	branches     map[string]*entity.Branch              // Branches by branch diagram ID, guarded by mu
+	workspace    *workspace                             // Directory the diagrams are kept in; nil keeps them in memory only
+	evicted      map[string]*entity.DiagramInfo         // Diagrams evicted to the workspace by ID, read back on first use; guarded by mu
+	inputDir     string                                 // Directory LoadFile is confined to; empty disables it
+	idleTTL      time.Duration
+	maxDiagrams  int
+	maxTotalSize int64
+}
+
+// OracleOption configures a D2OracleRepository.
+type OracleOption func(*D2OracleRepository)
+
+// WithHistoryDepth sets how many changes per diagram are kept for undo. Zero
+// disables undo.
+func WithHistoryDepth(depth int) OracleOption {
+	return func(r *D2OracleRepository) {
+		r.historyDepth = max(depth, 0)
+	}
 }
 
+var (
+	_ repository.OracleRepository   = (*D2OracleRepository)(nil)
+	_ repository.BoardRepository    = (*D2OracleRepository)(nil)
+	_ repository.SnapshotRepository = (*D2OracleRepository)(nil)
+	_ repository.BranchRepository   = (*D2OracleRepository)(nil)
+)
+
 // NewD2OracleRepository creates a new D2 repository with Oracle support
-func NewD2OracleRepository() repository.OracleRepository {
-	return &D2OracleRepository{
-		D2Repository: &D2Repository{
-			diagrams: make(map[string]*diagramData),
-		},
-		sessions: make(map[string]*OracleSession),
+func NewD2OracleRepository(opts ...OracleOption) *D2OracleRepository {
+	r := &D2OracleRepository{
+		D2Repository: newD2Repository(),
+		sessions:     make(map[string]*OracleSession),
+		historyDepth: defaultHistoryDepth,
+		snapshots:    make(map[string]map[string]*entity.Snapshot),
+		branches:     make(map[string]*entity.Branch),
+		evicted:      make(map[string]*entity.DiagramInfo),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	r.enforceLimits = r.evictBeyondLimits
+	return r
+}
+
+// Create creates a new diagram. Replacing an existing diagram keeps its Oracle
+// session in step and can be undone.
+func (r *D2OracleRepository) Create(ctx context.Context, diagram *entity.Diagram) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// A diagram loaded from a file keeps resolving its imports from its directory.
+	existing, exists := r.diagram(diagram.ID)
+	imports := r.store
+	if exists {
+		imports = existing.imports
+	}
+	data, err := newDiagramData(diagram, imports)
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		if err := r.checkRevision(ctx, diagram.ID, 0); err != nil {
+			return err
+		}
+		r.diagrams[diagram.ID] = data
+		r.changed(ctx, diagram.ID)
+		return nil
+	}
+
+	if err := r.checkRevision(ctx, diagram.ID, existing.revision); err != nil {
+		return err
 	}
+	r.commitGraphOptions(ctx, diagram.ID, data.graph, data.options, "replace diagram")
+	return nil
 }
 
 // LoadDiagram loads a diagram from D2 text
@@ -48,18 +117,35 @@ func (r *D2OracleRepository) LoadDiagram(ctx context.Context, diagramID string,
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	// Parse the content to create a graph
-	graph, _, err := d2compiler.Compile("", strings.NewReader(content), &d2compiler.CompileOptions{
-		UTF16Pos: false,
-	})
+	// Reloading replaces the source but keeps the diagram's render defaults and
+	// import root.
+	existing, exists := r.diagram(diagramID)
+	imports := r.store
+	if exists {
+		imports = existing.imports
+	}
+	graph, err := imports.compile(content)
 	if err != nil {
 		return fmt.Errorf("failed to compile diagram: %w", err)
 	}
 
+	if exists {
+		if err := r.checkRevision(ctx, diagramID, existing.revision); err != nil {
+			return err
+		}
+		r.commitGraph(ctx, diagramID, graph, "load diagram")
+		return nil
+	}
+
+	if err := r.checkRevision(ctx, diagramID, 0); err != nil {
+		return err
+	}
 	r.diagrams[diagramID] = &diagramData{
 		content: content,
 		graph:   graph,
+		imports: imports,
 	}
+	r.changed(ctx, diagramID)
 
 	return nil
 }
@@ -67,11 +153,11 @@ func (r *D2OracleRepository) LoadDiagram(ctx context.Context, diagramID string,
 // SerializeDiagram converts the current graph state back to D2 text
 func (r *D2OracleRepository) SerializeDiagram(ctx context.Context, diagramID string) (string, error) {
 	r.mu.RLock()
-	data, exists := r.diagrams[diagramID]
+	data, err := r.lookup(ctx, diagramID)
 	r.mu.RUnlock()
 
-	if !exists {
-		return "", fmt.Errorf("diagram %s not found", diagramID)
+	if err != nil {
+		return "", err
 	}
 
 	// Check if there's an active session with a modified graph
@@ -96,90 +182,330 @@ func (r *D2OracleRepository) SerializeDiagram(ctx context.Context, diagramID str
 	return formatted, nil
 }
 
+// SerializeBoard converts a single layer, scenario or step board back to D2 text
+func (r *D2OracleRepository) SerializeBoard(ctx context.Context, diagramID string, boardPath []string) (string, error) {
+	if len(boardPath) == 0 {
+		return r.SerializeDiagram(ctx, diagramID)
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	data, err := r.lookup(ctx, diagramID)
+	if err != nil {
+		return "", err
+	}
+
+	board, err := resolveBoard(data.graph, boardPath)
+	if err != nil {
+		return "", err
+	}
+	if board.AST == nil {
+		return "", nil
+	}
+
+	return d2format.Format(board.AST), nil
+}
+
 // CreateElement creates a new shape or connection
 func (r *D2OracleRepository) CreateElement(ctx context.Context, diagramID string, boardPath []string, key string) (*entity.OracleResult, error) {
+	return r.execute(ctx, diagramID, &entity.OracleOperation{
+		Type:      entity.OracleCreate,
+		BoardPath: boardPath,
+		Key:       key,
+	})
+}
+
+// SetAttribute sets attributes on a shape or connection
+func (r *D2OracleRepository) SetAttribute(ctx context.Context, diagramID string, boardPath []string, key string, tag, value *string) (*entity.OracleResult, error) {
+	return r.execute(ctx, diagramID, &entity.OracleOperation{
+		Type:      entity.OracleSet,
+		BoardPath: boardPath,
+		Key:       key,
+		Tag:       tag,
+		Value:     value,
+	})
+}
+
+// DeleteElement deletes a shape or connection
+func (r *D2OracleRepository) DeleteElement(ctx context.Context, diagramID string, boardPath []string, key string) (*entity.OracleResult, error) {
+	return r.execute(ctx, diagramID, &entity.OracleOperation{
+		Type:      entity.OracleDelete,
+		BoardPath: boardPath,
+		Key:       key,
+	})
+}
+
+// MoveElement moves a shape to a new container
+func (r *D2OracleRepository) MoveElement(ctx context.Context, diagramID string, boardPath []string, key, newKey string, includeDescendants bool) (*entity.OracleResult, error) {
+	return r.execute(ctx, diagramID, &entity.OracleOperation{
+		Type:               entity.OracleMove,
+		BoardPath:          boardPath,
+		Key:                key,
+		NewKey:             &newKey,
+		IncludeDescendants: includeDescendants,
+	})
+}
+
+// RenameElement renames a shape or connection
+func (r *D2OracleRepository) RenameElement(ctx context.Context, diagramID string, boardPath []string, key, newName string) (*entity.OracleResult, error) {
+	return r.execute(ctx, diagramID, &entity.OracleOperation{
+		Type:      entity.OracleRename,
+		BoardPath: boardPath,
+		Key:       key,
+		NewKey:    &newName,
+	})
+}
+
+// ExecuteBatch applies operations in order to a working copy of the diagram and
+// commits the result only when all of them succeed. If any operation fails, the
+// diagram is left as it was. The results do not include the graph state, which
+// would cost a conversion of the whole diagram per operation.
+func (r *D2OracleRepository) ExecuteBatch(ctx context.Context, diagramID string, ops []entity.OracleOperation) ([]*entity.OracleResult, error) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	data, exists := r.diagrams[diagramID]
-	if !exists {
-		return nil, fmt.Errorf("diagram %s not found", diagramID)
+	return r.executeBatch(ctx, diagramID, ops, fmt.Sprintf("batch of %d operations", len(ops)))
+}
+
+// executeBatch applies operations to a working copy of the diagram and commits
+// them as one change when all of them succeed. Callers must hold r.mu.
+func (r *D2OracleRepository) executeBatch(ctx context.Context, diagramID string, ops []entity.OracleOperation, change string) ([]*entity.OracleResult, error) {
+	data, err := r.lookup(ctx, diagramID)
+	if err != nil {
+		return nil, err
 	}
 
-	// Get or create session
 	session := r.getOrCreateSession(diagramID, data.graph)
 
-	// Use d2oracle to create element
-	newGraph, newKey, err := d2oracle.Create(session.Graph, boardPath, key)
+	// d2oracle edits the AST of the graph it is given, so work on a fresh compile.
+	graph, err := copyGraph(session.Graph)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create element: %w", err)
+		return nil, err
 	}
 
-	// Update session
-	session.Graph = newGraph
-	session.LastModified = time.Now()
+	results := make([]*entity.OracleResult, len(ops))
+	for i := range ops {
+		var result *entity.OracleResult
+		graph, result, err = applyOperation(graph, &ops[i])
+		if err != nil {
+			return nil, fmt.Errorf("operation %d (%s %s) failed, no operations were applied: %w", i+1, ops[i].Type, ops[i].Key, err)
+		}
+		results[i] = result
+	}
 
-	// Update stored graph
-	data.graph = newGraph
+	r.commitGraph(ctx, diagramID, graph, change, ops...)
 
-	// Serialize back to D2 text
-	if newGraph.AST != nil {
-		data.content = d2format.Format(newGraph.AST)
+	return results, nil
+}
+
+// GetObject retrieves object information
+func (r *D2OracleRepository) GetObject(ctx context.Context, diagramID string, boardPath []string, objectID string) (*entity.GraphObject, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	data, err := r.lookup(ctx, diagramID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := resolveBoard(data.graph, boardPath); err != nil {
+		return nil, err
 	}
 
-	return &entity.OracleResult{
-		Success: true,
-		NewKey:  newKey,
-		Graph:   r.graphToEntity(newGraph),
-	}, nil
+	// Use d2oracle to get object
+	obj := d2oracle.GetObj(data.graph, boardPath, objectID)
+	if obj == nil {
+		return nil, fmt.Errorf("object %s not found", objectID)
+	}
+
+	return r.objectToEntity(obj), nil
 }
 
-// SetAttribute sets attributes on a shape or connection
-func (r *D2OracleRepository) SetAttribute(ctx context.Context, diagramID string, boardPath []string, key string, tag, value *string) (*entity.OracleResult, error) {
-	r.mu.Lock()
-	defer r.mu.Unlock()
+// GetEdge retrieves edge information
+func (r *D2OracleRepository) GetEdge(ctx context.Context, diagramID string, boardPath []string, edgeID string) (*entity.GraphEdge, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 
-	data, exists := r.diagrams[diagramID]
-	if !exists {
-		return nil, fmt.Errorf("diagram %s not found", diagramID)
+	data, err := r.lookup(ctx, diagramID)
+	if err != nil {
+		return nil, err
 	}
 
-	session := r.getOrCreateSession(diagramID, data.graph)
+	if _, err := resolveBoard(data.graph, boardPath); err != nil {
+		return nil, err
+	}
+
+	// Use d2oracle to get edge
+	edge := d2oracle.GetEdge(data.graph, boardPath, edgeID)
+	if edge == nil {
+		return nil, fmt.Errorf("edge %s not found", edgeID)
+	}
+
+	return r.edgeToEntity(edge), nil
+}
+
+// GetChildren retrieves child element IDs
+func (r *D2OracleRepository) GetChildren(ctx context.Context, diagramID string, boardPath []string, parentID string) ([]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 
-	// Use d2oracle to set attribute
-	newGraph, err := d2oracle.Set(session.Graph, boardPath, key, tag, value)
+	data, err := r.lookup(ctx, diagramID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to set attribute: %w", err)
+		return nil, err
 	}
 
-	// Update session and stored graph
-	session.Graph = newGraph
-	session.LastModified = time.Now()
-	data.graph = newGraph
+	if _, err := resolveBoard(data.graph, boardPath); err != nil {
+		return nil, err
+	}
 
-	// Serialize back to D2 text
-	if newGraph.AST != nil {
-		data.content = d2format.Format(newGraph.AST)
+	// Use d2oracle to get children IDs
+	childrenIDs, err := d2oracle.GetChildrenIDs(data.graph, boardPath, parentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get children IDs: %w", err)
+	}
+	return childrenIDs, nil
+}
+
+// GetGraph retrieves the objects and edges of the diagram's root board
+func (r *D2OracleRepository) GetGraph(ctx context.Context, diagramID string) (*entity.DiagramGraph, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	data, err := r.lookup(ctx, diagramID)
+	if err != nil {
+		return nil, err
 	}
 
-	return &entity.OracleResult{
-		Success: true,
-		Graph:   r.graphToEntity(newGraph),
-	}, nil
+	graph := r.graphToEntity(data.graph)
+	if graph == nil {
+		graph = &entity.DiagramGraph{
+			Objects: make(map[string]*entity.GraphObject),
+			Edges:   make(map[string]*entity.GraphEdge),
+		}
+	}
+	graph.ID = diagramID
+	graph.Content = data.content
+	return graph, nil
 }
 
-// DeleteElement deletes a shape or connection
-func (r *D2OracleRepository) DeleteElement(ctx context.Context, diagramID string, boardPath []string, key string) (*entity.OracleResult, error) {
+// Helper methods
+
+// resolveBoard returns the graph of the layer, scenario or step at boardPath.
+// An empty board path refers to the root board.
+func resolveBoard(graph *d2graph.Graph, boardPath []string) (*d2graph.Graph, error) {
+	if len(boardPath) == 0 {
+		return graph, nil
+	}
+
+	board := d2oracle.GetBoardGraph(graph, boardPath)
+	if board == nil {
+		return nil, fmt.Errorf("board %s not found", strings.Join(boardPath, "."))
+	}
+	return board, nil
+}
+
+// ParseGraph compiles D2 text into the objects and edges of its root board without storing it
+func (r *D2OracleRepository) ParseGraph(ctx context.Context, content string) (*entity.DiagramGraph, error) {
+	r.mu.RLock()
+	graph, err := r.store.compile(content)
+	r.mu.RUnlock()
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile diagram: %w", err)
+	}
+
+	diagramGraph := r.graphToEntity(graph)
+	diagramGraph.Content = content
+	return diagramGraph, nil
+}
+
+// execute applies a single operation to a copy of the diagram's session graph and
+// commits the result.
+func (r *D2OracleRepository) execute(ctx context.Context, diagramID string, op *entity.OracleOperation) (*entity.OracleResult, error) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	data, exists := r.diagrams[diagramID]
-	if !exists {
-		return nil, fmt.Errorf("diagram %s not found", diagramID)
+	data, err := r.lookup(ctx, diagramID)
+	if err != nil {
+		return nil, err
 	}
 
 	session := r.getOrCreateSession(diagramID, data.graph)
 
+	// d2oracle edits the AST of the graph it is given in place, so the operation
+	// works on a copy and a failing one leaves the diagram as it was.
+	graph, err := copyGraph(session.Graph)
+	if err != nil {
+		return nil, err
+	}
+	newGraph, result, err := applyOperation(graph, op)
+	if err != nil {
+		return nil, err
+	}
+
+	r.commitGraph(ctx, diagramID, newGraph, describeOperation(op), *op)
+
+	result.Graph = r.graphToEntity(newGraph)
+	return result, nil
+}
+
+// applyOperation applies an operation to graph with d2oracle and returns the new graph.
+// The result does not include the graph state.
+func applyOperation(graph *d2graph.Graph, op *entity.OracleOperation) (*d2graph.Graph, *entity.OracleResult, error) {
+	if _, err := resolveBoard(graph, op.BoardPath); err != nil {
+		return nil, nil, err
+	}
+
+	switch op.Type {
+	case entity.OracleCreate:
+		newGraph, newKey, err := d2oracle.Create(graph, op.BoardPath, op.Key)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create element: %w", err)
+		}
+		return newGraph, &entity.OracleResult{Success: true, NewKey: newKey}, nil
+
+	case entity.OracleSet:
+		newGraph, err := d2oracle.Set(graph, op.BoardPath, op.Key, op.Tag, op.Value)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to set attribute: %w", err)
+		}
+		return newGraph, &entity.OracleResult{Success: true}, nil
+
+	case entity.OracleDelete:
+		return deleteElement(graph, op.BoardPath, op.Key)
+
+	case entity.OracleMove:
+		if op.NewKey == nil {
+			return nil, nil, fmt.Errorf("new key is required to move %s", op.Key)
+		}
+		newGraph, err := d2oracle.Move(graph, op.BoardPath, op.Key, *op.NewKey, op.IncludeDescendants)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to move element: %w", err)
+		}
+		return newGraph, &entity.OracleResult{Success: true}, nil
+
+	case entity.OracleRename:
+		if op.NewKey == nil {
+			return nil, nil, fmt.Errorf("new name is required to rename %s", op.Key)
+		}
+		// Get ID deltas before rename
+		idDeltas, err := d2oracle.RenameIDDeltas(graph, op.BoardPath, op.Key, *op.NewKey)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get rename ID deltas: %w", err)
+		}
+		newGraph, newKey, err := d2oracle.Rename(graph, op.BoardPath, op.Key, *op.NewKey)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to rename element: %w", err)
+		}
+		return newGraph, &entity.OracleResult{Success: true, NewKey: newKey, IDDeltas: idDeltas}, nil
+
+	default:
+		return nil, nil, fmt.Errorf("unknown operation type: %s", op.Type)
+	}
+}
+
+// deleteElement deletes a shape or connection from graph.
+func deleteElement(graph *d2graph.Graph, boardPath []string, key string) (*d2graph.Graph, *entity.OracleResult, error) {
 	// Check if this is a connection deletion (contains "->")
 	isConnection := strings.Contains(key, "->")
 
@@ -193,7 +519,7 @@ func (r *D2OracleRepository) DeleteElement(ctx context.Context, diagramID string
 			}
 		}()
 		var err error
-		idDeltas, err = d2oracle.DeleteIDDeltas(session.Graph, boardPath, key)
+		idDeltas, err = d2oracle.DeleteIDDeltas(graph, boardPath, key)
 		if err != nil {
 			idDeltas = make(map[string]string)
 		}
@@ -208,176 +534,69 @@ func (r *D2OracleRepository) DeleteElement(ctx context.Context, diagramID string
 				// If panic occurs during delete, try alternative approach for connections
 				if isConnection {
 					// For connections, we'll recreate the graph without this connection
-					deleteErr = r.deleteConnectionWorkaround(ctx, diagramID, key)
-					if deleteErr == nil {
-						// Reload the graph after workaround
-						newGraph = r.diagrams[diagramID].graph
-					}
+					newGraph, deleteErr = deleteConnectionWorkaround(graph, key)
 				} else {
 					deleteErr = fmt.Errorf("failed to delete element: panic occurred - %v", panicErr)
 				}
 			}
 		}()
-		if deleteErr == nil {
-			newGraph, deleteErr = d2oracle.Delete(session.Graph, boardPath, key)
-		}
+		newGraph, deleteErr = d2oracle.Delete(graph, boardPath, key)
 	}()
 
 	if deleteErr != nil {
-		return nil, deleteErr
-	}
-
-	// Update session and stored graph
-	session.Graph = newGraph
-	session.LastModified = time.Now()
-	data.graph = newGraph
-
-	// Serialize back to D2 text
-	if newGraph.AST != nil {
-		data.content = d2format.Format(newGraph.AST)
+		return nil, nil, deleteErr
 	}
 
-	return &entity.OracleResult{
-		Success:  true,
-		IDDeltas: idDeltas,
-		Graph:    r.graphToEntity(newGraph),
-	}, nil
+	return newGraph, &entity.OracleResult{Success: true, IDDeltas: idDeltas}, nil
 }
 
-// MoveElement moves a shape to a new container
-func (r *D2OracleRepository) MoveElement(ctx context.Context, diagramID string, boardPath []string, key, newKey string, includeDescendants bool) (*entity.OracleResult, error) {
-	r.mu.Lock()
-	defer r.mu.Unlock()
-
-	data, exists := r.diagrams[diagramID]
-	if !exists {
-		return nil, fmt.Errorf("diagram %s not found", diagramID)
-	}
-
-	session := r.getOrCreateSession(diagramID, data.graph)
-
-	// Use d2oracle to move element
-	newGraph, err := d2oracle.Move(session.Graph, boardPath, key, newKey, includeDescendants)
+// copyGraph compiles an independent copy of graph from its D2 text.
+func copyGraph(graph *d2graph.Graph) (*d2graph.Graph, error) {
+	copied, err := recompileGraph(graph, d2format.Format(graph.AST))
 	if err != nil {
-		return nil, fmt.Errorf("failed to move element: %w", err)
-	}
-
-	// Update session and stored graph
-	session.Graph = newGraph
-	session.LastModified = time.Now()
-	data.graph = newGraph
-
-	// Serialize back to D2 text
-	if newGraph.AST != nil {
-		data.content = d2format.Format(newGraph.AST)
+		return nil, fmt.Errorf("failed to copy diagram: %w", err)
 	}
-
-	return &entity.OracleResult{
-		Success: true,
-		Graph:   r.graphToEntity(newGraph),
-	}, nil
+	return copied, nil
 }
 
-// RenameElement renames a shape or connection
-func (r *D2OracleRepository) RenameElement(ctx context.Context, diagramID string, boardPath []string, key, newName string) (*entity.OracleResult, error) {
-	r.mu.Lock()
-	defer r.mu.Unlock()
-
-	data, exists := r.diagrams[diagramID]
-	if !exists {
-		return nil, fmt.Errorf("diagram %s not found", diagramID)
-	}
-
-	session := r.getOrCreateSession(diagramID, data.graph)
-
-	// Get ID deltas before rename
-	idDeltas, err := d2oracle.RenameIDDeltas(session.Graph, boardPath, key, newName)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get rename ID deltas: %w", err)
-	}
-
-	// Use d2oracle to rename element
-	newGraph, newRenamedKey, err := d2oracle.Rename(session.Graph, boardPath, key, newName)
-	if err != nil {
-		return nil, fmt.Errorf("failed to rename element: %w", err)
-	}
+// commitGraph stores newGraph as the current state of the diagram and its session,
+// records the change in the session history, increases the revision and tells the
+// change listeners.
+// Callers must hold r.mu.
+func (r *D2OracleRepository) commitGraph(ctx context.Context, diagramID string, newGraph *d2graph.Graph, change string, ops ...entity.OracleOperation) {
+	r.commitGraphOptions(ctx, diagramID, newGraph, r.diagrams[diagramID].options, change, ops...)
+}
 
-	// Update session and stored graph
-	session.Graph = newGraph
-	session.LastModified = time.Now()
+// commitGraphOptions is commitGraph for changes that also replace the render
+// defaults of the diagram, so that undoing them restores the previous defaults.
+// Callers must hold r.mu.
+func (r *D2OracleRepository) commitGraphOptions(ctx context.Context, diagramID string, newGraph *d2graph.Graph, options *entity.RenderOptions, change string, ops ...entity.OracleOperation) {
+	data := r.diagrams[diagramID]
+	// The stored source is the only copy of the previous state that d2oracle has
+	// not edited in place.
+	before := data.content
+	beforeOptions := data.options
+	data.options = options
 	data.graph = newGraph
-
-	// Serialize back to D2 text
 	if newGraph.AST != nil {
 		data.content = d2format.Format(newGraph.AST)
 	}
 
-	return &entity.OracleResult{
-		Success:  true,
-		NewKey:   newRenamedKey,
-		IDDeltas: idDeltas,
-		Graph:    r.graphToEntity(newGraph),
-	}, nil
-}
-
-// GetObject retrieves object information
-func (r *D2OracleRepository) GetObject(ctx context.Context, diagramID string, boardPath []string, objectID string) (*entity.GraphObject, error) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-
-	data, exists := r.diagrams[diagramID]
-	if !exists {
-		return nil, fmt.Errorf("diagram %s not found", diagramID)
-	}
-
-	// Use d2oracle to get object
-	obj := d2oracle.GetObj(data.graph, boardPath, objectID)
-	if obj == nil {
-		return nil, fmt.Errorf("object %s not found", objectID)
-	}
-
-	return r.objectToEntity(obj), nil
-}
-
-// GetEdge retrieves edge information
-func (r *D2OracleRepository) GetEdge(ctx context.Context, diagramID string, boardPath []string, edgeID string) (*entity.GraphEdge, error) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-
-	data, exists := r.diagrams[diagramID]
-	if !exists {
-		return nil, fmt.Errorf("diagram %s not found", diagramID)
-	}
-
-	// Use d2oracle to get edge
-	edge := d2oracle.GetEdge(data.graph, boardPath, edgeID)
-	if edge == nil {
-		return nil, fmt.Errorf("edge %s not found", edgeID)
-	}
-
-	return r.edgeToEntity(edge), nil
-}
-
-// GetChildren retrieves child element IDs
-func (r *D2OracleRepository) GetChildren(ctx context.Context, diagramID string, boardPath []string, parentID string) ([]string, error) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-
-	data, exists := r.diagrams[diagramID]
-	if !exists {
-		return nil, fmt.Errorf("diagram %s not found", diagramID)
-	}
+	session := r.getOrCreateSession(diagramID, newGraph)
+	session.Graph = newGraph
+	session.LastModified = time.Now()
+	r.record(session, historyEntry{
+		description:   change,
+		operations:    ops,
+		before:        before,
+		after:         data.content,
+		beforeOptions: beforeOptions,
+		afterOptions:  options,
+	})
 
-	// Use d2oracle to get children IDs
-	childrenIDs, err := d2oracle.GetChildrenIDs(data.graph, boardPath, parentID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get children IDs: %w", err)
-	}
-	return childrenIDs, nil
+	r.changed(ctx, diagramID)
 }
 
-// Helper methods
-
 func (r *D2OracleRepository) getOrCreateSession(diagramID string, graph *d2graph.Graph) *OracleSession {
 	r.sessionMu.Lock()
 	defer r.sessionMu.Unlock()
@@ -397,25 +616,15 @@ func (r *D2OracleRepository) getOrCreateSession(diagramID string, graph *d2graph
 	return session
 }
 
-// deleteConnectionWorkaround handles connection deletion when Oracle API panics
-// Note: This method assumes the caller already holds the mutex lock
-func (r *D2OracleRepository) deleteConnectionWorkaround(ctx context.Context, diagramID string, connectionKey string) error {
-	// Get current data - no lock needed as caller already has it
-	data, exists := r.diagrams[diagramID]
-	if !exists {
-		return fmt.Errorf("diagram %s not found", diagramID)
-	}
-
-	// Get current D2 text from the data
-	currentD2 := data.content
-	if data.graph != nil && data.graph.AST != nil {
-		currentD2 = d2format.Format(data.graph.AST)
-	}
+// deleteConnectionWorkaround removes a connection from the D2 text of graph and
+// compiles the result. It is used when d2oracle panics while deleting a connection.
+func deleteConnectionWorkaround(graph *d2graph.Graph, connectionKey string) (*d2graph.Graph, error) {
+	currentD2 := d2format.Format(graph.AST)
 
 	// Parse the connection key (e.g., "Customer -> Order")
 	parts := strings.Split(connectionKey, "->")
 	if len(parts) != 2 {
-		return fmt.Errorf("invalid connection key format: %s", connectionKey)
+		return nil, fmt.Errorf("invalid connection key format: %s", connectionKey)
 	}
 
 	src := strings.TrimSpace(parts[0])
@@ -437,23 +646,11 @@ func (r *D2OracleRepository) deleteConnectionWorkaround(ctx context.Context, dia
 		newLines = append(newLines, line)
 	}
 
-	newD2 := strings.Join(newLines, "\n")
-
-	// Compile the new D2 text directly without calling LoadDiagram to avoid deadlock
-	graph, _, err := d2compiler.Compile("", strings.NewReader(newD2), &d2compiler.CompileOptions{
-		UTF16Pos: false,
-	})
+	newGraph, err := recompileGraph(graph, strings.Join(newLines, "\n"))
 	if err != nil {
-		return fmt.Errorf("failed to compile diagram after removing connection: %w", err)
+		return nil, fmt.Errorf("failed to compile diagram after removing connection: %w", err)
 	}
-
-	// Update the diagram data directly
-	r.diagrams[diagramID] = &diagramData{
-		content: newD2,
-		graph:   graph,
-	}
-
-	return nil
+	return newGraph, nil
 }
 
 func (r *D2OracleRepository) graphToEntity(graph *d2graph.Graph) *entity.DiagramGraph {
@@ -466,9 +663,11 @@ func (r *D2OracleRepository) graphToEntity(graph *d2graph.Graph) *entity.Diagram
 		Edges:   make(map[string]*entity.GraphEdge),
 	}
 
-	// Convert objects
+	// Convert objects, keyed by their absolute IDs so nested objects with the same
+	// name stay apart
 	for _, obj := range graph.Objects {
-		diagramGraph.Objects[obj.ID] = r.objectToEntity(obj)
+		graphObj := r.objectToEntity(obj)
+		diagramGraph.Objects[graphObj.ID] = graphObj
 	}
 
 	// Convert edges
@@ -479,6 +678,16 @@ func (r *D2OracleRepository) graphToEntity(graph *d2graph.Graph) *entity.Diagram
 		}
 	}
 
+	for _, kind := range boardKinds {
+		for _, board := range boardGraphs(graph, kind) {
+			diagramGraph.Boards = append(diagramGraph.Boards, &entity.BoardGraph{
+				Name:  board.Name,
+				Kind:  kind,
+				Graph: r.graphToEntity(board),
+			})
+		}
+	}
+
 	return diagramGraph
 }
 
@@ -488,7 +697,7 @@ func (r *D2OracleRepository) objectToEntity(obj *d2graph.Object) *entity.GraphOb
 	}
 
 	graphObj := &entity.GraphObject{
-		ID:         obj.ID,
+		ID:         obj.AbsID(),
 		Label:      obj.Label.Value,
 		Attributes: make(map[string]interface{}),
 	}
@@ -498,7 +707,7 @@ func (r *D2OracleRepository) objectToEntity(obj *d2graph.Object) *entity.GraphOb
 	}
 
 	if obj.Parent != nil {
-		graphObj.Parent = obj.Parent.ID
+		graphObj.Parent = obj.Parent.AbsID()
 	}
 
 	// Convert key attributes
@@ -508,12 +717,22 @@ func (r *D2OracleRepository) objectToEntity(obj *d2graph.Object) *entity.GraphOb
 	if obj.Attributes.Shape.Value != "" {
 		graphObj.Attributes["shape"] = obj.Attributes.Shape.Value
 	}
-	if obj.Attributes.Style.Fill != nil && obj.Attributes.Style.Fill.Value != "" {
-		graphObj.Attributes["fill"] = obj.Attributes.Style.Fill.Value
+	if obj.Attributes.Icon != nil {
+		graphObj.Attributes["icon"] = obj.Attributes.Icon.String()
 	}
-	if obj.Attributes.Style.Stroke != nil && obj.Attributes.Style.Stroke.Value != "" {
-		graphObj.Attributes["stroke"] = obj.Attributes.Style.Stroke.Value
+	if obj.Attributes.NearKey != nil {
+		graphObj.Attributes["near"] = d2format.Format(obj.Attributes.NearKey)
 	}
+	if obj.Attributes.Direction.Value != "" {
+		graphObj.Attributes["direction"] = obj.Attributes.Direction.Value
+	}
+	addScalarAttributes(graphObj.Attributes, map[string]*d2graph.Scalar{
+		"tooltip": obj.Attributes.Tooltip,
+		"link":    obj.Attributes.Link,
+		"width":   obj.Attributes.WidthAttr,
+		"height":  obj.Attributes.HeightAttr,
+	})
+	addStyleAttributes(graphObj.Attributes, obj.Attributes.Style)
 
 	return graphObj
 }
@@ -523,10 +742,10 @@ func (r *D2OracleRepository) edgeToEntity(edge *d2graph.Edge) *entity.GraphEdge
 		return nil
 	}
 
-	// Generate ID from source and destination
+	// Use the same ID format that d2oracle accepts, e.g. (a -> b)[0]
 	edgeID := fmt.Sprintf("%d", edge.Index)
 	if edge.Src != nil && edge.Dst != nil {
-		edgeID = fmt.Sprintf("%s->%s", edge.Src.ID, edge.Dst.ID)
+		edgeID = edge.AbsID()
 	}
 
 	graphEdge := &entity.GraphEdge{
@@ -536,26 +755,64 @@ func (r *D2OracleRepository) edgeToEntity(edge *d2graph.Edge) *entity.GraphEdge
 	}
 
 	if edge.Src != nil {
-		graphEdge.From = edge.Src.ID
+		graphEdge.From = edge.Src.AbsID()
 	}
 
 	if edge.Dst != nil {
-		graphEdge.To = edge.Dst.ID
+		graphEdge.To = edge.Dst.AbsID()
 	}
 
 	// Convert key attributes
 	if edge.Attributes.Label.Value != "" {
 		graphEdge.Attributes["label"] = edge.Attributes.Label.Value
 	}
-	if edge.Attributes.Style.Stroke != nil && edge.Attributes.Style.Stroke.Value != "" {
-		graphEdge.Attributes["stroke"] = edge.Attributes.Style.Stroke.Value
-	}
 	if edge.SrcArrow {
 		graphEdge.Attributes["srcArrow"] = true
 	}
 	if edge.DstArrow {
 		graphEdge.Attributes["dstArrow"] = true
 	}
+	addScalarAttributes(graphEdge.Attributes, map[string]*d2graph.Scalar{
+		"tooltip": edge.Attributes.Tooltip,
+		"link":    edge.Attributes.Link,
+	})
+	addStyleAttributes(graphEdge.Attributes, edge.Attributes.Style)
 
 	return graphEdge
 }
+
+// addScalarAttributes adds the attributes that are set, under their D2 names.
+func addScalarAttributes(attributes map[string]interface{}, scalars map[string]*d2graph.Scalar) {
+	for name, scalar := range scalars {
+		if scalar != nil && scalar.Value != "" {
+			attributes[name] = scalar.Value
+		}
+	}
+}
+
+// addStyleAttributes adds the style attributes that are set, under their D2 names
+// without the style. prefix.
+func addStyleAttributes(attributes map[string]interface{}, style d2graph.Style) {
+	addScalarAttributes(attributes, map[string]*d2graph.Scalar{
+		"opacity":        style.Opacity,
+		"stroke":         style.Stroke,
+		"fill":           style.Fill,
+		"fill-pattern":   style.FillPattern,
+		"stroke-width":   style.StrokeWidth,
+		"stroke-dash":    style.StrokeDash,
+		"border-radius":  style.BorderRadius,
+		"shadow":         style.Shadow,
+		"3d":             style.ThreeDee,
+		"multiple":       style.Multiple,
+		"font":           style.Font,
+		"font-size":      style.FontSize,
+		"font-color":     style.FontColor,
+		"animated":       style.Animated,
+		"bold":           style.Bold,
+		"italic":         style.Italic,
+		"underline":      style.Underline,
+		"filled":         style.Filled,
+		"double-border":  style.DoubleBorder,
+		"text-transform": style.TextTransform,
+	})
+}
@@ -2,7 +2,10 @@ package d2
 
 import (
 	"context"
+	"strings"
 	"testing"
+
+	"github.com/i2y/d2mcp/internal/domain/entity"
 )
 
 func TestD2OracleRepository_LoadAndSerialize(t *testing.T) {
@@ -374,6 +377,239 @@ func TestD2OracleRepository_GetChildren(t *testing.T) {
 	}
 }
 
+func TestD2OracleRepository_GetGraph(t *testing.T) {
+	repo := NewD2OracleRepository()
+	ctx := context.Background()
+
+	diagramID := "test-graph"
+	if err := repo.LoadDiagram(ctx, diagramID, "api -> db: query"); err != nil {
+		t.Fatalf("LoadDiagram() error = %v", err)
+	}
+
+	graph, err := repo.GetGraph(ctx, diagramID)
+	if err != nil {
+		t.Fatalf("GetGraph() error = %v", err)
+	}
+	if graph.ID != diagramID {
+		t.Errorf("GetGraph() ID = %v, want %v", graph.ID, diagramID)
+	}
+	if _, ok := graph.Objects["api"]; !ok {
+		t.Error("GetGraph() missing object api")
+	}
+	if len(graph.Edges) != 1 {
+		t.Errorf("GetGraph() returned %d edges, want 1", len(graph.Edges))
+	}
+
+	if _, err := repo.GetGraph(ctx, "non-existent"); err == nil {
+		t.Error("GetGraph() should fail for non-existent diagram")
+	}
+}
+
+func TestD2OracleRepository_ParseGraph(t *testing.T) {
+	repo := NewD2OracleRepository()
+	ctx := context.Background()
+
+	graph, err := repo.ParseGraph(ctx, `
+a.api: { style.fill: "#fff" }
+b.api
+a.api -> b.api: sync { style.stroke-dash: 3 }
+`)
+	if err != nil {
+		t.Fatalf("ParseGraph() error = %v", err)
+	}
+
+	// Nested objects with the same name are keyed by their full path.
+	api, ok := graph.Objects["a.api"]
+	if !ok {
+		t.Fatalf("ParseGraph() objects = %v", graph.Objects)
+	}
+	if _, ok := graph.Objects["b.api"]; !ok {
+		t.Errorf("ParseGraph() missing object b.api")
+	}
+	if api.Parent != "a" || api.Attributes["fill"] != "#fff" {
+		t.Errorf("ParseGraph() a.api = %+v", api)
+	}
+
+	edge, ok := graph.Edges["(a.api -> b.api)[0]"]
+	if !ok {
+		t.Fatalf("ParseGraph() edges = %v", graph.Edges)
+	}
+	if edge.From != "a.api" || edge.To != "b.api" || edge.Attributes["stroke-dash"] != "3" {
+		t.Errorf("ParseGraph() edge = %+v", edge)
+	}
+
+	if _, err := repo.ParseGraph(ctx, "a -> {"); err == nil {
+		t.Error("ParseGraph() should fail for invalid D2")
+	}
+}
+
+func TestD2OracleRepository_OnChange(t *testing.T) {
+	repo := NewD2OracleRepository()
+	ctx := context.Background()
+
+	var changes []string
+	repo.OnChange(func(diagramID string) {
+		changes = append(changes, diagramID)
+	})
+
+	diagramID := "test-changes"
+	if err := repo.LoadDiagram(ctx, diagramID, "a -> b"); err != nil {
+		t.Fatalf("LoadDiagram() error = %v", err)
+	}
+	if _, err := repo.CreateElement(ctx, diagramID, nil, "c"); err != nil {
+		t.Fatalf("CreateElement() error = %v", err)
+	}
+	label := "Service"
+	if _, err := repo.SetAttribute(ctx, diagramID, nil, "c.label", nil, &label); err != nil {
+		t.Fatalf("SetAttribute() error = %v", err)
+	}
+	if _, err := repo.RenameElement(ctx, diagramID, nil, "c", "d"); err != nil {
+		t.Fatalf("RenameElement() error = %v", err)
+	}
+	if _, err := repo.MoveElement(ctx, diagramID, nil, "d", "a.d", true); err != nil {
+		t.Fatalf("MoveElement() error = %v", err)
+	}
+	if _, err := repo.DeleteElement(ctx, diagramID, nil, "a.d"); err != nil {
+		t.Fatalf("DeleteElement() error = %v", err)
+	}
+
+	if len(changes) != 6 {
+		t.Fatalf("OnChange() saw %d changes, want 6: %v", len(changes), changes)
+	}
+	for _, id := range changes {
+		if id != diagramID {
+			t.Errorf("OnChange() diagram ID = %v, want %v", id, diagramID)
+		}
+	}
+
+	// Failed operations leave the diagram unchanged.
+	if _, err := repo.DeleteElement(ctx, "non-existent", nil, "a"); err == nil {
+		t.Error("DeleteElement() should fail for non-existent diagram")
+	}
+	if len(changes) != 6 {
+		t.Errorf("OnChange() called for a failed operation")
+	}
+}
+
+func TestD2OracleRepository_ExecuteBatch(t *testing.T) {
+	repo := NewD2OracleRepository()
+	ctx := context.Background()
+
+	diagramID := "test-batch"
+	if err := repo.LoadDiagram(ctx, diagramID, "web -> api"); err != nil {
+		t.Fatalf("LoadDiagram() error = %v", err)
+	}
+
+	shape := "cylinder"
+	newName := "backend"
+	results, err := repo.ExecuteBatch(ctx, diagramID, []entity.OracleOperation{
+		{Type: entity.OracleCreate, Key: "db"},
+		{Type: entity.OracleSet, Key: "db.shape", Value: &shape},
+		{Type: entity.OracleCreate, Key: "api -> db"},
+		{Type: entity.OracleRename, Key: "api", NewKey: &newName},
+	})
+	if err != nil {
+		t.Fatalf("ExecuteBatch() error = %v", err)
+	}
+	if len(results) != 4 {
+		t.Fatalf("ExecuteBatch() returned %d results, want 4", len(results))
+	}
+	if results[0].NewKey != "db" {
+		t.Errorf("ExecuteBatch() create NewKey = %v, want db", results[0].NewKey)
+	}
+	if results[3].NewKey != "backend" || results[3].IDDeltas["api"] != "backend" {
+		t.Errorf("ExecuteBatch() rename NewKey = %v, IDDeltas = %v", results[3].NewKey, results[3].IDDeltas)
+	}
+
+	serialized, err := repo.SerializeDiagram(ctx, diagramID)
+	if err != nil {
+		t.Fatalf("SerializeDiagram() error = %v", err)
+	}
+	for _, want := range []string{"shape: cylinder", "backend -> db", "web -> backend"} {
+		if !strings.Contains(serialized, want) {
+			t.Errorf("SerializeDiagram() missing %q:\n%s", want, serialized)
+		}
+	}
+}
+
+func TestD2OracleRepository_ExecuteBatchRollsBack(t *testing.T) {
+	repo := NewD2OracleRepository()
+	ctx := context.Background()
+
+	diagramID := "test-batch-rollback"
+	if err := repo.LoadDiagram(ctx, diagramID, "web -> api"); err != nil {
+		t.Fatalf("LoadDiagram() error = %v", err)
+	}
+	before, err := repo.SerializeDiagram(ctx, diagramID)
+	if err != nil {
+		t.Fatalf("SerializeDiagram() error = %v", err)
+	}
+
+	var changes int
+	repo.OnChange(func(string) { changes++ })
+
+	newKey := "missing.api"
+	_, err = repo.ExecuteBatch(ctx, diagramID, []entity.OracleOperation{
+		{Type: entity.OracleCreate, Key: "db"},
+		{Type: entity.OracleCreate, Key: "api -> db"},
+		{Type: entity.OracleMove, Key: "nope", NewKey: &newKey},
+	})
+	if err == nil {
+		t.Fatal("ExecuteBatch() should fail when an operation fails")
+	}
+	if !strings.Contains(err.Error(), "operation 3") {
+		t.Errorf("ExecuteBatch() error = %v, want it to name operation 3", err)
+	}
+
+	after, err := repo.SerializeDiagram(ctx, diagramID)
+	if err != nil {
+		t.Fatalf("SerializeDiagram() error = %v", err)
+	}
+	if after != before {
+		t.Errorf("ExecuteBatch() changed the diagram after a failure:\n%s", after)
+	}
+	if changes != 0 {
+		t.Errorf("ExecuteBatch() reported %d changes after a failure", changes)
+	}
+}
+
+func TestD2OracleRepository_FailedOperationLeavesDiagram(t *testing.T) {
+	repo := NewD2OracleRepository()
+	ctx := context.Background()
+
+	diagramID := "test-failed-op"
+	if err := repo.LoadDiagram(ctx, diagramID, "a -> b"); err != nil {
+		t.Fatalf("LoadDiagram() error = %v", err)
+	}
+	before, _ := repo.SerializeDiagram(ctx, diagramID)
+
+	shape := "bogus_shape"
+	if _, err := repo.SetAttribute(ctx, diagramID, nil, "a.shape", nil, &shape); err == nil {
+		t.Fatal("SetAttribute() with an invalid shape succeeded")
+	}
+	if after, _ := repo.SerializeDiagram(ctx, diagramID); after != before {
+		t.Errorf("failed SetAttribute() changed the diagram:\n%s", after)
+	}
+
+	// Later operations and batches work on the unchanged diagram.
+	if _, err := repo.CreateElement(ctx, diagramID, nil, "c"); err != nil {
+		t.Fatalf("CreateElement() after a failed operation error = %v", err)
+	}
+	if _, err := repo.ExecuteBatch(ctx, diagramID, []entity.OracleOperation{
+		{Type: entity.OracleCreate, Key: "b -> c"},
+	}); err != nil {
+		t.Fatalf("ExecuteBatch() after a failed operation error = %v", err)
+	}
+	content, _ := repo.SerializeDiagram(ctx, diagramID)
+	if strings.Contains(content, "bogus_shape") || !strings.Contains(content, "b -> c") {
+		t.Errorf("SerializeDiagram() = %q, want b -> c without the failed change", content)
+	}
+	// Only the two successful changes were recorded.
+	if result, err := repo.Undo(ctx, diagramID, 1); err != nil || result.UndoDepth != 1 {
+		t.Errorf("Undo() = %+v, %v, want one change left to undo", result, err)
+	}
+}
+
 func TestD2OracleRepository_ComplexWorkflow(t *testing.T) {
 	repo := NewD2OracleRepository()
 	ctx := context.Background()
@@ -448,6 +684,64 @@ func TestD2OracleRepository_ComplexWorkflow(t *testing.T) {
 	}
 }
 
+func TestD2OracleRepository_BoardPath(t *testing.T) {
+	repo := NewD2OracleRepository()
+	ctx := context.Background()
+
+	diagramID := "test-boards"
+	content := `web -> api
+layers: {
+  network: {
+    router
+  }
+}
+scenarios: {
+  outage: {
+    api.style.fill: red
+  }
+}`
+
+	err := repo.LoadDiagram(ctx, diagramID, content)
+	if err != nil {
+		t.Fatalf("LoadDiagram() error = %v", err)
+	}
+
+	// Create an element on a layer
+	_, err = repo.CreateElement(ctx, diagramID, []string{"network"}, "firewall")
+	if err != nil {
+		t.Fatalf("CreateElement() on layer error = %v", err)
+	}
+
+	// The new element exists on the layer but not on the root board
+	if _, err := repo.GetObject(ctx, diagramID, []string{"network"}, "firewall"); err != nil {
+		t.Errorf("GetObject() on layer error = %v", err)
+	}
+	if _, err := repo.GetObject(ctx, diagramID, []string{}, "firewall"); err == nil {
+		t.Error("GetObject() on root should not find layer element")
+	}
+
+	// Scenarios inherit from the root board
+	if _, err := repo.GetObject(ctx, diagramID, []string{"outage"}, "web"); err != nil {
+		t.Errorf("GetObject() on scenario error = %v", err)
+	}
+
+	serialized, err := repo.SerializeBoard(ctx, diagramID, []string{"network"})
+	if err != nil {
+		t.Fatalf("SerializeBoard() error = %v", err)
+	}
+	if !strings.Contains(serialized, "firewall") || strings.Contains(serialized, "web") {
+		t.Errorf("SerializeBoard() = %q, want only the network layer", serialized)
+	}
+
+	// Unknown boards are rejected
+	if _, err := repo.CreateElement(ctx, diagramID, []string{"missing"}, "x"); err == nil {
+		t.Error("CreateElement() should fail for an unknown board")
+	}
+	if _, err := repo.GetChildren(ctx, diagramID, []string{"missing"}, ""); err == nil {
+		t.Error("GetChildren() should fail for an unknown board")
+	}
+}
+
 // Helper function
 func stringPtr(s string) *string {
 	return &s
@@ -0,0 +1,191 @@
+package d2
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strings"
+
+	"codeberg.org/go-pdf/fpdf"
+	"oss.terrastruct.com/d2/d2renderers/d2fonts"
+	"oss.terrastruct.com/d2/d2renderers/d2svg"
+	"oss.terrastruct.com/d2/d2target"
+
+	"github.com/i2y/d2mcp/internal/domain/entity"
+	"github.com/i2y/d2mcp/internal/infrastructure/raster"
+)
+
+const (
+	// pdfHeaderHeight is the height of the page title bar in points.
+	pdfHeaderHeight = 56.0
+	// pdfMargin is the horizontal margin of titles in points.
+	pdfMargin = 28.0
+	// pdfMinPageSize keeps pages of small boards readable.
+	pdfMinPageSize = 576.0
+	// pdfTitleSeparator separates the board names in a page title.
+	pdfTitleSeparator = "  /  "
+)
+
+// renderPDF renders every board of a compiled diagram to its own PDF page, titled
+// with its board path and optionally preceded by a linked table of contents.
+func renderPDF(diagram *d2target.Diagram, renderOpts *d2svg.RenderOpts, opts *entity.RenderOptions) ([]byte, error) {
+	boards := collectBoards(diagram, []string{rootBoardTitle}, []string{"root"}, nil)
+	tableOfContents := opts.TOC != nil && *opts.TOC
+
+	dpi := float64(boardDPI)
+	if opts.Raster != nil && opts.Raster.DPI > 0 {
+		dpi = opts.Raster.DPI
+	}
+
+	pdf := fpdf.NewCustom(&fpdf.InitType{UnitStr: "pt"})
+	pdf.SetCreator("d2mcp", true)
+	pdf.SetAutoPageBreak(false, 0)
+	pdf.SetMargins(0, 0, 0)
+	d2fonts.FontFamiliesMu.Lock()
+	regular := d2fonts.FontFaces.Get(d2fonts.SourceSansPro.Font(0, d2fonts.FONT_STYLE_REGULAR))
+	bold := d2fonts.FontFaces.Get(d2fonts.SourceSansPro.Font(0, d2fonts.FONT_STYLE_BOLD))
+	d2fonts.FontFamiliesMu.Unlock()
+	pdf.AddUTF8FontFromBytes("source", "", regular)
+	pdf.AddUTF8FontFromBytes("source", "B", bold)
+
+	// Board pages follow the table of contents, so page numbers are known up front.
+	firstBoardPage := 1
+	if tableOfContents {
+		firstBoardPage += tocPageCount(len(boards))
+	}
+	pageLinks := make(map[string]int, len(boards))
+	links := make([]int, len(boards))
+	for i, board := range boards {
+		links[i] = pdf.AddLink()
+		pageLinks[board.path()] = links[i]
+	}
+
+	if tableOfContents {
+		addTOCPages(pdf, boards, links)
+	}
+	for i, board := range boards {
+		if err := addBoardPage(pdf, board, renderOpts, dpi, pageLinks); err != nil {
+			return nil, err
+		}
+		pdf.SetLink(links[i], 0, firstBoardPage+i)
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, fmt.Errorf("failed to write PDF: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// addBoardPage adds a page with the board's title bar and its rasterized diagram.
+func addBoardPage(pdf *fpdf.Fpdf, board diagramBoard, renderOpts *d2svg.RenderOpts, dpi float64, pageLinks map[string]int) error {
+	title := strings.Join(board.titles, pdfTitleSeparator)
+	img, encoded, err := rasterizeBoard(board, renderOpts, dpi)
+	if err != nil {
+		return err
+	}
+
+	// One SVG pixel becomes one point, whatever resolution the image is embedded at.
+	imageOpts := fpdf.ImageOptions{ImageType: "PNG"}
+	pdf.RegisterImageOptionsReader(board.path(), imageOpts, bytes.NewReader(encoded))
+	if pdf.Err() {
+		return fmt.Errorf("failed to embed board %q: %w", title, pdf.Error())
+	}
+	imageWidth := float64(img.Bounds().Dx()) * raster.DefaultDPI / dpi
+	imageHeight := float64(img.Bounds().Dy()) * raster.DefaultDPI / dpi
+
+	pdf.SetFont("source", "B", 14)
+	pageWidth := math.Max(math.Max(pdfMinPageSize, imageWidth), pdf.GetStringWidth(title)+2*pdfMargin)
+	pageHeight := math.Max(pdfMinPageSize, imageHeight)
+	pdf.AddPageFormat("", fpdf.SizeType{Wd: pageWidth, Ht: pageHeight + pdfHeaderHeight})
+
+	// The page takes the diagram's background so that themed boards look seamless.
+	background := img.RGBAAt(0, 0)
+	if background.A == 0 {
+		background.R, background.G, background.B = 255, 255, 255
+	}
+	pdf.SetFillColor(int(background.R), int(background.G), int(background.B))
+	pdf.Rect(0, 0, pageWidth, pageHeight+pdfHeaderHeight, "F")
+
+	textR, textG, textB := 10, 15, 37
+	if luminance(background.R, background.G, background.B) < 0.5 {
+		textR, textG, textB = 255, 255, 255
+	}
+	pdf.SetTextColor(textR, textG, textB)
+	pdf.SetDrawColor(textR, textG, textB)
+
+	// Ancestors in the title link back to their pages.
+	x := pdfMargin
+	pdf.SetFont("source", "", 14)
+	for i := range board.titles[:len(board.titles)-1] {
+		name := board.titles[i]
+		pdf.SetXY(x, 0)
+		w := pdf.GetStringWidth(name)
+		pdf.CellFormat(w, pdfHeaderHeight, name, "", 0, "", false, pageLinks[board.paths[i]], "")
+		x += w
+		pdf.SetXY(x, 0)
+		w = pdf.GetStringWidth(pdfTitleSeparator)
+		pdf.CellFormat(w, pdfHeaderHeight, pdfTitleSeparator, "", 0, "", false, 0, "")
+		x += w
+	}
+	pdf.SetFont("source", "B", 14)
+	pdf.SetXY(x, 0)
+	pdf.CellFormat(pageWidth-x-pdfMargin, pdfHeaderHeight, board.titles[len(board.titles)-1], "", 0, "", false, 0, "")
+	pdf.SetLineWidth(1)
+	pdf.Line(pdfMargin, pdfHeaderHeight, pageWidth-pdfMargin, pdfHeaderHeight)
+
+	imageX := (pageWidth - imageWidth) / 2
+	imageY := pdfHeaderHeight + (pageHeight-imageHeight)/2
+	pdf.ImageOptions(board.path(), imageX, imageY, imageWidth, imageHeight, false, imageOpts, 0, "")
+
+	addShapeLinks(pdf, board.diagram, renderOpts, imageX, imageY, imageWidth, pageLinks)
+	return pdf.Error()
+}
+
+// addShapeLinks makes linked shapes clickable. Links to other boards jump to their
+// pages; other links open as URLs.
+func addShapeLinks(pdf *fpdf.Fpdf, diagram *d2target.Diagram, renderOpts *d2svg.RenderOpts, imageX, imageY, imageWidth float64, pageLinks map[string]int) {
+	for _, shape := range linkedShapes(diagram, renderOpts, imageWidth) {
+		x, y := imageX+shape.x, imageY+shape.y
+		if link, ok := pageLinks[shape.link]; ok {
+			pdf.Link(x, y, shape.width, shape.height, link)
+		} else if !isBoardLink(shape.link) {
+			pdf.LinkString(x, y, shape.width, shape.height, shape.link)
+		}
+	}
+}
+
+// tocEntriesPerPage is how many boards one table of contents page lists.
+const tocEntriesPerPage = 30
+
+func tocPageCount(boards int) int {
+	return (boards + tocEntriesPerPage - 1) / tocEntriesPerPage
+}
+
+// addTOCPages lists every board, indented by depth, with links to their pages.
+func addTOCPages(pdf *fpdf.Fpdf, boards []diagramBoard, links []int) {
+	const pageWidth, pageHeight, lineHeight = 595.0, 842.0, 22.0
+	for i, board := range boards {
+		if i%tocEntriesPerPage == 0 {
+			pdf.AddPageFormat("", fpdf.SizeType{Wd: pageWidth, Ht: pageHeight})
+			pdf.SetTextColor(10, 15, 37)
+			pdf.SetDrawColor(10, 15, 37)
+			pdf.SetFont("source", "B", 14)
+			pdf.SetXY(pdfMargin, 0)
+			pdf.CellFormat(pageWidth-2*pdfMargin, pdfHeaderHeight, "Contents", "", 0, "", false, 0, "")
+			pdf.SetLineWidth(1)
+			pdf.Line(pdfMargin, pdfHeaderHeight, pageWidth-pdfMargin, pdfHeaderHeight)
+			pdf.SetFont("source", "", 12)
+		}
+		indent := float64(len(board.titles)-1) * 16
+		y := pdfHeaderHeight + 16 + float64(i%tocEntriesPerPage)*lineHeight
+		pdf.SetXY(pdfMargin+indent, y)
+		name := board.titles[len(board.titles)-1]
+		pdf.CellFormat(pageWidth-2*pdfMargin-indent, lineHeight, name, "", 0, "", false, links[i], "")
+	}
+}
+
+// luminance returns the relative brightness of a color between 0 and 1.
+func luminance(r, g, b uint8) float64 {
+	return (0.2126*float64(r) + 0.7152*float64(g) + 0.0722*float64(b)) / 255
+}
@@ -0,0 +1,71 @@
+package d2
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"regexp"
+	"testing"
+
+	"github.com/i2y/d2mcp/internal/domain/entity"
+)
+
+// pdfPagePattern matches page objects but not the page tree.
+var pdfPagePattern = regexp.MustCompile(`/Type /Page\b[^s]`)
+
+const multiBoardContent = `a -> b
+layers: {
+  detail: {
+    c -> d
+  }
+}
+scenarios: {
+  outage: {
+    a -> b: down
+  }
+}
+steps: {
+  one: {
+    x
+  }
+  two: {
+    x -> y
+  }
+}
+`
+
+func TestD2Repository_RenderPDF(t *testing.T) {
+	repo := NewD2Repository()
+	ctx := context.Background()
+	yes := true
+
+	tests := []struct {
+		name      string
+		content   string
+		opts      *entity.RenderOptions
+		wantPages int
+	}{
+		{name: "single board", content: "a -> b", wantPages: 1},
+		{name: "page per board", content: multiBoardContent, wantPages: 5},
+		{name: "table of contents", content: multiBoardContent, opts: &entity.RenderOptions{TOC: &yes}, wantPages: 6},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader, err := repo.Render(ctx, tt.content, entity.FormatPDF, tt.opts)
+			if err != nil {
+				t.Fatalf("Render() error = %v", err)
+			}
+			pdf, err := io.ReadAll(reader)
+			if err != nil {
+				t.Fatalf("failed to read PDF: %v", err)
+			}
+			if !bytes.HasPrefix(pdf, []byte("%PDF-")) {
+				t.Fatalf("output is not a PDF")
+			}
+			if pages := len(pdfPagePattern.FindAll(pdf, -1)); pages != tt.wantPages {
+				t.Errorf("PDF has %d pages, want %d", pages, tt.wantPages)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package d2
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"strings"
+
+	"oss.terrastruct.com/d2/d2renderers/d2svg"
+	"oss.terrastruct.com/d2/d2target"
+	"oss.terrastruct.com/d2/lib/pptx"
+	"oss.terrastruct.com/d2/lib/version"
+
+	"github.com/i2y/d2mcp/internal/domain/entity"
+)
+
+// renderPPTX renders every board of a compiled diagram to its own slide, titled with
+// its board path. Boards with a label get it as their speaker notes.
+func renderPPTX(diagram *d2target.Diagram, renderOpts *d2svg.RenderOpts, opts *entity.RenderOptions) ([]byte, error) {
+	boards := collectBoards(diagram, []string{rootBoardTitle}, []string{"root"}, nil)
+
+	dpi := float64(boardDPI)
+	if opts.Raster != nil && opts.Raster.DPI > 0 {
+		dpi = opts.Raster.DPI
+	}
+
+	title := rootBoardTitle
+	if diagram.Root.Label != "" {
+		title = diagram.Root.Label
+	}
+	// PowerPoint rejects versions with anything but digits and dots.
+	presentation := pptx.NewPresentation(escapeXML(title), "", "", "d2mcp", version.OnlyNumbers(), true)
+
+	slideNumbers := make(map[string]int, len(boards))
+	for i, board := range boards {
+		slideNumbers[board.path()] = i + 1
+	}
+
+	notes := make([]string, len(boards))
+	for i, board := range boards {
+		img, encoded, err := rasterizeBoard(board, renderOpts, dpi)
+		if err != nil {
+			return nil, err
+		}
+
+		// The pptx package writes titles and links into XML templates unescaped.
+		titles := make([]pptx.BoardTitle, len(board.titles))
+		for j, name := range board.titles {
+			titles[j] = pptx.BoardTitle{
+				Name:        escapeXML(name),
+				BoardID:     escapeXML(board.paths[j]),
+				LinkToSlide: slideNumber(boards, slideNumbers, board.paths[j]),
+			}
+		}
+		slide, err := presentation.AddSlide(encoded, titles)
+		if err != nil {
+			return nil, fmt.Errorf("failed to add slide for board %q: %w", board.path(), err)
+		}
+
+		// Link areas are given in image pixels and scaled onto the slide.
+		for _, shape := range linkedShapes(board.diagram, renderOpts, float64(img.Bounds().Dx())) {
+			link := &pptx.Link{
+				Left:    int(shape.x),
+				Top:     int(shape.y),
+				Width:   int(shape.width),
+				Height:  int(shape.height),
+				Tooltip: escapeXML(shape.link),
+			}
+			if number, ok := slideNumbers[shape.link]; ok {
+				link.SlideIndex = number
+			} else if !isBoardLink(shape.link) {
+				link.ExternalUrl = escapeXML(shape.link)
+			} else {
+				continue
+			}
+			slide.AddLink(link)
+		}
+
+		// Boards without a label of their own are labeled with their name, which the
+		// slide title already shows.
+		if label := board.diagram.Root.Label; label != board.diagram.Name {
+			notes[i] = label
+		}
+	}
+
+	// The pptx package can only save to a file.
+	file, err := os.CreateTemp("", "d2-*.pptx")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp PPTX file: %w", err)
+	}
+	file.Close()
+	defer os.Remove(file.Name())
+
+	if err := presentation.SaveTo(file.Name()); err != nil {
+		return nil, fmt.Errorf("failed to write PPTX: %w", err)
+	}
+	output, err := os.ReadFile(file.Name())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PPTX: %w", err)
+	}
+	return addSpeakerNotes(output, notes)
+}
+
+// slideNumber returns the slide of a board path. Folder-only boards have no slide of
+// their own and resolve to the first slide below them.
+func slideNumber(boards []diagramBoard, slideNumbers map[string]int, path string) int {
+	if number, ok := slideNumbers[path]; ok {
+		return number
+	}
+	for i, board := range boards {
+		if strings.HasPrefix(board.path(), path+".") {
+			return i + 1
+		}
+	}
+	return 1
+}
+
+// escapeXML escapes text for use in XML content and attribute values.
+func escapeXML(s string) string {
+	var buf bytes.Buffer
+	_ = xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
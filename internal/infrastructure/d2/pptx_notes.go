@@ -0,0 +1,217 @@
+package d2
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// OOXML namespaces, relationship types and content types used by speaker notes.
+const (
+	pptxNamespaces = `xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main" ` +
+		`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships" ` +
+		`xmlns:p="http://schemas.openxmlformats.org/presentationml/2006/main"`
+	pptxRelationshipType   = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/"
+	pptxContentTypePrefix  = "application/vnd.openxmlformats-officedocument."
+	pptxNotesMasterRelID   = "notesMaster1"
+	pptxNotesSlideRelID    = "notesSlide"
+	pptxNotesThemeFileName = "ppt/theme/theme2.xml"
+)
+
+// pptxGroupShape is the required root group of a shape tree.
+const pptxGroupShape = `<p:nvGrpSpPr><p:cNvPr id="1" name=""/><p:cNvGrpSpPr/><p:nvPr/></p:nvGrpSpPr>` +
+	`<p:grpSpPr><a:xfrm><a:off x="0" y="0"/><a:ext cx="0" cy="0"/><a:chOff x="0" y="0"/><a:chExt cx="0" cy="0"/></a:xfrm></p:grpSpPr>`
+
+// pptxNotesMaster lays out notes pages: the slide image on top, the notes below.
+const pptxNotesMaster = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+	`<p:notesMaster ` + pptxNamespaces + `><p:cSld><p:bg><p:bgRef idx="1001"><a:schemeClr val="bg1"/></p:bgRef></p:bg><p:spTree>` +
+	pptxGroupShape +
+	`<p:sp><p:nvSpPr><p:cNvPr id="2" name="Slide Image Placeholder 1"/><p:cNvSpPr><a:spLocks noGrp="1" noRot="1" noChangeAspect="1"/></p:cNvSpPr><p:nvPr><p:ph type="sldImg" idx="2"/></p:nvPr></p:nvSpPr>` +
+	`<p:spPr><a:xfrm><a:off x="381000" y="685800"/><a:ext cx="6096000" cy="3429000"/></a:xfrm><a:prstGeom prst="rect"><a:avLst/></a:prstGeom><a:noFill/><a:ln w="12700"><a:solidFill><a:prstClr val="black"/></a:solidFill></a:ln></p:spPr></p:sp>` +
+	`<p:sp><p:nvSpPr><p:cNvPr id="3" name="Notes Placeholder 2"/><p:cNvSpPr><a:spLocks noGrp="1"/></p:cNvSpPr><p:nvPr><p:ph type="body" sz="quarter" idx="3"/></p:nvPr></p:nvSpPr>` +
+	`<p:spPr><a:xfrm><a:off x="685800" y="4400550"/><a:ext cx="5486400" cy="3600450"/></a:xfrm><a:prstGeom prst="rect"><a:avLst/></a:prstGeom></p:spPr>` +
+	`<p:txBody><a:bodyPr vert="horz" lIns="91440" tIns="45720" rIns="91440" bIns="45720" rtlCol="0"/><a:lstStyle/><a:p><a:endParaRPr lang="en-US"/></a:p></p:txBody></p:sp>` +
+	`</p:spTree></p:cSld>` +
+	`<p:clrMap bg1="lt1" tx1="dk1" bg2="lt2" tx2="dk2" accent1="accent1" accent2="accent2" accent3="accent3" accent4="accent4" accent5="accent5" accent6="accent6" hlink="hlink" folHlink="folHlink"/>` +
+	`<p:notesStyle><a:lvl1pPr marL="0" algn="l" defTabSz="914400" rtl="0" eaLnBrk="1" latinLnBrk="0" hangingPunct="1"><a:defRPr sz="1200" kern="1200"><a:solidFill><a:schemeClr val="tx1"/></a:solidFill><a:latin typeface="+mn-lt"/><a:ea typeface="+mn-ea"/><a:cs typeface="+mn-cs"/></a:defRPr></a:lvl1pPr></p:notesStyle>` +
+	`</p:notesMaster>`
+
+const pptxNotesMasterRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+	`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="` + pptxRelationshipType + `theme" Target="../theme/theme2.xml"/>` +
+	`</Relationships>`
+
+// addSpeakerNotes adds a notes page to every slide with non-empty notes. The pptx
+// package has no notes support, so the parts are added to the saved presentation:
+// a notes master with its own theme, and a notes slide per annotated slide.
+func addSpeakerNotes(presentation []byte, notes []string) ([]byte, error) {
+	annotated := false
+	for _, text := range notes {
+		if strings.TrimSpace(text) != "" {
+			annotated = true
+			break
+		}
+	}
+	if !annotated {
+		return presentation, nil
+	}
+
+	reader, err := zip.NewReader(bytes.NewReader(presentation), int64(len(presentation)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PPTX: %w", err)
+	}
+
+	var contentTypes strings.Builder
+	contentTypes.WriteString(pptxOverride("/ppt/notesMasters/notesMaster1.xml", "presentationml.notesMaster+xml"))
+	contentTypes.WriteString(pptxOverride("/"+pptxNotesThemeFileName, "theme+xml"))
+	slideRels := make(map[string]string)
+	for i, text := range notes {
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		contentTypes.WriteString(pptxOverride(fmt.Sprintf("/ppt/notesSlides/notesSlide%d.xml", i+1), "presentationml.notesSlide+xml"))
+		slideRels[fmt.Sprintf("ppt/slides/_rels/slide%d.xml.rels", i+1)] = pptxRelationship(pptxNotesSlideRelID, "notesSlide", fmt.Sprintf("../notesSlides/notesSlide%d.xml", i+1))
+	}
+
+	// Parts that reference the notes get the references inserted; everything else is copied.
+	edits := map[string]struct{ marker, insert string }{
+		"[Content_Types].xml": {marker: "</Types>", insert: contentTypes.String()},
+		"ppt/presentation.xml": {
+			marker: "<p:sldIdLst>",
+			insert: `<p:notesMasterIdLst><p:notesMasterId r:id="` + pptxNotesMasterRelID + `"/></p:notesMasterIdLst>`,
+		},
+		"ppt/_rels/presentation.xml.rels": {
+			marker: "</Relationships>",
+			insert: pptxRelationship(pptxNotesMasterRelID, "notesMaster", "notesMasters/notesMaster1.xml"),
+		},
+	}
+	for name, relationship := range slideRels {
+		edits[name] = struct{ marker, insert string }{marker: "</Relationships>", insert: relationship}
+	}
+
+	var buf bytes.Buffer
+	writer := zip.NewWriter(&buf)
+	var theme []byte
+	for _, file := range reader.File {
+		edit, ok := edits[file.Name]
+		if !ok && file.Name != "ppt/theme/theme1.xml" {
+			if err := writer.Copy(file); err != nil {
+				return nil, fmt.Errorf("failed to copy %s: %w", file.Name, err)
+			}
+			continue
+		}
+
+		content, err := readZipFile(file)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			// The notes master gets a theme of its own, copied from the slides.
+			theme = content
+		} else {
+			index := bytes.Index(content, []byte(edit.marker))
+			if index < 0 {
+				return nil, fmt.Errorf("failed to add speaker notes: %s has no %s", file.Name, edit.marker)
+			}
+			content = append(content[:index:index], append([]byte(edit.insert), content[index:]...)...)
+		}
+		if err := writeZipFile(writer, file.Name, content); err != nil {
+			return nil, err
+		}
+	}
+	if theme == nil {
+		return nil, fmt.Errorf("failed to add speaker notes: presentation has no theme")
+	}
+
+	added := map[string]string{
+		pptxNotesThemeFileName:                         string(theme),
+		"ppt/notesMasters/notesMaster1.xml":            pptxNotesMaster,
+		"ppt/notesMasters/_rels/notesMaster1.xml.rels": pptxNotesMasterRels,
+	}
+	for i, text := range notes {
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		added[fmt.Sprintf("ppt/notesSlides/notesSlide%d.xml", i+1)] = pptxNotesSlide(text)
+		added[fmt.Sprintf("ppt/notesSlides/_rels/notesSlide%d.xml.rels", i+1)] = pptxNotesSlideRels(i + 1)
+	}
+	names := make([]string, 0, len(added))
+	for name := range added {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := writeZipFile(writer, name, []byte(added[name])); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write PPTX: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// pptxNotesSlide returns a notes page with one paragraph per line of text.
+func pptxNotesSlide(text string) string {
+	var paragraphs strings.Builder
+	for _, line := range strings.Split(strings.TrimSpace(text), "\n") {
+		if line = strings.TrimSpace(line); line == "" {
+			paragraphs.WriteString(`<a:p><a:endParaRPr lang="en-US"/></a:p>`)
+			continue
+		}
+		paragraphs.WriteString(`<a:p><a:r><a:rPr lang="en-US"/><a:t>` + escapeXML(line) + `</a:t></a:r></a:p>`)
+	}
+
+	return `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+		`<p:notes ` + pptxNamespaces + `><p:cSld><p:spTree>` +
+		pptxGroupShape +
+		`<p:sp><p:nvSpPr><p:cNvPr id="2" name="Slide Image Placeholder 1"/><p:cNvSpPr><a:spLocks noGrp="1" noRot="1" noChangeAspect="1"/></p:cNvSpPr><p:nvPr><p:ph type="sldImg" idx="2"/></p:nvPr></p:nvSpPr><p:spPr/></p:sp>` +
+		`<p:sp><p:nvSpPr><p:cNvPr id="3" name="Notes Placeholder 2"/><p:cNvSpPr><a:spLocks noGrp="1"/></p:cNvSpPr><p:nvPr><p:ph type="body" idx="3"/></p:nvPr></p:nvSpPr><p:spPr/>` +
+		`<p:txBody><a:bodyPr/><a:lstStyle/>` + paragraphs.String() + `</p:txBody></p:sp>` +
+		`</p:spTree></p:cSld><p:clrMapOvr><a:masterClrMapping/></p:clrMapOvr></p:notes>`
+}
+
+// pptxNotesSlideRels links a notes page to its slide and the notes master.
+func pptxNotesSlideRels(slide int) string {
+	return `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+		`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		pptxRelationship("rId1", "notesMaster", "../notesMasters/notesMaster1.xml") +
+		pptxRelationship("rId2", "slide", fmt.Sprintf("../slides/slide%d.xml", slide)) +
+		`</Relationships>`
+}
+
+func pptxRelationship(id, kind, target string) string {
+	return fmt.Sprintf(`<Relationship Id="%s" Type="%s%s" Target="%s"/>`, id, pptxRelationshipType, kind, target)
+}
+
+func pptxOverride(part, contentType string) string {
+	return fmt.Sprintf(`<Override PartName="%s" ContentType="%s%s"/>`, part, pptxContentTypePrefix, contentType)
+}
+
+func readZipFile(file *zip.File) ([]byte, error) {
+	rc, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", file.Name, err)
+	}
+	defer rc.Close()
+	content, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", file.Name, err)
+	}
+	return content, nil
+}
+
+func writeZipFile(writer *zip.Writer, name string, content []byte) error {
+	w, err := writer.Create(name)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", name, err)
+	}
+	if _, err := w.Write(content); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package d2
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"io"
+	"path"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/i2y/d2mcp/internal/domain/entity"
+)
+
+const labeledBoardsContent = `label: "Checkout overview"
+a -> b
+layers: {
+  detail: {
+    label: "Payment & fraud checks"
+    c -> d
+  }
+  plain: {
+    e
+  }
+}
+`
+
+// pptxRelationshipPattern matches relationship targets inside the package.
+var pptxRelationshipPattern = regexp.MustCompile(`Target="([^"]+)"( TargetMode="External")?`)
+
+func TestD2Repository_RenderPPTX(t *testing.T) {
+	repo := NewD2Repository()
+	reader, err := repo.Render(context.Background(), labeledBoardsContent, entity.FormatPPTX, nil)
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("failed to read PPTX: %v", err)
+	}
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("output is not a PPTX package: %v", err)
+	}
+
+	files := make(map[string]string, len(archive.File))
+	for _, file := range archive.File {
+		content, err := readZipFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[file.Name] = string(content)
+	}
+
+	slides := 0
+	for name := range files {
+		if matched, _ := path.Match("ppt/slides/slide*.xml", name); matched {
+			slides++
+		}
+	}
+	if slides != 3 {
+		t.Errorf("PPTX has %d slides, want 3", slides)
+	}
+
+	// Layers do not inherit the root label, so the unlabeled layer gets no notes.
+	if !strings.Contains(files["ppt/notesSlides/notesSlide1.xml"], "Checkout overview") {
+		t.Error("root slide notes do not contain the board label")
+	}
+	if !strings.Contains(files["ppt/notesSlides/notesSlide2.xml"], "Payment &amp; fraud checks") {
+		t.Error("layer slide notes do not contain the board label")
+	}
+	if _, ok := files["ppt/notesSlides/notesSlide3.xml"]; ok {
+		t.Error("unlabeled layer has notes")
+	}
+
+	for name, content := range files {
+		if !strings.HasSuffix(name, ".xml") && !strings.HasSuffix(name, ".rels") {
+			continue
+		}
+		decoder := xml.NewDecoder(strings.NewReader(content))
+		for {
+			if _, err := decoder.Token(); err == io.EOF {
+				break
+			} else if err != nil {
+				t.Fatalf("%s is not well-formed XML: %v", name, err)
+			}
+		}
+
+		if !strings.HasSuffix(name, ".rels") {
+			continue
+		}
+		// Relationship parts live in _rels next to their source part.
+		base := path.Dir(path.Dir(name))
+		for _, match := range pptxRelationshipPattern.FindAllStringSubmatch(content, -1) {
+			if match[2] != "" {
+				continue
+			}
+			target := path.Join(base, match[1])
+			if strings.HasPrefix(match[1], "/") {
+				target = strings.TrimPrefix(match[1], "/")
+			}
+			if _, ok := files[target]; !ok {
+				t.Errorf("%s references missing part %s", name, target)
+			}
+		}
+	}
+}
+
+func TestAddSpeakerNotesWithoutNotes(t *testing.T) {
+	presentation := []byte("not a zip")
+	got, err := addSpeakerNotes(presentation, []string{"", "  "})
+	if err != nil {
+		t.Fatalf("addSpeakerNotes() error = %v", err)
+	}
+	if !bytes.Equal(got, presentation) {
+		t.Error("addSpeakerNotes() changed a presentation without notes")
+	}
+}
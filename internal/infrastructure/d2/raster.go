@@ -0,0 +1,152 @@
+package d2
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+
+	"github.com/i2y/d2mcp/internal/domain/entity"
+	"github.com/i2y/d2mcp/internal/infrastructure/raster"
+)
+
+// mergeRasterOptions returns defaults with every field set in overrides applied on top.
+func mergeRasterOptions(defaults, overrides *entity.RasterOptions) *entity.RasterOptions {
+	if overrides == nil {
+		return defaults
+	}
+	if defaults == nil {
+		return overrides
+	}
+
+	merged := *defaults
+	if overrides.Width > 0 {
+		merged.Width = overrides.Width
+	}
+	if overrides.Height > 0 {
+		merged.Height = overrides.Height
+	}
+	if overrides.DPI > 0 {
+		merged.DPI = overrides.DPI
+	}
+	if overrides.Backend != "" {
+		merged.Backend = overrides.Backend
+	}
+	return &merged
+}
+
+// renderPNG converts a rendered SVG to PNG. The native rasterizer is used unless the
+// external backend is requested.
+func renderPNG(svg []byte, opts *entity.RasterOptions) ([]byte, error) {
+	if opts == nil {
+		opts = &entity.RasterOptions{}
+	}
+	if opts.Backend == entity.RasterExternal {
+		return convertWithExternalTool(svg, entity.FormatPNG, opts)
+	}
+
+	output, err := raster.PNG(svg, raster.Options{
+		Width:  opts.Width,
+		Height: opts.Height,
+		DPI:    opts.DPI,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert SVG to PNG: %w", err)
+	}
+	return output, nil
+}
+
+// convertWithExternalTool converts a rendered SVG with rsvg-convert, falling back to
+// ImageMagick when rsvg-convert is unavailable.
+func convertWithExternalTool(svg []byte, format entity.ExportFormat, opts *entity.RasterOptions) ([]byte, error) {
+	if opts == nil {
+		opts = &entity.RasterOptions{}
+	}
+
+	// Create temporary files
+	tmpDir := os.TempDir()
+	svgFile, err := os.CreateTemp(tmpDir, "d2-*.svg")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp SVG file: %w", err)
+	}
+	defer os.Remove(svgFile.Name())
+
+	// Write SVG to temp file
+	if _, err := svgFile.Write(svg); err != nil {
+		svgFile.Close()
+		return nil, fmt.Errorf("failed to write SVG: %w", err)
+	}
+	svgFile.Close()
+
+	// Prepare output file
+	outFile, err := os.CreateTemp(tmpDir, "d2-*."+string(format))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp output file: %w", err)
+	}
+	defer os.Remove(outFile.Name())
+	outFile.Close()
+
+	// Try to convert using rsvg-convert (common on Unix systems)
+	args := []string{"-f", string(format), "-o", outFile.Name()}
+	if opts.Width > 0 {
+		args = append(args, "-w", strconv.Itoa(opts.Width))
+	}
+	if opts.Height > 0 {
+		args = append(args, "-h", strconv.Itoa(opts.Height))
+	}
+	if opts.Width > 0 && opts.Height > 0 {
+		args = append(args, "--keep-aspect-ratio")
+	}
+	if opts.DPI > 0 {
+		dpi := strconv.FormatFloat(opts.DPI, 'f', -1, 64)
+		args = append(args, "--dpi-x", dpi, "--dpi-y", dpi)
+	}
+	cmd := exec.Command("rsvg-convert", append(args, svgFile.Name())...)
+
+	if err := cmd.Run(); err != nil {
+		// Try ImageMagick convert as fallback
+		var magickArgs []string
+		if opts.DPI > 0 {
+			magickArgs = append(magickArgs, "-density", strconv.FormatFloat(opts.DPI, 'f', -1, 64))
+		}
+		magickArgs = append(magickArgs, svgFile.Name())
+		if opts.Width > 0 || opts.Height > 0 {
+			magickArgs = append(magickArgs, "-resize", magickGeometry(opts.Width, opts.Height))
+		}
+		cmd = exec.Command("convert", append(magickArgs, outFile.Name())...)
+		if err := cmd.Run(); err != nil {
+			return nil, fmt.Errorf("failed to convert SVG to %s: %w (install rsvg-convert or imagemagick)", formatName(format), err)
+		}
+	}
+
+	// Read the converted file
+	output, err := os.ReadFile(outFile.Name())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read converted file: %w", err)
+	}
+	return output, nil
+}
+
+// magickGeometry formats a size for ImageMagick's -resize, leaving unset sides free.
+func magickGeometry(width, height int) string {
+	geometry := ""
+	if width > 0 {
+		geometry = strconv.Itoa(width)
+	}
+	geometry += "x"
+	if height > 0 {
+		geometry += strconv.Itoa(height)
+	}
+	return geometry
+}
+
+func formatName(format entity.ExportFormat) string {
+	switch format {
+	case entity.FormatPNG:
+		return "PNG"
+	case entity.FormatPDF:
+		return "PDF"
+	default:
+		return string(format)
+	}
+}
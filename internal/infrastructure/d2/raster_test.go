@@ -0,0 +1,96 @@
+package d2
+
+import (
+	"context"
+	"image"
+	"image/png"
+	"testing"
+
+	"github.com/i2y/d2mcp/internal/domain/entity"
+)
+
+// renderPNGSize renders content to PNG and returns the decoded image size.
+func renderPNGSize(t *testing.T, repo *D2Repository, opts *entity.RenderOptions) image.Point {
+	t.Helper()
+	reader, err := repo.Render(context.Background(), "a -> b: hello", entity.FormatPNG, opts)
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	img, err := png.Decode(reader)
+	if err != nil {
+		t.Fatalf("failed to decode PNG: %v", err)
+	}
+	return img.Bounds().Size()
+}
+
+func TestD2Repository_RenderPNG(t *testing.T) {
+	repo := NewD2Repository().(*D2Repository)
+	natural := renderPNGSize(t, repo, nil)
+	if natural.X == 0 || natural.Y == 0 {
+		t.Fatalf("natural size = %v, want non-empty image", natural)
+	}
+
+	scale := 2.0
+	tests := []struct {
+		name string
+		opts *entity.RenderOptions
+		want func(image.Point) bool
+	}{
+		{
+			name: "width keeps aspect ratio",
+			opts: &entity.RenderOptions{Raster: &entity.RasterOptions{Width: 600}},
+			want: func(size image.Point) bool {
+				return size.X == 600 && abs(size.Y-natural.Y*600/natural.X) <= 1
+			},
+		},
+		{
+			name: "width and height fit",
+			opts: &entity.RenderOptions{Raster: &entity.RasterOptions{Width: 300, Height: 300}},
+			want: func(size image.Point) bool { return size.X == 300 && size.Y == 300 },
+		},
+		{
+			name: "dpi",
+			opts: &entity.RenderOptions{Raster: &entity.RasterOptions{DPI: 192}},
+			want: func(size image.Point) bool {
+				return abs(size.X-2*natural.X) <= 1 && abs(size.Y-2*natural.Y) <= 1
+			},
+		},
+		{
+			name: "scale",
+			opts: &entity.RenderOptions{Scale: &scale},
+			want: func(size image.Point) bool { return size.X > natural.X && size.Y > natural.Y },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if size := renderPNGSize(t, repo, tt.opts); !tt.want(size) {
+				t.Errorf("PNG size = %v (natural size %v)", size, natural)
+			}
+		})
+	}
+}
+
+func TestMergeRasterOptions(t *testing.T) {
+	defaults := &entity.RasterOptions{Width: 800, DPI: 144}
+	overrides := &entity.RasterOptions{Height: 600, Backend: entity.RasterExternal}
+
+	merged := mergeRasterOptions(defaults, overrides)
+	want := entity.RasterOptions{Width: 800, Height: 600, DPI: 144, Backend: entity.RasterExternal}
+	if *merged != want {
+		t.Errorf("mergeRasterOptions() = %+v, want %+v", *merged, want)
+	}
+	if defaults.Height != 0 {
+		t.Error("mergeRasterOptions() modified defaults")
+	}
+	if got := mergeRasterOptions(defaults, nil); got != defaults {
+		t.Error("mergeRasterOptions() with nil overrides should return defaults")
+	}
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,86 @@
+package d2
+
+import (
+	"fmt"
+
+	"oss.terrastruct.com/d2/d2renderers/d2svg"
+	"oss.terrastruct.com/d2/d2themes/d2themescatalog"
+
+	"github.com/i2y/d2mcp/internal/domain/entity"
+)
+
+// mergeRenderOptions returns defaults with every field set in overrides applied on top.
+func mergeRenderOptions(defaults, overrides *entity.RenderOptions) *entity.RenderOptions {
+	merged := &entity.RenderOptions{}
+	if defaults != nil {
+		*merged = *defaults
+	}
+	if overrides == nil {
+		return merged
+	}
+
+	if overrides.Theme != nil {
+		merged.Theme = overrides.Theme
+	}
+	if overrides.DarkTheme != nil {
+		merged.DarkTheme = overrides.DarkTheme
+	}
+	if overrides.Sketch != nil {
+		merged.Sketch = overrides.Sketch
+	}
+	if overrides.Pad != nil {
+		merged.Pad = overrides.Pad
+	}
+	if overrides.Center != nil {
+		merged.Center = overrides.Center
+	}
+	if overrides.Scale != nil {
+		merged.Scale = overrides.Scale
+	}
+	if overrides.TOC != nil {
+		merged.TOC = overrides.TOC
+	}
+	if overrides.Interval != nil {
+		merged.Interval = overrides.Interval
+	}
+	if overrides.Changes != nil {
+		merged.Changes = overrides.Changes
+	}
+	merged.Layout = mergeLayoutOptions(merged.Layout, overrides.Layout)
+	merged.Raster = mergeRasterOptions(merged.Raster, overrides.Raster)
+
+	return merged
+}
+
+// newSVGRenderOpts converts render options into D2 SVG render options.
+// Unset fields are left nil so that the diagram's d2-config and D2's defaults apply.
+func newSVGRenderOpts(opts *entity.RenderOptions) (*d2svg.RenderOpts, error) {
+	pad := int64(d2svg.DEFAULT_PADDING)
+	if opts.Pad != nil {
+		pad = int64(*opts.Pad)
+	}
+	renderOpts := &d2svg.RenderOpts{
+		Pad:    &pad,
+		Sketch: opts.Sketch,
+		Center: opts.Center,
+		Scale:  opts.Scale,
+	}
+
+	// Apply themes if provided
+	if opts.Theme != nil {
+		if d2themescatalog.Find(int64(opts.Theme.ID)).Name == "" {
+			return nil, fmt.Errorf("unknown theme ID: %d (see THEMES.md)", opts.Theme.ID)
+		}
+		themeID := int64(opts.Theme.ID)
+		renderOpts.ThemeID = &themeID
+	}
+	if opts.DarkTheme != nil {
+		if d2themescatalog.Find(int64(opts.DarkTheme.ID)).Name == "" {
+			return nil, fmt.Errorf("unknown dark theme ID: %d (see THEMES.md)", opts.DarkTheme.ID)
+		}
+		darkThemeID := int64(opts.DarkTheme.ID)
+		renderOpts.DarkThemeID = &darkThemeID
+	}
+
+	return renderOpts, nil
+}
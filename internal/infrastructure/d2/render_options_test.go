@@ -0,0 +1,155 @@
+package d2
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"oss.terrastruct.com/d2/d2themes/d2themescatalog"
+
+	"github.com/i2y/d2mcp/internal/domain/entity"
+)
+
+// sketchMarker only appears in SVGs rendered in sketch mode.
+const sketchMarker = `<pattern id="streaks`
+
+func TestD2Repository_RenderOptions(t *testing.T) {
+	repo := NewD2Repository()
+	ctx := context.Background()
+	yes := true
+	pad := 0
+	scale := 2.0
+
+	tests := []struct {
+		name    string
+		opts    *entity.RenderOptions
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "theme",
+			opts: &entity.RenderOptions{Theme: &entity.Theme{ID: 300}},
+			want: d2themescatalog.Terminal.Colors.B1,
+		},
+		{
+			name: "dark theme",
+			opts: &entity.RenderOptions{DarkTheme: &entity.Theme{ID: 200}},
+			want: "prefers-color-scheme:dark",
+		},
+		{
+			name: "sketch",
+			opts: &entity.RenderOptions{Sketch: &yes},
+			want: sketchMarker,
+		},
+		{
+			name: "pad center and scale",
+			opts: &entity.RenderOptions{Pad: &pad, Center: &yes, Scale: &scale},
+			want: "xMidYMid",
+		},
+		{
+			name:    "unknown theme",
+			opts:    &entity.RenderOptions{Theme: &entity.Theme{ID: 999}},
+			wantErr: true,
+		},
+		{
+			name:    "unknown dark theme",
+			opts:    &entity.RenderOptions{DarkTheme: &entity.Theme{ID: 999}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader, err := repo.Render(ctx, "a -> b", entity.FormatSVG, tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Render() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			svg, err := io.ReadAll(reader)
+			if err != nil {
+				t.Fatalf("failed to read SVG: %v", err)
+			}
+			if !strings.Contains(string(svg), tt.want) {
+				t.Errorf("rendered SVG does not contain %q", tt.want)
+			}
+		})
+	}
+}
+
+func TestD2Repository_ExportRenderDefaults(t *testing.T) {
+	repo := NewD2Repository()
+	ctx := context.Background()
+	yes := true
+	no := false
+
+	err := repo.Create(ctx, &entity.Diagram{
+		ID:      "sketchy",
+		Content: "a -> b",
+		Theme:   &entity.Theme{ID: 200},
+		Options: &entity.RenderOptions{Sketch: &yes},
+	})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	export := func(opts *entity.RenderOptions) string {
+		t.Helper()
+		reader, err := repo.Export(ctx, "sketchy", entity.FormatSVG, opts)
+		if err != nil {
+			t.Fatalf("Export() error = %v", err)
+		}
+		svg, err := io.ReadAll(reader)
+		if err != nil {
+			t.Fatalf("failed to read SVG: %v", err)
+		}
+		return string(svg)
+	}
+
+	// Stored defaults, including the legacy Theme field, apply to every export.
+	svg := export(nil)
+	if !strings.Contains(svg, sketchMarker) {
+		t.Error("stored sketch default was not applied")
+	}
+	withoutOverride := export(&entity.RenderOptions{Sketch: &no})
+	if strings.Contains(withoutOverride, sketchMarker) {
+		t.Error("sketch override was not applied")
+	}
+
+	// Overriding the theme changes the output while keeping the other defaults.
+	if export(&entity.RenderOptions{Theme: &entity.Theme{ID: 0}}) == svg {
+		t.Error("theme override did not change the output")
+	}
+}
+
+func TestMergeRenderOptions(t *testing.T) {
+	yes := true
+	no := false
+	defaults := &entity.RenderOptions{
+		Theme:  &entity.Theme{ID: 1},
+		Sketch: &yes,
+		Layout: &entity.LayoutOptions{Engine: entity.LayoutELK, Direction: "right", NodeSpacing: 50},
+	}
+	overrides := &entity.RenderOptions{
+		Sketch: &no,
+		Layout: &entity.LayoutOptions{Direction: "down", EdgeSpacing: 30},
+	}
+
+	merged := mergeRenderOptions(defaults, overrides)
+	want := entity.LayoutOptions{Engine: entity.LayoutELK, Direction: "down", NodeSpacing: 50, EdgeSpacing: 30}
+	if *merged.Layout != want {
+		t.Errorf("merged layout = %+v, want %+v", *merged.Layout, want)
+	}
+	if merged.Theme == nil || merged.Theme.ID != 1 {
+		t.Errorf("merged theme = %+v, want theme 1", merged.Theme)
+	}
+	if merged.Sketch == nil || *merged.Sketch {
+		t.Error("merged sketch should be overridden to false")
+	}
+	if defaults.Layout.Direction != "right" || !*defaults.Sketch {
+		t.Error("mergeRenderOptions modified the defaults")
+	}
+}
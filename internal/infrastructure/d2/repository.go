@@ -7,13 +7,12 @@ import (
 	"io"
 	"log/slog"
 	"os"
-	"os/exec"
-	"strings"
+	"sort"
 	"sync"
+	"time"
 
-	"oss.terrastruct.com/d2/d2compiler"
+	"oss.terrastruct.com/d2/d2format"
 	"oss.terrastruct.com/d2/d2graph"
-	"oss.terrastruct.com/d2/d2layouts/d2dagrelayout"
 	"oss.terrastruct.com/d2/d2lib"
 	"oss.terrastruct.com/d2/d2renderers/d2svg"
 	"oss.terrastruct.com/d2/lib/log"
@@ -25,21 +24,65 @@ import (
 
 // D2Repository implements the DiagramRepository interface using D2.
 type D2Repository struct {
-	diagrams map[string]*diagramData
-	mu       sync.RWMutex
+	diagrams        map[string]*diagramData
+	dependencies    map[string][]string // IDs of the stored diagrams each diagram imports directly, kept while it is evicted to a workspace
+	listeners       []func(diagramID string)
+	removeListeners []func(diagramID string)
+	persist         func(diagramID string)                      // Called after every change, before the listeners
+	enforceLimits   func(keep string)                           // Called after every change to evict diagrams other than keep
+	reload          func(diagramID string) (*diagramData, bool) // Reads back a diagram evicted to a workspace; nil when evicted diagrams are gone
+	store           *importRoot                                 // Resolves imports from the other stored diagrams
+	mu              repoLock
+}
+
+// repoLock guards a repository. Its read locks are exclusive while diagrams can be
+// read back from a workspace, since reading one back changes the repository.
+type repoLock struct {
+	sync.RWMutex
+	exclusive bool // Set before the repository is shared
+}
+
+// RLock locks l for reading, or for writing when reads may change the repository.
+func (l *repoLock) RLock() {
+	if l.exclusive {
+		l.Lock()
+		return
+	}
+	l.RWMutex.RLock()
+}
+
+// RUnlock undoes a single RLock call.
+func (l *repoLock) RUnlock() {
+	if l.exclusive {
+		l.Unlock()
+		return
+	}
+	l.RWMutex.RUnlock()
 }
 
 // diagramData holds the D2 graph and related data.
 type diagramData struct {
-	content string
-	graph   *d2graph.Graph
+	content  string
+	graph    *d2graph.Graph
+	options  *entity.RenderOptions
+	revision int64       // Increased by one on every change
+	modified time.Time   // Time of the last change
+	imports  *importRoot // Where imports are read from: the store, or the directory of the file the diagram was loaded from
 }
 
 // NewD2Repository creates a new D2 repository instance.
 func NewD2Repository() repository.DiagramRepository {
-	return &D2Repository{
-		diagrams: make(map[string]*diagramData),
+	return newD2Repository()
+}
+
+// newD2Repository creates an empty D2 repository whose diagrams can import each other.
+func newD2Repository() *D2Repository {
+	r := &D2Repository{
+		diagrams:     make(map[string]*diagramData),
+		dependencies: make(map[string][]string),
 	}
+	r.store = &importRoot{fs: storeFS{repo: r}}
+	return r
 }
 
 // withSilentD2 executes a function with D2 logging disabled.
@@ -59,7 +102,19 @@ func withSilentD2(ctx context.Context, fn func(context.Context) error) error {
 }
 
 // Render renders D2 text into a diagram with specified format.
-func (r *D2Repository) Render(ctx context.Context, content string, format entity.ExportFormat, theme *entity.Theme) (io.Reader, error) {
+func (r *D2Repository) Render(ctx context.Context, content string, format entity.ExportFormat, opts *entity.RenderOptions) (io.Reader, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.render(ctx, content, r.store, format, opts)
+}
+
+// render renders D2 text, resolving its imports within imports.
+func (r *D2Repository) render(ctx context.Context, content string, imports *importRoot, format entity.ExportFormat, opts *entity.RenderOptions) (io.Reader, error) {
+	if opts == nil {
+		opts = &entity.RenderOptions{}
+	}
+
 	var result io.Reader
 	err := withSilentD2(ctx, func(ctx context.Context) error {
 		// Create ruler for text measurement.
@@ -68,27 +123,25 @@ func (r *D2Repository) Render(ctx context.Context, content string, format entity
 			return fmt.Errorf("failed to create ruler: %w", err)
 		}
 
-		// Create layout resolver.
-		layoutResolver := func(engine string) (d2graph.LayoutGraph, error) {
-			return d2dagrelayout.DefaultLayout, nil
-		}
-
-		// Create compile options.
+		// Create compile options. Without an explicit engine the diagram's own
+		// d2-config is honored, falling back to dagre.
 		compileOpts := &d2lib.CompileOptions{
-			LayoutResolver: layoutResolver,
+			LayoutResolver: newLayoutResolver(opts.Layout),
 			Ruler:          ruler,
 		}
-
-		// Create render options.
-		pad := int64(d2svg.DEFAULT_PADDING)
-		renderOpts := &d2svg.RenderOpts{
-			Pad: &pad,
+		if imports != nil {
+			compileOpts.FS = imports.fs
+			compileOpts.InputPath = imports.path
+		}
+		if opts.Layout != nil && opts.Layout.Engine != "" {
+			engine := string(opts.Layout.Engine)
+			compileOpts.Layout = &engine
 		}
 
-		// Apply theme if provided
-		if theme != nil {
-			themeID := int64(theme.ID)
-			renderOpts.ThemeID = &themeID
+		// Create render options.
+		renderOpts, err := newSVGRenderOpts(opts)
+		if err != nil {
+			return err
 		}
 
 		// Compile the D2 script.
@@ -114,101 +167,56 @@ func (r *D2Repository) Render(ctx context.Context, content string, format entity
 				return fmt.Errorf("failed to render SVG: %w", err)
 			}
 
-			// Note: tdewolff/canvas has issues parsing complex D2-generated SVGs
-			// with embedded styles and fonts. Falling back to external tools.
-			// TODO: Consider alternative pure-Go SVG rasterizers or preprocessing SVG
-
-			// Create temporary files
-			tmpDir := os.TempDir()
-			svgFile, err := os.CreateTemp(tmpDir, "d2-*.svg")
+			output, err := renderPNG(svg, opts.Raster)
 			if err != nil {
-				return fmt.Errorf("failed to create temp SVG file: %w", err)
+				return err
 			}
-			defer os.Remove(svgFile.Name())
-
-			// Write SVG to temp file
-			if _, err := svgFile.Write(svg); err != nil {
-				svgFile.Close()
-				return fmt.Errorf("failed to write SVG: %w", err)
-			}
-			svgFile.Close()
-
-			// Prepare output file
-			outFile, err := os.CreateTemp(tmpDir, "d2-*.png")
-			if err != nil {
-				return fmt.Errorf("failed to create temp output file: %w", err)
-			}
-			defer os.Remove(outFile.Name())
-			outFile.Close()
-
-			// Try to convert using rsvg-convert (common on Unix systems)
-			cmd := exec.Command("rsvg-convert", "-f", "png", "-o", outFile.Name(), svgFile.Name())
+			result = bytes.NewReader(output)
+			return nil
 
-			if err := cmd.Run(); err != nil {
-				// Try ImageMagick convert as fallback
-				cmd = exec.Command("convert", svgFile.Name(), outFile.Name())
-				if err := cmd.Run(); err != nil {
-					return fmt.Errorf("failed to convert SVG to PNG: %w (install rsvg-convert or imagemagick)", err)
+		case entity.FormatPDF:
+			if opts.Raster != nil && opts.Raster.Backend == entity.RasterExternal {
+				// External converters only see the root board's SVG.
+				svg, err := d2svg.Render(diagram, renderOpts)
+				if err != nil {
+					return fmt.Errorf("failed to render SVG: %w", err)
+				}
+				output, err := convertWithExternalTool(svg, entity.FormatPDF, opts.Raster)
+				if err != nil {
+					return err
 				}
+				result = bytes.NewReader(output)
+				return nil
 			}
 
-			// Read the converted file
-			output, err := os.ReadFile(outFile.Name())
+			output, err := renderPDF(diagram, renderOpts, opts)
 			if err != nil {
-				return fmt.Errorf("failed to read converted file: %w", err)
+				return err
 			}
-
 			result = bytes.NewReader(output)
 			return nil
 
-		case entity.FormatPDF:
-			// First render as SVG
-			svg, err := d2svg.Render(diagram, renderOpts)
-			if err != nil {
-				return fmt.Errorf("failed to render SVG: %w", err)
-			}
-
-			// For PDF, still use external tools for now
-			// TODO: Consider using canvas for PDF rendering as well
-			tmpDir := os.TempDir()
-			svgFile, err := os.CreateTemp(tmpDir, "d2-*.svg")
+		case entity.FormatPPTX:
+			output, err := renderPPTX(diagram, renderOpts, opts)
 			if err != nil {
-				return fmt.Errorf("failed to create temp SVG file: %w", err)
+				return err
 			}
-			defer os.Remove(svgFile.Name())
-
-			// Write SVG to temp file
-			if _, err := svgFile.Write(svg); err != nil {
-				svgFile.Close()
-				return fmt.Errorf("failed to write SVG: %w", err)
-			}
-			svgFile.Close()
+			result = bytes.NewReader(output)
+			return nil
 
-			// Prepare output file
-			outFile, err := os.CreateTemp(tmpDir, "d2-*.pdf")
+		case entity.FormatAnimatedSVG:
+			output, err := renderAnimatedSVG(diagram, renderOpts, opts)
 			if err != nil {
-				return fmt.Errorf("failed to create temp output file: %w", err)
-			}
-			defer os.Remove(outFile.Name())
-			outFile.Close()
-
-			// Try to convert using rsvg-convert (common on Unix systems)
-			cmd := exec.Command("rsvg-convert", "-f", "pdf", "-o", outFile.Name(), svgFile.Name())
-
-			if err := cmd.Run(); err != nil {
-				// Try ImageMagick convert as fallback
-				cmd = exec.Command("convert", svgFile.Name(), outFile.Name())
-				if err := cmd.Run(); err != nil {
-					return fmt.Errorf("failed to convert SVG to PDF: %w (install rsvg-convert or imagemagick)", err)
-				}
+				return err
 			}
+			result = bytes.NewReader(output)
+			return nil
 
-			// Read the converted file
-			output, err := os.ReadFile(outFile.Name())
+		case entity.FormatGIF:
+			output, err := renderGIF(diagram, renderOpts, opts)
 			if err != nil {
-				return fmt.Errorf("failed to read converted file: %w", err)
+				return err
 			}
-
 			result = bytes.NewReader(output)
 			return nil
 
@@ -225,36 +233,292 @@ func (r *D2Repository) Create(ctx context.Context, diagram *entity.Diagram) erro
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	// Parse the content to create a graph.
-	graph, _, err := d2compiler.Compile("", strings.NewReader(diagram.Content), &d2compiler.CompileOptions{
-		UTF16Pos: false,
-	})
+	data, err := newDiagramData(diagram, r.store)
 	if err != nil {
-		return fmt.Errorf("failed to compile diagram: %w", err)
+		return err
 	}
 
-	r.diagrams[diagram.ID] = &diagramData{
-		content: diagram.Content,
-		graph:   graph,
+	if existing, exists := r.diagram(diagram.ID); exists {
+		data.revision = existing.revision
+	}
+	if err := r.checkRevision(ctx, diagram.ID, data.revision); err != nil {
+		return err
 	}
 
+	r.diagrams[diagram.ID] = data
+	r.changed(ctx, diagram.ID)
+
 	return nil
 }
 
+// newDiagramData compiles a diagram, resolving its imports within imports, and
+// resolves its render defaults.
+func newDiagramData(diagram *entity.Diagram, imports *importRoot) (*diagramData, error) {
+	// Parse the content to create a graph.
+	graph, err := imports.compile(diagram.Content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile diagram: %w", err)
+	}
+
+	// Keep the legacy Theme field working as a render default.
+	options := mergeRenderOptions(nil, diagram.Options)
+	if options.Theme == nil {
+		options.Theme = diagram.Theme
+	}
+
+	return &diagramData{
+		content: diagram.Content,
+		graph:   graph,
+		options: options,
+		imports: imports,
+	}, nil
+}
+
 // Export exports the diagram to the specified format.
-func (r *D2Repository) Export(ctx context.Context, diagramID string, format entity.ExportFormat) (io.Reader, error) {
+func (r *D2Repository) Export(ctx context.Context, diagramID string, format entity.ExportFormat, opts *entity.RenderOptions) (io.Reader, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
-	data, exists := r.diagrams[diagramID]
-	if !exists {
-		return nil, fmt.Errorf("diagram %s not found", diagramID)
+	data, err := r.lookup(ctx, diagramID)
+	if err != nil {
+		return nil, err
 	}
 
 	// For now, use the stored content
 	// TODO: Implement proper graph serialization to D2 text
 	currentContent := data.content
 
+	// Highlighting works on a copy of the source, so the stored diagram is untouched.
+	merged := mergeRenderOptions(data.options, opts)
+	if merged.Changes != nil {
+		highlighted, err := highlightChanges(currentContent, data.imports, merged.Changes)
+		if err != nil {
+			return nil, err
+		}
+		currentContent = highlighted
+	}
+
 	// Render the current state
-	return r.Render(ctx, currentContent, format, nil)
+	return r.render(ctx, currentContent, data.imports, format, merged)
+}
+
+// ListDiagrams describes all stored diagrams, sorted by ID.
+func (r *D2Repository) ListDiagrams(ctx context.Context) ([]*entity.DiagramInfo, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	infos := make([]*entity.DiagramInfo, 0, len(r.diagrams))
+	for id, data := range r.diagrams {
+		infos = append(infos, diagramInfo(id, data))
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].ID < infos[j].ID
+	})
+	return infos, nil
+}
+
+// diagramInfo describes a stored diagram.
+func diagramInfo(diagramID string, data *diagramData) *entity.DiagramInfo {
+	return &entity.DiagramInfo{
+		ID:       diagramID,
+		Elements: len(data.graph.Objects) + len(data.graph.Edges),
+		Size:     len(data.content),
+		Revision: data.revision,
+		Modified: data.modified,
+	}
+}
+
+// DeleteDiagram removes a diagram.
+func (r *D2Repository) DeleteDiagram(ctx context.Context, diagramID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, err := r.lookup(ctx, diagramID); err != nil {
+		return err
+	}
+	if err := r.checkNotImported(diagramID, "delete"); err != nil {
+		return err
+	}
+
+	delete(r.diagrams, diagramID)
+	delete(r.dependencies, diagramID)
+	r.notifyRemove(diagramID)
+	return nil
+}
+
+// CloneDiagram stores a copy of a diagram and its render defaults under newID.
+func (r *D2Repository) CloneDiagram(ctx context.Context, diagramID, newID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	data, err := r.lookup(ctx, diagramID)
+	if err != nil {
+		return err
+	}
+	if _, exists := r.diagram(newID); exists {
+		return fmt.Errorf("diagram %s already exists", newID)
+	}
+	if err := r.checkRevision(ctx, newID, 0); err != nil {
+		return err
+	}
+
+	clone, err := copyDiagramData(data)
+	if err != nil {
+		return err
+	}
+	r.diagrams[newID] = clone
+	r.changed(ctx, newID)
+	return nil
+}
+
+// RenameDiagram moves a diagram to newID, keeping its revision.
+func (r *D2Repository) RenameDiagram(ctx context.Context, diagramID, newID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.checkRename(ctx, diagramID, newID); err != nil {
+		return err
+	}
+
+	r.rename(diagramID, newID)
+	return nil
+}
+
+// checkRename checks that a diagram can be moved to newID. Callers must hold r.mu.
+func (r *D2Repository) checkRename(ctx context.Context, diagramID, newID string) error {
+	if _, err := r.lookup(ctx, diagramID); err != nil {
+		return err
+	}
+	if _, exists := r.diagram(newID); exists {
+		return fmt.Errorf("diagram %s already exists", newID)
+	}
+	return r.checkNotImported(diagramID, "rename")
+}
+
+// rename moves a diagram to newID and tells the listeners that the old ID is gone
+// and the new one appeared. Callers must hold r.mu.
+func (r *D2Repository) rename(diagramID, newID string) {
+	r.diagrams[newID] = r.diagrams[diagramID]
+	delete(r.diagrams, diagramID)
+	r.dependencies[newID] = r.dependencies[diagramID]
+	delete(r.dependencies, diagramID)
+
+	if r.persist != nil {
+		r.persist(newID)
+	}
+	r.notifyRemove(diagramID)
+	r.notifyChange(newID)
+}
+
+// copyDiagramData returns an independent copy of a diagram at revision zero.
+func copyDiagramData(data *diagramData) (*diagramData, error) {
+	content := data.content
+	if data.graph.AST != nil {
+		content = d2format.Format(data.graph.AST)
+	}
+	graph, err := data.imports.compile(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy diagram: %w", err)
+	}
+
+	copied := &diagramData{
+		content: content,
+		graph:   graph,
+		imports: data.imports,
+	}
+	if data.options != nil {
+		options := *data.options
+		copied.options = &options
+	}
+	return copied, nil
+}
+
+// OnChange registers a listener that is called with the diagram ID whenever a stored
+// diagram is created or modified. Listeners run while the repository is locked, so
+// they must not call back into it.
+func (r *D2Repository) OnChange(listener func(diagramID string)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.listeners = append(r.listeners, listener)
+}
+
+// OnRemove registers a listener that is called with the diagram ID whenever a
+// stored diagram is removed. Listeners run while the repository is locked, so they
+// must not call back into it.
+func (r *D2Repository) OnRemove(listener func(diagramID string)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.removeListeners = append(r.removeListeners, listener)
+}
+
+// lookup returns a stored diagram, checking and recording its revision for the
+// request. Callers must hold r.mu.
+func (r *D2Repository) lookup(ctx context.Context, diagramID string) (*diagramData, error) {
+	data, exists := r.diagram(diagramID)
+	if !exists {
+		return nil, fmt.Errorf("diagram %s not found", diagramID)
+	}
+	if err := r.checkRevision(ctx, diagramID, data.revision); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// diagram returns a stored diagram, reading it back first when it was evicted to a
+// workspace. Callers must hold r.mu.
+func (r *D2Repository) diagram(diagramID string) (*diagramData, bool) {
+	if data, exists := r.diagrams[diagramID]; exists {
+		return data, true
+	}
+	if r.reload != nil {
+		return r.reload(diagramID)
+	}
+	return nil, false
+}
+
+// checkRevision checks and records the revision of a diagram for the request.
+// Diagrams that do not exist are at revision 0.
+func (r *D2Repository) checkRevision(ctx context.Context, diagramID string, revision int64) error {
+	if rev := repository.RevisionFromContext(ctx, diagramID); rev != nil {
+		return rev.Check(revision)
+	}
+	return nil
+}
+
+// changed increases the revision of a diagram after a change, persists it when the
+// repository is durable, tells the change listeners, recompiles the diagrams
+// importing it and evicts other diagrams if the change took the repository over
+// its limits. Callers must hold r.mu.
+func (r *D2Repository) changed(ctx context.Context, diagramID string) {
+	data := r.diagrams[diagramID]
+	data.revision++
+	data.modified = time.Now()
+	if rev := repository.RevisionFromContext(ctx, diagramID); rev != nil {
+		rev.Advance(data.revision)
+	}
+	if r.persist != nil {
+		r.persist(diagramID)
+	}
+	r.dependencies[diagramID] = r.storeImports(data)
+	r.notifyChange(diagramID)
+	r.recompileImporters(diagramID)
+	if r.enforceLimits != nil {
+		r.enforceLimits(diagramID)
+	}
+}
+
+// notifyChange calls the change listeners for a diagram. Callers must hold r.mu.
+func (r *D2Repository) notifyChange(diagramID string) {
+	for _, listener := range r.listeners {
+		listener(diagramID)
+	}
+}
+
+// notifyRemove calls the remove listeners for a diagram. Callers must hold r.mu.
+func (r *D2Repository) notifyRemove(diagramID string) {
+	for _, listener := range r.removeListeners {
+		listener(diagramID)
+	}
 }
@@ -3,6 +3,7 @@ package d2
 import (
 	"context"
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/i2y/d2mcp/internal/domain/entity"
@@ -25,16 +26,16 @@ func TestD2Repository_Render(t *testing.T) {
 			wantErr: false,
 		},
 		{
-			name:    "render PNG requires external tool",
+			name:    "render PNG",
 			content: "a -> b",
 			format:  entity.FormatPNG,
-			wantErr: false, // May succeed if rsvg-convert or imagemagick is installed
+			wantErr: false,
 		},
 		{
-			name:    "render PDF requires external tool",
+			name:    "render PDF",
 			content: "a -> b",
 			format:  entity.FormatPDF,
-			wantErr: false, // May succeed if rsvg-convert or imagemagick is installed
+			wantErr: false,
 		},
 		{
 			name:    "invalid content",
@@ -73,7 +74,7 @@ func TestD2Repository_CreateAndExport(t *testing.T) {
 	}
 
 	// Test Export
-	reader, err := repo.Export(ctx, diagram.ID, entity.FormatSVG)
+	reader, err := repo.Export(ctx, diagram.ID, entity.FormatSVG, nil)
 	if err != nil {
 		t.Fatalf("Export() error = %v", err)
 	}
@@ -82,12 +83,49 @@ func TestD2Repository_CreateAndExport(t *testing.T) {
 	}
 
 	// Test Export non-existent diagram
-	_, err = repo.Export(ctx, "non-existent", entity.FormatSVG)
+	_, err = repo.Export(ctx, "non-existent", entity.FormatSVG, nil)
 	if err == nil {
 		t.Error("Export() should fail for non-existent diagram")
 	}
 }
 
+func TestD2Repository_ListDiagrams(t *testing.T) {
+	repo := NewD2Repository()
+	ctx := context.Background()
+
+	for _, id := range []string{"zeta", "alpha", "mid"} {
+		if err := repo.Create(ctx, &entity.Diagram{ID: id}); err != nil {
+			t.Fatalf("Create(%s) error = %v", id, err)
+		}
+	}
+	if err := repo.Create(ctx, &entity.Diagram{ID: "mid", Content: "a -> b"}); err != nil {
+		t.Fatalf("Create(mid) error = %v", err)
+	}
+
+	diagrams, err := repo.ListDiagrams(ctx)
+	if err != nil {
+		t.Fatalf("ListDiagrams() error = %v", err)
+	}
+	want := []string{"alpha", "mid", "zeta"}
+	if ids := diagramIDs(diagrams); strings.Join(ids, ",") != strings.Join(want, ",") {
+		t.Errorf("ListDiagrams() = %v, want %v", ids, want)
+	}
+
+	mid := diagrams[1]
+	if mid.Elements != 3 || mid.Size != len("a -> b") || mid.Revision != 2 || mid.Modified.IsZero() {
+		t.Errorf("ListDiagrams() mid = %+v", mid)
+	}
+}
+
+// diagramIDs returns the IDs of listed diagrams.
+func diagramIDs(diagrams []*entity.DiagramInfo) []string {
+	ids := make([]string, len(diagrams))
+	for i, diagram := range diagrams {
+		ids[i] = diagram.ID
+	}
+	return ids
+}
+
 func TestD2Repository_ConcurrentAccess(t *testing.T) {
 	repo := NewD2Repository()
 	ctx := context.Background()
@@ -111,7 +149,7 @@ func TestD2Repository_ConcurrentAccess(t *testing.T) {
 	// Concurrent reads/exports
 	for i := 0; i < 5; i++ {
 		go func(i int) {
-			_, err := repo.Export(ctx, fmt.Sprintf("concurrent-test-%d", i), entity.FormatSVG)
+			_, err := repo.Export(ctx, fmt.Sprintf("concurrent-test-%d", i), entity.FormatSVG, nil)
 			if err != nil {
 				errors <- err
 			}
@@ -0,0 +1,88 @@
+package d2
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/i2y/d2mcp/internal/domain/entity"
+	"github.com/i2y/d2mcp/internal/domain/repository"
+)
+
+func TestD2OracleRepository_Revisions(t *testing.T) {
+	repo := NewD2OracleRepository()
+	ctx := context.Background()
+
+	// revisionOf reads the current revision of a diagram.
+	revisionOf := func(diagramID string) int64 {
+		t.Helper()
+		revision := &repository.Revision{DiagramID: diagramID}
+		if _, err := repo.GetGraph(repository.WithRevision(ctx, revision), diagramID); err != nil {
+			t.Fatalf("GetGraph() error = %v", err)
+		}
+		return revision.Current
+	}
+
+	created := &repository.Revision{DiagramID: "test-revisions"}
+	if err := repo.LoadDiagram(repository.WithRevision(ctx, created), "test-revisions", "web -> api"); err != nil {
+		t.Fatalf("LoadDiagram() error = %v", err)
+	}
+	if created.Current != 1 {
+		t.Errorf("LoadDiagram() revision = %d, want 1", created.Current)
+	}
+
+	// Every change, including undo, moves the revision forward.
+	if _, err := repo.CreateElement(ctx, "test-revisions", nil, "db"); err != nil {
+		t.Fatalf("CreateElement() error = %v", err)
+	}
+	if _, err := repo.Undo(ctx, "test-revisions", 1); err != nil {
+		t.Fatalf("Undo() error = %v", err)
+	}
+	if got := revisionOf("test-revisions"); got != 3 {
+		t.Errorf("revision after create and undo = %d, want 3", got)
+	}
+
+	// A change based on an outdated revision is rejected and not applied.
+	stale := int64(1)
+	staleCtx := repository.WithRevision(ctx, &repository.Revision{DiagramID: "test-revisions", Expected: &stale})
+	_, err := repo.CreateElement(staleCtx, "test-revisions", nil, "cache")
+	var conflict *entity.RevisionConflictError
+	if !errors.As(err, &conflict) {
+		t.Fatalf("CreateElement() with a stale revision error = %v, want a revision conflict", err)
+	}
+	if conflict.Expected != 1 || conflict.Actual != 3 {
+		t.Errorf("conflict = %+v", conflict)
+	}
+	if _, err := repo.GetObject(ctx, "test-revisions", nil, "cache"); err == nil {
+		t.Error("a rejected change should not be applied")
+	}
+
+	// Later calls of the same request expect the revision it produced.
+	current := int64(3)
+	revision := &repository.Revision{DiagramID: "test-revisions", Expected: &current}
+	requestCtx := repository.WithRevision(ctx, revision)
+	if _, err := repo.CreateElement(requestCtx, "test-revisions", nil, "cache"); err != nil {
+		t.Fatalf("CreateElement() with the current revision error = %v", err)
+	}
+	if _, err := repo.SerializeDiagram(requestCtx, "test-revisions"); err != nil {
+		t.Errorf("SerializeDiagram() after the request's own change error = %v", err)
+	}
+	if revision.Current != 4 {
+		t.Errorf("revision = %d, want 4", revision.Current)
+	}
+
+	// Revision 0 stands for a diagram that does not exist yet.
+	none := int64(0)
+	err = repo.Create(repository.WithRevision(ctx, &repository.Revision{DiagramID: "test-revisions", Expected: &none}), &entity.Diagram{ID: "test-revisions", Content: "x"})
+	if !errors.As(err, &conflict) {
+		t.Errorf("Create() over an existing diagram with revision 0 error = %v", err)
+	}
+	if err := repo.Create(repository.WithRevision(ctx, &repository.Revision{DiagramID: "new", Expected: &none}), &entity.Diagram{ID: "new", Content: "x"}); err != nil {
+		t.Errorf("Create() of a new diagram with revision 0 error = %v", err)
+	}
+
+	// Revisions are tracked per diagram.
+	if _, err := repo.CreateElement(staleCtx, "new", nil, "y"); err != nil {
+		t.Errorf("CreateElement() on another diagram error = %v", err)
+	}
+}
@@ -0,0 +1,136 @@
+package d2
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"oss.terrastruct.com/d2/d2format"
+
+	"github.com/i2y/d2mcp/internal/domain/entity"
+)
+
+// CreateSnapshot saves the current state of a diagram under name, replacing any snapshot with the same name
+func (r *D2OracleRepository) CreateSnapshot(ctx context.Context, diagramID, name string) (*entity.Snapshot, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	data, err := r.lookup(ctx, diagramID)
+	if err != nil {
+		return nil, err
+	}
+
+	content := data.content
+	if data.graph.AST != nil {
+		content = d2format.Format(data.graph.AST)
+	}
+
+	now := time.Now()
+	snapshot, exists := r.snapshots[diagramID][name]
+	if !exists {
+		snapshot = &entity.Snapshot{
+			DiagramID: diagramID,
+			Name:      name,
+			CreatedAt: now,
+		}
+		if r.snapshots[diagramID] == nil {
+			r.snapshots[diagramID] = make(map[string]*entity.Snapshot)
+		}
+		r.snapshots[diagramID][name] = snapshot
+	}
+	snapshot.Content = content
+	snapshot.UpdatedAt = now
+	r.persistDiagram(diagramID)
+
+	copied := *snapshot
+	return &copied, nil
+}
+
+// ListSnapshots returns the snapshots of a diagram, oldest first
+func (r *D2OracleRepository) ListSnapshots(ctx context.Context, diagramID string) ([]*entity.Snapshot, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if _, err := r.lookup(ctx, diagramID); err != nil {
+		return nil, err
+	}
+
+	snapshots := make([]*entity.Snapshot, 0, len(r.snapshots[diagramID]))
+	for _, snapshot := range r.snapshots[diagramID] {
+		copied := *snapshot
+		snapshots = append(snapshots, &copied)
+	}
+	sort.Slice(snapshots, func(i, j int) bool {
+		if !snapshots[i].CreatedAt.Equal(snapshots[j].CreatedAt) {
+			return snapshots[i].CreatedAt.Before(snapshots[j].CreatedAt)
+		}
+		return snapshots[i].Name < snapshots[j].Name
+	})
+	return snapshots, nil
+}
+
+// GetSnapshot retrieves a snapshot
+func (r *D2OracleRepository) GetSnapshot(ctx context.Context, diagramID, name string) (*entity.Snapshot, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	snapshot, err := r.findSnapshot(ctx, diagramID, name)
+	if err != nil {
+		return nil, err
+	}
+
+	copied := *snapshot
+	return &copied, nil
+}
+
+// RestoreSnapshot replaces the diagram with the state saved in a snapshot. The
+// restore is recorded in the history, so it can be undone.
+func (r *D2OracleRepository) RestoreSnapshot(ctx context.Context, diagramID, name string) (*entity.Snapshot, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	snapshot, err := r.findSnapshot(ctx, diagramID, name)
+	if err != nil {
+		return nil, err
+	}
+
+	graph, err := r.diagrams[diagramID].imports.compile(snapshot.Content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore snapshot %s: %w", name, err)
+	}
+	r.commitGraph(ctx, diagramID, graph, "restore snapshot "+name)
+
+	copied := *snapshot
+	return &copied, nil
+}
+
+// DeleteSnapshot removes a snapshot
+func (r *D2OracleRepository) DeleteSnapshot(ctx context.Context, diagramID, name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, err := r.findSnapshot(ctx, diagramID, name); err != nil {
+		return err
+	}
+
+	delete(r.snapshots[diagramID], name)
+	if len(r.snapshots[diagramID]) == 0 {
+		delete(r.snapshots, diagramID)
+	}
+	r.persistDiagram(diagramID)
+	return nil
+}
+
+// findSnapshot looks up a snapshot of a stored diagram. Callers must hold r.mu.
+func (r *D2OracleRepository) findSnapshot(ctx context.Context, diagramID, name string) (*entity.Snapshot, error) {
+	if _, err := r.lookup(ctx, diagramID); err != nil {
+		return nil, err
+	}
+
+	snapshot, exists := r.snapshots[diagramID][name]
+	if !exists {
+		return nil, fmt.Errorf("snapshot %s not found in diagram %s", name, diagramID)
+	}
+	return snapshot, nil
+}
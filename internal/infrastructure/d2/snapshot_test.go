@@ -0,0 +1,126 @@
+package d2
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestD2OracleRepository_SnapshotLifecycle(t *testing.T) {
+	repo := NewD2OracleRepository()
+	ctx := context.Background()
+
+	diagramID := "test-snapshots"
+	if err := repo.LoadDiagram(ctx, diagramID, "web -> api"); err != nil {
+		t.Fatalf("LoadDiagram() error = %v", err)
+	}
+
+	snapshot, err := repo.CreateSnapshot(ctx, diagramID, "before-refactor")
+	if err != nil {
+		t.Fatalf("CreateSnapshot() error = %v", err)
+	}
+	if !strings.Contains(snapshot.Content, "web -> api") {
+		t.Errorf("CreateSnapshot() content = %q", snapshot.Content)
+	}
+	if snapshot.CreatedAt.IsZero() || !snapshot.UpdatedAt.Equal(snapshot.CreatedAt) {
+		t.Errorf("CreateSnapshot() timestamps = %v, %v", snapshot.CreatedAt, snapshot.UpdatedAt)
+	}
+
+	if _, err := repo.CreateElement(ctx, diagramID, nil, "db"); err != nil {
+		t.Fatalf("CreateElement() error = %v", err)
+	}
+	if _, err := repo.CreateSnapshot(ctx, diagramID, "with-db"); err != nil {
+		t.Fatalf("CreateSnapshot() error = %v", err)
+	}
+	if _, err := repo.RenameElement(ctx, diagramID, nil, "api", "backend"); err != nil {
+		t.Fatalf("RenameElement() error = %v", err)
+	}
+
+	snapshots, err := repo.ListSnapshots(ctx, diagramID)
+	if err != nil {
+		t.Fatalf("ListSnapshots() error = %v", err)
+	}
+	if len(snapshots) != 2 || snapshots[0].Name != "before-refactor" || snapshots[1].Name != "with-db" {
+		t.Fatalf("ListSnapshots() = %v", snapshots)
+	}
+
+	restored, err := repo.RestoreSnapshot(ctx, diagramID, "before-refactor")
+	if err != nil {
+		t.Fatalf("RestoreSnapshot() error = %v", err)
+	}
+	if restored.Name != "before-refactor" {
+		t.Errorf("RestoreSnapshot() name = %v", restored.Name)
+	}
+	serialized, _ := repo.SerializeDiagram(ctx, diagramID)
+	if serialized != snapshot.Content {
+		t.Errorf("RestoreSnapshot() left:\n%s", serialized)
+	}
+
+	// The restored graph is live and the restore can be undone.
+	if _, err := repo.GetObject(ctx, diagramID, nil, "api"); err != nil {
+		t.Errorf("GetObject() after RestoreSnapshot() error = %v", err)
+	}
+	result, err := repo.Undo(ctx, diagramID, 1)
+	if err != nil {
+		t.Fatalf("Undo() error = %v", err)
+	}
+	if result.Changes[0] != "restore snapshot before-refactor" {
+		t.Errorf("Undo() changes = %v", result.Changes)
+	}
+	serialized, _ = repo.SerializeDiagram(ctx, diagramID)
+	if !strings.Contains(serialized, "backend") {
+		t.Errorf("Undo() of the restore left:\n%s", serialized)
+	}
+
+	if err := repo.DeleteSnapshot(ctx, diagramID, "with-db"); err != nil {
+		t.Fatalf("DeleteSnapshot() error = %v", err)
+	}
+	if err := repo.DeleteSnapshot(ctx, diagramID, "with-db"); err == nil {
+		t.Error("DeleteSnapshot() should fail for a missing snapshot")
+	}
+	if _, err := repo.RestoreSnapshot(ctx, diagramID, "with-db"); err == nil {
+		t.Error("RestoreSnapshot() should fail for a deleted snapshot")
+	}
+	snapshots, _ = repo.ListSnapshots(ctx, diagramID)
+	if len(snapshots) != 1 {
+		t.Errorf("ListSnapshots() after delete = %d snapshots, want 1", len(snapshots))
+	}
+}
+
+func TestD2OracleRepository_SnapshotReplace(t *testing.T) {
+	repo := NewD2OracleRepository()
+	ctx := context.Background()
+
+	diagramID := "test-snapshot-replace"
+	if err := repo.LoadDiagram(ctx, diagramID, "a"); err != nil {
+		t.Fatalf("LoadDiagram() error = %v", err)
+	}
+	first, err := repo.CreateSnapshot(ctx, diagramID, "checkpoint")
+	if err != nil {
+		t.Fatalf("CreateSnapshot() error = %v", err)
+	}
+	if _, err := repo.CreateElement(ctx, diagramID, nil, "b"); err != nil {
+		t.Fatalf("CreateElement() error = %v", err)
+	}
+	second, err := repo.CreateSnapshot(ctx, diagramID, "checkpoint")
+	if err != nil {
+		t.Fatalf("CreateSnapshot() error = %v", err)
+	}
+
+	if !second.CreatedAt.Equal(first.CreatedAt) {
+		t.Errorf("CreateSnapshot() changed CreatedAt of a replaced snapshot")
+	}
+	if !strings.Contains(second.Content, "b") {
+		t.Errorf("CreateSnapshot() did not replace the content: %q", second.Content)
+	}
+	if strings.Contains(first.Content, "b") {
+		t.Errorf("CreateSnapshot() modified a snapshot returned earlier: %q", first.Content)
+	}
+
+	if _, err := repo.CreateSnapshot(ctx, "missing", "checkpoint"); err == nil {
+		t.Error("CreateSnapshot() should fail for a missing diagram")
+	}
+	if _, err := repo.ListSnapshots(ctx, "missing"); err == nil {
+		t.Error("ListSnapshots() should fail for a missing diagram")
+	}
+}
@@ -0,0 +1,174 @@
+package d2
+
+import (
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"oss.terrastruct.com/d2/d2ast"
+	"oss.terrastruct.com/d2/d2graph"
+)
+
+// storeFS exposes the stored diagrams as a read-only file system holding one
+// <id>.d2 file per diagram, so that diagrams can import each other by ID, as in
+// `...@shared-styles` or `infra: @network`. Its files are read while compiling,
+// which happens with the repository locked, so storeFS does not lock it again.
+// Diagrams evicted to a workspace are read back when imported.
+type storeFS struct {
+	repo *D2Repository
+}
+
+// Open opens the D2 source of the diagram stored under name without its .d2
+// extension.
+func (s storeFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+
+	diagramID, isSource := strings.CutSuffix(name, sourceExt)
+	data, exists := s.repo.diagram(diagramID)
+	if !isSource || !exists {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+
+	return &storeFile{
+		Reader: strings.NewReader(data.content),
+		info: storeFileInfo{
+			name:     path.Base(name),
+			size:     int64(len(data.content)),
+			modified: data.modified,
+		},
+	}, nil
+}
+
+// storeFile is an open diagram of a storeFS. It holds a copy of the diagram's
+// source, so it stays readable after the diagram changes.
+type storeFile struct {
+	*strings.Reader
+	info storeFileInfo
+}
+
+// Stat returns the file info of the diagram.
+func (f *storeFile) Stat() (fs.FileInfo, error) {
+	return f.info, nil
+}
+
+// Close does nothing, since the file is held in memory.
+func (f *storeFile) Close() error {
+	return nil
+}
+
+// storeFileInfo describes a diagram of a storeFS as a file.
+type storeFileInfo struct {
+	name     string
+	size     int64
+	modified time.Time
+}
+
+func (i storeFileInfo) Name() string       { return i.name }
+func (i storeFileInfo) Size() int64        { return i.size }
+func (i storeFileInfo) Mode() fs.FileMode  { return 0444 }
+func (i storeFileInfo) ModTime() time.Time { return i.modified }
+func (i storeFileInfo) IsDir() bool        { return false }
+func (i storeFileInfo) Sys() interface{}   { return nil }
+
+// storeImports returns the IDs of the stored diagrams that graph imports directly.
+// Diagrams loaded from a file import files instead, so they import none.
+func (r *D2Repository) storeImports(data *diagramData) []string {
+	if data.imports != r.store || data.graph == nil {
+		return nil
+	}
+	return importedIDs(data.graph)
+}
+
+// importedIDs returns the sorted paths without their .d2 extension of the files
+// graph imports directly, which for stored diagrams are diagram IDs.
+func importedIDs(graph *d2graph.Graph) []string {
+	if graph.AST == nil {
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	d2ast.Walk(graph.AST, func(node d2ast.Node) bool {
+		imp, isImport := node.(*d2ast.Import)
+		if !isImport {
+			return true
+		}
+		// Resolved as D2 does for the root file of a diagram.
+		if name := imp.PathWithPre(); name != "" && !path.IsAbs(name) {
+			if path.Ext(name) != sourceExt {
+				name += sourceExt
+			}
+			seen[strings.TrimSuffix(path.Clean(name), sourceExt)] = true
+		}
+		return false
+	})
+
+	ids := make([]string, 0, len(seen))
+	for id := range seen {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// importers returns the sorted IDs of the stored diagrams that import a diagram
+// directly, including those evicted to a workspace. Callers must hold r.mu.
+func (r *D2Repository) importers(diagramID string) []string {
+	var ids []string
+	for id, imports := range r.dependencies {
+		for _, imported := range imports {
+			if imported == diagramID && id != diagramID {
+				ids = append(ids, id)
+				break
+			}
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// checkNotImported fails when other stored diagrams import a diagram, since they
+// would no longer compile without it. Callers must hold r.mu.
+func (r *D2Repository) checkNotImported(diagramID, action string) error {
+	importers := r.importers(diagramID)
+	if len(importers) == 0 {
+		return nil
+	}
+	return fmt.Errorf("cannot %s diagram %s because it is imported by %s; remove the imports of it from those diagrams first", action, diagramID, strings.Join(importers, ", "))
+}
+
+// recompileImporters recompiles the diagrams that import a changed diagram,
+// directly or through other diagrams, so that their graphs reflect the change,
+// and tells the change listeners. Their source and revision stay as they are.
+// Importers that no longer compile keep their previous graph, and importers
+// evicted to a workspace are compiled when they are read back. Callers must hold r.mu.
+func (r *D2Repository) recompileImporters(diagramID string) {
+	visited := map[string]bool{diagramID: true}
+	queue := r.importers(diagramID)
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		if visited[id] {
+			continue
+		}
+		visited[id] = true
+
+		data, exists := r.diagrams[id]
+		if !exists {
+			continue
+		}
+		graph, err := data.imports.compile(data.content)
+		if err != nil {
+			log.Printf("Diagram %s no longer compiles after a change to %s: %v", id, diagramID, err)
+			continue
+		}
+		data.graph = graph
+		r.notifyChange(id)
+		queue = append(queue, r.importers(id)...)
+	}
+}
@@ -0,0 +1,199 @@
+package d2
+
+import (
+	"context"
+	"io"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/i2y/d2mcp/internal/domain/entity"
+)
+
+func TestD2OracleRepository_StoreImports(t *testing.T) {
+	ctx := context.Background()
+
+	create := func(t *testing.T, repo *D2OracleRepository, id, content string) {
+		t.Helper()
+		if err := repo.Create(ctx, &entity.Diagram{ID: id, Content: content}); err != nil {
+			t.Fatalf("Create(%s) error = %v", id, err)
+		}
+	}
+	exportSVG := func(t *testing.T, repo *D2OracleRepository, id string) string {
+		t.Helper()
+		reader, err := repo.Export(ctx, id, entity.FormatSVG, nil)
+		if err != nil {
+			t.Fatalf("Export(%s) error = %v", id, err)
+		}
+		svg, _ := io.ReadAll(reader)
+		return string(svg)
+	}
+
+	repo := NewD2OracleRepository()
+	create(t, repo, "shared-styles", "classes: {important: {style.fill: \"#ff0000\"}}")
+	create(t, repo, "network", "lb: Load Balancer\nvpc")
+	create(t, repo, "arch", "...@shared-styles\napi.class: important\ninfra: @network\napi -> infra.lb")
+
+	if obj, err := repo.GetObject(ctx, "arch", nil, "infra.lb"); err != nil || obj.Label != "Load Balancer" {
+		t.Errorf("GetObject(infra.lb) = %+v, %v", obj, err)
+	}
+	if _, err := repo.CreateElement(ctx, "arch", nil, "cache"); err != nil {
+		t.Fatalf("CreateElement() error = %v", err)
+	}
+	if svg := exportSVG(t, repo, "arch"); !strings.Contains(svg, "#ff0000") {
+		t.Error("exported SVG is missing the imported class")
+	}
+
+	// Importing diagrams render the current version of the diagrams they import.
+	fill := "#00ff00"
+	if _, err := repo.SetAttribute(ctx, "shared-styles", nil, "classes.important.style.fill", nil, &fill); err != nil {
+		t.Fatalf("SetAttribute() error = %v", err)
+	}
+	if svg := exportSVG(t, repo, "arch"); !strings.Contains(svg, "#00ff00") {
+		t.Error("exported SVG does not use the changed class")
+	}
+
+	if graph, err := repo.ParseGraph(ctx, "net: @network"); err != nil || graph.Objects["net.vpc"] == nil {
+		t.Errorf("ParseGraph() = %+v, %v", graph, err)
+	}
+	if reader, err := repo.Render(ctx, "net: @network", entity.FormatSVG, nil); err != nil {
+		t.Errorf("Render() error = %v", err)
+	} else if svg, _ := io.ReadAll(reader); !strings.Contains(string(svg), "Load Balancer") {
+		t.Error("rendered SVG is missing the imported diagram")
+	}
+
+	for _, content := range []string{"x: @missing", "...@../network"} {
+		if err := repo.Create(ctx, &entity.Diagram{ID: "broken", Content: content}); err == nil {
+			t.Errorf("Create() with %q succeeded", content)
+		}
+	}
+}
+
+func TestD2FileRepository_StoreImports(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	repo, err := NewD2FileRepository(dir)
+	if err != nil {
+		t.Fatalf("NewD2FileRepository() error = %v", err)
+	}
+	// The importing diagram sorts first, so it is read before the one it imports.
+	if err := repo.Create(ctx, &entity.Diagram{ID: "zz-shared", Content: "a: Shared"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Create(ctx, &entity.Diagram{ID: "main", Content: "...@zz-shared\na -> b"}); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := NewD2FileRepository(dir)
+	if err != nil {
+		t.Fatalf("NewD2FileRepository() reopen error = %v", err)
+	}
+	if obj, err := reopened.GetObject(ctx, "main", nil, "a"); err != nil || obj.Label != "Shared" {
+		t.Errorf("GetObject() after reopen = %+v, %v", obj, err)
+	}
+}
+
+func TestD2OracleRepository_StoreImportDependents(t *testing.T) {
+	ctx := context.Background()
+
+	create := func(t *testing.T, repo *D2OracleRepository, id, content string) {
+		t.Helper()
+		if err := repo.Create(ctx, &entity.Diagram{ID: id, Content: content}); err != nil {
+			t.Fatalf("Create(%s) error = %v", id, err)
+		}
+	}
+	label := func(t *testing.T, repo *D2OracleRepository, id, key string) string {
+		t.Helper()
+		obj, err := repo.GetObject(ctx, id, nil, key)
+		if err != nil {
+			t.Fatalf("GetObject(%s, %s) error = %v", id, key, err)
+		}
+		return obj.Label
+	}
+
+	repo := NewD2OracleRepository()
+	create(t, repo, "base", "db: Postgres")
+	create(t, repo, "network", "...@base\nlb: Load Balancer")
+	create(t, repo, "arch", "infra: @network\napi -> infra.lb")
+	create(t, repo, "other", "x: @base")
+
+	if got := strings.Join(repo.importers("base"), ","); got != "network,other" {
+		t.Errorf("importers(base) = %s, want network,other", got)
+	}
+
+	// Importers see changes without changing themselves, also through other diagrams.
+	var notified []string
+	repo.OnChange(func(diagramID string) {
+		notified = append(notified, diagramID)
+	})
+	label1 := "MySQL"
+	if _, err := repo.SetAttribute(ctx, "base", nil, "db.label", nil, &label1); err != nil {
+		t.Fatalf("SetAttribute() error = %v", err)
+	}
+	if got := label(t, repo, "arch", "infra.db"); got != "MySQL" {
+		t.Errorf("arch infra.db label = %s, want MySQL", got)
+	}
+	if got := label(t, repo, "other", "x.db"); got != "MySQL" {
+		t.Errorf("other x.db label = %s, want MySQL", got)
+	}
+	sort.Strings(notified)
+	if got := strings.Join(notified, ","); got != "arch,base,network,other" {
+		t.Errorf("notified = %s, want arch,base,network,other", got)
+	}
+	diagrams, _ := repo.ListDiagrams(ctx)
+	for _, diagram := range diagrams {
+		if diagram.ID != "base" && diagram.Revision != 1 {
+			t.Errorf("revision of %s = %d, want 1", diagram.ID, diagram.Revision)
+		}
+	}
+
+	// Imported diagrams cannot be deleted or renamed, naming their importers.
+	for name, fn := range map[string]func() error{
+		"delete": func() error { return repo.DeleteDiagram(ctx, "base") },
+		"rename": func() error { return repo.RenameDiagram(ctx, "base", "renamed") },
+	} {
+		err := fn()
+		if err == nil || !strings.Contains(err.Error(), "imported by network, other") {
+			t.Errorf("%s of an imported diagram error = %v, want it to name the importers", name, err)
+		}
+	}
+	if _, err := repo.GetGraph(ctx, "base"); err != nil {
+		t.Errorf("GetGraph(base) error = %v", err)
+	}
+
+	// Once nothing imports it, it can go.
+	if err := repo.DeleteDiagram(ctx, "other"); err != nil {
+		t.Fatalf("DeleteDiagram(other) error = %v", err)
+	}
+	create(t, repo, "network", "lb: Load Balancer")
+	if err := repo.RenameDiagram(ctx, "base", "renamed"); err != nil {
+		t.Errorf("RenameDiagram() error = %v", err)
+	}
+	if err := repo.DeleteDiagram(ctx, "renamed"); err != nil {
+		t.Errorf("DeleteDiagram() error = %v", err)
+	}
+}
+
+func TestD2OracleRepository_EvictionKeepsImportedDiagrams(t *testing.T) {
+	ctx := context.Background()
+
+	repo := NewD2OracleRepository(WithMaxDiagrams(1))
+	for _, diagram := range []*entity.Diagram{
+		{ID: "shared", Content: "a: Shared"},
+		{ID: "main", Content: "...@shared\na -> b"},
+	} {
+		if err := repo.Create(ctx, diagram); err != nil {
+			t.Fatalf("Create(%s) error = %v", diagram.ID, err)
+		}
+	}
+
+	// Without a workspace, evicting shared would break main.
+	diagrams, _ := repo.ListDiagrams(ctx)
+	if got := strings.Join(diagramIDs(diagrams), ","); got != "main,shared" {
+		t.Errorf("ListDiagrams() = %s, want main,shared", got)
+	}
+	if _, err := repo.Export(ctx, "main", entity.FormatSVG, nil); err != nil {
+		t.Errorf("Export(main) error = %v", err)
+	}
+}
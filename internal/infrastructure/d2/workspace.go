@@ -0,0 +1,306 @@
+package d2
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/i2y/d2mcp/internal/domain/entity"
+)
+
+const (
+	// sourceExt is the extension of the file holding the D2 source of a diagram.
+	sourceExt = ".d2"
+	// metadataExt is the extension of the sidecar file holding everything else.
+	metadataExt = ".json"
+)
+
+// workspace is a directory holding one D2 file per stored diagram, next to a JSON
+// sidecar with its revision, render defaults, snapshots, fork point and import root. File names
+// are path-escaped diagram IDs.
+type workspace struct {
+	dir string
+}
+
+// diagramMetadata is the content of a diagram's sidecar file.
+type diagramMetadata struct {
+	ID        string                `json:"id"`
+	Revision  int64                 `json:"revision"`
+	Options   *entity.RenderOptions `json:"options,omitempty"`
+	Snapshots []snapshotMetadata    `json:"snapshots,omitempty"`
+	Branch    *branchMetadata       `json:"branch,omitempty"`
+	Imports   *importMetadata       `json:"imports,omitempty"`
+	SavedAt   time.Time             `json:"saved_at"`
+}
+
+// snapshotMetadata is a snapshot stored in a sidecar file.
+type snapshotMetadata struct {
+	Name      string    `json:"name"`
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// branchMetadata is the fork point of a branch stored in a sidecar file.
+type branchMetadata struct {
+	DiagramID string    `json:"diagram_id"`
+	Base      string    `json:"base"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// importMetadata is the import root of a diagram loaded from a file, stored in a
+// sidecar file.
+type importMetadata struct {
+	Dir  string `json:"dir"`
+	Path string `json:"path"`
+}
+
+// NewD2FileRepository creates a D2 repository with Oracle support that keeps its
+// diagrams in the workspace directory dir, so they survive restarts. Diagrams
+// already in dir are loaded, including plain .d2 files without a sidecar. Undo
+// history is kept in memory only. Diagrams evicted by the limits stay listed and
+// are read back from dir when used again.
+func NewD2FileRepository(dir string, opts ...OracleOption) (*D2OracleRepository, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create workspace: %w", err)
+	}
+
+	r := NewD2OracleRepository(opts...)
+	r.workspace = &workspace{dir: dir}
+	if err := r.loadWorkspace(); err != nil {
+		return nil, err
+	}
+	r.persist = r.persistDiagram
+
+	// Evicted diagrams stay in the workspace and are read back on first use, which
+	// changes the repository even on reads.
+	if r.idleTTL > 0 || r.maxDiagrams > 0 || r.maxTotalSize > 0 {
+		r.reload = r.reloadDiagram
+		r.mu.exclusive = true
+	}
+
+	r.mu.Lock()
+	r.evictBeyondLimits("")
+	r.mu.Unlock()
+	return r, nil
+}
+
+// loadWorkspace reads every diagram in the workspace. Diagrams that no longer
+// compile are skipped with a log message rather than failing the start.
+func (r *D2OracleRepository) loadWorkspace() error {
+	entries, err := os.ReadDir(r.workspace.dir)
+	if err != nil {
+		return fmt.Errorf("failed to read workspace: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	pending := make(map[string]error)
+	for _, entry := range entries {
+		if name, isSource := strings.CutSuffix(entry.Name(), sourceExt); isSource && !entry.IsDir() {
+			pending[name] = nil
+		}
+	}
+
+	// Diagrams that import other stored diagrams only compile once those are
+	// loaded, so keep loading until a round makes no progress.
+	for loaded := true; loaded; {
+		loaded = false
+		for name := range pending {
+			if pending[name] = r.loadDiagram(name); pending[name] == nil {
+				delete(pending, name)
+				loaded = true
+			}
+		}
+	}
+	for name, err := range pending {
+		log.Printf("Skipping %s: %v", r.workspace.path(name, sourceExt), err)
+	}
+	return nil
+}
+
+// loadDiagram reads the diagram stored under the file name name. Callers must hold r.mu.
+func (r *D2OracleRepository) loadDiagram(name string) error {
+	content, err := os.ReadFile(r.workspace.path(name, sourceExt))
+	if err != nil {
+		return err
+	}
+
+	id, err := url.PathUnescape(name)
+	if err != nil {
+		return fmt.Errorf("invalid file name: %w", err)
+	}
+	metadata := &diagramMetadata{ID: id, Revision: 1}
+	if raw, err := os.ReadFile(r.workspace.path(name, metadataExt)); err == nil {
+		if err := json.Unmarshal(raw, metadata); err != nil {
+			return fmt.Errorf("invalid metadata: %w", err)
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	imports := r.store
+	if metadata.Imports != nil {
+		imports = newImportRoot(metadata.Imports.Dir, metadata.Imports.Path)
+	}
+	graph, err := imports.compile(string(content))
+	if err != nil {
+		return fmt.Errorf("failed to compile diagram: %w", err)
+	}
+
+	data := &diagramData{
+		content:  string(content),
+		graph:    graph,
+		options:  metadata.Options,
+		revision: metadata.Revision,
+		modified: time.Now(),
+		imports:  imports,
+	}
+	r.diagrams[metadata.ID] = data
+	r.dependencies[metadata.ID] = r.storeImports(data)
+	for _, stored := range metadata.Snapshots {
+		if r.snapshots[metadata.ID] == nil {
+			r.snapshots[metadata.ID] = make(map[string]*entity.Snapshot)
+		}
+		r.snapshots[metadata.ID][stored.Name] = &entity.Snapshot{
+			DiagramID: metadata.ID,
+			Name:      stored.Name,
+			Content:   stored.Content,
+			CreatedAt: stored.CreatedAt,
+			UpdatedAt: stored.UpdatedAt,
+		}
+	}
+	// Fork points stay in memory while a diagram is evicted and may have changed since.
+	if _, exists := r.branches[metadata.ID]; !exists && metadata.Branch != nil {
+		r.branches[metadata.ID] = &entity.Branch{
+			ID:        metadata.ID,
+			DiagramID: metadata.Branch.DiagramID,
+			Base:      metadata.Branch.Base,
+			CreatedAt: metadata.Branch.CreatedAt,
+		}
+	}
+	return nil
+}
+
+// persistDiagram writes a diagram to the workspace after a change. The diagram
+// stays changed in memory when the write fails, and the next change writes it
+// again. Callers must hold r.mu.
+func (r *D2OracleRepository) persistDiagram(diagramID string) {
+	if r.workspace == nil {
+		return
+	}
+	if err := r.saveDiagram(diagramID); err != nil {
+		log.Printf("Failed to save diagram %s: %v", diagramID, err)
+	}
+}
+
+// saveDiagram writes the source and sidecar file of a diagram. An evicted diagram
+// is read back first, so that its sidecar picks up a changed fork point. Callers
+// must hold r.mu.
+func (r *D2OracleRepository) saveDiagram(diagramID string) error {
+	data, exists := r.diagram(diagramID)
+	if !exists {
+		return fmt.Errorf("diagram %s not found", diagramID)
+	}
+
+	metadata := &diagramMetadata{
+		ID:       diagramID,
+		Revision: data.revision,
+		SavedAt:  time.Now(),
+	}
+	if data.options != nil {
+		options := *data.options
+		options.Changes = nil
+		metadata.Options = &options
+	}
+	for _, snapshot := range r.snapshots[diagramID] {
+		metadata.Snapshots = append(metadata.Snapshots, snapshotMetadata{
+			Name:      snapshot.Name,
+			Content:   snapshot.Content,
+			CreatedAt: snapshot.CreatedAt,
+			UpdatedAt: snapshot.UpdatedAt,
+		})
+	}
+	sort.Slice(metadata.Snapshots, func(i, j int) bool {
+		return metadata.Snapshots[i].Name < metadata.Snapshots[j].Name
+	})
+	if branch, exists := r.branches[diagramID]; exists {
+		metadata.Branch = &branchMetadata{
+			DiagramID: branch.DiagramID,
+			Base:      branch.Base,
+			CreatedAt: branch.CreatedAt,
+		}
+	}
+	if data.imports != r.store {
+		metadata.Imports = &importMetadata{
+			Dir:  data.imports.dir,
+			Path: data.imports.path,
+		}
+	}
+
+	raw, err := json.MarshalIndent(metadata, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode metadata: %w", err)
+	}
+
+	// The source goes first, so a crash in between leaves a sidecar that is at
+	// most one change behind rather than a source without its metadata.
+	name := url.PathEscape(diagramID)
+	if err := writeFileAtomic(r.workspace.path(name, sourceExt), []byte(data.content)); err != nil {
+		return err
+	}
+	return writeFileAtomic(r.workspace.path(name, metadataExt), raw)
+}
+
+// path returns the path of a workspace file.
+func (w *workspace) path(name, ext string) string {
+	return filepath.Join(w.dir, name+ext)
+}
+
+// remove deletes the source and sidecar file of a diagram.
+func (w *workspace) remove(diagramID string) error {
+	name := url.PathEscape(diagramID)
+	for _, ext := range []string{sourceExt, metadataExt} {
+		if err := os.Remove(w.path(name, ext)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeFileAtomic replaces the file at path with data, so that readers and crashes
+// see either the old or the new content.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
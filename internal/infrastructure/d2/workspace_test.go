@@ -0,0 +1,96 @@
+package d2
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/i2y/d2mcp/internal/domain/entity"
+	"github.com/i2y/d2mcp/internal/domain/repository"
+)
+
+func TestD2FileRepository_Persistence(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	repo, err := NewD2FileRepository(dir)
+	if err != nil {
+		t.Fatalf("NewD2FileRepository() error = %v", err)
+	}
+
+	sketch := true
+	diagram := &entity.Diagram{ID: "team/arch", Content: "web -> api", Options: &entity.RenderOptions{Sketch: &sketch}}
+	if err := repo.Create(ctx, diagram); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if _, err := repo.CreateSnapshot(ctx, "team/arch", "first"); err != nil {
+		t.Fatalf("CreateSnapshot() error = %v", err)
+	}
+	if _, err := repo.CreateElement(ctx, "team/arch", nil, "db"); err != nil {
+		t.Fatalf("CreateElement() error = %v", err)
+	}
+	if _, err := repo.CreateBranch(ctx, "team/arch", "arch-cache"); err != nil {
+		t.Fatalf("CreateBranch() error = %v", err)
+	}
+	want, _ := repo.SerializeDiagram(ctx, "team/arch")
+
+	// Plain D2 files dropped into the workspace are picked up, broken ones skipped.
+	if err := os.WriteFile(filepath.Join(dir, "plain.d2"), []byte("a -> b"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "broken.d2"), []byte("a -> {"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := NewD2FileRepository(dir)
+	if err != nil {
+		t.Fatalf("NewD2FileRepository() reopen error = %v", err)
+	}
+
+	diagrams, _ := reopened.ListDiagrams(ctx)
+	if ids := diagramIDs(diagrams); strings.Join(ids, ",") != "arch-cache,plain,team/arch" {
+		t.Errorf("ListDiagrams() = %v", ids)
+	}
+
+	revision := &repository.Revision{DiagramID: "team/arch"}
+	got, err := reopened.SerializeDiagram(repository.WithRevision(ctx, revision), "team/arch")
+	if err != nil || got != want {
+		t.Errorf("SerializeDiagram() = %q, %v, want %q", got, err, want)
+	}
+	if revision.Current != 2 {
+		t.Errorf("revision = %d, want 2", revision.Current)
+	}
+	if options := reopened.diagrams["team/arch"].options; options == nil || options.Sketch == nil || !*options.Sketch {
+		t.Errorf("render options = %+v", options)
+	}
+
+	snapshot, err := reopened.GetSnapshot(ctx, "team/arch", "first")
+	if err != nil || !strings.Contains(snapshot.Content, "web -> api") || strings.Contains(snapshot.Content, "db") {
+		t.Errorf("GetSnapshot() = %+v, %v", snapshot, err)
+	}
+	branch, err := reopened.GetBranch(ctx, "arch-cache")
+	if err != nil || branch.DiagramID != "team/arch" || branch.Base != want {
+		t.Errorf("GetBranch() = %+v, %v", branch, err)
+	}
+
+	// Reopened diagrams keep being edited and saved.
+	if _, err := reopened.GetObject(ctx, "plain", nil, "a"); err != nil {
+		t.Errorf("GetObject() on a plain file error = %v", err)
+	}
+	if _, err := reopened.CreateElement(ctx, "plain", nil, "c"); err != nil {
+		t.Fatalf("CreateElement() error = %v", err)
+	}
+	saved, _ := os.ReadFile(filepath.Join(dir, "plain.d2"))
+	if !strings.Contains(string(saved), "c") {
+		t.Errorf("plain.d2 = %q", saved)
+	}
+
+	entries, _ := os.ReadDir(dir)
+	for _, entry := range entries {
+		if strings.Contains(entry.Name(), ".tmp") {
+			t.Errorf("temporary file %s left in the workspace", entry.Name())
+		}
+	}
+}
@@ -2,7 +2,9 @@ package mcp
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log"
 	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
@@ -40,6 +42,7 @@ type StreamableHTTPConfig struct {
 // Server represents the MCP server instance.
 type Server struct {
 	mcpServer            *server.MCPServer
+	subscriptions        *subscriptions
 	transport            TransportType
 	sseConfig            *SSEConfig
 	streamableHTTPConfig *StreamableHTTPConfig
@@ -47,15 +50,22 @@ type Server struct {
 
 // NewServer creates a new MCP server instance with default stdio transport.
 func NewServer(name string, version string) (*Server, error) {
-	// Create MCP server.
+	subscriptions := newSubscriptions()
+
+	// Create MCP server. Resources are added as diagrams are created, so clients are
+	// told when the list changes.
 	mcpServer := server.NewMCPServer(
 		name,
 		version,
+		server.WithResourceCapabilities(false, true),
+		server.WithPromptCapabilities(false),
+		server.WithHooks(subscriptions.hooks()),
 	)
 
 	return &Server{
-		mcpServer: mcpServer,
-		transport: TransportStdio,
+		mcpServer:     mcpServer,
+		subscriptions: subscriptions,
+		transport:     TransportStdio,
 	}, nil
 }
 
@@ -83,6 +93,45 @@ func (s *Server) RegisterTool(tool mcp.Tool, handler server.ToolHandlerFunc) err
 	return nil
 }
 
+// RegisterResource registers a resource with a fixed URI with the MCP server.
+func (s *Server) RegisterResource(resource mcp.Resource, handler server.ResourceHandlerFunc) error {
+	s.mcpServer.AddResource(resource, handler)
+	return nil
+}
+
+// UnregisterResource removes a resource registered with RegisterResource.
+func (s *Server) UnregisterResource(uri string) {
+	s.mcpServer.RemoveResource(uri)
+}
+
+// RegisterResourceTemplate registers a resource template with the MCP server.
+func (s *Server) RegisterResourceTemplate(template mcp.ResourceTemplate, handler server.ResourceTemplateHandlerFunc) error {
+	s.mcpServer.AddResourceTemplate(template, handler)
+	return nil
+}
+
+// RegisterPrompt registers a prompt with the MCP server.
+func (s *Server) RegisterPrompt(prompt mcp.Prompt, handler server.PromptHandlerFunc) error {
+	s.mcpServer.AddPrompt(prompt, handler)
+	return nil
+}
+
+// NotifyResourceUpdated sends a resources/updated notification to every session that
+// has read the resource.
+func (s *Server) NotifyResourceUpdated(uri string) {
+	for _, sessionID := range s.subscriptions.subscribers(uri) {
+		err := s.mcpServer.SendNotificationToSpecificClient(sessionID, mcp.MethodNotificationResourceUpdated, map[string]any{
+			"uri": uri,
+		})
+		if errors.Is(err, server.ErrSessionNotFound) {
+			// The session ended without being unregistered.
+			s.subscriptions.remove(sessionID)
+		} else if err != nil {
+			log.Printf("Failed to notify session %s about %s: %v", sessionID, uri, err)
+		}
+	}
+}
+
 // Start starts the MCP server with the configured transport.
 func (s *Server) Start(ctx context.Context) error {
 	switch s.transport {
@@ -0,0 +1,72 @@
+package mcp
+
+import (
+	"context"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// subscriptions tracks which resources each client session follows. mcp-go does not
+// handle resources/subscribe yet, so a session follows every resource it has read.
+type subscriptions struct {
+	mu       sync.Mutex
+	sessions map[string]map[string]struct{} // session ID -> resource URIs
+}
+
+// newSubscriptions creates an empty subscription tracker.
+func newSubscriptions() *subscriptions {
+	return &subscriptions{
+		sessions: make(map[string]map[string]struct{}),
+	}
+}
+
+// hooks returns the server hooks that keep the tracker in step with client sessions.
+func (s *subscriptions) hooks() *server.Hooks {
+	hooks := &server.Hooks{}
+	hooks.AddAfterReadResource(func(ctx context.Context, id any, message *mcp.ReadResourceRequest, result *mcp.ReadResourceResult) {
+		if session := server.ClientSessionFromContext(ctx); session != nil {
+			s.add(session.SessionID(), message.Params.URI)
+		}
+	})
+	hooks.AddOnUnregisterSession(func(ctx context.Context, session server.ClientSession) {
+		s.remove(session.SessionID())
+	})
+	return hooks
+}
+
+// add subscribes a session to a resource.
+func (s *subscriptions) add(sessionID, uri string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	uris, ok := s.sessions[sessionID]
+	if !ok {
+		uris = make(map[string]struct{})
+		s.sessions[sessionID] = uris
+	}
+	uris[uri] = struct{}{}
+}
+
+// remove drops all subscriptions of a session.
+func (s *subscriptions) remove(sessionID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.sessions, sessionID)
+}
+
+// subscribers returns the IDs of the sessions subscribed to a resource.
+func (s *subscriptions) subscribers(uri string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var sessionIDs []string
+	for sessionID, uris := range s.sessions {
+		if _, ok := uris[uri]; ok {
+			sessionIDs = append(sessionIDs, sessionID)
+		}
+	}
+	return sessionIDs
+}
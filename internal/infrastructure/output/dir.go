@@ -0,0 +1,229 @@
+// Package output writes saved diagrams below a single allowlisted directory.
+package output
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/i2y/d2mcp/internal/infrastructure/sandbox"
+)
+
+// OverwritePolicy decides what happens when a saved diagram would replace an
+// existing file.
+type OverwritePolicy string
+
+const (
+	// OverwriteNever refuses to replace existing files.
+	OverwriteNever OverwritePolicy = "never"
+	// OverwriteAlways replaces existing files.
+	OverwriteAlways OverwritePolicy = "always"
+	// OverwriteRename keeps existing files and writes to a free name with a
+	// numeric suffix instead, such as diagram-1.svg.
+	OverwriteRename OverwritePolicy = "rename"
+)
+
+// maxRenameAttempts bounds the numeric suffixes tried by OverwriteRename.
+const maxRenameAttempts = 1000
+
+// ParseOverwritePolicy parses the name of an overwrite policy.
+func ParseOverwritePolicy(name string) (OverwritePolicy, error) {
+	switch policy := OverwritePolicy(name); policy {
+	case OverwriteNever, OverwriteAlways, OverwriteRename:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("invalid overwrite policy %q: must be 'never', 'always' or 'rename'", name)
+	}
+}
+
+// Dir is an output directory. Files are only ever written below it: paths that
+// leave it through ".." or through symlinks pointing outside of it are rejected,
+// and so are writes that would replace a file against its overwrite policy.
+type Dir struct {
+	dir    *sandbox.Dir
+	policy OverwritePolicy
+}
+
+// NewDir creates the output directory at path if needed and returns it.
+func NewDir(path string, policy OverwritePolicy) (*Dir, error) {
+	if _, err := ParseOverwritePolicy(string(policy)); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(path, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %w", err)
+	}
+	dir, err := sandbox.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve output directory: %w", err)
+	}
+	return &Dir{dir: dir, policy: policy}, nil
+}
+
+// Path returns the absolute path of the output directory.
+func (d *Dir) Path() string {
+	return d.dir.Path()
+}
+
+// Policy returns the overwrite policy of the output directory.
+func (d *Dir) Policy() OverwritePolicy {
+	return d.policy
+}
+
+// Write writes data to the file at name and returns the absolute path it was
+// written to. Relative names are resolved against the output directory, absolute
+// names must lie inside it. Missing parent directories are created.
+func (d *Dir) Write(name string, data []byte) (string, error) {
+	rel, err := d.relative(name)
+	if err != nil {
+		return "", err
+	}
+
+	// Opening the directory as a root makes every access below fail if it would
+	// leave the directory, including through symlinks changed after the checks.
+	root, err := os.OpenRoot(d.dir.Resolved())
+	if err != nil {
+		return "", fmt.Errorf("failed to open output directory: %w", err)
+	}
+	defer root.Close()
+
+	if err := d.checkSymlinks(rel); err != nil {
+		return "", err
+	}
+	if err := mkdirAll(root, filepath.Dir(rel)); err != nil {
+		return "", d.explain(rel, err)
+	}
+
+	file, rel, err := d.create(root, rel)
+	if err != nil {
+		return "", err
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return "", fmt.Errorf("failed to write %s: %w", d.abs(rel), err)
+	}
+	if err := file.Close(); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", d.abs(rel), err)
+	}
+	return d.abs(rel), nil
+}
+
+// relative returns name as a clean path relative to the output directory,
+// rejecting names that leave it.
+func (d *Dir) relative(name string) (string, error) {
+	if name == "" {
+		return "", errors.New("path is empty")
+	}
+
+	rel, err := d.dir.Relative(name)
+	if err != nil {
+		return "", d.outside(name)
+	}
+	if rel == "." {
+		return "", fmt.Errorf("%s is the output directory itself, not a file path", name)
+	}
+	return rel, nil
+}
+
+// checkSymlinks rejects rel when one of its existing parent directories, or the
+// file itself, is a symlink that resolves to a location outside the output
+// directory. The root enforces this as well; checking first gives a clear error.
+func (d *Dir) checkSymlinks(rel string) error {
+	for current := rel; current != "."; current = filepath.Dir(current) {
+		path := filepath.Join(d.dir.Resolved(), current)
+		resolved, err := filepath.EvalSymlinks(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			// A dangling symlink would create its target when written to.
+			target, linkErr := os.Readlink(path)
+			if linkErr != nil {
+				continue
+			}
+			if !filepath.IsAbs(target) {
+				target = filepath.Join(filepath.Dir(path), target)
+			}
+			resolved, err = filepath.Clean(target), nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to resolve %s: %w", d.abs(rel), err)
+		}
+		if sandbox.Within(d.dir.Resolved(), resolved) == "" {
+			return fmt.Errorf("path %s resolves through a symlink to %s, which is outside the output directory %s; symlinks may only point inside it", d.abs(rel), resolved, d.Path())
+		}
+		// Everything above an existing path was checked as part of resolving it.
+		return nil
+	}
+	return nil
+}
+
+// create opens the file to write according to the overwrite policy and returns it
+// with the path it ended up at.
+func (d *Dir) create(root *os.Root, rel string) (*os.File, string, error) {
+	switch d.policy {
+	case OverwriteAlways:
+		file, err := root.OpenFile(rel, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+		if err != nil {
+			return nil, "", d.explain(rel, err)
+		}
+		return file, rel, nil
+	case OverwriteRename:
+		ext := filepath.Ext(rel)
+		base := strings.TrimSuffix(rel, ext)
+		for i := 0; i < maxRenameAttempts; i++ {
+			candidate := rel
+			if i > 0 {
+				candidate = base + "-" + strconv.Itoa(i) + ext
+			}
+			file, err := root.OpenFile(candidate, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+			if errors.Is(err, fs.ErrExist) {
+				continue
+			}
+			if err != nil {
+				return nil, "", d.explain(candidate, err)
+			}
+			return file, candidate, nil
+		}
+		return nil, "", fmt.Errorf("%s and %d numbered alternatives already exist in the output directory", d.abs(rel), maxRenameAttempts-1)
+	default:
+		file, err := root.OpenFile(rel, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if errors.Is(err, fs.ErrExist) {
+			return nil, "", fmt.Errorf("%s already exists and the server is configured not to overwrite files (overwrite policy %q); choose a different path", d.abs(rel), d.policy)
+		}
+		if err != nil {
+			return nil, "", d.explain(rel, err)
+		}
+		return file, rel, nil
+	}
+}
+
+// explain wraps an error from the root, which also fails when a path changed
+// after it was checked so that it now leaves the output directory.
+func (d *Dir) explain(rel string, err error) error {
+	return fmt.Errorf("failed to write %s inside the output directory %s: %w", d.abs(rel), d.Path(), err)
+}
+
+// outside returns the error for a path outside the output directory.
+func (d *Dir) outside(name string) error {
+	return fmt.Errorf("path %s is outside the output directory %s; the server only writes files below it, so use a path relative to it or an absolute path inside it", name, d.Path())
+}
+
+// abs returns the absolute path of a path relative to the output directory.
+func (d *Dir) abs(rel string) string {
+	return d.dir.Abs(rel)
+}
+
+// mkdirAll creates the directory at rel and any missing parents inside root.
+func mkdirAll(root *os.Root, rel string) error {
+	if rel == "." {
+		return nil
+	}
+	if err := mkdirAll(root, filepath.Dir(rel)); err != nil {
+		return err
+	}
+	if err := root.Mkdir(rel, 0755); err != nil && !errors.Is(err, fs.ErrExist) {
+		return err
+	}
+	return nil
+}
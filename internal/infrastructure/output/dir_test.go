@@ -0,0 +1,163 @@
+package output
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDir_Write(t *testing.T) {
+	newDir := func(t *testing.T, policy OverwritePolicy) *Dir {
+		t.Helper()
+		dir, err := NewDir(filepath.Join(t.TempDir(), "out"), policy)
+		if err != nil {
+			t.Fatalf("NewDir() error = %v", err)
+		}
+		return dir
+	}
+	read := func(t *testing.T, path string) string {
+		t.Helper()
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("ReadFile(%s) error = %v", path, err)
+		}
+		return string(data)
+	}
+
+	t.Run("relative and absolute paths inside", func(t *testing.T) {
+		dir := newDir(t, OverwriteNever)
+
+		path, err := dir.Write("nested/deeper/a.svg", []byte("a"))
+		if err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+		if want := filepath.Join(dir.Path(), "nested", "deeper", "a.svg"); path != want {
+			t.Errorf("Write() = %s, want %s", path, want)
+		}
+		if got := read(t, path); got != "a" {
+			t.Errorf("file content = %q, want a", got)
+		}
+
+		path, err = dir.Write(filepath.Join(dir.Path(), "b.svg"), []byte("b"))
+		if err != nil {
+			t.Fatalf("Write() absolute error = %v", err)
+		}
+		if got := read(t, path); got != "b" {
+			t.Errorf("file content = %q, want b", got)
+		}
+
+		// ".." that stays inside is fine.
+		if _, err := dir.Write("nested/../c.svg", []byte("c")); err != nil {
+			t.Errorf("Write() with inner .. error = %v", err)
+		}
+	})
+
+	t.Run("paths outside are rejected", func(t *testing.T) {
+		dir := newDir(t, OverwriteAlways)
+		outside := filepath.Join(filepath.Dir(dir.Path()), "escaped.svg")
+
+		for _, name := range []string{"../escaped.svg", "nested/../../escaped.svg", outside, ".", ""} {
+			if _, err := dir.Write(name, []byte("x")); err == nil {
+				t.Errorf("Write(%q) succeeded", name)
+			}
+		}
+		if _, err := os.Stat(outside); !os.IsNotExist(err) {
+			t.Errorf("file outside the output directory was written: %v", err)
+		}
+		if _, err := dir.Write("../escaped.svg", nil); err == nil || !strings.Contains(err.Error(), "outside the output directory") {
+			t.Errorf("Write() error = %v, want it to explain the restriction", err)
+		}
+	})
+
+	t.Run("symlinks outside are rejected", func(t *testing.T) {
+		dir := newDir(t, OverwriteAlways)
+		target := t.TempDir()
+		if err := os.Symlink(target, filepath.Join(dir.Path(), "link")); err != nil {
+			t.Skipf("symlinks unsupported: %v", err)
+		}
+		if err := os.Symlink(filepath.Join(target, "dangling.svg"), filepath.Join(dir.Path(), "file.svg")); err != nil {
+			t.Fatalf("Symlink() error = %v", err)
+		}
+		if err := os.Mkdir(filepath.Join(dir.Path(), "real"), 0755); err != nil {
+			t.Fatalf("Mkdir() error = %v", err)
+		}
+		if err := os.Symlink("real", filepath.Join(dir.Path(), "inner")); err != nil {
+			t.Fatalf("Symlink() error = %v", err)
+		}
+
+		for _, name := range []string{"link/a.svg", "link/nested/a.svg", "file.svg"} {
+			_, err := dir.Write(name, []byte("x"))
+			if err == nil || !strings.Contains(err.Error(), "symlink") {
+				t.Errorf("Write(%q) error = %v, want a symlink error", name, err)
+			}
+		}
+		entries, _ := os.ReadDir(target)
+		if len(entries) != 0 {
+			t.Errorf("files written through a symlink: %v", entries)
+		}
+
+		// Symlinks that stay inside are followed.
+		if _, err := dir.Write("inner/a.svg", []byte("x")); err != nil {
+			t.Errorf("Write() through an inner symlink error = %v", err)
+		}
+		if got := read(t, filepath.Join(dir.Path(), "real", "a.svg")); got != "x" {
+			t.Errorf("file content = %q, want x", got)
+		}
+	})
+
+	t.Run("overwrite never", func(t *testing.T) {
+		dir := newDir(t, OverwriteNever)
+		if _, err := dir.Write("a.svg", []byte("first")); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+		_, err := dir.Write("a.svg", []byte("second"))
+		if err == nil || !strings.Contains(err.Error(), "already exists") {
+			t.Errorf("Write() error = %v, want an already exists error", err)
+		}
+		if got := read(t, filepath.Join(dir.Path(), "a.svg")); got != "first" {
+			t.Errorf("file content = %q, want first", got)
+		}
+	})
+
+	t.Run("overwrite always", func(t *testing.T) {
+		dir := newDir(t, OverwriteAlways)
+		for _, content := range []string{"first", "2nd"} {
+			if _, err := dir.Write("a.svg", []byte(content)); err != nil {
+				t.Fatalf("Write() error = %v", err)
+			}
+		}
+		if got := read(t, filepath.Join(dir.Path(), "a.svg")); got != "2nd" {
+			t.Errorf("file content = %q, want 2nd", got)
+		}
+	})
+
+	t.Run("overwrite rename", func(t *testing.T) {
+		dir := newDir(t, OverwriteRename)
+		var paths []string
+		for _, content := range []string{"first", "second", "third"} {
+			path, err := dir.Write("sub/a.svg", []byte(content))
+			if err != nil {
+				t.Fatalf("Write() error = %v", err)
+			}
+			paths = append(paths, filepath.Base(path))
+		}
+		if got := strings.Join(paths, ","); got != "a.svg,a-1.svg,a-2.svg" {
+			t.Errorf("written files = %s, want a.svg,a-1.svg,a-2.svg", got)
+		}
+		if got := read(t, filepath.Join(dir.Path(), "sub", "a.svg")); got != "first" {
+			t.Errorf("file content = %q, want first", got)
+		}
+	})
+}
+
+func TestParseOverwritePolicy(t *testing.T) {
+	for _, name := range []string{"never", "always", "rename"} {
+		if policy, err := ParseOverwritePolicy(name); err != nil || string(policy) != name {
+			t.Errorf("ParseOverwritePolicy(%q) = %q, %v", name, policy, err)
+		}
+	}
+	if _, err := ParseOverwritePolicy("sometimes"); err == nil {
+		t.Error("ParseOverwritePolicy(sometimes) succeeded")
+	}
+}
@@ -0,0 +1,264 @@
+package raster
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/golang/freetype/raster"
+	"golang.org/x/image/math/fixed"
+)
+
+// canvas composites anti-aliased fills and strokes onto an RGBA image.
+type canvas struct {
+	img        *image.RGBA
+	rasterizer *raster.Rasterizer
+}
+
+func newCanvas(img *image.RGBA) *canvas {
+	bounds := img.Bounds()
+	r := raster.NewRasterizer(bounds.Dx(), bounds.Dy())
+	r.UseNonZeroWinding = true
+	return &canvas{img: img, rasterizer: r}
+}
+
+// fill paints the interior of the polylines, which are in device space.
+func (c *canvas) fill(lines []polyline, col color.NRGBA, mask *image.Alpha) {
+	if col.A == 0 {
+		return
+	}
+	c.rasterizer.Clear()
+	for _, line := range lines {
+		if len(line.points) < 3 {
+			continue
+		}
+		c.rasterizer.Start(toFixed(line.points[0]))
+		for _, p := range line.points[1:] {
+			c.rasterizer.Add1(toFixed(p))
+		}
+		c.rasterizer.Add1(toFixed(line.points[0]))
+	}
+	c.rasterizer.Rasterize(&spanPainter{img: c.img, color: col, mask: mask})
+}
+
+// strokeStyle describes how outlines are drawn, in device units.
+type strokeStyle struct {
+	width   float64
+	dashes  []float64
+	lineCap string
+}
+
+// stroke paints the outline of the polylines, which are in device space.
+func (c *canvas) stroke(lines []polyline, col color.NRGBA, style strokeStyle, mask *image.Alpha) {
+	if col.A == 0 || style.width <= 0 {
+		return
+	}
+	if len(style.dashes) > 0 {
+		lines = applyDashes(lines, style.dashes)
+	}
+
+	// Every piece of the outline is emitted with the same orientation, so the
+	// non-zero winding rule paints their union without gaps or double coverage.
+	c.rasterizer.Clear()
+	hw := style.width / 2
+	for _, line := range lines {
+		points := dedupe(line.points)
+		if len(points) < 2 {
+			continue
+		}
+		if line.closed && points[0] != points[len(points)-1] {
+			points = append(points, points[0])
+		}
+
+		for i := 0; i+1 < len(points); i++ {
+			a, b := points[i], points[i+1]
+			if !line.closed {
+				if i == 0 && style.lineCap == "square" {
+					a = extend(b, a, hw)
+				}
+				if i+2 == len(points) && style.lineCap == "square" {
+					b = extend(a, b, hw)
+				}
+			}
+			c.addSegment(a, b, hw)
+		}
+
+		// Round joins look right for D2's rounded line joins and are a close
+		// approximation of miter joins at typical stroke widths. Overlapping
+		// pieces add up their anti-aliased edge coverage, so joins are left out
+		// where a flattened curve barely turns and they would only darken it.
+		for i := 1; i+1 < len(points); i++ {
+			if needsJoin(points[i-1], points[i], points[i+1], hw) {
+				c.addCircle(points[i], hw)
+			}
+		}
+		if line.closed {
+			c.addCircle(points[0], hw)
+		} else if style.lineCap == "round" {
+			c.addCircle(points[0], hw)
+			c.addCircle(points[len(points)-1], hw)
+		}
+	}
+	c.rasterizer.Rasterize(&spanPainter{img: c.img, color: col, mask: mask})
+}
+
+// addSegment adds the rectangle covering a stroked segment.
+func (c *canvas) addSegment(a, b point, hw float64) {
+	length := distance(a, b)
+	if length == 0 {
+		return
+	}
+	nx := -(b.y - a.y) / length * hw
+	ny := (b.x - a.x) / length * hw
+	c.rasterizer.Start(toFixed(point{a.x + nx, a.y + ny}))
+	c.rasterizer.Add1(toFixed(point{b.x + nx, b.y + ny}))
+	c.rasterizer.Add1(toFixed(point{b.x - nx, b.y - ny}))
+	c.rasterizer.Add1(toFixed(point{a.x - nx, a.y - ny}))
+	c.rasterizer.Add1(toFixed(point{a.x + nx, a.y + ny}))
+}
+
+// addCircle adds a circle with the same orientation as addSegment's rectangles.
+func (c *canvas) addCircle(center point, r float64) {
+	steps := max(8, int(math.Ceil(2*math.Pi*r/1.5)))
+	c.rasterizer.Start(toFixed(point{center.x + r, center.y}))
+	for i := 1; i <= steps; i++ {
+		angle := -2 * math.Pi * float64(i) / float64(steps)
+		sin, cos := math.Sincos(angle)
+		c.rasterizer.Add1(toFixed(point{center.x + r*cos, center.y + r*sin}))
+	}
+}
+
+// needsJoin reports whether the turn at b leaves a visible gap between the
+// rectangles of segments ab and bc.
+func needsJoin(a, b, c point, hw float64) bool {
+	ux, uy := b.x-a.x, b.y-a.y
+	vx, vy := c.x-b.x, c.y-b.y
+	angle := math.Abs(math.Atan2(ux*vy-uy*vx, ux*vx+uy*vy))
+	return angle*hw > 0.1
+}
+
+// applyDashes splits polylines into the dashes of the given pattern.
+func applyDashes(lines []polyline, pattern []float64) []polyline {
+	total := 0.0
+	for _, d := range pattern {
+		if d < 0 {
+			return lines
+		}
+		total += d
+	}
+	if total <= 0 {
+		return lines
+	}
+	if len(pattern)%2 == 1 {
+		pattern = append(pattern, pattern...)
+	}
+
+	var dashes []polyline
+	for _, line := range lines {
+		points := line.points
+		if line.closed && len(points) > 1 {
+			points = append(append([]point{}, points...), points[0])
+		}
+
+		index, remaining, on := 0, pattern[0], true
+		var current []point
+		if on && len(points) > 0 {
+			current = []point{points[0]}
+		}
+		for i := 0; i+1 < len(points); i++ {
+			a, b := points[i], points[i+1]
+			length := distance(a, b)
+			pos := 0.0
+			for length-pos > remaining {
+				pos += remaining
+				t := pos / length
+				p := point{a.x + (b.x-a.x)*t, a.y + (b.y-a.y)*t}
+				if on {
+					dashes = append(dashes, polyline{points: append(current, p)})
+					current = nil
+				} else {
+					current = []point{p}
+				}
+				on = !on
+				index = (index + 1) % len(pattern)
+				remaining = pattern[index]
+			}
+			remaining -= length - pos
+			if on {
+				current = append(current, b)
+			}
+		}
+		if on && len(current) > 1 {
+			dashes = append(dashes, polyline{points: current})
+		}
+	}
+	return dashes
+}
+
+// dedupe removes consecutive duplicate points.
+func dedupe(points []point) []point {
+	if len(points) < 2 {
+		return points
+	}
+	result := make([]point, 0, len(points))
+	result = append(result, points[0])
+	for _, p := range points[1:] {
+		if distance(p, result[len(result)-1]) > 1e-6 {
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+// extend moves b away from a by the given distance.
+func extend(a, b point, by float64) point {
+	length := distance(a, b)
+	if length == 0 {
+		return b
+	}
+	return point{b.x + (b.x-a.x)/length*by, b.y + (b.y-a.y)/length*by}
+}
+
+func toFixed(p point) fixed.Point26_6 {
+	return fixed.Point26_6{X: fixed.Int26_6(math.Round(p.x * 64)), Y: fixed.Int26_6(math.Round(p.y * 64))}
+}
+
+// spanPainter composites a solid color over an RGBA image, optionally through a mask.
+type spanPainter struct {
+	img   *image.RGBA
+	color color.NRGBA
+	mask  *image.Alpha
+}
+
+func (p *spanPainter) Paint(spans []raster.Span, done bool) {
+	bounds := p.img.Bounds()
+	for _, span := range spans {
+		if span.Y < bounds.Min.Y || span.Y >= bounds.Max.Y {
+			continue
+		}
+		x0 := max(span.X0, bounds.Min.X)
+		x1 := min(span.X1, bounds.Max.X)
+		for x := x0; x < x1; x++ {
+			alpha := span.Alpha * uint32(p.color.A) / 0xff
+			if p.mask != nil {
+				alpha = alpha * uint32(p.mask.AlphaAt(x, span.Y).A) / 0xff
+			}
+			blend(p.img, x, span.Y, p.color, alpha)
+		}
+	}
+}
+
+// blend composites a non-premultiplied color with coverage alpha (0 to 0xffff) over
+// the pixel at x, y.
+func blend(img *image.RGBA, x, y int, c color.NRGBA, alpha uint32) {
+	if alpha == 0 {
+		return
+	}
+	i := img.PixOffset(x, y)
+	pix := img.Pix[i : i+4 : i+4]
+	inv := 0xffff - alpha
+	pix[0] = uint8((uint32(c.R)*alpha + uint32(pix[0])*inv) / 0xffff)
+	pix[1] = uint8((uint32(c.G)*alpha + uint32(pix[1])*inv) / 0xffff)
+	pix[2] = uint8((uint32(c.B)*alpha + uint32(pix[2])*inv) / 0xffff)
+	pix[3] = uint8((0xff*alpha + uint32(pix[3])*inv) / 0xffff)
+}
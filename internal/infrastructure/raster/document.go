@@ -0,0 +1,172 @@
+package raster
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// node is an element or a run of character data in the parsed SVG document.
+type node struct {
+	name     string // Local element name; empty for character data
+	attrs    map[string]string
+	children []*node
+	text     string // Character data, only set when name is empty
+	classes  []string
+}
+
+// parseDocument parses an SVG document into a node tree rooted at the outermost <svg>.
+func parseDocument(data []byte) (*node, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	// D2 embeds XHTML for markdown labels, so accept HTML entities and void elements.
+	decoder.Strict = false
+	decoder.AutoClose = xml.HTMLAutoClose
+	decoder.Entity = xml.HTMLEntity
+
+	var root *node
+	var stack []*node
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse SVG: %w", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			n := &node{
+				name:  t.Name.Local,
+				attrs: make(map[string]string, len(t.Attr)),
+			}
+			for _, attr := range t.Attr {
+				n.attrs[attr.Name.Local] = attr.Value
+			}
+			n.classes = strings.Fields(n.attrs["class"])
+
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, n)
+			} else if root == nil {
+				root = n
+			}
+			stack = append(stack, n)
+
+		case xml.EndElement:
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+
+		case xml.CharData:
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, &node{text: string(t)})
+			}
+		}
+	}
+
+	if root == nil || root.name != "svg" {
+		return nil, fmt.Errorf("failed to parse SVG: no <svg> root element")
+	}
+	return root, nil
+}
+
+// walk calls fn for n and every element below it, depth first.
+func (n *node) walk(fn func(*node)) {
+	fn(n)
+	for _, child := range n.children {
+		if child.name != "" {
+			child.walk(fn)
+		}
+	}
+}
+
+// textContent returns the concatenated character data below n.
+func (n *node) textContent() string {
+	if n.name == "" {
+		return n.text
+	}
+	var b strings.Builder
+	for _, child := range n.children {
+		b.WriteString(child.textContent())
+	}
+	return b.String()
+}
+
+// hasClass reports whether n carries the given class name.
+func (n *node) hasClass(class string) bool {
+	for _, c := range n.classes {
+		if c == class {
+			return true
+		}
+	}
+	return false
+}
+
+// number parses a numeric attribute, returning def when it is missing or invalid.
+// Relative units are resolved against fontSize.
+func (n *node) number(name string, def, fontSize float64) float64 {
+	value, ok := n.attrs[name]
+	if !ok {
+		return def
+	}
+	// Attributes such as x and y may hold lists; only the first value is used.
+	if fields := strings.FieldsFunc(value, isListSeparator); len(fields) > 0 {
+		value = fields[0]
+	}
+	if v, ok := parseLength(value, fontSize); ok {
+		return v
+	}
+	return def
+}
+
+// parseLength parses a CSS length. Percentages are not supported.
+func parseLength(value string, fontSize float64) (float64, bool) {
+	value = strings.TrimSpace(value)
+	scale := 1.0
+	switch {
+	case strings.HasSuffix(value, "px"):
+		value = strings.TrimSuffix(value, "px")
+	case strings.HasSuffix(value, "em"):
+		value = strings.TrimSuffix(value, "em")
+		scale = fontSize
+	case strings.HasSuffix(value, "pt"):
+		value = strings.TrimSuffix(value, "pt")
+		scale = 96.0 / 72.0
+	}
+
+	v, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil {
+		return 0, false
+	}
+	return v * scale, true
+}
+
+// parseNumbers parses a whitespace- or comma-separated list of numbers.
+func parseNumbers(value string) []float64 {
+	var numbers []float64
+	for _, field := range strings.FieldsFunc(value, isListSeparator) {
+		if v, err := strconv.ParseFloat(field, 64); err == nil {
+			numbers = append(numbers, v)
+		}
+	}
+	return numbers
+}
+
+func isListSeparator(r rune) bool {
+	return r == ',' || r == ' ' || r == '\t' || r == '\n' || r == '\r'
+}
+
+// urlReference extracts the id from a url(#id) reference.
+func urlReference(value string) string {
+	value = strings.TrimSpace(value)
+	if !strings.HasPrefix(value, "url(") || !strings.HasSuffix(value, ")") {
+		return ""
+	}
+	ref := strings.Trim(value[4:len(value)-1], `"' `)
+	return strings.TrimPrefix(ref, "#")
+}
@@ -0,0 +1,269 @@
+package raster
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+	"oss.terrastruct.com/d2/d2renderers/d2fonts"
+)
+
+// fontSet resolves CSS font families to parsed fonts.
+type fontSet struct {
+	embedded map[string]*sfnt.Font
+	buf      sfnt.Buffer
+}
+
+// newFontSet parses the fonts embedded in the stylesheet. Fonts that fail to parse
+// are skipped; text using them falls back to D2's bundled fonts.
+func newFontSet(faces map[string][]byte) *fontSet {
+	fs := &fontSet{embedded: make(map[string]*sfnt.Font)}
+	for family, data := range faces {
+		if bytes.HasPrefix(data, []byte("wOFF")) {
+			converted, err := woffToSFNT(data)
+			if err != nil {
+				continue
+			}
+			data = converted
+		}
+		if f, err := sfnt.Parse(data); err == nil {
+			fs.embedded[family] = f
+		}
+	}
+	return fs
+}
+
+// lookup returns the font for a CSS font family, falling back to the D2 font whose
+// style matches the family name, e.g. "d2-123-font-bold".
+func (fs *fontSet) lookup(family string) *sfnt.Font {
+	family = unquote(strings.Split(family, ",")[0])
+	if f, ok := fs.embedded[family]; ok {
+		return f
+	}
+	return bundledFont(family)
+}
+
+// lookupSuffix returns the embedded font whose family ends in suffix, such as
+// "font-semibold", falling back to the bundled font for that style.
+func (fs *fontSet) lookupSuffix(suffix string) *sfnt.Font {
+	names := make([]string, 0, len(fs.embedded))
+	for name := range fs.embedded {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if strings.HasSuffix(name, "-"+suffix) {
+			return fs.embedded[name]
+		}
+	}
+	return bundledFont(suffix)
+}
+
+// glyph returns the index of r in f. D2 subsets embedded fonts to the characters
+// in use, so missing glyphs are looked up in the bundled font of the same style.
+func (fs *fontSet) glyph(f *sfnt.Font, family string, r rune) (*sfnt.Font, sfnt.GlyphIndex) {
+	if r == '\u00a0' {
+		r = ' '
+	}
+	if f != nil {
+		if index, err := f.GlyphIndex(&fs.buf, r); err == nil && index != 0 {
+			return f, index
+		}
+	}
+	if fallback := bundledFont(family); fallback != nil && fallback != f {
+		if index, err := fallback.GlyphIndex(&fs.buf, r); err == nil && index != 0 {
+			return fallback, index
+		}
+	}
+	return f, 0
+}
+
+// advance returns the horizontal advance of a glyph at the given size.
+func (fs *fontSet) advance(f *sfnt.Font, index sfnt.GlyphIndex, size float64) float64 {
+	adv, err := f.GlyphAdvance(&fs.buf, index, toPPEM(size), font.HintingNone)
+	if err != nil {
+		return 0
+	}
+	return float64(adv) / 64
+}
+
+// kern returns the kerning adjustment between two glyphs at the given size.
+func (fs *fontSet) kern(f *sfnt.Font, a, b sfnt.GlyphIndex, size float64) float64 {
+	k, err := f.Kern(&fs.buf, a, b, toPPEM(size), font.HintingNone)
+	if err != nil {
+		return 0
+	}
+	return float64(k) / 64
+}
+
+// outline adds the outline of a glyph, with its origin at the given point, to b.
+func (fs *fontSet) outline(b *pathBuilder, f *sfnt.Font, index sfnt.GlyphIndex, size float64, origin point) {
+	segments, err := f.LoadGlyph(&fs.buf, index, toPPEM(size), nil)
+	if err != nil {
+		return
+	}
+	at := func(p fixed.Point26_6) point {
+		return point{origin.x + float64(p.X)/64, origin.y + float64(p.Y)/64}
+	}
+	for _, seg := range segments {
+		switch seg.Op {
+		case sfnt.SegmentOpMoveTo:
+			b.close()
+			b.moveTo(at(seg.Args[0]))
+		case sfnt.SegmentOpLineTo:
+			b.lineTo(at(seg.Args[0]))
+		case sfnt.SegmentOpQuadTo:
+			b.quadTo(at(seg.Args[0]), at(seg.Args[1]))
+		case sfnt.SegmentOpCubeTo:
+			b.cubicTo(at(seg.Args[0]), at(seg.Args[1]), at(seg.Args[2]))
+		}
+	}
+	b.close()
+}
+
+// metrics returns the ascent and descent of f at the given size.
+func (fs *fontSet) metrics(f *sfnt.Font, size float64) (ascent, descent float64) {
+	if f != nil {
+		if m, err := f.Metrics(&fs.buf, toPPEM(size), font.HintingNone); err == nil {
+			return float64(m.Ascent) / 64, float64(m.Descent) / 64
+		}
+	}
+	return size * 0.8, size * 0.2
+}
+
+func toPPEM(size float64) fixed.Int26_6 {
+	return fixed.Int26_6(math.Round(size * 64))
+}
+
+var (
+	bundledMu    sync.Mutex
+	bundledFonts = make(map[d2fonts.Font]*sfnt.Font)
+)
+
+// bundledFont returns D2's bundled font matching the style named in family.
+func bundledFont(family string) *sfnt.Font {
+	family = strings.ToLower(family)
+	key := d2fonts.Font{Family: d2fonts.SourceSansPro, Style: d2fonts.FONT_STYLE_REGULAR}
+	if strings.Contains(family, "mono") || strings.Contains(family, "code") {
+		key.Family = d2fonts.SourceCodePro
+	}
+	switch {
+	case strings.Contains(family, "semibold"):
+		key.Style = d2fonts.FONT_STYLE_SEMIBOLD
+	case strings.Contains(family, "bold"):
+		key.Style = d2fonts.FONT_STYLE_BOLD
+	case strings.Contains(family, "italic"):
+		key.Style = d2fonts.FONT_STYLE_ITALIC
+	}
+
+	bundledMu.Lock()
+	defer bundledMu.Unlock()
+	if f, ok := bundledFonts[key]; ok {
+		return f
+	}
+
+	d2fonts.FontFamiliesMu.Lock()
+	data := d2fonts.FontFaces.Get(key)
+	d2fonts.FontFamiliesMu.Unlock()
+
+	f, err := sfnt.Parse(data)
+	if err != nil {
+		return nil
+	}
+	bundledFonts[key] = f
+	return f
+}
+
+// woffToSFNT converts a WOFF 1.0 font, as embedded by D2, back into an SFNT font.
+func woffToSFNT(data []byte) ([]byte, error) {
+	const headerSize, entrySize = 44, 20
+	if len(data) < headerSize {
+		return nil, fmt.Errorf("woff: truncated header")
+	}
+	flavor := binary.BigEndian.Uint32(data[4:8])
+	numTables := int(binary.BigEndian.Uint16(data[12:14]))
+	if len(data) < headerSize+numTables*entrySize {
+		return nil, fmt.Errorf("woff: truncated table directory")
+	}
+
+	type table struct {
+		tag      uint32
+		checksum uint32
+		data     []byte
+	}
+	tables := make([]table, 0, numTables)
+	for i := 0; i < numTables; i++ {
+		entry := data[headerSize+i*entrySize:]
+		tag := binary.BigEndian.Uint32(entry[0:4])
+		offset := int(binary.BigEndian.Uint32(entry[4:8]))
+		compLength := int(binary.BigEndian.Uint32(entry[8:12]))
+		origLength := int(binary.BigEndian.Uint32(entry[12:16]))
+		checksum := binary.BigEndian.Uint32(entry[16:20])
+		if offset < 0 || compLength < 0 || offset+compLength > len(data) {
+			return nil, fmt.Errorf("woff: table out of range")
+		}
+
+		raw := data[offset : offset+compLength]
+		if compLength < origLength {
+			r, err := zlib.NewReader(bytes.NewReader(raw))
+			if err != nil {
+				return nil, fmt.Errorf("woff: %w", err)
+			}
+			decoded, err := io.ReadAll(io.LimitReader(r, int64(origLength)))
+			r.Close()
+			if err != nil {
+				return nil, fmt.Errorf("woff: %w", err)
+			}
+			raw = decoded
+		}
+		if len(raw) != origLength {
+			return nil, fmt.Errorf("woff: table length mismatch")
+		}
+		tables = append(tables, table{tag: tag, checksum: checksum, data: raw})
+	}
+
+	// SFNT requires the table directory to be sorted by tag.
+	sort.Slice(tables, func(i, j int) bool { return tables[i].tag < tables[j].tag })
+
+	entrySelector := 0
+	for 1<<(entrySelector+1) <= numTables {
+		entrySelector++
+	}
+	searchRange := (1 << entrySelector) * 16
+
+	var out bytes.Buffer
+	header := make([]byte, 12)
+	binary.BigEndian.PutUint32(header[0:4], flavor)
+	binary.BigEndian.PutUint16(header[4:6], uint16(numTables))
+	binary.BigEndian.PutUint16(header[6:8], uint16(searchRange))
+	binary.BigEndian.PutUint16(header[8:10], uint16(entrySelector))
+	binary.BigEndian.PutUint16(header[10:12], uint16(numTables*16-searchRange))
+	out.Write(header)
+
+	offset := 12 + numTables*16
+	directory := make([]byte, 16)
+	for _, t := range tables {
+		binary.BigEndian.PutUint32(directory[0:4], t.tag)
+		binary.BigEndian.PutUint32(directory[4:8], t.checksum)
+		binary.BigEndian.PutUint32(directory[8:12], uint32(offset))
+		binary.BigEndian.PutUint32(directory[12:16], uint32(len(t.data)))
+		out.Write(directory)
+		offset += (len(t.data) + 3) &^ 3
+	}
+	for _, t := range tables {
+		out.Write(t.data)
+		if pad := (4 - len(t.data)%4) % 4; pad > 0 {
+			out.Write(make([]byte, pad))
+		}
+	}
+	return out.Bytes(), nil
+}
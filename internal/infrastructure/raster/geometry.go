@@ -0,0 +1,520 @@
+package raster
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// point is a 2D coordinate.
+type point struct{ x, y float64 }
+
+// matrix is an affine transform [a c e; b d f; 0 0 1].
+type matrix struct{ a, b, c, d, e, f float64 }
+
+var identity = matrix{a: 1, d: 1}
+
+func translate(x, y float64) matrix { return matrix{a: 1, d: 1, e: x, f: y} }
+
+func scale(sx, sy float64) matrix { return matrix{a: sx, d: sy} }
+
+func rotate(radians float64) matrix {
+	sin, cos := math.Sincos(radians)
+	return matrix{a: cos, b: sin, c: -sin, d: cos}
+}
+
+// mul returns m·n, which applies n first and then m.
+func (m matrix) mul(n matrix) matrix {
+	return matrix{
+		a: m.a*n.a + m.c*n.b,
+		b: m.b*n.a + m.d*n.b,
+		c: m.a*n.c + m.c*n.d,
+		d: m.b*n.c + m.d*n.d,
+		e: m.a*n.e + m.c*n.f + m.e,
+		f: m.b*n.e + m.d*n.f + m.f,
+	}
+}
+
+func (m matrix) apply(p point) point {
+	return point{m.a*p.x + m.c*p.y + m.e, m.b*p.x + m.d*p.y + m.f}
+}
+
+// scaleFactor returns the average linear scale of m, used for stroke widths and font sizes.
+func (m matrix) scaleFactor() float64 {
+	return math.Sqrt(math.Abs(m.a*m.d - m.b*m.c))
+}
+
+// parseTransform parses an SVG transform attribute.
+func parseTransform(value string) matrix {
+	result := identity
+	for {
+		open := strings.IndexByte(value, '(')
+		end := strings.IndexByte(value, ')')
+		if open < 0 || end < open {
+			return result
+		}
+		name := strings.TrimSpace(strings.Trim(value[:open], ", "))
+		args := parseNumbers(value[open+1 : end])
+		value = value[end+1:]
+
+		m := identity
+		switch name {
+		case "translate":
+			if len(args) == 1 {
+				m = translate(args[0], 0)
+			} else if len(args) >= 2 {
+				m = translate(args[0], args[1])
+			}
+		case "scale":
+			if len(args) == 1 {
+				m = scale(args[0], args[0])
+			} else if len(args) >= 2 {
+				m = scale(args[0], args[1])
+			}
+		case "rotate":
+			if len(args) >= 1 {
+				m = rotate(args[0] * math.Pi / 180)
+				if len(args) >= 3 {
+					m = translate(args[1], args[2]).mul(m).mul(translate(-args[1], -args[2]))
+				}
+			}
+		case "skewX":
+			if len(args) >= 1 {
+				m = matrix{a: 1, c: math.Tan(args[0] * math.Pi / 180), d: 1}
+			}
+		case "skewY":
+			if len(args) >= 1 {
+				m = matrix{a: 1, b: math.Tan(args[0] * math.Pi / 180), d: 1}
+			}
+		case "matrix":
+			if len(args) >= 6 {
+				m = matrix{args[0], args[1], args[2], args[3], args[4], args[5]}
+			}
+		}
+		result = result.mul(m)
+	}
+}
+
+// viewBoxTransform maps a viewBox onto a viewport of the given size following
+// preserveAspectRatio.
+func viewBoxTransform(viewBox []float64, width, height float64, preserveAspectRatio string) matrix {
+	if len(viewBox) != 4 || viewBox[2] <= 0 || viewBox[3] <= 0 {
+		return identity
+	}
+	sx := width / viewBox[2]
+	sy := height / viewBox[3]
+
+	fields := strings.Fields(preserveAspectRatio)
+	align := "xMidYMid"
+	if len(fields) > 0 {
+		align = fields[0]
+	}
+	if align != "none" {
+		s := math.Min(sx, sy)
+		if len(fields) > 1 && fields[1] == "slice" {
+			s = math.Max(sx, sy)
+		}
+		sx, sy = s, s
+	}
+
+	tx := -viewBox[0] * sx
+	ty := -viewBox[1] * sy
+	extraX := width - viewBox[2]*sx
+	extraY := height - viewBox[3]*sy
+	switch {
+	case strings.Contains(align, "xMid"):
+		tx += extraX / 2
+	case strings.Contains(align, "xMax"):
+		tx += extraX
+	}
+	switch {
+	case strings.Contains(align, "YMid"):
+		ty += extraY / 2
+	case strings.Contains(align, "YMax"):
+		ty += extraY
+	}
+	return matrix{a: sx, d: sy, e: tx, f: ty}
+}
+
+// polyline is a flattened subpath.
+type polyline struct {
+	points []point
+	closed bool
+}
+
+// pathBuilder flattens path commands into polylines. Curves are subdivided finely
+// enough for the given tolerance, expressed in user units.
+type pathBuilder struct {
+	lines     []polyline
+	current   []point
+	start     point
+	pen       point
+	tolerance float64
+}
+
+func newPathBuilder(tolerance float64) *pathBuilder {
+	if tolerance <= 0 {
+		tolerance = 0.25
+	}
+	return &pathBuilder{tolerance: tolerance}
+}
+
+func (b *pathBuilder) moveTo(p point) {
+	b.flush(false)
+	b.current = []point{p}
+	b.start = p
+	b.pen = p
+}
+
+func (b *pathBuilder) lineTo(p point) {
+	if len(b.current) == 0 {
+		b.current = []point{b.pen}
+		b.start = b.pen
+	}
+	b.current = append(b.current, p)
+	b.pen = p
+}
+
+func (b *pathBuilder) quadTo(c, p point) {
+	p0 := b.pen
+	b.cubicTo(
+		point{p0.x + 2.0/3.0*(c.x-p0.x), p0.y + 2.0/3.0*(c.y-p0.y)},
+		point{p.x + 2.0/3.0*(c.x-p.x), p.y + 2.0/3.0*(c.y-p.y)},
+		p,
+	)
+}
+
+func (b *pathBuilder) cubicTo(c1, c2, p point) {
+	p0 := b.pen
+	length := distance(p0, c1) + distance(c1, c2) + distance(c2, p)
+	steps := int(math.Ceil(math.Sqrt(length / b.tolerance)))
+	steps = max(1, min(steps, 256))
+	for i := 1; i <= steps; i++ {
+		t := float64(i) / float64(steps)
+		mt := 1 - t
+		b.lineTo(point{
+			mt*mt*mt*p0.x + 3*mt*mt*t*c1.x + 3*mt*t*t*c2.x + t*t*t*p.x,
+			mt*mt*mt*p0.y + 3*mt*mt*t*c1.y + 3*mt*t*t*c2.y + t*t*t*p.y,
+		})
+	}
+}
+
+// arcTo adds an elliptical arc using the endpoint parameterization of SVG paths.
+func (b *pathBuilder) arcTo(rx, ry, rotation float64, largeArc, sweep bool, p point) {
+	p0 := b.pen
+	if rx == 0 || ry == 0 || p0 == p {
+		b.lineTo(p)
+		return
+	}
+	rx, ry = math.Abs(rx), math.Abs(ry)
+	sin, cos := math.Sincos(rotation * math.Pi / 180)
+
+	// Compute the center following SVG implementation notes F.6.5.
+	dx, dy := (p0.x-p.x)/2, (p0.y-p.y)/2
+	x1 := cos*dx + sin*dy
+	y1 := -sin*dx + cos*dy
+	if lambda := x1*x1/(rx*rx) + y1*y1/(ry*ry); lambda > 1 {
+		rx *= math.Sqrt(lambda)
+		ry *= math.Sqrt(lambda)
+	}
+	num := rx*rx*ry*ry - rx*rx*y1*y1 - ry*ry*x1*x1
+	den := rx*rx*y1*y1 + ry*ry*x1*x1
+	coef := math.Sqrt(math.Max(0, num/den))
+	if largeArc == sweep {
+		coef = -coef
+	}
+	cx1 := coef * rx * y1 / ry
+	cy1 := -coef * ry * x1 / rx
+	cx := cos*cx1 - sin*cy1 + (p0.x+p.x)/2
+	cy := sin*cx1 + cos*cy1 + (p0.y+p.y)/2
+
+	theta := vectorAngle(1, 0, (x1-cx1)/rx, (y1-cy1)/ry)
+	delta := vectorAngle((x1-cx1)/rx, (y1-cy1)/ry, (-x1-cx1)/rx, (-y1-cy1)/ry)
+	if !sweep && delta > 0 {
+		delta -= 2 * math.Pi
+	} else if sweep && delta < 0 {
+		delta += 2 * math.Pi
+	}
+
+	steps := int(math.Ceil(math.Abs(delta) * math.Sqrt(math.Max(rx, ry)/b.tolerance)))
+	steps = max(1, min(steps, 256))
+	for i := 1; i <= steps; i++ {
+		angle := theta + delta*float64(i)/float64(steps)
+		s, c := math.Sincos(angle)
+		b.lineTo(point{cx + rx*c*cos - ry*s*sin, cy + rx*c*sin + ry*s*cos})
+	}
+	b.pen = p
+}
+
+func (b *pathBuilder) close() {
+	if len(b.current) > 0 {
+		b.pen = b.start
+	}
+	b.flush(true)
+}
+
+func (b *pathBuilder) flush(closed bool) {
+	if len(b.current) > 0 {
+		b.lines = append(b.lines, polyline{points: b.current, closed: closed})
+	}
+	b.current = nil
+}
+
+func (b *pathBuilder) finish() []polyline {
+	b.flush(false)
+	return b.lines
+}
+
+// ellipse adds a closed ellipse.
+func (b *pathBuilder) ellipse(cx, cy, rx, ry float64) {
+	b.moveTo(point{cx + rx, cy})
+	b.arcTo(rx, ry, 0, false, true, point{cx - rx, cy})
+	b.arcTo(rx, ry, 0, false, true, point{cx + rx, cy})
+	b.close()
+}
+
+// roundedRect adds a closed rectangle with optional rounded corners.
+func (b *pathBuilder) roundedRect(x, y, w, h, rx, ry float64) {
+	rx = math.Min(rx, w/2)
+	ry = math.Min(ry, h/2)
+	if rx <= 0 || ry <= 0 {
+		b.moveTo(point{x, y})
+		b.lineTo(point{x + w, y})
+		b.lineTo(point{x + w, y + h})
+		b.lineTo(point{x, y + h})
+		b.close()
+		return
+	}
+	b.moveTo(point{x + rx, y})
+	b.lineTo(point{x + w - rx, y})
+	b.arcTo(rx, ry, 0, false, true, point{x + w, y + ry})
+	b.lineTo(point{x + w, y + h - ry})
+	b.arcTo(rx, ry, 0, false, true, point{x + w - rx, y + h})
+	b.lineTo(point{x + rx, y + h})
+	b.arcTo(rx, ry, 0, false, true, point{x, y + h - ry})
+	b.lineTo(point{x, y + ry})
+	b.arcTo(rx, ry, 0, false, true, point{x + rx, y})
+	b.close()
+}
+
+// pathData adds the commands of an SVG path "d" attribute.
+func (b *pathBuilder) pathData(d string) {
+	s := &pathScanner{data: d}
+	var command byte
+	var lastControl point
+	var lastCommand byte
+
+	for {
+		if c, ok := s.command(); ok {
+			command = c
+		} else if command == 0 || !s.more() {
+			return
+		}
+
+		relative := command >= 'a' && command <= 'z'
+		upper := command &^ 0x20
+		origin := point{}
+		if relative {
+			origin = b.pen
+		}
+		at := func(x, y float64) point { return point{origin.x + x, origin.y + y} }
+
+		switch upper {
+		case 'M':
+			x, y, ok := s.pair()
+			if !ok {
+				return
+			}
+			b.moveTo(at(x, y))
+			// Further coordinate pairs are implicit lineto commands.
+			if relative {
+				command = 'l'
+			} else {
+				command = 'L'
+			}
+		case 'L':
+			x, y, ok := s.pair()
+			if !ok {
+				return
+			}
+			b.lineTo(at(x, y))
+		case 'H':
+			x, ok := s.number()
+			if !ok {
+				return
+			}
+			if relative {
+				x += b.pen.x
+			}
+			b.lineTo(point{x, b.pen.y})
+		case 'V':
+			y, ok := s.number()
+			if !ok {
+				return
+			}
+			if relative {
+				y += b.pen.y
+			}
+			b.lineTo(point{b.pen.x, y})
+		case 'C':
+			x1, y1, ok1 := s.pair()
+			x2, y2, ok2 := s.pair()
+			x, y, ok3 := s.pair()
+			if !ok1 || !ok2 || !ok3 {
+				return
+			}
+			c2 := at(x2, y2)
+			b.cubicTo(at(x1, y1), c2, at(x, y))
+			lastControl = c2
+		case 'S':
+			x2, y2, ok1 := s.pair()
+			x, y, ok2 := s.pair()
+			if !ok1 || !ok2 {
+				return
+			}
+			c1 := b.pen
+			if l := lastCommand &^ 0x20; l == 'C' || l == 'S' {
+				c1 = point{2*b.pen.x - lastControl.x, 2*b.pen.y - lastControl.y}
+			}
+			c2 := at(x2, y2)
+			b.cubicTo(c1, c2, at(x, y))
+			lastControl = c2
+		case 'Q':
+			x1, y1, ok1 := s.pair()
+			x, y, ok2 := s.pair()
+			if !ok1 || !ok2 {
+				return
+			}
+			c := at(x1, y1)
+			b.quadTo(c, at(x, y))
+			lastControl = c
+		case 'T':
+			x, y, ok := s.pair()
+			if !ok {
+				return
+			}
+			c := b.pen
+			if l := lastCommand &^ 0x20; l == 'Q' || l == 'T' {
+				c = point{2*b.pen.x - lastControl.x, 2*b.pen.y - lastControl.y}
+			}
+			b.quadTo(c, at(x, y))
+			lastControl = c
+		case 'A':
+			rx, ok1 := s.number()
+			ry, ok2 := s.number()
+			rotation, ok3 := s.number()
+			large, ok4 := s.flag()
+			sweepFlag, ok5 := s.flag()
+			x, y, ok6 := s.pair()
+			if !ok1 || !ok2 || !ok3 || !ok4 || !ok5 || !ok6 {
+				return
+			}
+			b.arcTo(rx, ry, rotation, large, sweepFlag, at(x, y))
+		case 'Z':
+			b.close()
+			// Z takes no arguments, so the command must be repeated explicitly.
+			command = 0
+		default:
+			return
+		}
+		lastCommand = upper
+	}
+}
+
+// pathScanner tokenizes SVG path data.
+type pathScanner struct {
+	data string
+	pos  int
+}
+
+func (s *pathScanner) skipSeparators() {
+	for s.pos < len(s.data) {
+		switch s.data[s.pos] {
+		case ' ', ',', '\t', '\n', '\r':
+			s.pos++
+		default:
+			return
+		}
+	}
+}
+
+func (s *pathScanner) more() bool {
+	s.skipSeparators()
+	return s.pos < len(s.data)
+}
+
+func (s *pathScanner) command() (byte, bool) {
+	s.skipSeparators()
+	if s.pos >= len(s.data) {
+		return 0, false
+	}
+	c := s.data[s.pos]
+	if strings.IndexByte("MmLlHhVvCcSsQqTtAaZz", c) < 0 {
+		return 0, false
+	}
+	s.pos++
+	return c, true
+}
+
+func (s *pathScanner) number() (float64, bool) {
+	s.skipSeparators()
+	start := s.pos
+	if s.pos < len(s.data) && (s.data[s.pos] == '-' || s.data[s.pos] == '+') {
+		s.pos++
+	}
+	seenDot, seenDigit := false, false
+scan:
+	for s.pos < len(s.data) {
+		c := s.data[s.pos]
+		switch {
+		case c >= '0' && c <= '9':
+			seenDigit = true
+		case c == '.' && !seenDot:
+			seenDot = true
+		case (c == 'e' || c == 'E') && seenDigit:
+			if s.pos+1 < len(s.data) && (s.data[s.pos+1] == '-' || s.data[s.pos+1] == '+') {
+				s.pos++
+			}
+		default:
+			break scan
+		}
+		s.pos++
+	}
+	if !seenDigit {
+		s.pos = start
+		return 0, false
+	}
+	v, err := strconv.ParseFloat(s.data[start:s.pos], 64)
+	return v, err == nil
+}
+
+func (s *pathScanner) pair() (float64, float64, bool) {
+	x, ok1 := s.number()
+	y, ok2 := s.number()
+	return x, y, ok1 && ok2
+}
+
+// flag reads an arc flag, which may be written without a separator.
+func (s *pathScanner) flag() (bool, bool) {
+	s.skipSeparators()
+	if s.pos >= len(s.data) {
+		return false, false
+	}
+	switch s.data[s.pos] {
+	case '0':
+		s.pos++
+		return false, true
+	case '1':
+		s.pos++
+		return true, true
+	}
+	return false, false
+}
+
+func distance(a, b point) float64 {
+	return math.Hypot(b.x-a.x, b.y-a.y)
+}
+
+func vectorAngle(ux, uy, vx, vy float64) float64 {
+	return math.Atan2(ux*vy-uy*vx, ux*vx+uy*vy)
+}
@@ -0,0 +1,136 @@
+package raster
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"sort"
+)
+
+// maxPaletteSize is the number of colors a GIF frame can hold.
+const maxPaletteSize = 256
+
+// GIF encodes frames as a looping animated GIF that shows each frame for intervalMS
+// milliseconds. Frames of different sizes are centered on a canvas that fits all of
+// them, filled with each frame's own background color.
+func GIF(frames []*image.RGBA, intervalMS int) ([]byte, error) {
+	if len(frames) == 0 {
+		return nil, errors.New("no frames to animate")
+	}
+
+	var size image.Point
+	for _, frame := range frames {
+		size.X = max(size.X, frame.Bounds().Dx())
+		size.Y = max(size.Y, frame.Bounds().Dy())
+	}
+	canvases := make([]*image.RGBA, len(frames))
+	for i, frame := range frames {
+		canvases[i] = flatten(frame, size)
+	}
+
+	palette := buildPalette(canvases)
+	indexes := make(map[color.RGBA]uint8)
+	anim := &gif.GIF{LoopCount: 0}
+	for _, canvas := range canvases {
+		paletted := image.NewPaletted(canvas.Bounds(), palette)
+		for y := 0; y < size.Y; y++ {
+			for x := 0; x < size.X; x++ {
+				c := canvas.RGBAAt(x, y)
+				index, ok := indexes[c]
+				if !ok {
+					index = uint8(palette.Index(c))
+					indexes[c] = index
+				}
+				paletted.SetColorIndex(x, y, index)
+			}
+		}
+		anim.Image = append(anim.Image, paletted)
+		// GIF delays are in hundredths of a second.
+		anim.Delay = append(anim.Delay, max(1, intervalMS/10))
+	}
+
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, anim); err != nil {
+		return nil, fmt.Errorf("failed to encode GIF: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// flatten centers a frame on an opaque canvas of the given size. GIF transparency is
+// all or nothing, so the frame is composited over its background color, or white if
+// the frame has none.
+func flatten(frame *image.RGBA, size image.Point) *image.RGBA {
+	bounds := frame.Bounds()
+	background := frame.RGBAAt(bounds.Min.X, bounds.Min.Y)
+	if background.A != 0xff {
+		background = color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+	}
+	canvas := image.NewRGBA(image.Rectangle{Max: size})
+	draw.Draw(canvas, canvas.Bounds(), image.NewUniform(background), image.Point{}, draw.Src)
+	offset := image.Pt((size.X-bounds.Dx())/2, (size.Y-bounds.Dy())/2)
+	draw.Draw(canvas, bounds.Sub(bounds.Min).Add(offset), frame, bounds.Min, draw.Over)
+	return canvas
+}
+
+// buildPalette picks a palette shared by all frames so that colors do not flicker
+// between them. Diagrams use few flat colors, which are kept exactly when they fit;
+// otherwise colors are grouped into buckets and the most frequent buckets are kept,
+// each represented by its average color.
+func buildPalette(frames []*image.RGBA) color.Palette {
+	counts := make(map[color.RGBA]int)
+	for _, frame := range frames {
+		bounds := frame.Bounds()
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			for x := bounds.Min.X; x < bounds.Max.X; x++ {
+				counts[frame.RGBAAt(x, y)]++
+			}
+		}
+	}
+	if len(counts) <= maxPaletteSize {
+		palette := make(color.Palette, 0, len(counts))
+		for c := range counts {
+			palette = append(palette, c)
+		}
+		return palette
+	}
+
+	type bucket struct {
+		r, g, b, count int
+	}
+	buckets := make(map[uint16]*bucket)
+	for c, count := range counts {
+		key := uint16(c.R>>3)<<10 | uint16(c.G>>3)<<5 | uint16(c.B>>3)
+		b, ok := buckets[key]
+		if !ok {
+			b = &bucket{}
+			buckets[key] = b
+		}
+		b.r += int(c.R) * count
+		b.g += int(c.G) * count
+		b.b += int(c.B) * count
+		b.count += count
+	}
+	sorted := make([]*bucket, 0, len(buckets))
+	for _, b := range buckets {
+		sorted = append(sorted, b)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].count > sorted[j].count })
+	if len(sorted) > maxPaletteSize {
+		sorted = sorted[:maxPaletteSize]
+	}
+
+	palette := make(color.Palette, len(sorted))
+	for i, b := range sorted {
+		palette[i] = color.RGBA{
+			R: uint8(b.r / b.count),
+			G: uint8(b.g / b.count),
+			B: uint8(b.b / b.count),
+			A: 0xff,
+		}
+	}
+	return palette
+}
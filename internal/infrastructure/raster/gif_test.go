@@ -0,0 +1,62 @@
+package raster
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"testing"
+)
+
+func solidFrame(width, height int, c color.RGBA) *image.RGBA {
+	frame := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(frame, frame.Bounds(), image.NewUniform(c), image.Point{}, draw.Src)
+	return frame
+}
+
+func TestGIF(t *testing.T) {
+	red := color.RGBA{R: 255, A: 255}
+	blue := color.RGBA{B: 255, A: 255}
+	data, err := GIF([]*image.RGBA{solidFrame(40, 20, red), solidFrame(20, 30, blue)}, 700)
+	if err != nil {
+		t.Fatalf("GIF() error = %v", err)
+	}
+	anim, err := gif.DecodeAll(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("failed to decode GIF: %v", err)
+	}
+	if len(anim.Image) != 2 {
+		t.Fatalf("GIF has %d frames, want 2", len(anim.Image))
+	}
+	for i, frame := range anim.Image {
+		if size := frame.Bounds().Size(); size != image.Pt(40, 30) {
+			t.Errorf("frame %d size = %v, want 40x30", i, size)
+		}
+		if anim.Delay[i] != 70 {
+			t.Errorf("frame %d delay = %d, want 70", i, anim.Delay[i])
+		}
+	}
+	// Smaller frames are centered on their own background color.
+	if r, g, b, _ := anim.Image[1].At(0, 0).RGBA(); r != 0 || g != 0 || b>>8 != 255 {
+		t.Errorf("padding of frame 1 = %v, want blue", anim.Image[1].At(0, 0))
+	}
+}
+
+func TestGIFNoFrames(t *testing.T) {
+	if _, err := GIF(nil, 100); err == nil {
+		t.Error("GIF() expected error for no frames")
+	}
+}
+
+func TestBuildPaletteLimit(t *testing.T) {
+	frame := image.NewRGBA(image.Rect(0, 0, 64, 64))
+	for y := 0; y < 64; y++ {
+		for x := 0; x < 64; x++ {
+			frame.SetRGBA(x, y, color.RGBA{R: uint8(x * 4), G: uint8(y * 4), B: uint8(x + y), A: 255})
+		}
+	}
+	if size := len(buildPalette([]*image.RGBA{frame})); size > maxPaletteSize {
+		t.Errorf("palette has %d colors, want at most %d", size, maxPaletteSize)
+	}
+}
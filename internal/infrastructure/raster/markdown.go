@@ -0,0 +1,431 @@
+package raster
+
+import (
+	"image/color"
+	"strconv"
+	"strings"
+
+	"golang.org/x/image/font/sfnt"
+)
+
+// D2 renders markdown labels as XHTML inside a <foreignObject>, styled with a copy of
+// GitHub's markdown stylesheet. Only its class rules are understood by the stylesheet
+// parser, so the element styles below mirror the rules that matter for layout.
+
+// headingScales are the font sizes of h1 to h6 relative to the base font size.
+var headingScales = map[string]float64{
+	"h1": 2, "h2": 1.5, "h3": 1.25, "h4": 1, "h5": 0.875, "h6": 0.85,
+}
+
+// blockElements start a new line and are laid out vertically.
+var blockElements = map[string]bool{
+	"address": true, "article": true, "aside": true, "blockquote": true, "dd": true,
+	"details": true, "div": true, "dl": true, "dt": true, "figcaption": true,
+	"figure": true, "footer": true, "h1": true, "h2": true, "h3": true, "h4": true,
+	"h5": true, "h6": true, "header": true, "hr": true, "li": true, "main": true,
+	"nav": true, "ol": true, "p": true, "pre": true, "section": true, "summary": true,
+	"table": true, "tbody": true, "thead": true, "tfoot": true, "tr": true, "ul": true,
+}
+
+// mdFormat is the inline formatting in effect for a piece of markdown text.
+type mdFormat struct {
+	family string
+	font   *sfnt.Font
+	size   float64
+	color  color.NRGBA
+}
+
+// mdWord is a word, or a forced line break, in a paragraph being laid out.
+type mdWord struct {
+	text      string
+	format    mdFormat
+	space     bool // Whether whitespace precedes the word
+	lineBreak bool
+}
+
+// mdBlock is the block context markdown content is laid out in.
+type mdBlock struct {
+	format     mdFormat
+	indent     float64
+	lineHeight float64 // Multiple of the font size
+	pre        bool
+	background *color.NRGBA
+}
+
+// mdLayout lays out markdown content top to bottom within a fixed width.
+type mdLayout struct {
+	r       *renderer
+	ctm     matrix
+	opacity float64
+	left    float64
+	top     float64
+	width   float64
+	y       float64
+	margin  float64 // Pending collapsed vertical margin
+	started bool
+	words   []mdWord
+	marker  *mdWord // List marker drawn before the next line
+}
+
+// renderForeignObject draws the markdown content of a <foreignObject>.
+func (r *renderer) renderForeignObject(n *node, ctm matrix, s style) {
+	if s.invisible {
+		return
+	}
+	x := n.number("x", 0, s.fontSize)
+	y := n.number("y", 0, s.fontSize)
+	layout := &mdLayout{
+		r:       r,
+		ctm:     ctm,
+		opacity: s.opacity,
+		left:    x,
+		top:     y,
+		width:   n.number("width", 0, s.fontSize),
+	}
+	base := mdBlock{
+		format:     r.mdFormat(s, "font-regular"),
+		lineHeight: 1.5,
+	}
+	layout.block(n, s, base)
+	layout.flush(base)
+}
+
+// mdFormat returns the inline format for a style, using the embedded font whose family
+// ends in fallback when the style does not name one.
+func (r *renderer) mdFormat(s style, fallback string) mdFormat {
+	f := mdFormat{family: s.fontFamily, size: s.fontSize, color: s.color}
+	if f.family == "" {
+		f.family = fallback
+		f.font = r.fonts.lookupSuffix(fallback)
+	} else {
+		f.font = r.fonts.lookup(f.family)
+	}
+	return f
+}
+
+// withFont returns f using the embedded font whose family ends in suffix.
+func (r *renderer) withFont(f mdFormat, suffix string) mdFormat {
+	f.family = suffix
+	f.font = r.fonts.lookupSuffix(suffix)
+	return f
+}
+
+// restyle applies the properties a child's class rules changed to f.
+func (r *renderer) restyle(f mdFormat, parent, child style) mdFormat {
+	if child.fontFamily != parent.fontFamily {
+		f.family = child.fontFamily
+		f.font = r.fonts.lookup(child.fontFamily)
+	}
+	if child.fontSize != parent.fontSize {
+		f.size = child.fontSize
+	}
+	if child.color != parent.color {
+		f.color = child.color
+	}
+	return f
+}
+
+// variableColor resolves a CSS color custom property of the markdown stylesheet.
+func (r *renderer) variableColor(name string, def color.NRGBA) color.NRGBA {
+	if c, ok := parseColor(r.sheet.resolve("var(" + name + ")")); ok {
+		return c
+	}
+	return def
+}
+
+// block lays out the children of n. Inline content accumulates into the current
+// paragraph; block children flush it and are laid out below.
+func (l *mdLayout) block(n *node, s style, ctx mdBlock) {
+	for _, child := range n.children {
+		if child.name == "" {
+			l.text(child.text, ctx)
+			continue
+		}
+		cs := l.r.computeStyle(s, child)
+		if cs.hidden {
+			continue
+		}
+		ctx := ctx
+		ctx.format = l.r.restyle(ctx.format, s, cs)
+		if !blockElements[child.name] {
+			l.inline(child, cs, ctx)
+			continue
+		}
+		l.flush(ctx)
+		l.blockElement(child, cs, ctx)
+	}
+}
+
+func (l *mdLayout) blockElement(n *node, s style, ctx mdBlock) {
+	r := l.r
+	inner := ctx
+	switch n.name {
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		inner.format = r.withFont(ctx.format, "font-semibold")
+		inner.format.size = ctx.format.size * headingScales[n.name]
+		inner.lineHeight = 1.25
+		l.addMargin(24)
+		l.block(n, s, inner)
+		l.flush(inner)
+		if n.name == "h1" || n.name == "h2" {
+			l.y += 0.3 * inner.format.size
+			border := r.variableColor("--color-border-muted", ctx.format.color)
+			r.fillRect(l.left+ctx.indent, l.top+l.y, l.width-ctx.indent, 1, l.ctm, withOpacity(border, l.opacity), nil)
+			l.y++
+		}
+		l.addMargin(16)
+	case "p", "table", "dl":
+		l.addMargin(0)
+		l.block(n, s, inner)
+		l.flush(inner)
+		l.addMargin(16)
+	case "blockquote":
+		inner.indent += ctx.format.size
+		inner.format.color = r.variableColor("--color-fg-muted", ctx.format.color)
+		l.addMargin(0)
+		l.block(n, s, inner)
+		l.flush(inner)
+		l.addMargin(16)
+	case "ul", "ol":
+		inner.indent += 2 * ctx.format.size
+		l.addMargin(0)
+		number := 1
+		if start, err := strconv.Atoi(n.attrs["start"]); err == nil {
+			number = start
+		}
+		for _, item := range n.children {
+			if item.name != "li" {
+				continue
+			}
+			marker := "•"
+			if n.name == "ol" {
+				marker = strconv.Itoa(number) + "."
+				number++
+			}
+			l.listItem(item, l.r.computeStyle(s, item), inner, marker)
+		}
+		l.addMargin(16)
+	case "li":
+		l.listItem(n, s, ctx, "•")
+	case "pre":
+		inner.format = r.withFont(ctx.format, "font-mono")
+		inner.format.size = ctx.format.size * 0.85
+		inner.lineHeight = 1.45
+		inner.pre = true
+		background := r.variableColor("--color-canvas-subtle", color.NRGBA{})
+		inner.background = &background
+		l.addMargin(0)
+		l.block(n, s, inner)
+		l.flush(inner)
+		l.addMargin(16)
+	case "hr":
+		l.addMargin(24)
+		l.startBlock()
+		c := r.variableColor("--color-border-default", ctx.format.color)
+		r.fillRect(l.left+ctx.indent, l.top+l.y, l.width-ctx.indent, 0.25*ctx.format.size, l.ctm, withOpacity(c, l.opacity), nil)
+		l.y += 0.25 * ctx.format.size
+		l.addMargin(24)
+	case "dd":
+		inner.indent += ctx.format.size
+		l.block(n, s, inner)
+		l.flush(inner)
+	case "dt":
+		inner.format = r.withFont(ctx.format, "font-semibold")
+		l.addMargin(16)
+		l.block(n, s, inner)
+		l.flush(inner)
+	default:
+		l.block(n, s, inner)
+		l.flush(inner)
+	}
+}
+
+// listItem lays out an <li>, drawing its marker in the indentation to the left.
+func (l *mdLayout) listItem(n *node, s style, ctx mdBlock, marker string) {
+	l.addMargin(0.25 * ctx.format.size)
+	l.marker = &mdWord{text: marker, format: ctx.format}
+	l.block(n, s, ctx)
+	l.flush(ctx)
+}
+
+// inline adds an inline element to the current paragraph.
+func (l *mdLayout) inline(n *node, s style, ctx mdBlock) {
+	r := l.r
+	switch n.name {
+	case "br":
+		l.words = append(l.words, mdWord{lineBreak: true, format: ctx.format})
+		return
+	case "img", "input", "svg", "script", "style":
+		return
+	case "em", "i", "dfn", "cite":
+		ctx.format = r.withFont(ctx.format, "font-italic")
+	case "strong", "b":
+		ctx.format = r.withFont(ctx.format, "font-bold")
+	case "code", "kbd", "samp", "tt":
+		ctx.format = r.withFont(ctx.format, "font-mono")
+		if !ctx.pre {
+			ctx.format.size *= 0.85
+		}
+	case "a":
+		ctx.format.color = r.variableColor("--color-accent-fg", ctx.format.color)
+	case "small":
+		ctx.format.size *= 0.9
+	case "sub", "sup":
+		ctx.format.size *= 0.75
+	case "td", "th":
+		if n.name == "th" {
+			ctx.format = r.withFont(ctx.format, "font-semibold")
+		}
+		l.text(" ", ctx)
+	}
+	l.block(n, s, ctx)
+}
+
+// text adds character data to the current paragraph.
+func (l *mdLayout) text(text string, ctx mdBlock) {
+	if ctx.pre {
+		lines := strings.Split(text, "\n")
+		for i, line := range lines {
+			if i > 0 {
+				l.words = append(l.words, mdWord{lineBreak: true, format: ctx.format})
+			}
+			if line != "" {
+				line = strings.ReplaceAll(line, "\t", "    ")
+				l.words = append(l.words, mdWord{text: line, format: ctx.format})
+			}
+		}
+		return
+	}
+
+	space := len(text) > 0 && isSpace(rune(text[0]))
+	for _, word := range strings.FieldsFunc(text, isSpace) {
+		l.words = append(l.words, mdWord{text: word, format: ctx.format, space: space})
+		space = true
+	}
+	if len(text) > 0 && isSpace(rune(text[len(text)-1])) {
+		// Mark the trailing space on the next word via a zero-width entry.
+		l.words = append(l.words, mdWord{format: ctx.format, space: true})
+	}
+}
+
+func isSpace(r rune) bool {
+	return r == ' ' || r == '\t' || r == '\n' || r == '\r'
+}
+
+func (l *mdLayout) addMargin(margin float64) {
+	l.margin = max(l.margin, margin)
+}
+
+// startBlock applies the pending margin before content, ignoring it at the top.
+func (l *mdLayout) startBlock() {
+	if l.started {
+		l.y += l.margin
+	}
+	l.started = true
+	l.margin = 0
+}
+
+// mdLine is a laid out line of words with their horizontal offsets.
+type mdLine struct {
+	words   []mdWord
+	offsets []float64
+	size    float64
+}
+
+// flush wraps the current paragraph into lines and draws them.
+func (l *mdLayout) flush(ctx mdBlock) {
+	words := l.words
+	l.words = nil
+	hasText := false
+	for _, w := range words {
+		if w.text != "" {
+			hasText = true
+			break
+		}
+	}
+	if !hasText {
+		return
+	}
+	l.startBlock()
+
+	padding := 0.0
+	if ctx.background != nil {
+		padding = 16
+	}
+	available := l.width - ctx.indent - 2*padding
+
+	var lines []mdLine
+	current := mdLine{size: ctx.format.size}
+	x := 0.0
+	pendingSpace := false
+	for _, w := range words {
+		if w.lineBreak {
+			lines = append(lines, current)
+			current = mdLine{size: ctx.format.size}
+			x, pendingSpace = 0, false
+			continue
+		}
+		if w.text == "" {
+			pendingSpace = pendingSpace || w.space
+			continue
+		}
+		spaceWidth := 0.0
+		if (w.space || pendingSpace) && len(current.words) > 0 {
+			spaceWidth = l.r.measure(l.run(" ", w.format))
+		}
+		width := l.r.measure(l.run(w.text, w.format))
+		if !ctx.pre && len(current.words) > 0 && x+spaceWidth+width > available+0.5 {
+			lines = append(lines, current)
+			current = mdLine{size: ctx.format.size}
+			x, spaceWidth = 0, 0
+		}
+		x += spaceWidth
+		current.words = append(current.words, w)
+		current.offsets = append(current.offsets, x)
+		current.size = max(current.size, w.format.size)
+		x += width
+		pendingSpace = false
+	}
+	lines = append(lines, current)
+
+	height := 0.0
+	for _, line := range lines {
+		height += line.size * ctx.lineHeight
+	}
+	left := l.left + ctx.indent
+	if ctx.background != nil {
+		l.r.fillRect(left, l.top+l.y, l.width-ctx.indent, height+2*padding, l.ctm, withOpacity(*ctx.background, l.opacity), nil)
+		l.y += padding
+	}
+
+	for i, line := range lines {
+		lineHeight := line.size * ctx.lineHeight
+		ascent, descent := l.r.fonts.metrics(ctx.format.font, line.size)
+		baseline := l.top + l.y + (lineHeight-ascent-descent)/2 + ascent
+		for j, w := range line.words {
+			l.r.drawRun(l.run(w.text, w.format), point{left + padding + line.offsets[j], baseline}, l.ctm)
+		}
+		if i == 0 && l.marker != nil {
+			// List markers hang in the indentation to the left of the item.
+			marker := l.run(l.marker.text+" ", l.marker.format)
+			l.r.drawRun(marker, point{left - l.r.measure(marker), baseline}, l.ctm)
+			l.marker = nil
+		}
+		l.y += lineHeight
+	}
+	l.y += padding
+}
+
+// run converts a word into a text run for measuring and drawing.
+func (l *mdLayout) run(text string, f mdFormat) textRun {
+	s := defaultStyle()
+	s.fill = paint{color: f.color}
+	s.fontSize = f.size
+	s.opacity = l.opacity
+	return textRun{
+		text:   text,
+		style:  s,
+		family: f.family,
+		font:   f.font,
+	}
+}
@@ -0,0 +1,137 @@
+package raster
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+const testSVG = `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 100 50">
+<style>.red{fill:#ff0000}.hidden{display:none}</style>
+<defs>
+  <mask id="m" maskUnits="userSpaceOnUse"><rect x="0" y="0" width="100" height="50" fill="white"/><rect x="30" y="0" width="20" height="50" fill="black"/></mask>
+</defs>
+<rect x="0" y="0" width="100" height="50" fill="#ffffff"/>
+<rect x="0" y="0" width="50" height="50" class="red"/>
+<g transform="translate(50 0)"><rect width="50" height="50" style="fill:rgb(0,0,255)" mask="url(#m)"/></g>
+<rect x="0" y="0" width="100" height="50" class="hidden" fill="#00ff00"/>
+</svg>`
+
+func TestRasterize(t *testing.T) {
+	img, err := Rasterize([]byte(testSVG), Options{})
+	if err != nil {
+		t.Fatalf("Rasterize() error = %v", err)
+	}
+	if size := img.Bounds().Size(); size != image.Pt(100, 50) {
+		t.Fatalf("size = %v, want 100x50", size)
+	}
+
+	tests := []struct {
+		name string
+		x, y int
+		want color.RGBA
+	}{
+		{name: "class fill", x: 25, y: 25, want: color.RGBA{R: 255, A: 255}},
+		{name: "inline style under transform", x: 60, y: 25, want: color.RGBA{B: 255, A: 255}},
+		{name: "masked out", x: 90, y: 25, want: color.RGBA{R: 255, G: 255, B: 255, A: 255}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := img.RGBAAt(tt.x, tt.y); got != tt.want {
+				t.Errorf("pixel (%d, %d) = %v, want %v", tt.x, tt.y, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRasterizeSize(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+		want image.Point
+	}{
+		{name: "natural", opts: Options{}, want: image.Pt(100, 50)},
+		{name: "width", opts: Options{Width: 300}, want: image.Pt(300, 150)},
+		{name: "height", opts: Options{Height: 25}, want: image.Pt(50, 25)},
+		{name: "width and height", opts: Options{Width: 400, Height: 400}, want: image.Pt(400, 400)},
+		{name: "dpi", opts: Options{DPI: 192}, want: image.Pt(200, 100)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img, err := Rasterize([]byte(testSVG), tt.opts)
+			if err != nil {
+				t.Fatalf("Rasterize() error = %v", err)
+			}
+			if size := img.Bounds().Size(); size != tt.want {
+				t.Errorf("size = %v, want %v", size, tt.want)
+			}
+		})
+	}
+}
+
+func TestRasterizeFitPreservesAspectRatio(t *testing.T) {
+	img, err := Rasterize([]byte(testSVG), Options{Width: 400, Height: 400})
+	if err != nil {
+		t.Fatalf("Rasterize() error = %v", err)
+	}
+	// The 2:1 drawing fills the top half of the square image only.
+	if got := img.RGBAAt(100, 100); got != (color.RGBA{R: 255, A: 255}) {
+		t.Errorf("pixel inside the drawing = %v, want red", got)
+	}
+	if got := img.RGBAAt(100, 300); got.A != 0 {
+		t.Errorf("pixel below the drawing = %v, want transparent", got)
+	}
+}
+
+func TestRasterizeErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		svg  string
+		opts Options
+	}{
+		{name: "not SVG", svg: `<html></html>`},
+		{name: "no size", svg: `<svg xmlns="http://www.w3.org/2000/svg"></svg>`},
+		{name: "too large", svg: testSVG, opts: Options{Width: maxDimension + 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Rasterize([]byte(tt.svg), tt.opts); err == nil {
+				t.Error("Rasterize() expected error")
+			}
+		})
+	}
+}
+
+func TestPNG(t *testing.T) {
+	data, err := PNG([]byte(testSVG), Options{Width: 200})
+	if err != nil {
+		t.Fatalf("PNG() error = %v", err)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("failed to decode PNG: %v", err)
+	}
+	if size := img.Bounds().Size(); size != image.Pt(200, 100) {
+		t.Errorf("size = %v, want 200x100", size)
+	}
+}
+
+func TestStrokeCurveCoverageIsUniform(t *testing.T) {
+	// A straight cubic is flattened into many short segments; its edge pixels must
+	// not darken where the segments meet.
+	svg := `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 20 100">
+<path d="M 10.5 0 C 10.5 40 10.5 60 10.5 100" stroke="#000000" stroke-width="2" fill="none"/>
+</svg>`
+	img, err := Rasterize([]byte(svg), Options{})
+	if err != nil {
+		t.Fatalf("Rasterize() error = %v", err)
+	}
+	want := img.RGBAAt(9, 50)
+	for y := 5; y < 95; y++ {
+		if got := img.RGBAAt(9, y); got != want {
+			t.Fatalf("edge pixel (9, %d) = %v, want %v like the rest of the stroke", y, got, want)
+		}
+	}
+}
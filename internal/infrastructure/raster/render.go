@@ -0,0 +1,312 @@
+package raster
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/color"
+	_ "image/gif"  // Registers GIF decoding for embedded images.
+	_ "image/jpeg" // Registers JPEG decoding for embedded images.
+	_ "image/png"  // Registers PNG decoding for embedded images.
+	"math"
+	"strings"
+
+	"golang.org/x/image/draw"
+	"golang.org/x/image/math/f64"
+)
+
+// renderer draws a parsed SVG document onto a canvas.
+type renderer struct {
+	canvas *canvas
+	sheet  *stylesheet
+	fonts  *fontSet
+	ids    map[string]*node
+	masks  map[maskKey]*image.Alpha
+}
+
+type maskKey struct {
+	id  string
+	ctm matrix
+}
+
+// renderChildren renders the element children of n.
+func (r *renderer) renderChildren(n *node, ctm matrix, s style, mask *image.Alpha) {
+	for _, child := range n.children {
+		if child.name != "" {
+			r.render(child, ctm, s, mask)
+		}
+	}
+}
+
+// render draws n and its subtree with the given current transformation matrix.
+func (r *renderer) render(n *node, ctm matrix, parent style, mask *image.Alpha) {
+	switch n.name {
+	case "defs", "marker", "mask", "clipPath", "pattern", "linearGradient", "radialGradient",
+		"style", "title", "desc", "symbol", "filter", "metadata", "script":
+		return
+	}
+
+	s := r.computeStyle(parent, n)
+	if s.hidden || s.opacity <= 0 {
+		return
+	}
+	if transform, ok := n.attrs["transform"]; ok {
+		ctm = ctm.mul(parseTransform(transform))
+	}
+	if id := urlReference(n.attrs["mask"]); id != "" {
+		mask = r.combineMasks(mask, r.mask(id, ctm))
+	}
+
+	switch n.name {
+	case "svg":
+		x := n.number("x", 0, s.fontSize)
+		y := n.number("y", 0, s.fontSize)
+		viewBox := parseNumbers(n.attrs["viewBox"])
+		width, height := 0.0, 0.0
+		if len(viewBox) == 4 {
+			width, height = viewBox[2], viewBox[3]
+		}
+		width = n.number("width", width, s.fontSize)
+		height = n.number("height", height, s.fontSize)
+		ctm = ctm.mul(translate(x, y)).mul(viewBoxTransform(viewBox, width, height, n.attrs["preserveAspectRatio"]))
+		r.renderChildren(n, ctm, s, mask)
+	case "g", "a", "switch":
+		r.renderChildren(n, ctm, s, mask)
+	case "use":
+		ref := strings.TrimPrefix(n.attrs["href"], "#")
+		if target, ok := r.ids[ref]; ok && target != n {
+			ctm = ctm.mul(translate(n.number("x", 0, s.fontSize), n.number("y", 0, s.fontSize)))
+			if target.name == "symbol" {
+				r.renderChildren(target, ctm, s, mask)
+			} else {
+				r.render(target, ctm, s, mask)
+			}
+		}
+	case "rect", "circle", "ellipse", "line", "polyline", "polygon", "path":
+		if !s.invisible {
+			r.renderShape(n, ctm, s, mask)
+		}
+	case "text":
+		if !s.invisible {
+			r.renderText(n, ctm, s)
+		}
+	case "foreignObject":
+		r.renderForeignObject(n, ctm, s)
+	case "image":
+		if !s.invisible {
+			r.renderImage(n, ctm, s)
+		}
+	}
+}
+
+// renderShape fills and strokes a basic shape or path, then draws its markers.
+func (r *renderer) renderShape(n *node, ctm matrix, s style, mask *image.Alpha) {
+	scale := ctm.scaleFactor()
+	if scale == 0 {
+		return
+	}
+	b := newPathBuilder(0.2 / scale)
+	num := func(name string) float64 { return n.number(name, 0, s.fontSize) }
+
+	fillable := true
+	switch n.name {
+	case "rect":
+		w, h := num("width"), num("height")
+		if w <= 0 || h <= 0 {
+			return
+		}
+		rx, hasRx := n.attrs["rx"]
+		ry, hasRy := n.attrs["ry"]
+		radiusX, _ := parseLength(rx, s.fontSize)
+		radiusY, _ := parseLength(ry, s.fontSize)
+		if !hasRy {
+			radiusY = radiusX
+		} else if !hasRx {
+			radiusX = radiusY
+		}
+		b.roundedRect(num("x"), num("y"), w, h, radiusX, radiusY)
+	case "circle":
+		radius := num("r")
+		if radius <= 0 {
+			return
+		}
+		b.ellipse(num("cx"), num("cy"), radius, radius)
+	case "ellipse":
+		rx, ry := num("rx"), num("ry")
+		if rx <= 0 || ry <= 0 {
+			return
+		}
+		b.ellipse(num("cx"), num("cy"), rx, ry)
+	case "line":
+		fillable = false
+		b.moveTo(point{num("x1"), num("y1")})
+		b.lineTo(point{num("x2"), num("y2")})
+	case "polyline", "polygon":
+		coords := parseNumbers(n.attrs["points"])
+		for i := 0; i+1 < len(coords); i += 2 {
+			if i == 0 {
+				b.moveTo(point{coords[i], coords[i+1]})
+			} else {
+				b.lineTo(point{coords[i], coords[i+1]})
+			}
+		}
+		if n.name == "polygon" {
+			b.close()
+		}
+	case "path":
+		b.pathData(n.attrs["d"])
+	}
+
+	userLines := b.finish()
+	deviceLines := transformLines(userLines, ctm)
+
+	if fillable && !s.fill.none {
+		r.canvas.fill(deviceLines, withOpacity(s.fill.color, s.fillOpacity*s.opacity), mask)
+	}
+	if !s.stroke.none {
+		dashes := make([]float64, len(s.dashes))
+		for i, d := range s.dashes {
+			dashes[i] = d * scale
+		}
+		r.canvas.stroke(deviceLines, withOpacity(s.stroke.color, s.strokeOpacity*s.opacity), strokeStyle{
+			width:   s.strokeWidth * scale,
+			dashes:  dashes,
+			lineCap: s.lineCap,
+		}, mask)
+	}
+
+	if len(userLines) > 0 && (s.markerStart != "" || s.markerEnd != "") {
+		first := dedupe(userLines[0].points)
+		last := dedupe(userLines[len(userLines)-1].points)
+		if s.markerStart != "" && len(first) >= 2 {
+			angle := math.Atan2(first[1].y-first[0].y, first[1].x-first[0].x)
+			r.renderMarker(s.markerStart, first[0], angle, true, ctm, s, mask)
+		}
+		if s.markerEnd != "" && len(last) >= 2 {
+			end, before := last[len(last)-1], last[len(last)-2]
+			angle := math.Atan2(end.y-before.y, end.x-before.x)
+			r.renderMarker(s.markerEnd, end, angle, false, ctm, s, mask)
+		}
+	}
+}
+
+// renderMarker draws the marker with the given id at a vertex of a path.
+func (r *renderer) renderMarker(id string, at point, angle float64, start bool, ctm matrix, pathStyle style, mask *image.Alpha) {
+	marker, ok := r.ids[id]
+	if !ok || marker.name != "marker" {
+		return
+	}
+	s := r.computeStyle(defaultStyle(), marker)
+	s.opacity *= pathStyle.opacity
+
+	switch orient := marker.attrs["orient"]; orient {
+	case "auto":
+	case "auto-start-reverse":
+		if start {
+			angle += math.Pi
+		}
+	default:
+		degrees := parseNumbers(orient)
+		angle = 0
+		if len(degrees) > 0 {
+			angle = degrees[0] * math.Pi / 180
+		}
+	}
+
+	width := marker.number("markerWidth", 3, s.fontSize)
+	height := marker.number("markerHeight", 3, s.fontSize)
+	ref := point{marker.number("refX", 0, s.fontSize), marker.number("refY", 0, s.fontSize)}
+
+	t := ctm.mul(translate(at.x, at.y)).mul(rotate(angle))
+	if marker.attrs["markerUnits"] != "userSpaceOnUse" {
+		t = t.mul(scale(pathStyle.strokeWidth, pathStyle.strokeWidth))
+	}
+	content := viewBoxTransform(parseNumbers(marker.attrs["viewBox"]), width, height, marker.attrs["preserveAspectRatio"])
+	ref = content.apply(ref)
+	t = t.mul(translate(-ref.x, -ref.y)).mul(content)
+
+	r.renderChildren(marker, t, s, mask)
+}
+
+// mask returns the coverage of the mask with the given id, rendered in device space.
+func (r *renderer) mask(id string, ctm matrix) *image.Alpha {
+	key := maskKey{id: id, ctm: ctm}
+	if m, ok := r.masks[key]; ok {
+		return m
+	}
+	maskNode, ok := r.ids[id]
+	if !ok || maskNode.name != "mask" {
+		return nil
+	}
+
+	bounds := r.canvas.img.Bounds()
+	layer := image.NewRGBA(bounds)
+	sub := &renderer{
+		canvas: newCanvas(layer),
+		sheet:  r.sheet,
+		fonts:  r.fonts,
+		ids:    r.ids,
+		masks:  r.masks,
+	}
+	sub.renderChildren(maskNode, ctm, r.computeStyle(defaultStyle(), maskNode), nil)
+
+	// Mask coverage is the luminance of the premultiplied mask content.
+	m := image.NewAlpha(bounds)
+	for i := 0; i < len(m.Pix); i++ {
+		px := layer.Pix[i*4 : i*4+3]
+		m.Pix[i] = uint8(0.2125*float64(px[0]) + 0.7154*float64(px[1]) + 0.0721*float64(px[2]) + 0.5)
+	}
+	r.masks[key] = m
+	return m
+}
+
+// combineMasks multiplies two masks, either of which may be nil.
+func (r *renderer) combineMasks(a, b *image.Alpha) *image.Alpha {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+	combined := image.NewAlpha(a.Bounds())
+	for i := range combined.Pix {
+		combined.Pix[i] = uint8(uint32(a.Pix[i]) * uint32(b.Pix[i]) / 0xff)
+	}
+	return combined
+}
+
+// renderImage draws an embedded raster image. Remote images are skipped because
+// rendering must not depend on network access.
+func (r *renderer) renderImage(n *node, ctm matrix, s style) {
+	href := n.attrs["href"]
+	comma := strings.IndexByte(href, ',')
+	if !strings.HasPrefix(href, "data:") || comma < 0 || !strings.Contains(href[:comma], ";base64") {
+		return
+	}
+	data, err := base64.StdEncoding.DecodeString(href[comma+1:])
+	if err != nil {
+		return
+	}
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return
+	}
+
+	srcBounds := src.Bounds()
+	width := n.number("width", float64(srcBounds.Dx()), s.fontSize)
+	height := n.number("height", float64(srcBounds.Dy()), s.fontSize)
+	if width <= 0 || height <= 0 || srcBounds.Empty() {
+		return
+	}
+
+	viewBox := []float64{0, 0, float64(srcBounds.Dx()), float64(srcBounds.Dy())}
+	t := ctm.mul(translate(n.number("x", 0, s.fontSize), n.number("y", 0, s.fontSize))).
+		mul(viewBoxTransform(viewBox, width, height, n.attrs["preserveAspectRatio"])).
+		mul(translate(float64(srcBounds.Min.X), float64(srcBounds.Min.Y)))
+
+	var opts *draw.Options
+	if s.opacity < 1 {
+		opts = &draw.Options{SrcMask: image.NewUniform(color.Alpha{A: clampByte(s.opacity * 255)})}
+	}
+	draw.CatmullRom.Transform(r.canvas.img, f64.Aff3{t.a, t.c, t.e, t.b, t.d, t.f}, src, srcBounds, draw.Over, opts)
+}
@@ -0,0 +1,347 @@
+package raster
+
+import (
+	"encoding/base64"
+	"image/color"
+	"strconv"
+	"strings"
+
+	"golang.org/x/image/colornames"
+)
+
+// declaration is a single CSS property assignment.
+type declaration struct {
+	property string
+	value    string
+}
+
+// classRule holds the declarations a stylesheet applies to one class name.
+type classRule struct {
+	order        int
+	declarations []declaration
+}
+
+// stylesheet is the subset of CSS that D2 emits and the rasterizer understands:
+// rules whose selector ends in a single class, @font-face blocks and custom properties.
+type stylesheet struct {
+	classes   map[string][]classRule
+	fontFaces map[string][]byte // Font family name to font file data
+	variables map[string]string
+	order     int
+}
+
+func newStylesheet() *stylesheet {
+	return &stylesheet{
+		classes:   make(map[string][]classRule),
+		fontFaces: make(map[string][]byte),
+		variables: make(map[string]string),
+	}
+}
+
+// parse adds the rules of a <style> element. @media blocks are skipped so that the
+// light variant of themes and code blocks is used.
+func (s *stylesheet) parse(css string) {
+	css = stripComments(css)
+	for len(css) > 0 {
+		open := strings.IndexByte(css, '{')
+		if open < 0 {
+			return
+		}
+		selector := strings.TrimSpace(css[:open])
+
+		end := matchingBrace(css, open)
+		body := css[open+1 : end]
+		css = css[min(end+1, len(css)):]
+
+		switch {
+		case strings.HasPrefix(selector, "@font-face"):
+			s.parseFontFace(parseDeclarations(body))
+		case strings.HasPrefix(selector, "@"):
+			// @media, @keyframes and friends do not apply to a static light image.
+		default:
+			s.addRule(selector, parseDeclarations(body))
+		}
+	}
+}
+
+// addRule records declarations for each selector in the list that ends in a plain class.
+func (s *stylesheet) addRule(selectors string, declarations []declaration) {
+	s.order++
+	for _, selector := range strings.Split(selectors, ",") {
+		parts := strings.Fields(selector)
+		if len(parts) == 0 {
+			continue
+		}
+		last := parts[len(parts)-1]
+		if !strings.HasPrefix(last, ".") || strings.ContainsAny(last[1:], ".:[>#*") {
+			continue
+		}
+
+		class := last[1:]
+		s.classes[class] = append(s.classes[class], classRule{order: s.order, declarations: declarations})
+
+		// Markdown colors are custom properties on the .md rule.
+		for _, d := range declarations {
+			if strings.HasPrefix(d.property, "--") {
+				s.variables[d.property] = d.value
+			}
+		}
+	}
+}
+
+// parseFontFace stores the data of an @font-face rule with an embedded data URL.
+func (s *stylesheet) parseFontFace(declarations []declaration) {
+	var family, src string
+	for _, d := range declarations {
+		switch d.property {
+		case "font-family":
+			family = unquote(d.value)
+		case "src":
+			src = d.value
+		}
+	}
+
+	start := strings.Index(src, "base64,")
+	if family == "" || start < 0 {
+		return
+	}
+	encoded := src[start+len("base64,"):]
+	if end := strings.IndexAny(encoded, `")'`); end >= 0 {
+		encoded = encoded[:end]
+	}
+	data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return
+	}
+	s.fontFaces[family] = data
+}
+
+// rulesFor returns the declarations that apply to an element with the given
+// classes, in stylesheet order.
+func (s *stylesheet) rulesFor(classes []string) []declaration {
+	var rules []classRule
+	for _, class := range classes {
+		rules = append(rules, s.classes[class]...)
+	}
+	// Insertion sort keeps the usual case of one or two rules cheap.
+	for i := 1; i < len(rules); i++ {
+		for j := i; j > 0 && rules[j].order < rules[j-1].order; j-- {
+			rules[j], rules[j-1] = rules[j-1], rules[j]
+		}
+	}
+
+	var declarations []declaration
+	for _, rule := range rules {
+		declarations = append(declarations, rule.declarations...)
+	}
+	return declarations
+}
+
+// resolve substitutes var() references with the stylesheet's custom properties.
+func (s *stylesheet) resolve(value string) string {
+	for i := 0; i < 4 && strings.Contains(value, "var("); i++ {
+		start := strings.Index(value, "var(")
+		end := strings.IndexByte(value[start:], ')')
+		if end < 0 {
+			return value
+		}
+		name := strings.TrimSpace(value[start+4 : start+end])
+		fallback := ""
+		if comma := strings.IndexByte(name, ','); comma >= 0 {
+			name, fallback = strings.TrimSpace(name[:comma]), strings.TrimSpace(name[comma+1:])
+		}
+		replacement, ok := s.variables[name]
+		if !ok {
+			replacement = fallback
+		}
+		value = value[:start] + replacement + value[start+end+1:]
+	}
+	return value
+}
+
+// parseDeclarations parses the body of a CSS rule or a style attribute.
+func parseDeclarations(body string) []declaration {
+	var declarations []declaration
+	for _, part := range splitDeclarations(body) {
+		colon := strings.IndexByte(part, ':')
+		if colon < 0 {
+			continue
+		}
+		property := strings.ToLower(strings.TrimSpace(part[:colon]))
+		value := strings.TrimSpace(part[colon+1:])
+		value = strings.TrimSpace(strings.TrimSuffix(value, "!important"))
+		if property != "" && value != "" {
+			declarations = append(declarations, declaration{property: property, value: value})
+		}
+	}
+	return declarations
+}
+
+// splitDeclarations splits on semicolons outside of parentheses and quotes,
+// which keeps data URLs intact.
+func splitDeclarations(body string) []string {
+	var parts []string
+	depth := 0
+	var quote byte
+	start := 0
+	for i := 0; i < len(body); i++ {
+		c := body[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == ';' && depth == 0:
+			parts = append(parts, body[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, body[start:])
+}
+
+// matchingBrace returns the index of the brace closing the one at open.
+func matchingBrace(css string, open int) int {
+	depth := 0
+	for i := open; i < len(css); i++ {
+		switch css[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return len(css)
+}
+
+func stripComments(css string) string {
+	for {
+		start := strings.Index(css, "/*")
+		if start < 0 {
+			return css
+		}
+		end := strings.Index(css[start+2:], "*/")
+		if end < 0 {
+			return css[:start]
+		}
+		css = css[:start] + css[start+2+end+2:]
+	}
+}
+
+func unquote(value string) string {
+	return strings.Trim(strings.TrimSpace(value), `"'`)
+}
+
+// paint is a resolved fill or stroke.
+type paint struct {
+	color color.NRGBA
+	ref   string // Referenced paint server for url(#id) values
+	none  bool
+}
+
+// parsePaint parses a fill or stroke value.
+func parsePaint(value string) (paint, bool) {
+	value = strings.TrimSpace(value)
+	switch strings.ToLower(value) {
+	case "none", "transparent":
+		return paint{none: true}, true
+	}
+	if ref := urlReference(value); ref != "" {
+		return paint{ref: ref}, true
+	}
+	c, ok := parseColor(value)
+	if !ok {
+		return paint{}, false
+	}
+	return paint{color: c}, true
+}
+
+// parseColor parses hex, rgb(), rgba() and named CSS colors.
+func parseColor(value string) (color.NRGBA, bool) {
+	value = strings.ToLower(strings.TrimSpace(value))
+
+	if strings.HasPrefix(value, "#") {
+		hex := value[1:]
+		switch len(hex) {
+		case 3, 4:
+			var expanded strings.Builder
+			for _, c := range hex {
+				expanded.WriteRune(c)
+				expanded.WriteRune(c)
+			}
+			hex = expanded.String()
+		}
+		if len(hex) != 6 && len(hex) != 8 {
+			return color.NRGBA{}, false
+		}
+		v, err := strconv.ParseUint(hex, 16, 32)
+		if err != nil {
+			return color.NRGBA{}, false
+		}
+		if len(hex) == 6 {
+			return color.NRGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 255}, true
+		}
+		return color.NRGBA{R: uint8(v >> 24), G: uint8(v >> 16), B: uint8(v >> 8), A: uint8(v)}, true
+	}
+
+	if strings.HasPrefix(value, "rgb") {
+		open := strings.IndexByte(value, '(')
+		end := strings.IndexByte(value, ')')
+		if open < 0 || end < open {
+			return color.NRGBA{}, false
+		}
+		fields := strings.FieldsFunc(value[open+1:end], func(r rune) bool { return r == ',' || r == ' ' || r == '/' })
+		if len(fields) < 3 {
+			return color.NRGBA{}, false
+		}
+		var channels [4]float64
+		channels[3] = 1
+		for i := 0; i < len(fields) && i < 4; i++ {
+			field := fields[i]
+			v, err := strconv.ParseFloat(strings.TrimSuffix(field, "%"), 64)
+			if err != nil {
+				return color.NRGBA{}, false
+			}
+			if strings.HasSuffix(field, "%") {
+				v /= 100
+				if i < 3 {
+					v *= 255
+				}
+			}
+			channels[i] = v
+		}
+		return color.NRGBA{
+			R: clampByte(channels[0]),
+			G: clampByte(channels[1]),
+			B: clampByte(channels[2]),
+			A: clampByte(channels[3] * 255),
+		}, true
+	}
+
+	if value == "transparent" {
+		return color.NRGBA{}, true
+	}
+	if c, ok := colornames.Map[value]; ok {
+		return color.NRGBA{R: c.R, G: c.G, B: c.B, A: c.A}, true
+	}
+	return color.NRGBA{}, false
+}
+
+func clampByte(v float64) uint8 {
+	switch {
+	case v <= 0:
+		return 0
+	case v >= 255:
+		return 255
+	default:
+		return uint8(v + 0.5)
+	}
+}
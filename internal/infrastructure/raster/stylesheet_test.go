@@ -0,0 +1,70 @@
+package raster
+
+import (
+	"image/color"
+	"testing"
+)
+
+func TestParseColor(t *testing.T) {
+	tests := []struct {
+		value  string
+		want   color.NRGBA
+		wantOK bool
+	}{
+		{value: "#0D32B2", want: color.NRGBA{R: 0x0d, G: 0x32, B: 0xb2, A: 255}, wantOK: true},
+		{value: "#fff", want: color.NRGBA{R: 255, G: 255, B: 255, A: 255}, wantOK: true},
+		{value: "#ff000080", want: color.NRGBA{R: 255, A: 0x80}, wantOK: true},
+		{value: "rgb(1, 2, 3)", want: color.NRGBA{R: 1, G: 2, B: 3, A: 255}, wantOK: true},
+		{value: "rgba(0,0,0,0.5)", want: color.NRGBA{A: 128}, wantOK: true},
+		{value: "red", want: color.NRGBA{R: 255, A: 255}, wantOK: true},
+		{value: "transparent", want: color.NRGBA{}, wantOK: true},
+		{value: "not-a-color", wantOK: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, ok := parseColor(tt.value)
+			if ok != tt.wantOK {
+				t.Fatalf("parseColor(%q) ok = %v, want %v", tt.value, ok, tt.wantOK)
+			}
+			if ok && got != tt.want {
+				t.Errorf("parseColor(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStylesheet(t *testing.T) {
+	sheet := newStylesheet()
+	sheet.parse(`
+/* comment */
+.d2-1 .fill-B1{fill:#0D32B2;}
+.d2-1 .md h1, .d2-1 .text-bold { font-family: "d2-1-font-bold"; }
+.md{--color-fg-default:#0A0F25;--accent:var(--color-fg-default)}
+@media screen and (prefers-color-scheme:dark){.d2-1 .fill-B1{fill:#CBA6f7;}}
+.d2-1 .fill-B1{stroke:none}
+`)
+
+	got := sheet.rulesFor([]string{"shape", "fill-B1"})
+	want := []declaration{{property: "fill", value: "#0D32B2"}, {property: "stroke", value: "none"}}
+	if len(got) != len(want) {
+		t.Fatalf("rulesFor() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("rulesFor()[%d] = %v, want %v", i, got[i], want[i])
+		}
+	}
+
+	if rules := sheet.rulesFor([]string{"text-bold"}); len(rules) != 1 || rules[0].value != `"d2-1-font-bold"` {
+		t.Errorf("rulesFor(text-bold) = %v", rules)
+	}
+	if rules := sheet.rulesFor([]string{"h1"}); len(rules) != 0 {
+		t.Errorf("element selectors should be ignored, got %v", rules)
+	}
+	if got := sheet.resolve("var(--accent)"); got != "#0A0F25" {
+		t.Errorf("resolve() = %q, want #0A0F25", got)
+	}
+	if got := sheet.resolve("var(--missing, red)"); got != "red" {
+		t.Errorf("resolve() with fallback = %q, want red", got)
+	}
+}
@@ -0,0 +1,240 @@
+package raster
+
+import (
+	"image"
+	"image/color"
+	"strings"
+
+	"golang.org/x/image/font/sfnt"
+)
+
+// textRun is a piece of text drawn with a single style.
+type textRun struct {
+	text   string
+	style  style
+	family string
+	font   *sfnt.Font
+	dx, dy float64 // Offset applied before the run, from dx/dy attributes
+}
+
+// textChunk is a sequence of runs that is anchored as a whole, starting at an
+// absolutely positioned point.
+type textChunk struct {
+	origin point
+	anchor string
+	runs   []textRun
+}
+
+// renderText lays out and draws a <text> element, including its <tspan> children.
+func (r *renderer) renderText(n *node, ctm matrix, s style) {
+	origin := point{n.number("x", 0, s.fontSize), n.number("y", 0, s.fontSize)}
+	chunks := []*textChunk{{origin: origin, anchor: s.textAnchor}}
+	r.collectRuns(n, s, &chunks)
+
+	for _, chunk := range chunks {
+		trimChunk(chunk)
+		pen := chunk.origin
+		width := 0.0
+		for _, run := range chunk.runs {
+			width += run.dx + r.measure(run)
+		}
+		switch chunk.anchor {
+		case "middle":
+			pen.x -= width / 2
+		case "end":
+			pen.x -= width
+		}
+		for _, run := range chunk.runs {
+			pen.x += run.dx
+			pen.y += run.dy
+			pen.x += r.drawRun(run, pen, ctm)
+		}
+	}
+}
+
+// collectRuns flattens the character data of a text element into chunks of runs.
+func (r *renderer) collectRuns(n *node, s style, chunks *[]*textChunk) {
+	for _, child := range n.children {
+		if child.name == "" {
+			r.appendRun(chunks, child.text, s, 0, 0)
+			continue
+		}
+		if child.name != "tspan" && child.name != "a" {
+			continue
+		}
+		cs := r.computeStyle(s, child)
+		if cs.hidden {
+			continue
+		}
+
+		_, hasX := child.attrs["x"]
+		_, hasY := child.attrs["y"]
+		if hasX || hasY {
+			current := (*chunks)[len(*chunks)-1]
+			origin := r.chunkEnd(current)
+			origin.x = child.number("x", origin.x, cs.fontSize)
+			origin.y = child.number("y", origin.y, cs.fontSize)
+			*chunks = append(*chunks, &textChunk{origin: origin, anchor: cs.textAnchor})
+		}
+		dx := child.number("dx", 0, cs.fontSize)
+		dy := child.number("dy", 0, cs.fontSize)
+		if dx != 0 || dy != 0 {
+			r.appendRun(chunks, "", cs, dx, dy)
+		}
+		r.collectRuns(child, cs, chunks)
+	}
+}
+
+func (r *renderer) appendRun(chunks *[]*textChunk, text string, s style, dx, dy float64) {
+	family := s.fontFamily
+	if family == "" {
+		family = "font-regular"
+	}
+	f := r.fonts.lookup(family)
+	if s.fontFamily == "" {
+		f = r.fonts.lookupSuffix("font-regular")
+	}
+	chunk := (*chunks)[len(*chunks)-1]
+	chunk.runs = append(chunk.runs, textRun{
+		text:   collapseWhitespace(text),
+		style:  s,
+		family: family,
+		font:   f,
+		dx:     dx,
+		dy:     dy,
+	})
+}
+
+// chunkEnd returns the pen position at the end of a chunk, before anchoring.
+func (r *renderer) chunkEnd(chunk *textChunk) point {
+	p := chunk.origin
+	for _, run := range chunk.runs {
+		p.x += run.dx + r.measure(run)
+		p.y += run.dy
+	}
+	return p
+}
+
+// trimChunk removes whitespace at the start and end of a chunk and merges runs of
+// spaces that span run boundaries.
+func trimChunk(chunk *textChunk) {
+	leading := true
+	for i := range chunk.runs {
+		text := chunk.runs[i].text
+		if leading {
+			text = strings.TrimLeft(text, " ")
+		}
+		if text != "" {
+			leading = strings.HasSuffix(text, " ")
+		}
+		chunk.runs[i].text = text
+	}
+	for i := len(chunk.runs) - 1; i >= 0; i-- {
+		chunk.runs[i].text = strings.TrimRight(chunk.runs[i].text, " ")
+		if chunk.runs[i].text != "" {
+			break
+		}
+	}
+}
+
+// collapseWhitespace applies SVG's default whitespace handling to character data.
+// Non-breaking spaces, which D2 uses to indent code, are preserved.
+func collapseWhitespace(text string) string {
+	text = strings.NewReplacer("\r", "", "\n", "", "\t", " ").Replace(text)
+	var b strings.Builder
+	space := false
+	for _, c := range text {
+		if c == ' ' {
+			if !space {
+				b.WriteRune(c)
+			}
+			space = true
+			continue
+		}
+		space = false
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+// measure returns the advance width of a run.
+func (r *renderer) measure(run textRun) float64 {
+	width := 0.0
+	var prevFont *sfnt.Font
+	var prev sfnt.GlyphIndex
+	for _, c := range run.text {
+		f, index := r.fonts.glyph(run.font, run.family, c)
+		if f == nil {
+			continue
+		}
+		if f == prevFont && prev != 0 {
+			width += r.fonts.kern(f, prev, index, run.style.fontSize)
+		}
+		width += r.fonts.advance(f, index, run.style.fontSize)
+		prevFont, prev = f, index
+	}
+	return width
+}
+
+// drawRun draws a run with its baseline origin at pen and returns its advance.
+func (r *renderer) drawRun(run textRun, pen point, ctm matrix) float64 {
+	s := run.style
+	if s.invisible || run.text == "" {
+		return r.measure(run)
+	}
+	scale := ctm.scaleFactor()
+	if scale == 0 {
+		return 0
+	}
+
+	b := newPathBuilder(0.1 / scale)
+	x := pen.x
+	var prevFont *sfnt.Font
+	var prev sfnt.GlyphIndex
+	for _, c := range run.text {
+		f, index := r.fonts.glyph(run.font, run.family, c)
+		if f == nil {
+			continue
+		}
+		if f == prevFont && prev != 0 {
+			x += r.fonts.kern(f, prev, index, s.fontSize)
+		}
+		if c != ' ' && c != '\u00a0' {
+			r.fonts.outline(b, f, index, s.fontSize, point{x, pen.y})
+		}
+		x += r.fonts.advance(f, index, s.fontSize)
+		prevFont, prev = f, index
+	}
+	if strings.Contains(s.textDecoration, "underline") {
+		thickness := max(1, s.fontSize/16)
+		b.roundedRect(pen.x, pen.y+s.fontSize*0.12, x-pen.x, thickness, 0, 0)
+	}
+
+	if !s.fill.none {
+		r.canvas.fill(transformLines(b.finish(), ctm), withOpacity(s.fill.color, s.fillOpacity*s.opacity), nil)
+	}
+	return x - pen.x
+}
+
+// transformLines maps polylines from user space to device space.
+func transformLines(lines []polyline, ctm matrix) []polyline {
+	out := make([]polyline, len(lines))
+	for i, line := range lines {
+		points := make([]point, len(line.points))
+		for j, p := range line.points {
+			points[j] = ctm.apply(p)
+		}
+		out[i] = polyline{points: points, closed: line.closed}
+	}
+	return out
+}
+
+// fillRect fills an axis-aligned rectangle given in user space.
+func (r *renderer) fillRect(x, y, w, h float64, ctm matrix, c color.NRGBA, mask *image.Alpha) {
+	if w <= 0 || h <= 0 {
+		return
+	}
+	b := newPathBuilder(1)
+	b.roundedRect(x, y, w, h, 0, 0)
+	r.canvas.fill(transformLines(b.finish(), ctm), c, mask)
+}
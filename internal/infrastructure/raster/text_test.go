@@ -0,0 +1,62 @@
+package raster
+
+import (
+	"image/color"
+	"testing"
+)
+
+func TestCollapseWhitespace(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "a  b", want: "a b"},
+		{in: "a\nb", want: "ab"},
+		{in: "a\t\tb", want: "a b"},
+		{in: "  x", want: "  x"},
+	}
+	for _, tt := range tests {
+		if got := collapseWhitespace(tt.in); got != tt.want {
+			t.Errorf("collapseWhitespace(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestBundledFont(t *testing.T) {
+	for _, family := range []string{"d2-1-font-regular", "d2-1-font-bold", "d2-1-font-semibold", "d2-1-font-italic", "d2-1-font-mono"} {
+		if bundledFont(family) == nil {
+			t.Errorf("bundledFont(%q) = nil", family)
+		}
+	}
+}
+
+func TestRasterizeText(t *testing.T) {
+	svg := `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 200 40">
+<text x="100" y="30" fill="#000000" style="text-anchor:middle;font-size:24px">Hello</text>
+</svg>`
+	img, err := Rasterize([]byte(svg), Options{})
+	if err != nil {
+		t.Fatalf("Rasterize() error = %v", err)
+	}
+
+	// Anchored in the middle, the text covers the center but not the edges.
+	inked := func(x0, x1 int) bool {
+		for y := 0; y < 40; y++ {
+			for x := x0; x < x1; x++ {
+				if img.RGBAAt(x, y).A > 0 {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	if !inked(70, 130) {
+		t.Error("expected text around the anchor")
+	}
+	if inked(0, 40) || inked(160, 200) {
+		t.Error("expected no text near the edges")
+	}
+	if got := img.RGBAAt(0, 0); got != (color.RGBA{}) {
+		t.Errorf("background = %v, want transparent", got)
+	}
+}
@@ -0,0 +1,73 @@
+// Package sandbox confines file paths to a single directory.
+package sandbox
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+)
+
+// ErrOutside is returned for paths that leave the directory.
+var ErrOutside = errors.New("path is outside the directory")
+
+// Dir is a directory that file paths are confined to.
+type Dir struct {
+	path     string // Absolute path as configured
+	resolved string // path with symlinks resolved
+}
+
+// Open returns the directory at path, which must exist.
+func Open(path string) (*Dir, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	// The directory itself may be reached through symlinks, such as /tmp on macOS,
+	// so absolute paths are accepted through either location.
+	resolved, err := filepath.EvalSymlinks(abs)
+	if err != nil {
+		return nil, err
+	}
+	return &Dir{path: abs, resolved: resolved}, nil
+}
+
+// Path returns the absolute path of the directory as configured.
+func (d *Dir) Path() string {
+	return d.path
+}
+
+// Resolved returns the absolute path of the directory with symlinks resolved.
+func (d *Dir) Resolved() string {
+	return d.resolved
+}
+
+// Abs returns the absolute path of a path relative to the directory.
+func (d *Dir) Abs(rel string) string {
+	return filepath.Join(d.path, rel)
+}
+
+// Relative returns name as a clean path relative to the directory. Relative names
+// are resolved against the directory. Names that leave it through ".." fail with
+// ErrOutside. Symlinks below the directory are not resolved.
+func (d *Dir) Relative(name string) (string, error) {
+	rel := filepath.Clean(name)
+	if filepath.IsAbs(rel) {
+		abs := rel
+		if rel = Within(d.path, abs); rel == "" {
+			rel = Within(d.resolved, abs)
+		}
+	}
+	if rel == "" || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", ErrOutside
+	}
+	return rel, nil
+}
+
+// Within returns path relative to dir, or an empty string when path is not inside dir.
+func Within(dir, path string) string {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return ""
+	}
+	return rel
+}
@@ -0,0 +1,74 @@
+package sandbox
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDir_Relative(t *testing.T) {
+	base := t.TempDir()
+	path := filepath.Join(base, "dir")
+	if err := os.Mkdir(path, 0755); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(base, "link")
+	if err := os.Symlink(path, link); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+
+	// The directory is opened through a symlink, so absolute paths are accepted
+	// through the link and through the directory it points to.
+	dir, err := Open(link)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"a.d2", "a.d2"},
+		{"nested/../b.d2", "b.d2"},
+		{".", "."},
+		{filepath.Join(link, "c.d2"), "c.d2"},
+		{filepath.Join(path, "nested", "d.d2"), filepath.Join("nested", "d.d2")},
+	}
+	for _, tt := range tests {
+		got, err := dir.Relative(tt.name)
+		if err != nil {
+			t.Errorf("Relative(%s) error = %v", tt.name, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Relative(%s) = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+
+	for _, name := range []string{"..", "../escaped.d2", "nested/../../escaped.d2", filepath.Join(base, "escaped.d2")} {
+		if _, err := dir.Relative(name); !errors.Is(err, ErrOutside) {
+			t.Errorf("Relative(%s) error = %v, want ErrOutside", name, err)
+		}
+	}
+
+	if got, want := dir.Abs("a.d2"), filepath.Join(link, "a.d2"); got != want {
+		t.Errorf("Abs() = %s, want %s", got, want)
+	}
+}
+
+func TestWithin(t *testing.T) {
+	tests := []struct {
+		dir, path, want string
+	}{
+		{"/srv/d2", "/srv/d2/a.d2", "a.d2"},
+		{"/srv/d2", "/srv/d2", "."},
+		{"/srv/d2", "/srv/d2-other/a.d2", ""},
+		{"/srv/d2", "/srv", ""},
+	}
+	for _, tt := range tests {
+		if got := Within(filepath.FromSlash(tt.dir), filepath.FromSlash(tt.path)); got != filepath.FromSlash(tt.want) {
+			t.Errorf("Within(%s, %s) = %q, want %q", tt.dir, tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/i2y/d2mcp/internal/domain/entity"
+	"github.com/i2y/d2mcp/internal/usecase"
+)
+
+// BoardCreateHandler handles the d2_board_create tool.
+type BoardCreateHandler struct {
+	useCase *usecase.BoardUseCase
+}
+
+// NewBoardCreateHandler creates a new board create handler.
+func NewBoardCreateHandler(useCase *usecase.BoardUseCase) *BoardCreateHandler {
+	return &BoardCreateHandler{
+		useCase: useCase,
+	}
+}
+
+// GetTool returns the MCP tool definition.
+func (h *BoardCreateHandler) GetTool() mcp.Tool {
+	return mcp.NewTool(
+		"d2_board_create",
+		mcp.WithDescription("Add a new board to a diagram to build multi-page diagrams. Boards come in three kinds: 'layer' starts from a blank canvas (use for drill-downs such as a detailed view of one service), 'scenario' inherits everything from its parent board (use for variations such as an outage or a future state), and 'step' inherits from the previous step (use for sequential walkthroughs). Boards can be nested by passing parent_path. After creating a board, populate it with the d2_oracle_* tools using board_path."),
+		mcp.WithString("diagram_id", mcp.Description("ID of the diagram to modify"), mcp.Required()),
+		withExpectedRevision(),
+		mcp.WithString("name", mcp.Description("Name of the new board (e.g., 'network', 'outage', '1')"), mcp.Required()),
+		mcp.WithString("kind", mcp.Description("Kind of board to create: 'layer', 'scenario' or 'step'"), mcp.Enum("layer", "scenario", "step"), mcp.DefaultString("layer")),
+		mcp.WithString("parent_path", mcp.Description("Optional dot-separated path of the parent board. Leave empty to add the board to the root board")),
+	)
+}
+
+// GetHandler returns the tool handler function.
+func (h *BoardCreateHandler) GetHandler() server.ToolHandlerFunc {
+	return h.Handle
+}
+
+// Handle processes the board create request.
+func (h *BoardCreateHandler) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// Extract arguments.
+	diagramID := mcp.ParseString(request, "diagram_id", "")
+
+	ctx, revision, err := expectRevision(ctx, request, diagramID)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Invalid expected_revision", err), nil
+	}
+	name := mcp.ParseString(request, "name", "")
+	kind := entity.BoardKind(mcp.ParseString(request, "kind", string(entity.BoardLayer)))
+	parentPath := splitBoardPath(mcp.ParseString(request, "parent_path", ""))
+
+	board, err := h.useCase.CreateBoard(ctx, diagramID, parentPath, kind, name)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to create board", err), nil
+	}
+
+	return withRevision(mcp.NewToolResultText(fmt.Sprintf("Created %s '%s'. Use board_path '%s' with the d2_oracle_* tools to edit it.", board.Kind, board.Name, strings.Join(board.Path, "."))), revision.Current), nil
+}
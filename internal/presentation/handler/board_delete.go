@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/i2y/d2mcp/internal/usecase"
+)
+
+// BoardDeleteHandler handles the d2_board_delete tool.
+type BoardDeleteHandler struct {
+	useCase *usecase.BoardUseCase
+}
+
+// NewBoardDeleteHandler creates a new board delete handler.
+func NewBoardDeleteHandler(useCase *usecase.BoardUseCase) *BoardDeleteHandler {
+	return &BoardDeleteHandler{
+		useCase: useCase,
+	}
+}
+
+// GetTool returns the MCP tool definition.
+func (h *BoardDeleteHandler) GetTool() mcp.Tool {
+	return mcp.NewTool(
+		"d2_board_delete",
+		mcp.WithDescription("Remove a layer, scenario or step board from a diagram. WARNING: all boards nested inside the deleted board are removed as well. The root board cannot be deleted."),
+		mcp.WithString("diagram_id", mcp.Description("ID of the diagram to modify"), mcp.Required()),
+		withExpectedRevision(),
+		mcp.WithString("board_path", mcp.Description("Dot-separated path of the board to delete (e.g., 'network' or 'network.details')"), mcp.Required()),
+	)
+}
+
+// GetHandler returns the tool handler function.
+func (h *BoardDeleteHandler) GetHandler() server.ToolHandlerFunc {
+	return h.Handle
+}
+
+// Handle processes the board delete request.
+func (h *BoardDeleteHandler) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// Extract arguments.
+	diagramID := mcp.ParseString(request, "diagram_id", "")
+
+	ctx, revision, err := expectRevision(ctx, request, diagramID)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Invalid expected_revision", err), nil
+	}
+	boardPath := parseBoardPath(request)
+
+	if err := h.useCase.DeleteBoard(ctx, diagramID, boardPath); err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to delete board", err), nil
+	}
+
+	return withRevision(mcp.NewToolResultText(fmt.Sprintf("Board '%s' deleted successfully", mcp.ParseString(request, "board_path", ""))), revision.Current), nil
+}
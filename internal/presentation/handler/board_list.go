@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/i2y/d2mcp/internal/usecase"
+)
+
+// BoardListHandler handles the d2_board_list tool.
+type BoardListHandler struct {
+	useCase *usecase.BoardUseCase
+}
+
+// NewBoardListHandler creates a new board list handler.
+func NewBoardListHandler(useCase *usecase.BoardUseCase) *BoardListHandler {
+	return &BoardListHandler{
+		useCase: useCase,
+	}
+}
+
+// GetTool returns the MCP tool definition.
+func (h *BoardListHandler) GetTool() mcp.Tool {
+	return mcp.NewTool(
+		"d2_board_list",
+		mcp.WithDescription("List the layers, scenarios and steps of a diagram as a JSON tree. Each entry contains the board name, its kind, its full path (usable as board_path in the d2_oracle_* tools) and its nested boards. Use this to discover the structure of a multi-page diagram before editing it."),
+		mcp.WithString("diagram_id", mcp.Description("ID of the diagram"), mcp.Required()),
+	)
+}
+
+// GetHandler returns the tool handler function.
+func (h *BoardListHandler) GetHandler() server.ToolHandlerFunc {
+	return h.Handle
+}
+
+// Handle processes the board list request.
+func (h *BoardListHandler) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// Extract arguments.
+	diagramID := mcp.ParseString(request, "diagram_id", "")
+
+	ctx, revision := trackRevision(ctx, diagramID)
+	boards, err := h.useCase.ListBoards(ctx, diagramID)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to list boards", err), nil
+	}
+
+	if len(boards) == 0 {
+		return withRevision(mcp.NewToolResultText(fmt.Sprintf("Diagram '%s' has no layers, scenarios or steps", diagramID)), revision.Current), nil
+	}
+
+	jsonData, err := json.MarshalIndent(boards, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("Failed to format board tree"), nil
+	}
+
+	return withRevision(mcp.NewToolResultText(fmt.Sprintf("Boards of '%s':\n%s", diagramID, string(jsonData))), revision.Current), nil
+}
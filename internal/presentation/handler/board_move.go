@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/i2y/d2mcp/internal/usecase"
+)
+
+// BoardMoveHandler handles the d2_board_move tool.
+type BoardMoveHandler struct {
+	useCase *usecase.BoardUseCase
+}
+
+// NewBoardMoveHandler creates a new board move handler.
+func NewBoardMoveHandler(useCase *usecase.BoardUseCase) *BoardMoveHandler {
+	return &BoardMoveHandler{
+		useCase: useCase,
+	}
+}
+
+// GetTool returns the MCP tool definition.
+func (h *BoardMoveHandler) GetTool() mcp.Tool {
+	return mcp.NewTool(
+		"d2_board_move",
+		mcp.WithDescription("Reorder a board among its siblings of the same kind. Order matters for steps, which inherit from the previous step, and determines page order when exporting multi-board diagrams. Index 0 moves the board to the front; an index past the end moves it to the back."),
+		mcp.WithString("diagram_id", mcp.Description("ID of the diagram to modify"), mcp.Required()),
+		withExpectedRevision(),
+		mcp.WithString("board_path", mcp.Description("Dot-separated path of the board to move (e.g., 'network' or 'walkthrough.2')"), mcp.Required()),
+		mcp.WithNumber("index", mcp.Description("New zero-based position of the board among its siblings"), mcp.Required()),
+	)
+}
+
+// GetHandler returns the tool handler function.
+func (h *BoardMoveHandler) GetHandler() server.ToolHandlerFunc {
+	return h.Handle
+}
+
+// Handle processes the board move request.
+func (h *BoardMoveHandler) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// Extract arguments.
+	diagramID := mcp.ParseString(request, "diagram_id", "")
+
+	ctx, revision, err := expectRevision(ctx, request, diagramID)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Invalid expected_revision", err), nil
+	}
+	boardPath := parseBoardPath(request)
+	index := mcp.ParseInt(request, "index", 0)
+
+	if err := h.useCase.MoveBoard(ctx, diagramID, boardPath, index); err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to move board", err), nil
+	}
+
+	return withRevision(mcp.NewToolResultText(fmt.Sprintf("Board '%s' moved to position %d", mcp.ParseString(request, "board_path", ""), index)), revision.Current), nil
+}
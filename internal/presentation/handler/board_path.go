@@ -0,0 +1,26 @@
+package handler
+
+import (
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// boardPathDescription documents the board_path argument shared by the Oracle tools.
+const boardPathDescription = "Optional path to a layer, scenario or step board. Board names are separated by dots, e.g. 'network' or 'network.details'. Leave empty to target the root board"
+
+// parseBoardPath extracts the board_path argument as a list of board names.
+func parseBoardPath(request mcp.CallToolRequest) []string {
+	return splitBoardPath(mcp.ParseString(request, "board_path", ""))
+}
+
+// splitBoardPath splits a dot-separated board path into board names.
+func splitBoardPath(raw string) []string {
+	boardPath := []string{}
+	for _, name := range strings.Split(raw, ".") {
+		if name = strings.TrimSpace(name); name != "" {
+			boardPath = append(boardPath, name)
+		}
+	}
+	return boardPath
+}
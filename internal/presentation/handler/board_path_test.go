@@ -0,0 +1,33 @@
+package handler
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestParseBoardPath(t *testing.T) {
+	tests := []struct {
+		name      string
+		arguments map[string]any
+		want      []string
+	}{
+		{"missing", map[string]any{}, []string{}},
+		{"empty", map[string]any{"board_path": ""}, []string{}},
+		{"single board", map[string]any{"board_path": "network"}, []string{"network"}},
+		{"nested boards", map[string]any{"board_path": "network.details"}, []string{"network", "details"}},
+		{"spaces and empty names", map[string]any{"board_path": " network . .details. "}, []string{"network", "details"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := mcp.CallToolRequest{}
+			request.Params.Arguments = tt.arguments
+
+			if got := parseBoardPath(request); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
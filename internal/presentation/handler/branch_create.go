@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/i2y/d2mcp/internal/usecase"
+)
+
+// BranchCreateHandler handles the d2_branch_create tool.
+type BranchCreateHandler struct {
+	useCase *usecase.BranchUseCase
+}
+
+// NewBranchCreateHandler creates a new branch create handler.
+func NewBranchCreateHandler(useCase *usecase.BranchUseCase) *BranchCreateHandler {
+	return &BranchCreateHandler{
+		useCase: useCase,
+	}
+}
+
+// GetTool returns the MCP tool definition.
+func (h *BranchCreateHandler) GetTool() mcp.Tool {
+	return mcp.NewTool(
+		"d2_branch_create",
+		mcp.WithDescription("Fork a diagram into a branch: a new stored diagram that starts as a copy and can be edited independently with all the d2_oracle_* tools, using the branch ID as diagram_id. Use this to try out a larger change, or to let several agents work on the same diagram without stepping on each other, then bring the changes back with d2_branch_merge."),
+		mcp.WithString("diagram_id", mcp.Description("ID of the diagram to fork"), mcp.Required()),
+		mcp.WithString("branch_id", mcp.Description("ID of the new branch diagram (e.g., 'my-diagram-caching')"), mcp.Required()),
+	)
+}
+
+// GetHandler returns the tool handler function.
+func (h *BranchCreateHandler) GetHandler() server.ToolHandlerFunc {
+	return h.Handle
+}
+
+// Handle processes the branch create request.
+func (h *BranchCreateHandler) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// Extract arguments.
+	diagramID := mcp.ParseString(request, "diagram_id", "")
+	branchID := mcp.ParseString(request, "branch_id", "")
+
+	ctx, revision := trackRevision(ctx, branchID)
+	branch, err := h.useCase.CreateBranch(ctx, diagramID, branchID)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to create branch", err), nil
+	}
+
+	return withRevision(mcp.NewToolResultText(fmt.Sprintf("Created branch '%s' of diagram '%s'. Edit it with diagram_id '%s' and merge it back with d2_branch_merge.", branch.ID, branch.DiagramID, branch.ID)), revision.Current), nil
+}
@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/i2y/d2mcp/internal/usecase"
+)
+
+// BranchListHandler handles the d2_branch_list tool.
+type BranchListHandler struct {
+	useCase *usecase.BranchUseCase
+}
+
+// NewBranchListHandler creates a new branch list handler.
+func NewBranchListHandler(useCase *usecase.BranchUseCase) *BranchListHandler {
+	return &BranchListHandler{
+		useCase: useCase,
+	}
+}
+
+// branchSummary describes a branch in the d2_branch_list output.
+type branchSummary struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// GetTool returns the MCP tool definition.
+func (h *BranchListHandler) GetTool() mcp.Tool {
+	return mcp.NewTool(
+		"d2_branch_list",
+		mcp.WithDescription("List the branches forked from a diagram with d2_branch_create, oldest first."),
+		mcp.WithString("diagram_id", mcp.Description("ID of the diagram the branches were forked from"), mcp.Required()),
+	)
+}
+
+// GetHandler returns the tool handler function.
+func (h *BranchListHandler) GetHandler() server.ToolHandlerFunc {
+	return h.Handle
+}
+
+// Handle processes the branch list request.
+func (h *BranchListHandler) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// Extract arguments.
+	diagramID := mcp.ParseString(request, "diagram_id", "")
+
+	ctx, revision := trackRevision(ctx, diagramID)
+	branches, err := h.useCase.ListBranches(ctx, diagramID)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to list branches", err), nil
+	}
+
+	if len(branches) == 0 {
+		return withRevision(mcp.NewToolResultText(fmt.Sprintf("Diagram '%s' has no branches", diagramID)), revision.Current), nil
+	}
+
+	summaries := make([]branchSummary, len(branches))
+	for i, branch := range branches {
+		summaries[i] = branchSummary{
+			ID:        branch.ID,
+			CreatedAt: branch.CreatedAt,
+		}
+	}
+
+	jsonData, err := json.MarshalIndent(summaries, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("Failed to format branch list"), nil
+	}
+
+	return withRevision(mcp.NewToolResultText(fmt.Sprintf("Branches of '%s':\n%s", diagramID, string(jsonData))), revision.Current), nil
+}
@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/i2y/d2mcp/internal/usecase"
+)
+
+// BranchMergeHandler handles the d2_branch_merge tool.
+type BranchMergeHandler struct {
+	useCase *usecase.BranchUseCase
+}
+
+// NewBranchMergeHandler creates a new branch merge handler.
+func NewBranchMergeHandler(useCase *usecase.BranchUseCase) *BranchMergeHandler {
+	return &BranchMergeHandler{
+		useCase: useCase,
+	}
+}
+
+// mergeConflict is the JSON form of a merge conflict. Null values are unset.
+type mergeConflict struct {
+	Kind      string `json:"kind"`
+	BoardPath string `json:"board_path,omitempty"`
+	ElementID string `json:"element_id"`
+	Attribute string `json:"attribute,omitempty"`
+	Base      any    `json:"base"`
+	Diagram   any    `json:"diagram"`
+	Branch    any    `json:"branch"`
+	Message   string `json:"message"`
+}
+
+// GetTool returns the MCP tool definition.
+func (h *BranchMergeHandler) GetTool() mcp.Tool {
+	return mcp.NewTool(
+		"d2_branch_merge",
+		mcp.WithDescription("Merge the changes made in a branch back into the diagram it was forked from. This is a three-way merge of shapes, connections and attributes on every board, including layers, scenarios and steps, using the fork point as the base, so changes made to the diagram in the meantime are kept. Boards cannot be added or deleted by a merge: a board added or deleted only in the branch is reported as a conflict until the diagram gets the same board change. If both sides changed the same attribute differently, or one side deleted an element the other modified, nothing is merged and the conflicts are returned as JSON entries with the base, diagram and branch values; resolve them by editing either side and merge again. A successful merge is a single change that d2_undo can revert, and the branch can keep being edited and merged again."),
+		mcp.WithString("branch_id", mcp.Description("ID of the branch to merge"), mcp.Required()),
+		mcp.WithNumber("expected_revision", mcp.Description("Optional revision of the diagram merged into that the merge is based on. "+expectedRevisionDescription), mcp.Min(0)),
+	)
+}
+
+// GetHandler returns the tool handler function.
+func (h *BranchMergeHandler) GetHandler() server.ToolHandlerFunc {
+	return h.Handle
+}
+
+// Handle processes the branch merge request.
+func (h *BranchMergeHandler) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// Extract arguments.
+	branchID := mcp.ParseString(request, "branch_id", "")
+	expectedRevision, err := parseExpectedRevision(request)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Invalid expected_revision", err), nil
+	}
+
+	result, err := h.useCase.MergeBranch(ctx, branchID, expectedRevision)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to merge branch", err), nil
+	}
+
+	if result.Merged {
+		return withRevision(mcp.NewToolResultText(fmt.Sprintf("Merged branch '%s' into '%s' (%d operations applied)", result.BranchID, result.DiagramID, result.Operations)), result.Revision), nil
+	}
+
+	conflicts := make([]mergeConflict, len(result.Conflicts))
+	messages := make([]string, len(result.Conflicts))
+	for i, conflict := range result.Conflicts {
+		conflicts[i] = mergeConflict{
+			Kind:      string(conflict.Kind),
+			BoardPath: strings.Join(conflict.BoardPath, "."),
+			ElementID: conflict.ElementID,
+			Attribute: conflict.Attribute,
+			Base:      conflict.Base,
+			Diagram:   conflict.Target,
+			Branch:    conflict.Branch,
+			Message:   conflict.Message,
+		}
+		messages[i] = "- " + conflict.Message
+	}
+
+	jsonData, err := json.MarshalIndent(conflicts, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("Failed to format merge conflicts"), nil
+	}
+
+	summary := fmt.Sprintf("Branch '%s' was not merged into '%s' because of %d conflicts:\n%s", result.BranchID, result.DiagramID, len(conflicts), strings.Join(messages, "\n"))
+	return withRevision(&mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.NewTextContent(summary),
+			mcp.NewTextContent(string(jsonData)),
+		},
+		IsError: true,
+	}, result.Revision), nil
+}
@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/i2y/d2mcp/internal/usecase"
+)
+
+// CloneHandler handles the d2_clone tool.
+type CloneHandler struct {
+	useCase *usecase.DiagramUseCase
+}
+
+// NewCloneHandler creates a new clone handler.
+func NewCloneHandler(useCase *usecase.DiagramUseCase) *CloneHandler {
+	return &CloneHandler{
+		useCase: useCase,
+	}
+}
+
+// GetTool returns the MCP tool definition.
+func (h *CloneHandler) GetTool() mcp.Tool {
+	return mcp.NewTool(
+		"d2_clone",
+		mcp.WithDescription("Copy a diagram and its render defaults to a new, independent diagram. Unlike d2_branch_create, the copy keeps no link to the original and cannot be merged back. Undo history and snapshots are not copied."),
+		mcp.WithString("diagram_id", mcp.Description("ID of the diagram to copy"), mcp.Required()),
+		mcp.WithString("new_id", mcp.Description("ID of the new diagram"), mcp.Required()),
+	)
+}
+
+// GetHandler returns the tool handler function.
+func (h *CloneHandler) GetHandler() server.ToolHandlerFunc {
+	return h.Handle
+}
+
+// Handle processes the clone request.
+func (h *CloneHandler) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// Extract arguments.
+	diagramID := mcp.ParseString(request, "diagram_id", "")
+	newID := mcp.ParseString(request, "new_id", "")
+
+	ctx, revision := trackRevision(ctx, newID)
+	if err := h.useCase.CloneDiagram(ctx, diagramID, newID); err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to clone diagram", err), nil
+	}
+
+	return withRevision(mcp.NewToolResultText(fmt.Sprintf("Diagram '%s' copied to '%s'", diagramID, newID)), revision.Current), nil
+}
@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/i2y/d2mcp/internal/domain/entity"
+	"github.com/i2y/d2mcp/internal/usecase"
+)
+
+// defaultThumbnailSize is the size of the box thumbnails are fit into, in pixels.
+const defaultThumbnailSize = 256
+
+// thumbnailToolOptions returns the thumbnail arguments shared by d2_export and d2_save.
+func thumbnailToolOptions() []mcp.ToolOption {
+	return []mcp.ToolOption{
+		mcp.WithBoolean("thumbnail", mcp.Description("Also return a small PNG preview of the diagram as image content")),
+		mcp.WithNumber("thumbnail_size", mcp.Description("Size of the square box the thumbnail is fit into, in pixels (default 256)")),
+	}
+}
+
+// exportContent wraps exported data in the MCP content type that suits its format:
+// text for SVG, image content for PNG and GIF, and an embedded blob resource for
+// PDF and PPTX documents. The blob carries the URI of the diagram's pdf or pptx
+// resource, so clients can read the document again later.
+func exportContent(diagramID string, format entity.ExportFormat, data []byte) []mcp.Content {
+	switch format {
+	case entity.FormatSVG, entity.FormatAnimatedSVG, "":
+		return []mcp.Content{mcp.NewTextContent(string(data))}
+	case entity.FormatPNG, entity.FormatGIF:
+		return []mcp.Content{
+			mcp.NewTextContent(fmt.Sprintf("Exported diagram %s as %s (%d bytes)", diagramID, format, len(data))),
+			mcp.NewImageContent(base64.StdEncoding.EncodeToString(data), getMimeType(format)),
+		}
+	default:
+		return []mcp.Content{
+			mcp.NewTextContent(fmt.Sprintf("Exported diagram %s as %s (%d bytes)", diagramID, format, len(data))),
+			mcp.NewEmbeddedResource(mcp.BlobResourceContents{
+				URI:      diagramResourceURI(diagramID, string(format)),
+				MIMEType: getMimeType(format),
+				Blob:     base64.StdEncoding.EncodeToString(data),
+			}),
+		}
+	}
+}
+
+// thumbnailContent renders a PNG preview of the diagram when the request asks for one.
+// It keeps the render options of the main export and returns nil when no thumbnail
+// was requested.
+func thumbnailContent(ctx context.Context, useCase *usecase.DiagramUseCase, diagramID string, request mcp.CallToolRequest, renderOpts *entity.RenderOptions) (mcp.Content, error) {
+	if !mcp.ParseBoolean(request, "thumbnail", false) {
+		return nil, nil
+	}
+	size := mcp.ParseInt(request, "thumbnail_size", defaultThumbnailSize)
+	if size <= 0 {
+		return nil, fmt.Errorf("thumbnail_size must be greater than zero")
+	}
+
+	// The thumbnail keeps the requested look but always uses the built-in rasterizer.
+	opts := &entity.RenderOptions{}
+	if renderOpts != nil {
+		*opts = *renderOpts
+	}
+	opts.Raster = &entity.RasterOptions{Width: size, Height: size, Backend: entity.RasterNative}
+
+	reader, err := useCase.ExportDiagram(ctx, diagramID, entity.FormatPNG, opts)
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewImageContent(base64.StdEncoding.EncodeToString(data), "image/png"), nil
+}
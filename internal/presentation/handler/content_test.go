@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"io"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/i2y/d2mcp/internal/domain/entity"
+)
+
+func TestExportContent(t *testing.T) {
+	data := []byte("data")
+
+	t.Run("svg", func(t *testing.T) {
+		content := exportContent("net", entity.FormatSVG, []byte("<svg/>"))
+		if len(content) != 1 {
+			t.Fatalf("Expected 1 content, got %d", len(content))
+		}
+		if text, ok := content[0].(mcp.TextContent); !ok || text.Text != "<svg/>" {
+			t.Errorf("Expected the SVG as text, got %#v", content[0])
+		}
+	})
+
+	t.Run("png", func(t *testing.T) {
+		content := exportContent("net", entity.FormatPNG, data)
+		if len(content) != 2 {
+			t.Fatalf("Expected 2 contents, got %d", len(content))
+		}
+		image, ok := content[1].(mcp.ImageContent)
+		if !ok {
+			t.Fatalf("Expected image content, got %T", content[1])
+		}
+		if image.MIMEType != "image/png" || image.Data != base64.StdEncoding.EncodeToString(data) {
+			t.Errorf("Unexpected image content %#v", image)
+		}
+	})
+
+	for _, format := range []entity.ExportFormat{entity.FormatPDF, entity.FormatPPTX} {
+		t.Run(string(format), func(t *testing.T) {
+			content := exportContent("team/net", format, data)
+			if len(content) != 2 {
+				t.Fatalf("Expected 2 contents, got %d", len(content))
+			}
+			embedded, ok := content[1].(mcp.EmbeddedResource)
+			if !ok {
+				t.Fatalf("Expected an embedded resource, got %T", content[1])
+			}
+			blob, ok := embedded.Resource.(mcp.BlobResourceContents)
+			if !ok {
+				t.Fatalf("Expected blob contents, got %T", embedded.Resource)
+			}
+			if want := "d2://diagram/team%2Fnet/" + string(format); blob.URI != want {
+				t.Errorf("Expected URI %s, got %s", want, blob.URI)
+			}
+			if blob.MIMEType != getMimeType(format) {
+				t.Errorf("Expected MIME type %s, got %s", getMimeType(format), blob.MIMEType)
+			}
+		})
+	}
+}
+
+func TestExportContent_DocumentURIsAreReadable(t *testing.T) {
+	ctx := context.Background()
+	server := newTestServer(t)
+	if err := server.diagramUseCase.Create(ctx, "team/net", "router -> firewall"); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	tests := []struct {
+		format entity.ExportFormat
+		magic  []byte
+	}{
+		{entity.FormatPDF, []byte("%PDF")},
+		{entity.FormatPPTX, []byte("PK")},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			reader, err := server.diagramUseCase.ExportDiagram(ctx, "team/net", tt.format, nil)
+			if err != nil {
+				t.Fatalf("ExportDiagram failed: %v", err)
+			}
+			data, err := io.ReadAll(reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+			uri := exportContent("team/net", tt.format, data)[1].(mcp.EmbeddedResource).Resource.(mcp.BlobResourceContents).URI
+
+			contents := server.read(t, ctx, uri)
+			if len(contents) != 1 {
+				t.Fatalf("Expected 1 content, got %d", len(contents))
+			}
+			blob, ok := contents[0].(mcp.BlobResourceContents)
+			if !ok {
+				t.Fatalf("Expected blob contents, got %T", contents[0])
+			}
+			if blob.MIMEType != getMimeType(tt.format) {
+				t.Errorf("Expected MIME type %s, got %s", getMimeType(tt.format), blob.MIMEType)
+			}
+			document, err := base64.StdEncoding.DecodeString(blob.Blob)
+			if err != nil {
+				t.Fatalf("Failed to decode blob: %v", err)
+			}
+			if !bytes.HasPrefix(document, tt.magic) {
+				t.Errorf("Expected a %s document, got %q", tt.format, document[:min(len(document), 8)])
+			}
+		})
+	}
+}
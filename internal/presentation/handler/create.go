@@ -25,12 +25,15 @@ func NewCreateHandler(useCase *usecase.DiagramUseCase) *CreateHandler {
 
 // GetTool returns the MCP tool definition.
 func (h *CreateHandler) GetTool() mcp.Tool {
-	return mcp.NewTool(
-		"d2_create",
+	opts := []mcp.ToolOption{
 		mcp.WithDescription("Create a new diagram that can be edited with Oracle API tools. This is the unified way to create diagrams:\n\n1. Empty diagram (no content): For building incrementally with Oracle API\n2. From D2 text (with content): For rendering complete D2 diagrams\n\nBoth types are fully editable using d2_oracle_* tools.\n\nExamples:\n- d2_create(id=\"arch\") → Empty diagram for incremental building\n- d2_create(id=\"arch\", content=\"a -> b\") → Diagram from D2 text\n\nUse cases:\n- Building diagrams from data sources (use empty)\n- Rendering complete D2 text (use with content)\n- Converting existing D2 to editable form (use with content)\n- Interactive diagram creation (use empty)"),
 		mcp.WithString("id", mcp.Description("Unique identifier for the diagram"), mcp.Required()),
-		mcp.WithString("content", mcp.Description("Optional D2 text content. If provided, creates a diagram from this content (which can then be edited with Oracle API). If not provided, creates an empty diagram for incremental building. Both are fully editable."), mcp.DefaultString("")),
-	)
+		withExpectedRevision(),
+		mcp.WithString("content", mcp.Description("Optional D2 text content. If provided, creates a diagram from this content (which can then be edited with Oracle API). If not provided, creates an empty diagram for incremental building. Both are fully editable. The content may import other stored diagrams by ID, e.g. '...@shared-styles' or 'infra: @network'."), mcp.DefaultString("")),
+	}
+	opts = append(opts, renderToolOptions()...)
+
+	return mcp.NewTool("d2_create", opts...)
 }
 
 // GetHandler returns the tool handler function.
@@ -46,15 +49,21 @@ func (h *CreateHandler) Handle(ctx context.Context, request mcp.CallToolRequest)
 		return mcp.NewToolResultError("id is required"), nil
 	}
 
+	ctx, revision, err := expectRevision(ctx, request, id)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Invalid expected_revision", err), nil
+	}
+
 	content := mcp.ParseString(request, "content", "")
 
 	// Create the diagram.
 	diagram := &entity.Diagram{
 		ID:      id,
 		Content: content,
+		Options: parseRenderOptions(request),
 	}
 
-	err := h.useCase.CreateDiagram(ctx, diagram)
+	err = h.useCase.CreateDiagram(ctx, diagram)
 	if err != nil {
 		return mcp.NewToolResultErrorFromErr("Failed to create diagram", err), nil
 	}
@@ -67,5 +76,5 @@ func (h *CreateHandler) Handle(ctx context.Context, request mcp.CallToolRequest)
 		message = fmt.Sprintf("Empty diagram '%s' created successfully. Use d2_oracle_create to add shapes and connections.", id)
 	}
 
-	return mcp.NewToolResultText(message), nil
+	return withRevision(mcp.NewToolResultText(message), revision.Current), nil
 }
@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/i2y/d2mcp/internal/usecase"
+)
+
+// DeleteHandler handles the d2_delete tool.
+type DeleteHandler struct {
+	useCase *usecase.DiagramUseCase
+}
+
+// NewDeleteHandler creates a new delete handler.
+func NewDeleteHandler(useCase *usecase.DiagramUseCase) *DeleteHandler {
+	return &DeleteHandler{
+		useCase: useCase,
+	}
+}
+
+// GetTool returns the MCP tool definition.
+func (h *DeleteHandler) GetTool() mcp.Tool {
+	return mcp.NewTool(
+		"d2_delete",
+		mcp.WithDescription("Delete a stored diagram together with its undo history and snapshots. This cannot be undone; take a copy with d2_clone first if the diagram may still be needed. Diagrams that other stored diagrams import cannot be deleted; the error names the importers, whose imports of it must be removed first."),
+		mcp.WithString("diagram_id", mcp.Description("ID of the diagram to delete"), mcp.Required()),
+		withExpectedRevision(),
+	)
+}
+
+// GetHandler returns the tool handler function.
+func (h *DeleteHandler) GetHandler() server.ToolHandlerFunc {
+	return h.Handle
+}
+
+// Handle processes the delete request.
+func (h *DeleteHandler) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// Extract arguments.
+	diagramID := mcp.ParseString(request, "diagram_id", "")
+
+	ctx, _, err := expectRevision(ctx, request, diagramID)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Invalid expected_revision", err), nil
+	}
+
+	if err := h.useCase.DeleteDiagram(ctx, diagramID); err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to delete diagram", err), nil
+	}
+
+	return mcp.NewToolResultText(fmt.Sprintf("Diagram '%s' deleted successfully", diagramID)), nil
+}
@@ -0,0 +1,325 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/i2y/d2mcp/internal/domain/entity"
+	"github.com/i2y/d2mcp/internal/usecase"
+)
+
+// DiffHandler handles the d2_diff tool.
+type DiffHandler struct {
+	useCase *usecase.DiffUseCase
+}
+
+// NewDiffHandler creates a new diff handler.
+func NewDiffHandler(useCase *usecase.DiffUseCase) *DiffHandler {
+	return &DiffHandler{
+		useCase: useCase,
+	}
+}
+
+// diffOutput is the JSON form of a structural diff. Objects and edges of all
+// boards are listed together, each with the path of its board.
+type diffOutput struct {
+	From    string       `json:"from"`
+	To      string       `json:"to"`
+	Objects elementsDiff `json:"objects"`
+	Edges   elementsDiff `json:"edges"`
+	Boards  boardsDiff   `json:"boards"`
+}
+
+// elementsDiff lists the objects or edges that were added, removed or changed.
+type elementsDiff struct {
+	Added   []any           `json:"added"`
+	Removed []any           `json:"removed"`
+	Changed []elementChange `json:"changed"`
+}
+
+// boardsDiff lists the boards that were added or removed.
+type boardsDiff struct {
+	Added   []diffBoard `json:"added"`
+	Removed []diffBoard `json:"removed"`
+}
+
+// diffBoard describes an added or removed board.
+type diffBoard struct {
+	Path string `json:"path"`
+	Kind string `json:"kind"`
+}
+
+// diffObject describes an added or removed object. Board is empty for the root board.
+type diffObject struct {
+	Board      string         `json:"board,omitempty"`
+	ID         string         `json:"id"`
+	Label      string         `json:"label,omitempty"`
+	Shape      string         `json:"shape,omitempty"`
+	Parent     string         `json:"parent,omitempty"`
+	Attributes map[string]any `json:"attributes,omitempty"`
+}
+
+// diffEdge describes an added or removed edge. Board is empty for the root board.
+type diffEdge struct {
+	Board      string         `json:"board,omitempty"`
+	ID         string         `json:"id"`
+	From       string         `json:"from"`
+	To         string         `json:"to"`
+	Label      string         `json:"label,omitempty"`
+	Attributes map[string]any `json:"attributes,omitempty"`
+}
+
+// elementChange lists the attributes of an object or edge that changed. Board is
+// empty for the root board.
+type elementChange struct {
+	Board      string            `json:"board,omitempty"`
+	ID         string            `json:"id"`
+	Attributes []attributeChange `json:"attributes"`
+}
+
+// attributeChange is the old and new value of an attribute. A null value means
+// the attribute is not set.
+type attributeChange struct {
+	Name   string `json:"name"`
+	Before any    `json:"before"`
+	After  any    `json:"after"`
+}
+
+// boardChanges is the part of a diff that belongs to one board.
+type boardChanges struct {
+	board string // Dot-separated board path; empty for the root board
+	diff  *entity.DiagramDiff
+}
+
+// diffBoards returns the changes of the root board followed by those within
+// each nested board.
+func diffBoards(diff *entity.DiagramDiff) []boardChanges {
+	boards := []boardChanges{{diff: diff}}
+	for _, board := range diff.Boards {
+		boards = append(boards, boardChanges{board: strings.Join(board.Path, "."), diff: board.Diff})
+	}
+	return boards
+}
+
+// GetTool returns the MCP tool definition.
+func (h *DiffHandler) GetTool() mcp.Tool {
+	return mcp.NewTool(
+		"d2_diff",
+		mcp.WithDescription("Compare two versions of a diagram structurally and list the objects and connections that were added, removed or changed, with the before and after value of every changed attribute. Each version is a stored diagram, a snapshot of a stored diagram, or D2 text. Use this to review exactly what an edit changed instead of comparing D2 text by eye, e.g. compare snapshot 'before-refactor' with the current diagram. Returns a readable summary followed by the diff as JSON. Objects are matched by their full key and connections by their D2 ID such as '(a -> b)[0]', so a moved object appears as removed and added. Layers, scenarios and steps are compared too, and changes inside them carry their board path."),
+		mcp.WithString("from_diagram_id", mcp.Description("ID of the stored diagram to compare from")),
+		mcp.WithString("from_snapshot", mcp.Description("Name of a snapshot of from_diagram_id to compare from, instead of its current state")),
+		mcp.WithString("from_content", mcp.Description("D2 text to compare from, instead of a stored diagram")),
+		mcp.WithString("to_diagram_id", mcp.Description("ID of the stored diagram to compare to. Defaults to from_diagram_id")),
+		mcp.WithString("to_snapshot", mcp.Description("Name of a snapshot to compare to. Leave empty to compare to the current state of the diagram")),
+		mcp.WithString("to_content", mcp.Description("D2 text to compare to, instead of a stored diagram")),
+	)
+}
+
+// GetHandler returns the tool handler function.
+func (h *DiffHandler) GetHandler() server.ToolHandlerFunc {
+	return h.Handle
+}
+
+// Handle processes the diff request.
+func (h *DiffHandler) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// Extract arguments.
+	from := entity.DiagramVersion{
+		DiagramID: mcp.ParseString(request, "from_diagram_id", ""),
+		Snapshot:  mcp.ParseString(request, "from_snapshot", ""),
+		Content:   mcp.ParseString(request, "from_content", ""),
+	}
+	to := entity.DiagramVersion{
+		DiagramID: mcp.ParseString(request, "to_diagram_id", ""),
+		Snapshot:  mcp.ParseString(request, "to_snapshot", ""),
+		Content:   mcp.ParseString(request, "to_content", ""),
+	}
+
+	diff, err := h.useCase.Diff(ctx, from, to)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to compare diagrams", err), nil
+	}
+
+	// Keep the arrows in connection IDs readable instead of escaping them.
+	var data strings.Builder
+	encoder := json.NewEncoder(&data)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(newDiffOutput(diff)); err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to encode diff", err), nil
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.NewTextContent(formatDiffSummary(diff)),
+			mcp.NewTextContent(strings.TrimSuffix(data.String(), "\n")),
+		},
+	}, nil
+}
+
+// describeVersion returns a short name for a diagram version.
+func describeVersion(version entity.DiagramVersion) string {
+	switch {
+	case version.DiagramID == "":
+		return "D2 content"
+	case version.Snapshot != "":
+		return fmt.Sprintf("snapshot '%s' of '%s'", version.Snapshot, version.DiagramID)
+	default:
+		return fmt.Sprintf("diagram '%s'", version.DiagramID)
+	}
+}
+
+// newDiffOutput converts a diff to its JSON form.
+func newDiffOutput(diff *entity.DiagramDiff) diffOutput {
+	output := diffOutput{
+		From:    describeVersion(diff.From),
+		To:      describeVersion(diff.To),
+		Objects: elementsDiff{Added: []any{}, Removed: []any{}, Changed: []elementChange{}},
+		Edges:   elementsDiff{Added: []any{}, Removed: []any{}, Changed: []elementChange{}},
+		Boards:  boardsDiff{Added: newDiffBoards(diff.AddedBoards), Removed: newDiffBoards(diff.RemovedBoards)},
+	}
+	for _, changes := range diffBoards(diff) {
+		for _, obj := range changes.diff.AddedObjects {
+			output.Objects.Added = append(output.Objects.Added, newDiffObject(changes.board, obj))
+		}
+		for _, obj := range changes.diff.RemovedObjects {
+			output.Objects.Removed = append(output.Objects.Removed, newDiffObject(changes.board, obj))
+		}
+		output.Objects.Changed = append(output.Objects.Changed, newElementChanges(changes.board, changes.diff.ChangedObjects)...)
+		for _, edge := range changes.diff.AddedEdges {
+			output.Edges.Added = append(output.Edges.Added, newDiffEdge(changes.board, edge))
+		}
+		for _, edge := range changes.diff.RemovedEdges {
+			output.Edges.Removed = append(output.Edges.Removed, newDiffEdge(changes.board, edge))
+		}
+		output.Edges.Changed = append(output.Edges.Changed, newElementChanges(changes.board, changes.diff.ChangedEdges)...)
+	}
+	return output
+}
+
+func newDiffBoards(boards []*entity.Board) []diffBoard {
+	result := make([]diffBoard, len(boards))
+	for i, board := range boards {
+		result[i] = diffBoard{Path: strings.Join(board.Path, "."), Kind: string(board.Kind)}
+	}
+	return result
+}
+
+func newDiffObject(board string, obj *entity.GraphObject) diffObject {
+	return diffObject{
+		Board:      board,
+		ID:         obj.ID,
+		Label:      obj.Label,
+		Shape:      obj.Shape,
+		Parent:     obj.Parent,
+		Attributes: obj.Attributes,
+	}
+}
+
+func newDiffEdge(board string, edge *entity.GraphEdge) diffEdge {
+	return diffEdge{
+		Board:      board,
+		ID:         edge.ID,
+		From:       edge.From,
+		To:         edge.To,
+		Label:      edge.Label,
+		Attributes: edge.Attributes,
+	}
+}
+
+func newElementChanges(board string, changes []*entity.ElementChange) []elementChange {
+	result := make([]elementChange, len(changes))
+	for i, change := range changes {
+		result[i] = elementChange{Board: board, ID: change.ID}
+		for _, attribute := range change.Attributes {
+			result[i].Attributes = append(result[i].Attributes, attributeChange{
+				Name:   attribute.Name,
+				Before: attribute.Before,
+				After:  attribute.After,
+			})
+		}
+	}
+	return result
+}
+
+// formatDiffSummary describes a diff as one line per added, removed or changed
+// element or board. Elements of nested boards are prefixed with the board path.
+func formatDiffSummary(diff *entity.DiagramDiff) string {
+	from, to := describeVersion(diff.From), describeVersion(diff.To)
+	if diff.Empty() {
+		return fmt.Sprintf("No structural differences between %s and %s", from, to)
+	}
+
+	boards := diffBoards(diff)
+	var objects, edges [3]int
+	for _, changes := range boards {
+		objects[0] += len(changes.diff.AddedObjects)
+		objects[1] += len(changes.diff.RemovedObjects)
+		objects[2] += len(changes.diff.ChangedObjects)
+		edges[0] += len(changes.diff.AddedEdges)
+		edges[1] += len(changes.diff.RemovedEdges)
+		edges[2] += len(changes.diff.ChangedEdges)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Changes from %s to %s:\n", from, to)
+	fmt.Fprintf(&b, "Objects: %d added, %d removed, %d changed\n", objects[0], objects[1], objects[2])
+	fmt.Fprintf(&b, "Connections: %d added, %d removed, %d changed\n", edges[0], edges[1], edges[2])
+	if len(diff.AddedBoards) > 0 || len(diff.RemovedBoards) > 0 {
+		fmt.Fprintf(&b, "Boards: %d added, %d removed\n", len(diff.AddedBoards), len(diff.RemovedBoards))
+	}
+
+	for _, board := range diff.AddedBoards {
+		fmt.Fprintf(&b, "+ %s %s\n", board.Kind, strings.Join(board.Path, "."))
+	}
+	for _, board := range diff.RemovedBoards {
+		fmt.Fprintf(&b, "- %s %s\n", board.Kind, strings.Join(board.Path, "."))
+	}
+	for _, changes := range boards {
+		prefix := ""
+		if changes.board != "" {
+			prefix = "[" + changes.board + "] "
+		}
+		for _, obj := range changes.diff.AddedObjects {
+			fmt.Fprintf(&b, "+ %s%s\n", prefix, obj.ID)
+		}
+		for _, obj := range changes.diff.RemovedObjects {
+			fmt.Fprintf(&b, "- %s%s\n", prefix, obj.ID)
+		}
+		for _, change := range changes.diff.ChangedObjects {
+			fmt.Fprintf(&b, "~ %s%s: %s\n", prefix, change.ID, formatAttributeChanges(change.Attributes))
+		}
+		for _, edge := range changes.diff.AddedEdges {
+			fmt.Fprintf(&b, "+ %s%s\n", prefix, edge.ID)
+		}
+		for _, edge := range changes.diff.RemovedEdges {
+			fmt.Fprintf(&b, "- %s%s\n", prefix, edge.ID)
+		}
+		for _, change := range changes.diff.ChangedEdges {
+			fmt.Fprintf(&b, "~ %s%s: %s\n", prefix, change.ID, formatAttributeChanges(change.Attributes))
+		}
+	}
+
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// formatAttributeChanges describes attribute changes as name old -> new pairs.
+func formatAttributeChanges(changes []*entity.AttributeChange) string {
+	parts := make([]string, len(changes))
+	for i, change := range changes {
+		parts[i] = fmt.Sprintf("%s %s -> %s", change.Name, formatAttributeValue(change.Before), formatAttributeValue(change.After))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// formatAttributeValue quotes a value, or reports that it is not set.
+func formatAttributeValue(value any) string {
+	if value == nil {
+		return "(unset)"
+	}
+	return fmt.Sprintf("%q", fmt.Sprint(value))
+}
@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/i2y/d2mcp/internal/domain/entity"
+	"github.com/i2y/d2mcp/internal/infrastructure/d2"
+	"github.com/i2y/d2mcp/internal/usecase"
+)
+
+func TestDiffHandler_Boards(t *testing.T) {
+	ctx := context.Background()
+	repo := d2.NewD2OracleRepository()
+	if err := usecase.NewDiagramUseCase(repo).Create(ctx, "net", "web\nlayers: {\n  net: {\n    router\n  }\n  old: {\n    a\n  }\n}\n"); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if _, err := usecase.NewSnapshotUseCase(repo).CreateSnapshot(ctx, "net", "s1"); err != nil {
+		t.Fatalf("CreateSnapshot failed: %v", err)
+	}
+
+	oracleUseCase := usecase.NewOracleUseCase(repo)
+	if _, err := oracleUseCase.CreateElement(ctx, &entity.OracleOperation{DiagramID: "net", BoardPath: []string{"net"}, Key: "firewall"}); err != nil {
+		t.Fatalf("CreateElement failed: %v", err)
+	}
+	if err := usecase.NewBoardUseCase(repo).DeleteBoard(ctx, "net", []string{"old"}); err != nil {
+		t.Fatalf("DeleteBoard failed: %v", err)
+	}
+
+	h := NewDiffHandler(usecase.NewDiffUseCase(repo, repo))
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = map[string]any{"from_diagram_id": "net", "from_snapshot": "s1"}
+	result, err := h.Handle(ctx, request)
+	if err != nil {
+		t.Fatalf("Handle failed: %v", err)
+	}
+	if result.IsError || len(result.Content) != 2 {
+		t.Fatalf("Unexpected result %#v", result)
+	}
+
+	summary := result.Content[0].(mcp.TextContent).Text
+	for _, want := range []string{"+ [net] firewall", "- layer old", "Objects: 1 added"} {
+		if !strings.Contains(summary, want) {
+			t.Errorf("Expected %q in the summary:\n%s", want, summary)
+		}
+	}
+
+	var output diffOutput
+	if err := json.Unmarshal([]byte(result.Content[1].(mcp.TextContent).Text), &output); err != nil {
+		t.Fatalf("Failed to decode diff: %v", err)
+	}
+	if len(output.Objects.Added) != 1 {
+		t.Fatalf("Expected 1 added object, got %v", output.Objects.Added)
+	}
+	if added := output.Objects.Added[0].(map[string]any); added["board"] != "net" || added["id"] != "firewall" {
+		t.Errorf("Expected firewall in board net, got %v", added)
+	}
+	if len(output.Boards.Removed) != 1 || output.Boards.Removed[0].Path != "old" {
+		t.Errorf("Expected board old to be removed, got %v", output.Boards.Removed)
+	}
+}
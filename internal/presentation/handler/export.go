@@ -2,8 +2,6 @@ package handler
 
 import (
 	"context"
-	"encoding/base64"
-	"fmt"
 	"io"
 
 	"github.com/mark3labs/mcp-go/mcp"
@@ -15,24 +13,30 @@ import (
 
 // ExportHandler handles diagram export operations.
 type ExportHandler struct {
-	useCase *usecase.DiagramUseCase
+	useCase     *usecase.DiagramUseCase
+	diffUseCase *usecase.DiffUseCase
 }
 
 // NewExportHandler creates a new export handler.
-func NewExportHandler(useCase *usecase.DiagramUseCase) *ExportHandler {
+func NewExportHandler(useCase *usecase.DiagramUseCase, diffUseCase *usecase.DiffUseCase) *ExportHandler {
 	return &ExportHandler{
-		useCase: useCase,
+		useCase:     useCase,
+		diffUseCase: diffUseCase,
 	}
 }
 
 // GetTool returns the MCP tool definition.
 func (h *ExportHandler) GetTool() mcp.Tool {
-	return mcp.NewTool(
-		"d2_export",
-		mcp.WithDescription("Export an existing diagram to SVG, PNG, or PDF format. The diagram must first be created using d2_create (not d2_render). Supports exporting all D2 features including SQL tables, UML classes, sequence diagrams, code blocks, and markdown-rich documentation. Note: PNG and PDF formats require external tools (e.g., Chromium) to be installed on the system."),
+	opts := []mcp.ToolOption{
+		mcp.WithDescription("Export an existing diagram to SVG, PNG, PDF, PowerPoint (PPTX), animated SVG or GIF format. The diagram must first be created using d2_create (not d2_render). Supports exporting all D2 features including SQL tables, UML classes, sequence diagrams, code blocks, and markdown-rich documentation. PNG and PDF are rendered in-process without external tools; PDF and PPTX get one titled page or slide per board (root, layers, scenarios and steps), and PPTX slides carry board labels as speaker notes. animated_svg and gif cycle through the root board and its steps in order. SVG is returned as text, PNG and GIF as image content, and PDF and PPTX as embedded resources; set thumbnail to also get a small PNG preview."),
 		mcp.WithString("diagramId", mcp.Description("ID of the diagram to export"), mcp.Required()),
-		mcp.WithString("format", mcp.Description("Export format (svg, png, pdf)"), mcp.Enum("svg", "png", "pdf"), mcp.DefaultString("svg")),
-	)
+		mcp.WithString("format", mcp.Description("Export format (svg, png, pdf, pptx, animated_svg, gif)"), mcp.Enum("svg", "png", "pdf", "pptx", "animated_svg", "gif"), mcp.DefaultString("svg")),
+	}
+	opts = append(opts, renderToolOptions()...)
+	opts = append(opts, highlightToolOptions()...)
+	opts = append(opts, thumbnailToolOptions()...)
+
+	return mcp.NewTool("d2_export", opts...)
 }
 
 // GetHandler returns the tool handler function.
@@ -51,8 +55,14 @@ func (h *ExportHandler) Handle(ctx context.Context, request mcp.CallToolRequest)
 	formatStr := mcp.ParseString(request, "format", "svg")
 	format := entity.ExportFormat(formatStr)
 
+	ctx, revision := trackRevision(ctx, diagramID)
+	renderOpts, err := withHighlight(ctx, h.diffUseCase, diagramID, request, parseRenderOptions(request))
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to compare with the baseline", err), nil
+	}
+
 	// Export the diagram.
-	reader, err := h.useCase.ExportDiagram(ctx, diagramID, format)
+	reader, err := h.useCase.ExportDiagram(ctx, diagramID, format, renderOpts)
 	if err != nil {
 		return mcp.NewToolResultErrorFromErr("Failed to export diagram", err), nil
 	}
@@ -64,15 +74,17 @@ func (h *ExportHandler) Handle(ctx context.Context, request mcp.CallToolRequest)
 	}
 
 	// Return result based on format.
-	if format == entity.FormatSVG {
-		return mcp.NewToolResultText(string(data)), nil
-	}
+	result := &mcp.CallToolResult{Content: exportContent(diagramID, format, data)}
 
-	// For binary formats, encode as base64.
-	encoded := base64.StdEncoding.EncodeToString(data)
-	mimeType := getMimeType(format)
+	thumbnail, err := thumbnailContent(ctx, h.useCase, diagramID, request, renderOpts)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to render thumbnail", err), nil
+	}
+	if thumbnail != nil {
+		result.Content = append(result.Content, thumbnail)
+	}
 
-	return mcp.NewToolResultText(fmt.Sprintf("data:%s;base64,%s", mimeType, encoded)), nil
+	return withRevision(result, revision.Current), nil
 }
 
 // getMimeType returns the MIME type for the given format.
@@ -82,6 +94,10 @@ func getMimeType(format entity.ExportFormat) string {
 		return "image/png"
 	case entity.FormatPDF:
 		return "application/pdf"
+	case entity.FormatPPTX:
+		return "application/vnd.openxmlformats-officedocument.presentationml.presentation"
+	case entity.FormatGIF:
+		return "image/gif"
 	default:
 		return "image/svg+xml"
 	}
@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/i2y/d2mcp/internal/domain/entity"
+	"github.com/i2y/d2mcp/internal/usecase"
+)
+
+// highlightToolOptions returns the arguments of d2_export and d2_save that highlight
+// the changes since an earlier version of the diagram.
+func highlightToolOptions() []mcp.ToolOption {
+	return []mcp.ToolOption{
+		mcp.WithString("baseline_diagram_id", mcp.Description("Highlight the changes since another stored diagram: added elements in green, removed ones as faded red ghosts and modified ones outlined in orange. The stored diagram is not modified")),
+		mcp.WithString("baseline_snapshot", mcp.Description("Highlight the changes since a snapshot. Without baseline_diagram_id, the snapshot is one of the exported diagram")),
+		mcp.WithString("baseline_content", mcp.Description("Highlight the changes since this D2 text, e.g. the source before an edit")),
+	}
+}
+
+// withHighlight adds the changes to highlight to the render options when the
+// request names a baseline version.
+func withHighlight(ctx context.Context, diffUseCase *usecase.DiffUseCase, diagramID string, request mcp.CallToolRequest, opts *entity.RenderOptions) (*entity.RenderOptions, error) {
+	baseline := entity.DiagramVersion{
+		DiagramID: mcp.ParseString(request, "baseline_diagram_id", ""),
+		Snapshot:  mcp.ParseString(request, "baseline_snapshot", ""),
+		Content:   mcp.ParseString(request, "baseline_content", ""),
+	}
+	if baseline == (entity.DiagramVersion{}) {
+		return opts, nil
+	}
+	if baseline.DiagramID == "" && baseline.Content == "" {
+		baseline.DiagramID = diagramID
+	}
+
+	changes, err := diffUseCase.Diff(ctx, baseline, entity.DiagramVersion{DiagramID: diagramID})
+	if err != nil {
+		return nil, err
+	}
+
+	if opts == nil {
+		opts = &entity.RenderOptions{}
+	}
+	opts.Changes = changes
+	return opts, nil
+}
@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/i2y/d2mcp/internal/usecase"
+)
+
+// ListHandler handles the d2_list tool.
+type ListHandler struct {
+	useCase *usecase.DiagramUseCase
+}
+
+// NewListHandler creates a new list handler.
+func NewListHandler(useCase *usecase.DiagramUseCase) *ListHandler {
+	return &ListHandler{
+		useCase: useCase,
+	}
+}
+
+// diagramSummary describes a diagram in the d2_list output.
+type diagramSummary struct {
+	ID       string    `json:"id"`
+	Elements int       `json:"elements"` // Objects and connections in the root board
+	Size     int       `json:"size"`     // Length of the D2 source in bytes
+	Revision int64     `json:"revision"`
+	Modified time.Time `json:"modified"`
+}
+
+// GetTool returns the MCP tool definition.
+func (h *ListHandler) GetTool() mcp.Tool {
+	return mcp.NewTool(
+		"d2_list",
+		mcp.WithDescription("List all stored diagrams as JSON, sorted by ID, with the number of objects and connections in each, the size of its D2 source in bytes, its revision and when it was last changed. Use this to find out which diagrams exist before editing, cloning or deleting them."),
+	)
+}
+
+// GetHandler returns the tool handler function.
+func (h *ListHandler) GetHandler() server.ToolHandlerFunc {
+	return h.Handle
+}
+
+// Handle processes the list request.
+func (h *ListHandler) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	diagrams, err := h.useCase.ListDiagrams(ctx)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to list diagrams", err), nil
+	}
+
+	if len(diagrams) == 0 {
+		return mcp.NewToolResultText("No diagrams are stored"), nil
+	}
+
+	summaries := make([]diagramSummary, len(diagrams))
+	for i, diagram := range diagrams {
+		summaries[i] = diagramSummary{
+			ID:       diagram.ID,
+			Elements: diagram.Elements,
+			Size:     diagram.Size,
+			Revision: diagram.Revision,
+			Modified: diagram.Modified,
+		}
+	}
+
+	jsonData, err := json.MarshalIndent(summaries, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("Failed to format diagram list"), nil
+	}
+
+	return mcp.NewToolResultText(fmt.Sprintf("%d diagrams:\n%s", len(summaries), string(jsonData))), nil
+}
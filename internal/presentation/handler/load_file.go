@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/i2y/d2mcp/internal/usecase"
+)
+
+// LoadFileHandler handles the d2_load_file tool.
+type LoadFileHandler struct {
+	useCase *usecase.OracleUseCase
+}
+
+// NewLoadFileHandler creates a new load file handler.
+func NewLoadFileHandler(useCase *usecase.OracleUseCase) *LoadFileHandler {
+	return &LoadFileHandler{
+		useCase: useCase,
+	}
+}
+
+// GetTool returns the MCP tool definition.
+func (h *LoadFileHandler) GetTool() mcp.Tool {
+	return mcp.NewTool(
+		"d2_load_file",
+		mcp.WithDescription("Load a .d2 file from disk as a stored diagram that can be edited with the d2_oracle_* tools and exported. Files are only read inside the server's input directory: relative paths are resolved against it, absolute paths must lie inside it, and paths leaving it through '..' or symlinks are rejected, as are files not ending in .d2. Relative imports (@file and ...@file) are resolved from the file's directory, or from root when the diagram imports files from a parent directory, and keep resolving from there while the diagram is edited and exported; they cannot leave root either. Loading over an existing diagram replaces its content and can be undone. Fails when the server was started without an input directory."),
+		mcp.WithString("path", mcp.Description("Path of the .d2 file to load, relative to the server's input directory or absolute inside it"), mcp.Required()),
+		mcp.WithString("diagram_id", mcp.Description("ID to store the diagram under (default: the file name without .d2)")),
+		mcp.WithString("root", mcp.Description("Directory imports may be read from; must contain the file and lie inside the input directory (default: the file's directory)")),
+		withExpectedRevision(),
+	)
+}
+
+// GetHandler returns the tool handler function.
+func (h *LoadFileHandler) GetHandler() server.ToolHandlerFunc {
+	return h.Handle
+}
+
+// Handle processes the load file request.
+func (h *LoadFileHandler) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// Extract arguments.
+	path := mcp.ParseString(request, "path", "")
+	root := mcp.ParseString(request, "root", "")
+	diagramID := mcp.ParseString(request, "diagram_id", "")
+	if diagramID == "" && path != "" {
+		diagramID = strings.TrimSuffix(filepath.Base(path), ".d2")
+	}
+
+	ctx, revision, err := expectRevision(ctx, request, diagramID)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Invalid expected_revision", err), nil
+	}
+
+	if err := h.useCase.LoadFile(ctx, diagramID, path, root); err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to load file", err), nil
+	}
+
+	return withRevision(mcp.NewToolResultText(fmt.Sprintf("Loaded %s as diagram '%s'", path, diagramID)), revision.Current), nil
+}
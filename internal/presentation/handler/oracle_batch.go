@@ -0,0 +1,179 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/i2y/d2mcp/internal/domain/entity"
+	"github.com/i2y/d2mcp/internal/usecase"
+)
+
+// OracleBatchHandler handles the d2_oracle_batch tool.
+type OracleBatchHandler struct {
+	useCase *usecase.OracleUseCase
+}
+
+// NewOracleBatchHandler creates a new Oracle batch handler.
+func NewOracleBatchHandler(useCase *usecase.OracleUseCase) *OracleBatchHandler {
+	return &OracleBatchHandler{
+		useCase: useCase,
+	}
+}
+
+// batchOperation is a single operation of a d2_oracle_batch request.
+type batchOperation struct {
+	Type               string  `json:"type"`
+	Key                string  `json:"key"`
+	Value              *string `json:"value"`
+	Tag                *string `json:"tag"`
+	NewKey             *string `json:"new_key"`
+	IncludeDescendants *bool   `json:"include_descendants"` // Defaults to true, as in d2_oracle_move
+	BoardPath          string  `json:"board_path"`
+}
+
+// batchResult reports the outcome of a single operation of a batch.
+type batchResult struct {
+	Operation int               `json:"operation"` // 1-based position in the batch
+	Type      string            `json:"type"`
+	Key       string            `json:"key"`
+	NewKey    string            `json:"new_key,omitempty"`
+	IDDeltas  map[string]string `json:"id_deltas,omitempty"`
+}
+
+// GetTool returns the MCP tool definition.
+func (h *OracleBatchHandler) GetTool() mcp.Tool {
+	return mcp.NewTool(
+		"d2_oracle_batch",
+		mcp.WithDescription("Apply many Oracle operations to a diagram in one call. Use this instead of dozens of single d2_oracle_* calls when building or restructuring a diagram. Operations run in order, each seeing the result of the previous ones, and are applied atomically: if any operation fails, none are applied and the diagram is left unchanged. Returns the result of every operation, including the key each created or renamed element ended up with and the IDs that changed."),
+		mcp.WithString("diagram_id", mcp.Description("ID of the diagram to modify"), mcp.Required()),
+		withExpectedRevision(),
+		mcp.WithArray("operations",
+			mcp.Description("Ordered list of operations to apply"),
+			mcp.Required(),
+			mcp.Items(map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"type": map[string]any{
+						"type":        "string",
+						"enum":        []string{"create", "set", "delete", "move", "rename"},
+						"description": "Operation to apply",
+					},
+					"key": map[string]any{
+						"type":        "string",
+						"description": "Element or attribute key, as in the single d2_oracle_* tools. Examples: 'User', 'User -> API', 'User.shape'",
+					},
+					"value": map[string]any{
+						"type":        "string",
+						"description": "Value for set operations",
+					},
+					"tag": map[string]any{
+						"type":        "string",
+						"description": "Optional tag for set operations",
+					},
+					"new_key": map[string]any{
+						"type":        "string",
+						"description": "Destination key for move operations, or the new name for rename operations",
+					},
+					"include_descendants": map[string]any{
+						"type":        "boolean",
+						"description": "For move operations, whether to move children along with the element (default true, as in d2_oracle_move)",
+						"default":     true,
+					},
+					"board_path": map[string]any{
+						"type":        "string",
+						"description": boardPathDescription,
+					},
+				},
+				"required": []string{"type", "key"},
+			}),
+		),
+	)
+}
+
+// GetHandler returns the tool handler function.
+func (h *OracleBatchHandler) GetHandler() server.ToolHandlerFunc {
+	return h.Handle
+}
+
+// Handle processes the batch request.
+func (h *OracleBatchHandler) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// Extract arguments.
+	diagramID := mcp.ParseString(request, "diagram_id", "")
+
+	ctx, revision, err := expectRevision(ctx, request, diagramID)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Invalid expected_revision", err), nil
+	}
+
+	operations, err := parseBatchOperations(request)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Invalid operations", err), nil
+	}
+
+	ops := make([]entity.OracleOperation, len(operations))
+	for i, operation := range operations {
+		includeDescendants := operation.IncludeDescendants == nil || *operation.IncludeDescendants
+		ops[i] = entity.OracleOperation{
+			Type:               entity.OracleOperationType(operation.Type),
+			DiagramID:          diagramID,
+			BoardPath:          splitBoardPath(operation.BoardPath),
+			Key:                operation.Key,
+			Value:              operation.Value,
+			Tag:                operation.Tag,
+			NewKey:             operation.NewKey,
+			IncludeDescendants: includeDescendants,
+		}
+	}
+
+	results, err := h.useCase.ExecuteBatch(ctx, diagramID, ops)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to apply batch", err), nil
+	}
+
+	batchResults := make([]batchResult, len(results))
+	for i, result := range results {
+		batchResults[i] = batchResult{
+			Operation: i + 1,
+			Type:      operations[i].Type,
+			Key:       operations[i].Key,
+			NewKey:    result.NewKey,
+			IDDeltas:  result.IDDeltas,
+		}
+	}
+
+	data, err := json.MarshalIndent(batchResults, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to encode results", err), nil
+	}
+
+	return withRevision(mcp.NewToolResultText(fmt.Sprintf("Applied %d operations to diagram %s\n%s", len(results), diagramID, data)), revision.Current), nil
+}
+
+// parseBatchOperations decodes the operations argument. Clients that cannot send
+// arrays may pass the list as a JSON string.
+func parseBatchOperations(request mcp.CallToolRequest) ([]batchOperation, error) {
+	raw, ok := request.GetArguments()["operations"]
+	if !ok {
+		return nil, fmt.Errorf("operations is required")
+	}
+
+	var data []byte
+	if text, isString := raw.(string); isString {
+		data = []byte(text)
+	} else {
+		var err error
+		if data, err = json.Marshal(raw); err != nil {
+			return nil, err
+		}
+	}
+
+	var operations []batchOperation
+	if err := json.Unmarshal(data, &operations); err != nil {
+		return nil, fmt.Errorf("operations must be a list of operation objects: %w", err)
+	}
+	return operations, nil
+}
@@ -29,7 +29,9 @@ func (h *OracleCreateHandler) GetTool() mcp.Tool {
 		"d2_oracle_create",
 		mcp.WithDescription("Add new shapes or connections to an existing D2 diagram incrementally. Use this when you need to build diagrams piece-by-piece or add elements to a diagram after initial creation. Perfect for: iteratively building complex diagrams, adding elements based on parsed data, or modifying existing diagrams without regenerating everything. Creates basic elements only - use d2_oracle_set afterward to add special shapes (sql_table, class), styles, or properties. Example: Create 'User' shape, then set 'User.shape: person' with d2_oracle_set."),
 		mcp.WithString("diagram_id", mcp.Description("ID of the diagram to modify"), mcp.Required()),
+		withExpectedRevision(),
 		mcp.WithString("key", mcp.Description("Key for the new element. Examples: 'User' for shape, 'User -> API' for connection, 'System.Database' for nested shape. Use dots for nesting, arrows (->) for connections"), mcp.Required()),
+		mcp.WithString("board_path", mcp.Description(boardPathDescription)),
 	)
 }
 
@@ -42,13 +44,18 @@ func (h *OracleCreateHandler) GetHandler() server.ToolHandlerFunc {
 func (h *OracleCreateHandler) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	// Extract arguments.
 	diagramID := mcp.ParseString(request, "diagram_id", "")
+
+	ctx, revision, err := expectRevision(ctx, request, diagramID)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Invalid expected_revision", err), nil
+	}
 	key := mcp.ParseString(request, "key", "")
 
 	op := &entity.OracleOperation{
 		Type:      entity.OracleCreate,
 		DiagramID: diagramID,
 		Key:       key,
-		BoardPath: []string{}, // For now, single board support
+		BoardPath: parseBoardPath(request),
 	}
 
 	result, err := h.useCase.CreateElement(ctx, op)
@@ -61,5 +68,5 @@ func (h *OracleCreateHandler) Handle(ctx context.Context, request mcp.CallToolRe
 		response += fmt.Sprintf(" (auto-generated from '%s')", key)
 	}
 
-	return mcp.NewToolResultText(response), nil
+	return withRevision(mcp.NewToolResultText(response), revision.Current), nil
 }
@@ -29,7 +29,9 @@ func (h *OracleDeleteHandler) GetTool() mcp.Tool {
 		"d2_oracle_delete",
 		mcp.WithDescription("Remove shapes or connections from a D2 diagram. Use this when you need to: clean up unwanted elements, refactor diagram structure, or remove outdated components. Important: deleting a container shape will also delete ALL its child elements. Connections to/from deleted shapes are automatically removed. Use this carefully - consider using d2_oracle_move to relocate elements instead if you want to preserve them. Perfect for iterative diagram refinement and cleanup operations."),
 		mcp.WithString("diagram_id", mcp.Description("ID of the diagram to modify"), mcp.Required()),
+		withExpectedRevision(),
 		mcp.WithString("key", mcp.Description("Key of the element to delete. Examples: 'server' for a shape, 'server -> database' for a connection, 'System.Database' for nested element. WARNING: Deleting containers removes all children"), mcp.Required()),
+		mcp.WithString("board_path", mcp.Description(boardPathDescription)),
 	)
 }
 
@@ -42,13 +44,18 @@ func (h *OracleDeleteHandler) GetHandler() server.ToolHandlerFunc {
 func (h *OracleDeleteHandler) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	// Extract arguments.
 	diagramID := mcp.ParseString(request, "diagram_id", "")
+
+	ctx, revision, err := expectRevision(ctx, request, diagramID)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Invalid expected_revision", err), nil
+	}
 	key := mcp.ParseString(request, "key", "")
 
 	op := &entity.OracleOperation{
 		Type:      entity.OracleDelete,
 		DiagramID: diagramID,
 		Key:       key,
-		BoardPath: []string{}, // For now, single board support
+		BoardPath: parseBoardPath(request),
 	}
 
 	result, err := h.useCase.DeleteElement(ctx, op)
@@ -61,5 +68,5 @@ func (h *OracleDeleteHandler) Handle(ctx context.Context, request mcp.CallToolRe
 		response += fmt.Sprintf(" (affected %d related elements)", len(result.IDDeltas))
 	}
 
-	return mcp.NewToolResultText(response), nil
+	return withRevision(mcp.NewToolResultText(response), revision.Current), nil
 }
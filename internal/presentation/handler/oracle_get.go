@@ -31,6 +31,7 @@ func (h *OracleGetHandler) GetTool() mcp.Tool {
 		mcp.WithString("diagram_id", mcp.Description("ID of the diagram"), mcp.Required()),
 		mcp.WithString("key", mcp.Description("Key of the element to inspect. Examples: 'server' for shape info, 'server -> database' for connection info, 'System' to see what's inside a container"), mcp.Required()),
 		mcp.WithString("info_type", mcp.Description("Type of information to retrieve: 'object' for shape/container details, 'edge' for connection properties, 'children' to list elements inside a container"), mcp.DefaultString("object")),
+		mcp.WithString("board_path", mcp.Description(boardPathDescription)),
 	)
 }
 
@@ -46,8 +47,9 @@ func (h *OracleGetHandler) Handle(ctx context.Context, request mcp.CallToolReque
 	key := mcp.ParseString(request, "key", "")
 	infoType := mcp.ParseString(request, "info_type", "object")
 
-	boardPath := []string{} // For now, single board support
+	boardPath := parseBoardPath(request)
 
+	ctx, revision := trackRevision(ctx, diagramID)
 	switch infoType {
 	case "object":
 		obj, err := h.useCase.GetObject(ctx, diagramID, boardPath, key)
@@ -60,7 +62,7 @@ func (h *OracleGetHandler) Handle(ctx context.Context, request mcp.CallToolReque
 			return mcp.NewToolResultError("Failed to format object info"), nil
 		}
 
-		return mcp.NewToolResultText(fmt.Sprintf("Object info for '%s':\n%s", key, string(jsonData))), nil
+		return withRevision(mcp.NewToolResultText(fmt.Sprintf("Object info for '%s':\n%s", key, string(jsonData))), revision.Current), nil
 
 	case "edge":
 		edge, err := h.useCase.GetEdge(ctx, diagramID, boardPath, key)
@@ -73,7 +75,7 @@ func (h *OracleGetHandler) Handle(ctx context.Context, request mcp.CallToolReque
 			return mcp.NewToolResultError("Failed to format edge info"), nil
 		}
 
-		return mcp.NewToolResultText(fmt.Sprintf("Edge info for '%s':\n%s", key, string(jsonData))), nil
+		return withRevision(mcp.NewToolResultText(fmt.Sprintf("Edge info for '%s':\n%s", key, string(jsonData))), revision.Current), nil
 
 	case "children":
 		children, err := h.useCase.GetChildren(ctx, diagramID, boardPath, key)
@@ -82,7 +84,7 @@ func (h *OracleGetHandler) Handle(ctx context.Context, request mcp.CallToolReque
 		}
 
 		if len(children) == 0 {
-			return mcp.NewToolResultText(fmt.Sprintf("No children found for '%s'", key)), nil
+			return withRevision(mcp.NewToolResultText(fmt.Sprintf("No children found for '%s'", key)), revision.Current), nil
 		}
 
 		jsonData, err := json.MarshalIndent(children, "", "  ")
@@ -90,7 +92,7 @@ func (h *OracleGetHandler) Handle(ctx context.Context, request mcp.CallToolReque
 			return mcp.NewToolResultError("Failed to format children info"), nil
 		}
 
-		return mcp.NewToolResultText(fmt.Sprintf("Children of '%s':\n%s", key, string(jsonData))), nil
+		return withRevision(mcp.NewToolResultText(fmt.Sprintf("Children of '%s':\n%s", key, string(jsonData))), revision.Current), nil
 
 	default:
 		return mcp.NewToolResultError(fmt.Sprintf("Invalid info_type: %s. Must be 'object', 'edge', or 'children'", infoType)), nil
@@ -29,9 +29,11 @@ func (h *OracleMoveHandler) GetTool() mcp.Tool {
 		"d2_oracle_move",
 		mcp.WithDescription("Reorganize diagram structure by moving shapes between containers. Use this when you need to: group related components together, refactor diagram hierarchy, move elements into or out of systems/packages, or restructure without losing connections. Containers are shapes that hold other shapes (like 'System', 'Network', or any shape with children). Moving preserves all connections - they're automatically rerouted. Set include_descendants=false to move only the parent shape, leaving children in original location. Essential for maintaining clean, logical diagram organization."),
 		mcp.WithString("diagram_id", mcp.Description("ID of the diagram to modify"), mcp.Required()),
+		withExpectedRevision(),
 		mcp.WithString("key", mcp.Description("Key of the element to move (e.g., 'server', 'Database.users_table')"), mcp.Required()),
 		mcp.WithString("new_parent", mcp.Description("Target container key where element will be moved. Use empty string '' to move to root level. Examples: 'System' to move into System container, 'Network.DMZ' for nested container"), mcp.Required()),
 		mcp.WithString("include_descendants", mcp.Description("Whether to move child elements along with the parent (true/false). Default true preserves hierarchy"), mcp.DefaultString("true")),
+		mcp.WithString("board_path", mcp.Description(boardPathDescription)),
 	)
 }
 
@@ -44,6 +46,11 @@ func (h *OracleMoveHandler) GetHandler() server.ToolHandlerFunc {
 func (h *OracleMoveHandler) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	// Extract arguments.
 	diagramID := mcp.ParseString(request, "diagram_id", "")
+
+	ctx, revision, err := expectRevision(ctx, request, diagramID)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Invalid expected_revision", err), nil
+	}
 	key := mcp.ParseString(request, "key", "")
 	newParent := mcp.ParseString(request, "new_parent", "")
 	includeDescendantsStr := mcp.ParseString(request, "include_descendants", "true")
@@ -61,10 +68,10 @@ func (h *OracleMoveHandler) Handle(ctx context.Context, request mcp.CallToolRequ
 		Key:                key,
 		NewKey:             &newKey,
 		IncludeDescendants: includeDescendants,
-		BoardPath:          []string{}, // For now, single board support
+		BoardPath:          parseBoardPath(request),
 	}
 
-	_, err := h.useCase.MoveElement(ctx, op)
+	_, err = h.useCase.MoveElement(ctx, op)
 	if err != nil {
 		return mcp.NewToolResultErrorFromErr("Failed to move element", err), nil
 	}
@@ -74,5 +81,5 @@ func (h *OracleMoveHandler) Handle(ctx context.Context, request mcp.CallToolRequ
 		response += " (including descendants)"
 	}
 
-	return mcp.NewToolResultText(response), nil
+	return withRevision(mcp.NewToolResultText(response), revision.Current), nil
 }
@@ -29,8 +29,10 @@ func (h *OracleRenameHandler) GetTool() mcp.Tool {
 		"d2_oracle_rename",
 		mcp.WithDescription("Change the identifier of shapes or connections while preserving all relationships. Use this when you need to: improve clarity with better names, fix typos or naming inconsistencies, refactor diagram elements, or align with updated terminology. The rename is intelligent - ALL connections referencing the old name are automatically updated to use the new name. This includes connections where the element is source, target, or part of a longer path. Child elements keep their relative names. Safe operation that maintains diagram integrity."),
 		mcp.WithString("diagram_id", mcp.Description("ID of the diagram to modify"), mcp.Required()),
+		withExpectedRevision(),
 		mcp.WithString("key", mcp.Description("Current key of the element to rename (e.g., 'server', 'DB', 'System.OldName')"), mcp.Required()),
 		mcp.WithString("new_name", mcp.Description("New identifier for the element (e.g., 'web_server', 'Database', 'NewName'). Connections are automatically updated"), mcp.Required()),
+		mcp.WithString("board_path", mcp.Description(boardPathDescription)),
 	)
 }
 
@@ -43,6 +45,11 @@ func (h *OracleRenameHandler) GetHandler() server.ToolHandlerFunc {
 func (h *OracleRenameHandler) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	// Extract arguments.
 	diagramID := mcp.ParseString(request, "diagram_id", "")
+
+	ctx, revision, err := expectRevision(ctx, request, diagramID)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Invalid expected_revision", err), nil
+	}
 	key := mcp.ParseString(request, "key", "")
 	newName := mcp.ParseString(request, "new_name", "")
 
@@ -51,7 +58,7 @@ func (h *OracleRenameHandler) Handle(ctx context.Context, request mcp.CallToolRe
 		DiagramID: diagramID,
 		Key:       key,
 		NewKey:    &newName,
-		BoardPath: []string{}, // For now, single board support
+		BoardPath: parseBoardPath(request),
 	}
 
 	result, err := h.useCase.RenameElement(ctx, op)
@@ -64,5 +71,5 @@ func (h *OracleRenameHandler) Handle(ctx context.Context, request mcp.CallToolRe
 		response += fmt.Sprintf(" (updated %d references)", len(result.IDDeltas))
 	}
 
-	return mcp.NewToolResultText(response), nil
+	return withRevision(mcp.NewToolResultText(response), revision.Current), nil
 }
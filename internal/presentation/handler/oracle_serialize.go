@@ -27,6 +27,7 @@ func (h *OracleSerializeHandler) GetTool() mcp.Tool {
 		"d2_oracle_serialize",
 		mcp.WithDescription("Export the current state of an Oracle-edited diagram as D2 text. Use this when you need to: see the complete D2 syntax after incremental changes, save diagram source for version control, share diagram definition with others, debug complex diagrams, or transition from Oracle API to direct D2 text editing. Returns the exact D2 code that would produce the current diagram, including all shapes, connections, special elements (sql_table, class), styles, and content. This is THE way to get the textual representation after using Oracle API tools."),
 		mcp.WithString("diagram_id", mcp.Description("ID of the diagram to get D2 text for"), mcp.Required()),
+		mcp.WithString("board_path", mcp.Description(boardPathDescription)),
 	)
 }
 
@@ -39,11 +40,13 @@ func (h *OracleSerializeHandler) GetHandler() server.ToolHandlerFunc {
 func (h *OracleSerializeHandler) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	// Extract arguments.
 	diagramID := mcp.ParseString(request, "diagram_id", "")
+	boardPath := parseBoardPath(request)
 
-	content, err := h.useCase.SerializeDiagram(ctx, diagramID)
+	ctx, revision := trackRevision(ctx, diagramID)
+	content, err := h.useCase.SerializeBoard(ctx, diagramID, boardPath)
 	if err != nil {
 		return mcp.NewToolResultErrorFromErr("Failed to serialize diagram", err), nil
 	}
 
-	return mcp.NewToolResultText(content), nil
+	return withRevision(mcp.NewToolResultText(content), revision.Current), nil
 }
@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/i2y/d2mcp/internal/usecase"
+)
+
+// d2SyntaxGuide is the D2 syntax summary shared by all diagram prompts.
+const d2SyntaxGuide = `D2 syntax essentials:
+- Declare a shape by its key and give it a label after a colon: ` + "`api: API Gateway`" + `.
+- Nest shapes with blocks or dotted keys: ` + "`cloud: { api; db }`" + ` or ` + "`cloud.api`" + `. Refer to nested shapes by their full path.
+- Connect shapes with ` + "`->`, `<-`, `<->` or `--`" + ` and label the connection after a colon: ` + "`web -> cloud.api: HTTPS`" + `.
+- Set attributes in a block: ` + "`db: { shape: cylinder; style.fill: \"#e3f2fd\" }`" + `.
+- Useful shapes: rectangle, oval, circle, cylinder, queue, package, page, person, cloud, hexagon, diamond, document, stored_data, sql_table, class, sequence_diagram, text.
+- Useful styles: style.fill, style.stroke, style.stroke-dash, style.stroke-width, style.border-radius, style.shadow, style.opacity, style.font-size, style.bold.
+- Set the layout direction at the top level with ` + "`direction: right`" + ` (up, down, left or right).
+- Add icons with ` + "`icon: https://icons.terrastruct.com/...`" + ` and markdown with ` + "`note: |md # Title |`" + `.
+- Quote keys that contain special characters: ` + "`\"user-service\" -> db`" + `. Comments start with #.`
+
+// d2ERDGuide explains the sql_table shape used for entity relationship diagrams.
+const d2ERDGuide = `Entity relationship diagrams in D2:
+- Give each table ` + "`shape: sql_table`" + ` and declare one column per line as ` + "`name: type`" + `.
+- Mark keys with constraints: ` + "`{constraint: primary_key}`, `{constraint: foreign_key}`, `{constraint: unique}`" + `, or a list such as ` + "`{constraint: [primary_key; unique]}`" + `.
+- Connect foreign key columns to the columns they reference: ` + "`orders.user_id -> users.id`" + `.
+
+Example:
+` + "```d2" + `
+users: {
+  shape: sql_table
+  id: int {constraint: primary_key}
+  email: varchar(255) {constraint: unique}
+}
+orders: {
+  shape: sql_table
+  id: int {constraint: primary_key}
+  user_id: int {constraint: foreign_key}
+}
+orders.user_id -> users.id
+` + "```"
+
+// d2SequenceGuide explains the sequence_diagram shape.
+const d2SequenceGuide = `Sequence diagrams in D2:
+- Put ` + "`shape: sequence_diagram`" + ` at the top level, or on a container holding the sequence.
+- Actors appear left to right in the order they are first declared, so declare them up front.
+- Messages are connections and appear top to bottom in the order written: ` + "`client -> api: POST /orders`" + `.
+- Send a message through a span to show activation: ` + "`api.handle -> db: INSERT`" + `.
+- Group messages under a label with a container: ` + "`retry: { api -> queue: enqueue }`" + `.
+- Attach a note to an actor: ` + "`api.\"validates the token\"`" + `.
+- Use ` + "`style.stroke-dash: 3`" + ` on replies so they stand apart from requests.
+
+Example:
+` + "```d2" + `
+shape: sequence_diagram
+client: { shape: person }
+api
+db: { shape: cylinder }
+client -> api: POST /orders
+api.handle -> db: INSERT order
+db -> api.handle: order id {style.stroke-dash: 3}
+api -> client: 201 Created {style.stroke-dash: 3}
+` + "```"
+
+// diagramPromptArgument is the optional argument naming a stored diagram to build on.
+func diagramPromptArgument() mcp.PromptOption {
+	return mcp.WithArgument("diagram_id", mcp.ArgumentDescription("ID of a stored diagram to extend. Its current D2 source is included in the prompt. Leave empty to start a new diagram."))
+}
+
+// toolGuide tells the model which tools to use on a new or stored diagram.
+func toolGuide(diagramID string) string {
+	if diagramID == "" {
+		return `Working with the d2mcp tools:
+- Store the diagram with d2_create, passing a short descriptive id and the D2 source as content.
+- Make follow-up changes with d2_oracle_create, d2_oracle_set, d2_oracle_delete, d2_oracle_move and d2_oracle_rename.
+- Check the result with d2_oracle_serialize and render it with d2_export.`
+	}
+	return fmt.Sprintf(`Working with the d2mcp tools:
+- The diagram is stored as %[1]q and its current D2 source is attached below.
+- Edit it in place with d2_oracle_create, d2_oracle_set, d2_oracle_delete, d2_oracle_move and d2_oracle_rename using diagram_id %[1]q. Keep existing keys stable, since connections refer to them.
+- For a rewrite, call d2_create with id %[1]q and the complete new source.
+- Check the result with d2_oracle_serialize and render it with d2_export.`, diagramID)
+}
+
+// diagramPrompt builds the messages of a diagram prompt: the task with D2 guidance,
+// followed by the current source of the diagram when diagramID names one.
+func diagramPrompt(ctx context.Context, useCase *usecase.OracleUseCase, description, task string, guides []string, diagramID string) (*mcp.GetPromptResult, error) {
+	sections := append([]string{task}, guides...)
+	sections = append(sections, toolGuide(diagramID))
+	messages := []mcp.PromptMessage{
+		mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent(strings.Join(sections, "\n\n"))),
+	}
+
+	if diagramID != "" {
+		source, err := useCase.SerializeDiagram(ctx, diagramID)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, mcp.NewPromptMessage(mcp.RoleUser, mcp.NewEmbeddedResource(mcp.TextResourceContents{
+			URI:      diagramResourceURI(diagramID, "source"),
+			MIMEType: "text/x-d2",
+			Text:     source,
+		})))
+	}
+
+	return mcp.NewGetPromptResult(description, messages), nil
+}
@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/i2y/d2mcp/internal/usecase"
+)
+
+// ArchitecturePromptHandler handles the d2_architecture prompt.
+type ArchitecturePromptHandler struct {
+	useCase *usecase.OracleUseCase
+}
+
+// NewArchitecturePromptHandler creates a new architecture prompt handler.
+func NewArchitecturePromptHandler(useCase *usecase.OracleUseCase) *ArchitecturePromptHandler {
+	return &ArchitecturePromptHandler{
+		useCase: useCase,
+	}
+}
+
+// GetPrompt returns the MCP prompt definition.
+func (h *ArchitecturePromptHandler) GetPrompt() mcp.Prompt {
+	return mcp.NewPrompt(
+		"d2_architecture",
+		mcp.WithPromptDescription("Draw a software architecture diagram in D2 from a plain-language description of a system."),
+		mcp.WithArgument("description", mcp.ArgumentDescription("Description of the system: its components, how they communicate and where they run"), mcp.RequiredArgument()),
+		diagramPromptArgument(),
+	)
+}
+
+// GetHandler returns the prompt handler function.
+func (h *ArchitecturePromptHandler) GetHandler() server.PromptHandlerFunc {
+	return h.Handle
+}
+
+// Handle processes the prompt request.
+func (h *ArchitecturePromptHandler) Handle(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	description := request.Params.Arguments["description"]
+	if description == "" {
+		return nil, fmt.Errorf("description is required")
+	}
+
+	task := "Draw a software architecture diagram in D2 for the system described below. Group components by where they run, such as clients, services, data stores and external providers, label every connection with what flows over it, and pick shapes that match each component's role.\n\nSystem description:\n" + description
+	return diagramPrompt(ctx, h.useCase, "Architecture diagram from a description", task, []string{d2SyntaxGuide}, request.Params.Arguments["diagram_id"])
+}
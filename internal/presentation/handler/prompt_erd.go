@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/i2y/d2mcp/internal/usecase"
+)
+
+// ERDPromptHandler handles the d2_erd prompt.
+type ERDPromptHandler struct {
+	useCase *usecase.OracleUseCase
+}
+
+// NewERDPromptHandler creates a new ERD prompt handler.
+func NewERDPromptHandler(useCase *usecase.OracleUseCase) *ERDPromptHandler {
+	return &ERDPromptHandler{
+		useCase: useCase,
+	}
+}
+
+// GetPrompt returns the MCP prompt definition.
+func (h *ERDPromptHandler) GetPrompt() mcp.Prompt {
+	return mcp.NewPrompt(
+		"d2_erd",
+		mcp.WithPromptDescription("Draw an entity relationship diagram in D2 from a database schema."),
+		mcp.WithArgument("schema", mcp.ArgumentDescription("Database schema as SQL DDL, ORM models or a list of tables and columns"), mcp.RequiredArgument()),
+		diagramPromptArgument(),
+	)
+}
+
+// GetHandler returns the prompt handler function.
+func (h *ERDPromptHandler) GetHandler() server.PromptHandlerFunc {
+	return h.Handle
+}
+
+// Handle processes the prompt request.
+func (h *ERDPromptHandler) Handle(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	schema := request.Params.Arguments["schema"]
+	if schema == "" {
+		return nil, fmt.Errorf("schema is required")
+	}
+
+	task := "Draw an entity relationship diagram in D2 for the schema below. Use one sql_table per table with every column, its type and its key constraints, and connect each foreign key to the column it references.\n\nSchema:\n" + schema
+	return diagramPrompt(ctx, h.useCase, "ERD from a schema", task, []string{d2SyntaxGuide, d2ERDGuide}, request.Params.Arguments["diagram_id"])
+}
@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/i2y/d2mcp/internal/usecase"
+)
+
+// RefinePromptHandler handles the d2_refine prompt.
+type RefinePromptHandler struct {
+	useCase *usecase.OracleUseCase
+}
+
+// NewRefinePromptHandler creates a new refine prompt handler.
+func NewRefinePromptHandler(useCase *usecase.OracleUseCase) *RefinePromptHandler {
+	return &RefinePromptHandler{
+		useCase: useCase,
+	}
+}
+
+// GetPrompt returns the MCP prompt definition.
+func (h *RefinePromptHandler) GetPrompt() mcp.Prompt {
+	return mcp.NewPrompt(
+		"d2_refine",
+		mcp.WithPromptDescription("Refine a stored D2 diagram according to feedback, editing it in place."),
+		mcp.WithArgument("diagram_id", mcp.ArgumentDescription("ID of the stored diagram to refine. Its current D2 source is included in the prompt."), mcp.RequiredArgument()),
+		mcp.WithArgument("instructions", mcp.ArgumentDescription("What to change, such as layout, grouping, labels or styling"), mcp.RequiredArgument()),
+	)
+}
+
+// GetHandler returns the prompt handler function.
+func (h *RefinePromptHandler) GetHandler() server.PromptHandlerFunc {
+	return h.Handle
+}
+
+// Handle processes the prompt request.
+func (h *RefinePromptHandler) Handle(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	diagramID := request.Params.Arguments["diagram_id"]
+	if diagramID == "" {
+		return nil, fmt.Errorf("diagram_id is required")
+	}
+	instructions := request.Params.Arguments["instructions"]
+	if instructions == "" {
+		return nil, fmt.Errorf("instructions is required")
+	}
+
+	task := "Refine the stored D2 diagram attached below. Make the requested changes and keep everything else as it is. Prefer small in-place edits with the Oracle tools over rewriting the diagram.\n\nRequested changes:\n" + instructions
+	return diagramPrompt(ctx, h.useCase, "Refine an existing diagram", task, []string{d2SyntaxGuide}, diagramID)
+}
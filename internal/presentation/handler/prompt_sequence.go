@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/i2y/d2mcp/internal/usecase"
+)
+
+// SequencePromptHandler handles the d2_sequence prompt.
+type SequencePromptHandler struct {
+	useCase *usecase.OracleUseCase
+}
+
+// NewSequencePromptHandler creates a new sequence prompt handler.
+func NewSequencePromptHandler(useCase *usecase.OracleUseCase) *SequencePromptHandler {
+	return &SequencePromptHandler{
+		useCase: useCase,
+	}
+}
+
+// GetPrompt returns the MCP prompt definition.
+func (h *SequencePromptHandler) GetPrompt() mcp.Prompt {
+	return mcp.NewPrompt(
+		"d2_sequence",
+		mcp.WithPromptDescription("Draw a D2 sequence diagram from a description of an API flow."),
+		mcp.WithArgument("flow", mcp.ArgumentDescription("The API flow to draw: the participants and the requests and responses between them, in order"), mcp.RequiredArgument()),
+		diagramPromptArgument(),
+	)
+}
+
+// GetHandler returns the prompt handler function.
+func (h *SequencePromptHandler) GetHandler() server.PromptHandlerFunc {
+	return h.Handle
+}
+
+// Handle processes the prompt request.
+func (h *SequencePromptHandler) Handle(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	flow := request.Params.Arguments["flow"]
+	if flow == "" {
+		return nil, fmt.Errorf("flow is required")
+	}
+
+	task := "Draw a sequence diagram in D2 for the API flow below. Declare the participants in the order they first act, write requests and responses in the order they happen, and group alternative or repeated steps.\n\nAPI flow:\n" + flow
+	return diagramPrompt(ctx, h.useCase, "Sequence diagram from an API flow", task, []string{d2SyntaxGuide, d2SequenceGuide}, request.Params.Arguments["diagram_id"])
+}
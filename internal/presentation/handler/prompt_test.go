@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/i2y/d2mcp/internal/infrastructure/d2"
+	"github.com/i2y/d2mcp/internal/usecase"
+)
+
+// promptRequest builds a prompt request with the given arguments.
+func promptRequest(arguments map[string]string) mcp.GetPromptRequest {
+	request := mcp.GetPromptRequest{}
+	request.Params.Arguments = arguments
+	return request
+}
+
+// promptText returns the text of a prompt message.
+func promptText(t *testing.T, message mcp.PromptMessage) string {
+	t.Helper()
+
+	content, ok := message.Content.(mcp.TextContent)
+	if !ok {
+		t.Fatalf("Expected text content, got %T", message.Content)
+	}
+	return content.Text
+}
+
+func TestArchitecturePrompt_NewDiagram(t *testing.T) {
+	h := NewArchitecturePromptHandler(usecase.NewOracleUseCase(d2.NewD2OracleRepository()))
+
+	result, err := h.Handle(context.Background(), promptRequest(map[string]string{
+		"description": "A web app talking to a Postgres database",
+	}))
+	if err != nil {
+		t.Fatalf("Handle failed: %v", err)
+	}
+	if len(result.Messages) != 1 {
+		t.Fatalf("Expected 1 message, got %d", len(result.Messages))
+	}
+	text := promptText(t, result.Messages[0])
+	for _, want := range []string{"A web app talking to a Postgres database", "D2 syntax essentials", "d2_create"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Expected the prompt to contain %q", want)
+		}
+	}
+}
+
+func TestRefinePrompt_AttachesSource(t *testing.T) {
+	ctx := context.Background()
+	repo := d2.NewD2OracleRepository()
+	if err := usecase.NewDiagramUseCase(repo).Create(ctx, "team/net", "router -> firewall"); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	h := NewRefinePromptHandler(usecase.NewOracleUseCase(repo))
+
+	result, err := h.Handle(ctx, promptRequest(map[string]string{
+		"diagram_id":   "team/net",
+		"instructions": "Put the firewall in a dmz container",
+	}))
+	if err != nil {
+		t.Fatalf("Handle failed: %v", err)
+	}
+	if len(result.Messages) != 2 {
+		t.Fatalf("Expected 2 messages, got %d", len(result.Messages))
+	}
+	if text := promptText(t, result.Messages[0]); !strings.Contains(text, "Put the firewall in a dmz container") || !strings.Contains(text, `"team/net"`) {
+		t.Errorf("Expected the instructions and diagram ID in the prompt, got %s", text)
+	}
+
+	embedded, ok := result.Messages[1].Content.(mcp.EmbeddedResource)
+	if !ok {
+		t.Fatalf("Expected an embedded resource, got %T", result.Messages[1].Content)
+	}
+	source, ok := embedded.Resource.(mcp.TextResourceContents)
+	if !ok {
+		t.Fatalf("Expected text contents, got %T", embedded.Resource)
+	}
+	if source.URI != "d2://diagram/team%2Fnet/source" {
+		t.Errorf("Expected the escaped source URI, got %s", source.URI)
+	}
+	if !strings.Contains(source.Text, "router -> firewall") {
+		t.Errorf("Expected the diagram source, got %s", source.Text)
+	}
+}
+
+func TestRefinePrompt_Errors(t *testing.T) {
+	h := NewRefinePromptHandler(usecase.NewOracleUseCase(d2.NewD2OracleRepository()))
+
+	tests := []struct {
+		name      string
+		arguments map[string]string
+	}{
+		{"missing diagram", map[string]string{"instructions": "Use a dark theme"}},
+		{"missing instructions", map[string]string{"diagram_id": "net"}},
+		{"unknown diagram", map[string]string{"diagram_id": "net", "instructions": "Use a dark theme"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := h.Handle(context.Background(), promptRequest(tt.arguments)); err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+}
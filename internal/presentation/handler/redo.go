@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/i2y/d2mcp/internal/usecase"
+)
+
+// RedoHandler handles the d2_redo tool.
+type RedoHandler struct {
+	useCase *usecase.OracleUseCase
+}
+
+// NewRedoHandler creates a new redo handler.
+func NewRedoHandler(useCase *usecase.OracleUseCase) *RedoHandler {
+	return &RedoHandler{
+		useCase: useCase,
+	}
+}
+
+// GetTool returns the MCP tool definition.
+func (h *RedoHandler) GetTool() mcp.Tool {
+	return mcp.NewTool(
+		"d2_redo",
+		mcp.WithDescription("Reapply changes reverted with d2_undo, most recently undone first. Redo is only possible until the diagram is changed again; any new change discards the changes waiting to be redone."),
+		mcp.WithString("diagram_id", mcp.Description("ID of the diagram to reapply changes to"), mcp.Required()),
+		withExpectedRevision(),
+		mcp.WithNumber("steps", mcp.Description("Number of changes to redo (default 1)")),
+	)
+}
+
+// GetHandler returns the tool handler function.
+func (h *RedoHandler) GetHandler() server.ToolHandlerFunc {
+	return h.Handle
+}
+
+// Handle processes the redo request.
+func (h *RedoHandler) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// Extract arguments.
+	diagramID := mcp.ParseString(request, "diagram_id", "")
+
+	ctx, revision, err := expectRevision(ctx, request, diagramID)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Invalid expected_revision", err), nil
+	}
+	steps := mcp.ParseInt(request, "steps", 1)
+
+	result, err := h.useCase.Redo(ctx, diagramID, steps)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to redo", err), nil
+	}
+
+	return withRevision(mcp.NewToolResultText(formatHistoryResult("Redid", diagramID, result)), revision.Current), nil
+}
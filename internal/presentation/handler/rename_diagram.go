@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/i2y/d2mcp/internal/usecase"
+)
+
+// RenameDiagramHandler handles the d2_rename_diagram tool.
+type RenameDiagramHandler struct {
+	useCase *usecase.DiagramUseCase
+}
+
+// NewRenameDiagramHandler creates a new rename diagram handler.
+func NewRenameDiagramHandler(useCase *usecase.DiagramUseCase) *RenameDiagramHandler {
+	return &RenameDiagramHandler{
+		useCase: useCase,
+	}
+}
+
+// GetTool returns the MCP tool definition.
+func (h *RenameDiagramHandler) GetTool() mcp.Tool {
+	return mcp.NewTool(
+		"d2_rename_diagram",
+		mcp.WithDescription("Change the ID of a stored diagram. Its content, revision, undo history, snapshots and branches move with it. Diagrams that other stored diagrams import cannot be renamed; the error names the importers. To rename an element inside a diagram, use d2_oracle_rename instead."),
+		mcp.WithString("diagram_id", mcp.Description("Current ID of the diagram"), mcp.Required()),
+		mcp.WithString("new_id", mcp.Description("New ID of the diagram"), mcp.Required()),
+		withExpectedRevision(),
+	)
+}
+
+// GetHandler returns the tool handler function.
+func (h *RenameDiagramHandler) GetHandler() server.ToolHandlerFunc {
+	return h.Handle
+}
+
+// Handle processes the rename diagram request.
+func (h *RenameDiagramHandler) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// Extract arguments.
+	diagramID := mcp.ParseString(request, "diagram_id", "")
+	newID := mcp.ParseString(request, "new_id", "")
+
+	ctx, revision, err := expectRevision(ctx, request, diagramID)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Invalid expected_revision", err), nil
+	}
+
+	if err := h.useCase.RenameDiagram(ctx, diagramID, newID); err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to rename diagram", err), nil
+	}
+
+	return withRevision(mcp.NewToolResultText(fmt.Sprintf("Diagram '%s' renamed to '%s'", diagramID, newID)), revision.Current), nil
+}
@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/i2y/d2mcp/internal/domain/entity"
+)
+
+// renderToolOptions returns the layout and render arguments shared by d2_create, d2_export and d2_save.
+func renderToolOptions() []mcp.ToolOption {
+	return []mcp.ToolOption{
+		mcp.WithNumber("theme", mcp.Description("Theme ID, e.g. 0 (Neutral Default), 4 (Cool Classics), 300 (Terminal). See THEMES.md for all 20 themes")),
+		mcp.WithNumber("dark_theme", mcp.Description("Theme ID used when the viewer prefers a dark color scheme, e.g. 200 (Dark Mauve) or 201 (Dark Flagship Terrastruct). SVG only")),
+		mcp.WithBoolean("sketch", mcp.Description("Render with a hand-drawn look")),
+		mcp.WithNumber("pad", mcp.Description("Padding around the diagram in pixels (default 100)")),
+		mcp.WithBoolean("center", mcp.Description("Center the diagram in the SVG viewport")),
+		mcp.WithNumber("scale", mcp.Description("Fixed output scale, e.g. 0.5 or 2. Omit to fit the diagram to its viewport")),
+		mcp.WithString("layout", mcp.Description("Layout engine: 'dagre' (default, fast hierarchical layout) or 'elk' (better for nested containers, draws orthogonal edges by default)"), mcp.Enum(string(entity.LayoutDagre), string(entity.LayoutELK))),
+		mcp.WithString("direction", mcp.Description("Default flow direction used when the diagram does not declare one"), mcp.Enum("up", "down", "left", "right")),
+		mcp.WithNumber("node_spacing", mcp.Description("Spacing between nodes in pixels (0 keeps the engine default)")),
+		mcp.WithNumber("edge_spacing", mcp.Description("Edge routing spacing in pixels: between parallel edges for dagre, between edges and nodes for elk (0 keeps the engine default)")),
+		mcp.WithString("edge_routing", mcp.Description("How edges are drawn: 'orthogonal' (right-angled segments, elk only), 'splines' (smooth curves) or 'straight' (a direct line between the shapes). Defaults to splines for dagre and orthogonal for elk"), mcp.Enum(string(entity.RoutingOrthogonal), string(entity.RoutingSplines), string(entity.RoutingStraight))),
+		mcp.WithNumber("width", mcp.Description("PNG and GIF frame width in pixels. With height, the diagram is fit inside both; alone, the height follows the aspect ratio")),
+		mcp.WithNumber("height", mcp.Description("PNG and GIF frame height in pixels. Alone, the width follows the aspect ratio")),
+		mcp.WithNumber("dpi", mcp.Description("PNG and GIF resolution when width and height are not set (default 96; 192 doubles the pixel size). For PDF and PPTX, the resolution boards are embedded at (default 192)")),
+		mcp.WithString("raster_backend", mcp.Description("PNG and PDF converter: 'native' (default, built in; PDF gets a page per board) or 'external' (rsvg-convert or ImageMagick, which must be installed; root board only)"), mcp.Enum(string(entity.RasterNative), string(entity.RasterExternal))),
+		mcp.WithBoolean("toc", mcp.Description("Start PDF output with a table of contents linking to the page of every layer, scenario and step")),
+		mcp.WithNumber("interval", mcp.Description("Milliseconds each step is shown in animated_svg and gif output (default 1000)")),
+	}
+}
+
+// parseRenderOptions extracts the shared layout and render arguments.
+// It returns nil when none of them were provided.
+func parseRenderOptions(request mcp.CallToolRequest) *entity.RenderOptions {
+	opts := &entity.RenderOptions{}
+	provided := false
+
+	if hasArgument(request, "theme") {
+		opts.Theme = &entity.Theme{ID: mcp.ParseInt(request, "theme", 0)}
+		provided = true
+	}
+	if hasArgument(request, "dark_theme") {
+		opts.DarkTheme = &entity.Theme{ID: mcp.ParseInt(request, "dark_theme", 0)}
+		provided = true
+	}
+	if hasArgument(request, "sketch") {
+		sketch := mcp.ParseBoolean(request, "sketch", false)
+		opts.Sketch = &sketch
+		provided = true
+	}
+	if hasArgument(request, "pad") {
+		pad := mcp.ParseInt(request, "pad", 0)
+		opts.Pad = &pad
+		provided = true
+	}
+	if hasArgument(request, "center") {
+		center := mcp.ParseBoolean(request, "center", false)
+		opts.Center = &center
+		provided = true
+	}
+	if hasArgument(request, "scale") {
+		scale := mcp.ParseFloat64(request, "scale", 0)
+		opts.Scale = &scale
+		provided = true
+	}
+	if hasArgument(request, "toc") {
+		toc := mcp.ParseBoolean(request, "toc", false)
+		opts.TOC = &toc
+		provided = true
+	}
+	if hasArgument(request, "interval") {
+		interval := mcp.ParseInt(request, "interval", 0)
+		opts.Interval = &interval
+		provided = true
+	}
+
+	layout := &entity.LayoutOptions{
+		Engine:      entity.LayoutEngine(mcp.ParseString(request, "layout", "")),
+		Direction:   mcp.ParseString(request, "direction", ""),
+		NodeSpacing: mcp.ParseInt(request, "node_spacing", 0),
+		EdgeSpacing: mcp.ParseInt(request, "edge_spacing", 0),
+		Routing:     entity.EdgeRouting(mcp.ParseString(request, "edge_routing", "")),
+	}
+	if *layout != (entity.LayoutOptions{}) {
+		opts.Layout = layout
+		provided = true
+	}
+
+	raster := &entity.RasterOptions{
+		Width:   mcp.ParseInt(request, "width", 0),
+		Height:  mcp.ParseInt(request, "height", 0),
+		DPI:     mcp.ParseFloat64(request, "dpi", 0),
+		Backend: entity.RasterBackend(mcp.ParseString(request, "raster_backend", "")),
+	}
+	if *raster != (entity.RasterOptions{}) {
+		opts.Raster = raster
+		provided = true
+	}
+
+	if !provided {
+		return nil
+	}
+	return opts
+}
+
+// hasArgument reports whether the request explicitly sets the argument, so that
+// false and 0 can be told apart from "not given".
+func hasArgument(request mcp.CallToolRequest, key string) bool {
+	value, exists := request.GetArguments()[key]
+	return exists && value != nil
+}
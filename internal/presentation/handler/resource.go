@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// diagramsResourceURI is the URI of the resource listing all stored diagrams.
+const diagramsResourceURI = "d2://diagrams"
+
+// diagramResourceURI returns the URI of one view of a stored diagram, such as its
+// source or its SVG rendering. The ID is escaped, since diagram IDs may contain
+// spaces, slashes or percent signs.
+func diagramResourceURI(diagramID, view string) string {
+	return fmt.Sprintf("d2://diagram/%s/%s", url.PathEscape(diagramID), view)
+}
+
+// diagramResourceTemplate returns the URI template of one view of any stored diagram.
+// The server decodes the {id} it matches, so template handlers get the diagram ID as is.
+func diagramResourceTemplate(view string) string {
+	return "d2://diagram/{id}/" + view
+}
+
+// resourceDiagramID returns the diagram ID of a resource request. Templates pass it as
+// an argument; resources registered for a single diagram only have it in their URI.
+func resourceDiagramID(request mcp.ReadResourceRequest) (string, error) {
+	switch id := request.Params.Arguments["id"].(type) {
+	case string:
+		if id != "" {
+			return id, nil
+		}
+	case []string:
+		if len(id) > 0 && id[0] != "" {
+			return id[0], nil
+		}
+	}
+
+	if rest, ok := strings.CutPrefix(request.Params.URI, "d2://diagram/"); ok {
+		if i := strings.LastIndex(rest, "/"); i > 0 {
+			id, err := url.PathUnescape(rest[:i])
+			if err != nil {
+				return "", fmt.Errorf("invalid diagram ID in resource URI %s: %w", request.Params.URI, err)
+			}
+			return id, nil
+		}
+	}
+	return "", fmt.Errorf("no diagram ID in resource URI %s", request.Params.URI)
+}
@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/i2y/d2mcp/internal/usecase"
+)
+
+// DiagramsResourceHandler handles the d2://diagrams resource.
+type DiagramsResourceHandler struct {
+	useCase *usecase.DiagramUseCase
+}
+
+// NewDiagramsResourceHandler creates a new diagrams resource handler.
+func NewDiagramsResourceHandler(useCase *usecase.DiagramUseCase) *DiagramsResourceHandler {
+	return &DiagramsResourceHandler{
+		useCase: useCase,
+	}
+}
+
+// diagramListing describes a stored diagram and the resources it can be read from.
+type diagramListing struct {
+	ID        string `json:"id"`
+	SourceURI string `json:"source_uri"`
+	SVGURI    string `json:"svg_uri"`
+	GraphURI  string `json:"graph_uri"`
+}
+
+// GetResource returns the MCP resource definition.
+func (h *DiagramsResourceHandler) GetResource() mcp.Resource {
+	return mcp.NewResource(
+		diagramsResourceURI,
+		"Diagrams",
+		mcp.WithResourceDescription("All diagrams stored on this server, with the URIs of their D2 source, SVG rendering and object graph."),
+		mcp.WithMIMEType("application/json"),
+	)
+}
+
+// GetHandler returns the resource handler function.
+func (h *DiagramsResourceHandler) GetHandler() server.ResourceHandlerFunc {
+	return h.Handle
+}
+
+// Handle processes the read request.
+func (h *DiagramsResourceHandler) Handle(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	diagrams, err := h.useCase.ListDiagrams(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	listings := make([]diagramListing, len(diagrams))
+	for i, diagram := range diagrams {
+		listings[i] = diagramListing{
+			ID:        diagram.ID,
+			SourceURI: diagramResourceURI(diagram.ID, "source"),
+			SVGURI:    diagramResourceURI(diagram.ID, "svg"),
+			GraphURI:  diagramResourceURI(diagram.ID, "graph.json"),
+		}
+	}
+
+	data, err := json.MarshalIndent(listings, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return []mcp.ResourceContents{
+		mcp.TextResourceContents{
+			URI:      request.Params.URI,
+			MIMEType: "application/json",
+			Text:     string(data),
+		},
+	}, nil
+}
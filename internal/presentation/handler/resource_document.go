@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/i2y/d2mcp/internal/domain/entity"
+	"github.com/i2y/d2mcp/internal/usecase"
+)
+
+// DocumentResourceHandler handles the d2://diagram/{id}/pdf and d2://diagram/{id}/pptx
+// resource templates. These are the URIs d2_export and d2_save embed PDF and PPTX
+// exports under.
+type DocumentResourceHandler struct {
+	useCase *usecase.DiagramUseCase
+	format  entity.ExportFormat
+}
+
+// NewDocumentResourceHandler creates a new diagram document resource handler for
+// entity.FormatPDF or entity.FormatPPTX.
+func NewDocumentResourceHandler(useCase *usecase.DiagramUseCase, format entity.ExportFormat) *DocumentResourceHandler {
+	return &DocumentResourceHandler{
+		useCase: useCase,
+		format:  format,
+	}
+}
+
+// GetResourceTemplate returns the MCP resource template definition.
+func (h *DocumentResourceHandler) GetResourceTemplate() mcp.ResourceTemplate {
+	description := "A stored diagram exported to PDF with its saved layout and render options, one page per board."
+	if h.format == entity.FormatPPTX {
+		description = "A stored diagram exported to PowerPoint with its saved layout and render options, one slide per board."
+	}
+	return mcp.NewResourceTemplate(
+		diagramResourceTemplate(string(h.format)),
+		fmt.Sprintf("Diagram %s", strings.ToUpper(string(h.format))),
+		mcp.WithTemplateDescription(description),
+		mcp.WithTemplateMIMEType(getMimeType(h.format)),
+	)
+}
+
+// GetHandler returns the resource template handler function.
+func (h *DocumentResourceHandler) GetHandler() server.ResourceTemplateHandlerFunc {
+	return h.Handle
+}
+
+// Handle processes the read request.
+func (h *DocumentResourceHandler) Handle(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	diagramID, err := resourceDiagramID(request)
+	if err != nil {
+		return nil, err
+	}
+
+	reader, err := h.useCase.ExportDiagram(ctx, diagramID, h.format, nil)
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	return []mcp.ResourceContents{
+		mcp.BlobResourceContents{
+			URI:      request.Params.URI,
+			MIMEType: getMimeType(h.format),
+			Blob:     base64.StdEncoding.EncodeToString(data),
+		},
+	}, nil
+}
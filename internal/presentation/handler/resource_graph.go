@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/i2y/d2mcp/internal/domain/entity"
+	"github.com/i2y/d2mcp/internal/usecase"
+)
+
+// graphOutput is the JSON form of a diagram graph.
+type graphOutput struct {
+	ID      string                 `json:"id,omitempty"`
+	Content string                 `json:"content,omitempty"`
+	Objects map[string]graphObject `json:"objects"`
+	Edges   map[string]graphEdge   `json:"edges"`
+	Boards  []graphBoard           `json:"boards,omitempty"`
+}
+
+// graphObject is the JSON form of an object.
+type graphObject struct {
+	ID         string         `json:"id"`
+	Label      string         `json:"label,omitempty"`
+	Shape      string         `json:"shape,omitempty"`
+	Parent     string         `json:"parent,omitempty"`
+	Attributes map[string]any `json:"attributes,omitempty"`
+}
+
+// graphEdge is the JSON form of a connection.
+type graphEdge struct {
+	ID         string         `json:"id"`
+	From       string         `json:"from"`
+	To         string         `json:"to"`
+	Label      string         `json:"label,omitempty"`
+	Attributes map[string]any `json:"attributes,omitempty"`
+}
+
+// graphBoard is the JSON form of a layer, scenario or step and its graph.
+type graphBoard struct {
+	Name  string      `json:"name"`
+	Kind  string      `json:"kind"`
+	Graph graphOutput `json:"graph"`
+}
+
+// GraphResourceHandler handles the d2://diagram/{id}/graph.json resource template.
+type GraphResourceHandler struct {
+	useCase *usecase.OracleUseCase
+}
+
+// NewGraphResourceHandler creates a new diagram graph resource handler.
+func NewGraphResourceHandler(useCase *usecase.OracleUseCase) *GraphResourceHandler {
+	return &GraphResourceHandler{
+		useCase: useCase,
+	}
+}
+
+// GetResourceTemplate returns the MCP resource template definition.
+func (h *GraphResourceHandler) GetResourceTemplate() mcp.ResourceTemplate {
+	return mcp.NewResourceTemplate(
+		diagramResourceTemplate("graph.json"),
+		"Diagram graph",
+		mcp.WithTemplateDescription("The objects and connections of a stored diagram's root board as JSON, with their labels, shapes and styles, followed by those of its layers, scenarios and steps under boards."),
+		mcp.WithTemplateMIMEType("application/json"),
+	)
+}
+
+// GetHandler returns the resource template handler function.
+func (h *GraphResourceHandler) GetHandler() server.ResourceTemplateHandlerFunc {
+	return h.Handle
+}
+
+// Handle processes the read request.
+func (h *GraphResourceHandler) Handle(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	diagramID, err := resourceDiagramID(request)
+	if err != nil {
+		return nil, err
+	}
+
+	graph, err := h.useCase.GetGraph(ctx, diagramID)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.MarshalIndent(newGraphOutput(graph), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return []mcp.ResourceContents{
+		mcp.TextResourceContents{
+			URI:      request.Params.URI,
+			MIMEType: "application/json",
+			Text:     string(data),
+		},
+	}, nil
+}
+
+// newGraphOutput converts a diagram graph and its boards to their JSON form.
+func newGraphOutput(graph *entity.DiagramGraph) graphOutput {
+	output := graphOutput{
+		ID:      graph.ID,
+		Content: graph.Content,
+		Objects: make(map[string]graphObject, len(graph.Objects)),
+		Edges:   make(map[string]graphEdge, len(graph.Edges)),
+	}
+	for key, obj := range graph.Objects {
+		output.Objects[key] = graphObject{
+			ID:         obj.ID,
+			Label:      obj.Label,
+			Shape:      obj.Shape,
+			Parent:     obj.Parent,
+			Attributes: obj.Attributes,
+		}
+	}
+	for key, edge := range graph.Edges {
+		output.Edges[key] = graphEdge{
+			ID:         edge.ID,
+			From:       edge.From,
+			To:         edge.To,
+			Label:      edge.Label,
+			Attributes: edge.Attributes,
+		}
+	}
+	for _, board := range graph.Boards {
+		output.Boards = append(output.Boards, graphBoard{
+			Name:  board.Name,
+			Kind:  string(board.Kind),
+			Graph: newGraphOutput(board.Graph),
+		})
+	}
+	return output
+}
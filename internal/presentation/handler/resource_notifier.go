@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"log"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// diagramViews are the views of a diagram that are served as resources.
+var diagramViews = []string{"source", "svg", "graph.json", "pdf", "pptx"}
+
+// ResourcePublisher registers and removes resources and tells the sessions following
+// them when they change.
+type ResourcePublisher interface {
+	RegisterResource(resource mcp.Resource, handler server.ResourceHandlerFunc) error
+	UnregisterResource(uri string)
+	NotifyResourceUpdated(uri string)
+}
+
+// DiagramResourceNotifier registers every stored diagram as a resource and notifies
+// the sessions following a diagram's resources whenever it changes or is removed.
+type DiagramResourceNotifier struct {
+	publisher  ResourcePublisher
+	source     *SourceResourceHandler
+	mu         sync.Mutex
+	registered map[string]bool
+}
+
+// NewDiagramResourceNotifier creates a new diagram resource notifier. Diagram
+// resources are read through the source resource handler.
+func NewDiagramResourceNotifier(publisher ResourcePublisher, source *SourceResourceHandler) *DiagramResourceNotifier {
+	return &DiagramResourceNotifier{
+		publisher:  publisher,
+		source:     source,
+		registered: make(map[string]bool),
+	}
+}
+
+// DiagramChanged registers the diagram as a resource the first time it is seen and
+// sends resources/updated notifications for each of its views.
+func (n *DiagramResourceNotifier) DiagramChanged(diagramID string) {
+	n.mu.Lock()
+	added := !n.registered[diagramID]
+	n.registered[diagramID] = true
+	n.mu.Unlock()
+
+	if added {
+		resource := mcp.NewResource(
+			diagramResourceURI(diagramID, "source"),
+			diagramID,
+			mcp.WithResourceDescription("D2 source of the diagram "+diagramID),
+			mcp.WithMIMEType("text/x-d2"),
+		)
+		if err := n.publisher.RegisterResource(resource, n.source.Handle); err != nil {
+			log.Printf("Failed to register resource for diagram %s: %v", diagramID, err)
+		}
+		n.publisher.NotifyResourceUpdated(diagramsResourceURI)
+	}
+
+	for _, view := range diagramViews {
+		n.publisher.NotifyResourceUpdated(diagramResourceURI(diagramID, view))
+	}
+}
+
+// DiagramRemoved removes the resource of a diagram that is no longer stored and
+// sends resources/updated notifications for the diagram list and its views, so
+// that sessions following them read them again.
+func (n *DiagramResourceNotifier) DiagramRemoved(diagramID string) {
+	n.mu.Lock()
+	registered := n.registered[diagramID]
+	delete(n.registered, diagramID)
+	n.mu.Unlock()
+
+	if registered {
+		n.publisher.UnregisterResource(diagramResourceURI(diagramID, "source"))
+	}
+	n.publisher.NotifyResourceUpdated(diagramsResourceURI)
+	for _, view := range diagramViews {
+		n.publisher.NotifyResourceUpdated(diagramResourceURI(diagramID, view))
+	}
+}
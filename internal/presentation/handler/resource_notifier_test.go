@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/i2y/d2mcp/internal/domain/entity"
+)
+
+// testSession is a client session that collects the notifications it is sent.
+type testSession struct {
+	id            string
+	notifications chan mcp.JSONRPCNotification
+}
+
+func (s *testSession) SessionID() string { return s.id }
+
+func (s *testSession) NotificationChannel() chan<- mcp.JSONRPCNotification {
+	return s.notifications
+}
+
+func (s *testSession) Initialize() {}
+
+func (s *testSession) Initialized() bool { return true }
+
+// waitForUpdate waits for a resources/updated notification about uri.
+func (s *testSession) waitForUpdate(t *testing.T, uri string) {
+	t.Helper()
+
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case notification := <-s.notifications:
+			if notification.Method == mcp.MethodNotificationResourceUpdated && notification.Params.AdditionalFields["uri"] == uri {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("No resources/updated notification for %s", uri)
+		}
+	}
+}
+
+func TestDiagramResourceNotifier_EscapedIDs(t *testing.T) {
+	for _, id := range []string{"my diagram", "team/net"} {
+		t.Run(id, func(t *testing.T) {
+			ctx := context.Background()
+			server := newTestServer(t)
+
+			if err := server.diagramUseCase.Create(ctx, id, "a -> b"); err != nil {
+				t.Fatalf("Create failed: %v", err)
+			}
+
+			session := &testSession{id: "session", notifications: make(chan mcp.JSONRPCNotification, 100)}
+			mcpServer := server.server.GetMCPServer()
+			if err := mcpServer.RegisterSession(ctx, session); err != nil {
+				t.Fatalf("RegisterSession failed: %v", err)
+			}
+			sessionCtx := mcpServer.WithContext(ctx, session)
+
+			// The diagram's own resource and the graph template both follow the change.
+			sourceURI := diagramResourceURI(id, "source")
+			graphURI := diagramResourceURI(id, "graph.json")
+			server.read(t, sessionCtx, sourceURI)
+			server.read(t, sessionCtx, graphURI)
+
+			if _, err := server.oracleUseCase.CreateElement(ctx, &entity.OracleOperation{
+				DiagramID: id,
+				Type:      entity.OracleCreate,
+				Key:       "c",
+			}); err != nil {
+				t.Fatalf("CreateElement failed: %v", err)
+			}
+			session.waitForUpdate(t, sourceURI)
+			session.waitForUpdate(t, graphURI)
+
+			if err := server.diagramUseCase.DeleteDiagram(ctx, id); err != nil {
+				t.Fatalf("DeleteDiagram failed: %v", err)
+			}
+			session.waitForUpdate(t, sourceURI)
+		})
+	}
+}
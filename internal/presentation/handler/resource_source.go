@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/i2y/d2mcp/internal/usecase"
+)
+
+// SourceResourceHandler handles the d2://diagram/{id}/source resource template.
+type SourceResourceHandler struct {
+	useCase *usecase.OracleUseCase
+}
+
+// NewSourceResourceHandler creates a new diagram source resource handler.
+func NewSourceResourceHandler(useCase *usecase.OracleUseCase) *SourceResourceHandler {
+	return &SourceResourceHandler{
+		useCase: useCase,
+	}
+}
+
+// GetResourceTemplate returns the MCP resource template definition.
+func (h *SourceResourceHandler) GetResourceTemplate() mcp.ResourceTemplate {
+	return mcp.NewResourceTemplate(
+		diagramResourceTemplate("source"),
+		"Diagram source",
+		mcp.WithTemplateDescription("The current D2 source of a stored diagram, including all edits made with the Oracle and board tools."),
+		mcp.WithTemplateMIMEType("text/x-d2"),
+	)
+}
+
+// GetHandler returns the resource template handler function.
+func (h *SourceResourceHandler) GetHandler() server.ResourceTemplateHandlerFunc {
+	return h.Handle
+}
+
+// Handle processes the read request.
+func (h *SourceResourceHandler) Handle(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	diagramID, err := resourceDiagramID(request)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := h.useCase.SerializeDiagram(ctx, diagramID)
+	if err != nil {
+		return nil, err
+	}
+
+	return []mcp.ResourceContents{
+		mcp.TextResourceContents{
+			URI:      request.Params.URI,
+			MIMEType: "text/x-d2",
+			Text:     content,
+		},
+	}, nil
+}
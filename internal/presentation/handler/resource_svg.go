@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"context"
+	"io"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/i2y/d2mcp/internal/domain/entity"
+	"github.com/i2y/d2mcp/internal/usecase"
+)
+
+// SVGResourceHandler handles the d2://diagram/{id}/svg resource template.
+type SVGResourceHandler struct {
+	useCase *usecase.DiagramUseCase
+}
+
+// NewSVGResourceHandler creates a new diagram SVG resource handler.
+func NewSVGResourceHandler(useCase *usecase.DiagramUseCase) *SVGResourceHandler {
+	return &SVGResourceHandler{
+		useCase: useCase,
+	}
+}
+
+// GetResourceTemplate returns the MCP resource template definition.
+func (h *SVGResourceHandler) GetResourceTemplate() mcp.ResourceTemplate {
+	return mcp.NewResourceTemplate(
+		diagramResourceTemplate("svg"),
+		"Diagram SVG",
+		mcp.WithTemplateDescription("A stored diagram rendered to SVG with its saved layout and render options."),
+		mcp.WithTemplateMIMEType("image/svg+xml"),
+	)
+}
+
+// GetHandler returns the resource template handler function.
+func (h *SVGResourceHandler) GetHandler() server.ResourceTemplateHandlerFunc {
+	return h.Handle
+}
+
+// Handle processes the read request.
+func (h *SVGResourceHandler) Handle(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	diagramID, err := resourceDiagramID(request)
+	if err != nil {
+		return nil, err
+	}
+
+	reader, err := h.useCase.ExportDiagram(ctx, diagramID, entity.FormatSVG, nil)
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	return []mcp.ResourceContents{
+		mcp.TextResourceContents{
+			URI:      request.Params.URI,
+			MIMEType: "image/svg+xml",
+			Text:     string(data),
+		},
+	}, nil
+}
@@ -0,0 +1,237 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/i2y/d2mcp/internal/domain/entity"
+	"github.com/i2y/d2mcp/internal/infrastructure/d2"
+	mcpserver "github.com/i2y/d2mcp/internal/infrastructure/mcp"
+	"github.com/i2y/d2mcp/internal/usecase"
+)
+
+// testServer is an MCP server serving the diagram resources of an in-memory repository.
+type testServer struct {
+	server         *mcpserver.Server
+	diagramUseCase *usecase.DiagramUseCase
+	oracleUseCase  *usecase.OracleUseCase
+}
+
+// newTestServer registers the diagram resources the way cmd/d2mcp does.
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+
+	repo := d2.NewD2OracleRepository()
+	diagramUseCase := usecase.NewDiagramUseCase(repo)
+	oracleUseCase := usecase.NewOracleUseCase(repo)
+
+	server, err := mcpserver.NewServer("d2mcp-test", "test")
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+
+	diagrams := NewDiagramsResourceHandler(diagramUseCase)
+	source := NewSourceResourceHandler(oracleUseCase)
+	svg := NewSVGResourceHandler(diagramUseCase)
+	graph := NewGraphResourceHandler(oracleUseCase)
+	pdf := NewDocumentResourceHandler(diagramUseCase, entity.FormatPDF)
+	pptx := NewDocumentResourceHandler(diagramUseCase, entity.FormatPPTX)
+	if err := server.RegisterResource(diagrams.GetResource(), diagrams.GetHandler()); err != nil {
+		t.Fatal(err)
+	}
+	if err := server.RegisterResourceTemplate(source.GetResourceTemplate(), source.GetHandler()); err != nil {
+		t.Fatal(err)
+	}
+	if err := server.RegisterResourceTemplate(svg.GetResourceTemplate(), svg.GetHandler()); err != nil {
+		t.Fatal(err)
+	}
+	if err := server.RegisterResourceTemplate(graph.GetResourceTemplate(), graph.GetHandler()); err != nil {
+		t.Fatal(err)
+	}
+	if err := server.RegisterResourceTemplate(pdf.GetResourceTemplate(), pdf.GetHandler()); err != nil {
+		t.Fatal(err)
+	}
+	if err := server.RegisterResourceTemplate(pptx.GetResourceTemplate(), pptx.GetHandler()); err != nil {
+		t.Fatal(err)
+	}
+
+	notifier := NewDiagramResourceNotifier(server, source)
+	repo.OnChange(notifier.DiagramChanged)
+	repo.OnRemove(notifier.DiagramRemoved)
+
+	return &testServer{
+		server:         server,
+		diagramUseCase: diagramUseCase,
+		oracleUseCase:  oracleUseCase,
+	}
+}
+
+// read reads a resource through the MCP server and returns its contents.
+func (s *testServer) read(t *testing.T, ctx context.Context, uri string) []mcp.ResourceContents {
+	t.Helper()
+
+	message, err := json.Marshal(map[string]any{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  "resources/read",
+		"params":  map[string]any{"uri": uri},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	switch response := s.server.GetMCPServer().HandleMessage(ctx, message).(type) {
+	case mcp.JSONRPCResponse:
+		result, ok := response.Result.(mcp.ReadResourceResult)
+		if !ok {
+			t.Fatalf("Reading %s returned %T", uri, response.Result)
+		}
+		return result.Contents
+	case mcp.JSONRPCError:
+		t.Fatalf("Reading %s failed: %s", uri, response.Error.Message)
+	default:
+		t.Fatalf("Reading %s returned %T", uri, response)
+	}
+	return nil
+}
+
+// text returns the text of a single text resource.
+func text(t *testing.T, contents []mcp.ResourceContents) string {
+	t.Helper()
+
+	if len(contents) != 1 {
+		t.Fatalf("Expected 1 content, got %d", len(contents))
+	}
+	content, ok := contents[0].(mcp.TextResourceContents)
+	if !ok {
+		t.Fatalf("Expected text contents, got %T", contents[0])
+	}
+	return content.Text
+}
+
+func TestDiagramResourceURI(t *testing.T) {
+	tests := []struct {
+		id   string
+		want string
+	}{
+		{"simple", "d2://diagram/simple/source"},
+		{"my diagram", "d2://diagram/my%20diagram/source"},
+		{"team/net", "d2://diagram/team%2Fnet/source"},
+		{"100%", "d2://diagram/100%25/source"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			uri := diagramResourceURI(tt.id, "source")
+			if uri != tt.want {
+				t.Errorf("Expected URI %s, got %s", tt.want, uri)
+			}
+
+			// Resources registered for a single diagram only carry the ID in their URI.
+			request := mcp.ReadResourceRequest{}
+			request.Params.URI = uri
+			id, err := resourceDiagramID(request)
+			if err != nil {
+				t.Fatalf("resourceDiagramID failed: %v", err)
+			}
+			if id != tt.id {
+				t.Errorf("Expected ID %q, got %q", tt.id, id)
+			}
+		})
+	}
+}
+
+func TestResourceDiagramID_Argument(t *testing.T) {
+	request := mcp.ReadResourceRequest{}
+	request.Params.URI = "d2://diagram/team%2Fnet/svg"
+	request.Params.Arguments = map[string]any{"id": []string{"team/net"}}
+
+	id, err := resourceDiagramID(request)
+	if err != nil {
+		t.Fatalf("resourceDiagramID failed: %v", err)
+	}
+	if id != "team/net" {
+		t.Errorf("Expected ID team/net, got %q", id)
+	}
+
+	request.Params.URI = "d2://diagrams"
+	request.Params.Arguments = nil
+	if _, err := resourceDiagramID(request); err == nil {
+		t.Error("Expected an error for a URI without a diagram ID")
+	}
+}
+
+func TestResources_ReadListedURIs(t *testing.T) {
+	ctx := context.Background()
+	server := newTestServer(t)
+
+	for _, id := range []string{"simple", "my diagram", "team/net", "100%"} {
+		if err := server.diagramUseCase.Create(ctx, id, "a -> b"); err != nil {
+			t.Fatalf("Create %q failed: %v", id, err)
+		}
+	}
+
+	var listing []diagramListing
+	if err := json.Unmarshal([]byte(text(t, server.read(t, ctx, diagramsResourceURI))), &listing); err != nil {
+		t.Fatalf("Failed to decode diagram list: %v", err)
+	}
+	if len(listing) != 4 {
+		t.Fatalf("Expected 4 diagrams, got %d", len(listing))
+	}
+
+	for _, diagram := range listing {
+		if source := text(t, server.read(t, ctx, diagram.SourceURI)); !strings.Contains(source, "a -> b") {
+			t.Errorf("Source of %q does not contain the diagram: %s", diagram.ID, source)
+		}
+		if svg := text(t, server.read(t, ctx, diagram.SVGURI)); !strings.Contains(svg, "<svg") {
+			t.Errorf("SVG of %q is not an SVG", diagram.ID)
+		}
+
+		var graph map[string]any
+		if err := json.Unmarshal([]byte(text(t, server.read(t, ctx, diagram.GraphURI))), &graph); err != nil {
+			t.Fatalf("Failed to decode graph of %q: %v", diagram.ID, err)
+		}
+		if graph["id"] != diagram.ID {
+			t.Errorf("Expected graph id %q, got %v", diagram.ID, graph["id"])
+		}
+		objects, ok := graph["objects"].(map[string]any)
+		if !ok || len(objects) != 2 {
+			t.Errorf("Expected 2 objects under \"objects\", got %v", graph["objects"])
+		}
+		if _, ok := graph["edges"].(map[string]any); !ok {
+			t.Errorf("Expected edges under \"edges\", got %v", graph)
+		}
+	}
+}
+
+func TestGraphResource_Boards(t *testing.T) {
+	ctx := context.Background()
+	server := newTestServer(t)
+
+	content := "a\nlayers: {\n  net: {\n    router -> firewall\n  }\n}\n"
+	if err := server.diagramUseCase.Create(ctx, "boards", content); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	var graph graphOutput
+	if err := json.Unmarshal([]byte(text(t, server.read(t, ctx, diagramResourceURI("boards", "graph.json")))), &graph); err != nil {
+		t.Fatalf("Failed to decode graph: %v", err)
+	}
+	if len(graph.Boards) != 1 {
+		t.Fatalf("Expected 1 board, got %d", len(graph.Boards))
+	}
+	board := graph.Boards[0]
+	if board.Name != "net" || board.Kind != "layer" {
+		t.Errorf("Expected layer net, got %s %s", board.Kind, board.Name)
+	}
+	if _, ok := board.Graph.Objects["firewall"]; !ok {
+		t.Errorf("Expected firewall in the layer, got %v", board.Graph.Objects)
+	}
+	if len(board.Graph.Edges) != 1 {
+		t.Errorf("Expected 1 edge in the layer, got %d", len(board.Graph.Edges))
+	}
+}
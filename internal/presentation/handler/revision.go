@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	"github.com/i2y/d2mcp/internal/domain/repository"
+)
+
+// expectedRevisionDescription documents the expected_revision argument of the tools that change a diagram.
+const expectedRevisionDescription = "Optional revision the change is based on, as reported by an earlier call. The change is rejected with a revision conflict if the diagram has been changed since, so concurrent edits cannot overwrite each other. Use 0 to require that the diagram does not exist yet"
+
+// withExpectedRevision adds the expected_revision argument to a tool.
+func withExpectedRevision() mcp.ToolOption {
+	return mcp.WithNumber("expected_revision", mcp.Description(expectedRevisionDescription), mcp.Min(0))
+}
+
+// parseExpectedRevision extracts the expected_revision argument, or nil when it is not given.
+func parseExpectedRevision(request mcp.CallToolRequest) (*int64, error) {
+	if value, ok := request.GetArguments()["expected_revision"]; !ok || value == nil {
+		return nil, nil
+	}
+
+	expected := mcp.ParseInt64(request, "expected_revision", -1)
+	if expected < 0 {
+		return nil, fmt.Errorf("expected_revision must be a non-negative integer")
+	}
+	return &expected, nil
+}
+
+// trackRevision returns a context under which the revision of diagramID is recorded.
+func trackRevision(ctx context.Context, diagramID string) (context.Context, *repository.Revision) {
+	revision := &repository.Revision{DiagramID: diagramID}
+	return repository.WithRevision(ctx, revision), revision
+}
+
+// expectRevision is trackRevision for tools that change the diagram, rejecting the
+// change unless the diagram is at the expected_revision of the request.
+func expectRevision(ctx context.Context, request mcp.CallToolRequest, diagramID string) (context.Context, *repository.Revision, error) {
+	expected, err := parseExpectedRevision(request)
+	if err != nil {
+		return ctx, nil, err
+	}
+
+	ctx, revision := trackRevision(ctx, diagramID)
+	revision.Expected = expected
+	return ctx, revision, nil
+}
+
+// withRevision appends the revision of the diagram to a tool result.
+func withRevision(result *mcp.CallToolResult, revision int64) *mcp.CallToolResult {
+	result.Content = append(result.Content, mcp.NewTextContent(fmt.Sprintf("Revision: %d", revision)))
+	return result
+}
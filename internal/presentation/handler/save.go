@@ -4,8 +4,7 @@ import (
 	"context"
 	"fmt"
 	"io"
-	"os"
-	"path/filepath"
+	"net/url"
 	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
@@ -15,27 +14,42 @@ import (
 	"github.com/i2y/d2mcp/internal/usecase"
 )
 
+// OutputWriter writes saved diagrams below the server's output directory. Write
+// returns the absolute path the file ended up at, and fails for paths outside of
+// the output directory or files it may not overwrite.
+type OutputWriter interface {
+	Write(name string, data []byte) (string, error)
+}
+
 // SaveHandler handles the d2_save tool.
 type SaveHandler struct {
-	useCase *usecase.DiagramUseCase
+	useCase     *usecase.DiagramUseCase
+	diffUseCase *usecase.DiffUseCase
+	output      OutputWriter
 }
 
-// NewSaveHandler creates a new save handler.
-func NewSaveHandler(useCase *usecase.DiagramUseCase) *SaveHandler {
+// NewSaveHandler creates a new save handler writing to output.
+func NewSaveHandler(useCase *usecase.DiagramUseCase, diffUseCase *usecase.DiffUseCase, output OutputWriter) *SaveHandler {
 	return &SaveHandler{
-		useCase: useCase,
+		useCase:     useCase,
+		diffUseCase: diffUseCase,
+		output:      output,
 	}
 }
 
 // GetTool returns the MCP tool definition.
 func (h *SaveHandler) GetTool() mcp.Tool {
-	return mcp.NewTool(
-		"d2_save",
-		mcp.WithDescription("Save an existing diagram to a file on disk. The diagram must be created first using d2_create. This tool exports the diagram in the specified format and writes it to a file path, returning the path where it was saved. Supported formats: svg (default), png, pdf. If no path is provided, saves to a temporary directory with a timestamped filename. Path handling: absolute paths (e.g., /Users/name/diagram.svg) are used as-is; relative paths (e.g., diagram.svg) are resolved from the MCP server's working directory. When unsure, either use absolute paths or omit the path to use the temp directory."),
+	opts := []mcp.ToolOption{
+		mcp.WithDescription("Save an existing diagram to a file on disk. The diagram must be created first using d2_create. This tool exports the diagram in the specified format and writes it to a file path, returning the path where it was saved. Supported formats: svg (default), png, pdf, pptx (a slide per board), animated_svg (saved as .svg) and gif; the animated formats cycle through the root board and its steps. Files are only written inside the server's output directory: relative paths (e.g., diagram.svg or exports/diagram.svg) are resolved against it, absolute paths must lie inside it, and paths leaving it through '..' or symlinks are rejected. If no path is provided, saves to the output directory with a timestamped filename. Existing files are replaced by default; servers can instead be configured to keep them and save the new file under a numbered name, or to report an error. The result reports the path actually written."),
 		mcp.WithString("diagramId", mcp.Description("ID of the diagram to save"), mcp.Required()),
-		mcp.WithString("format", mcp.Description("Export format (svg, png, pdf)"), mcp.Enum("svg", "png", "pdf"), mcp.DefaultString("svg")),
-		mcp.WithString("path", mcp.Description("Output file path. Examples: '/Users/name/diagram.svg' (absolute), 'output/diagram.svg' (relative to MCP server), or omit for auto-generated path in temp directory")),
-	)
+		mcp.WithString("format", mcp.Description("Export format (svg, png, pdf, pptx, animated_svg, gif)"), mcp.Enum("svg", "png", "pdf", "pptx", "animated_svg", "gif"), mcp.DefaultString("svg")),
+		mcp.WithString("path", mcp.Description("Output file path inside the server's output directory. Examples: 'diagram.svg', 'exports/diagram.svg' (relative to the output directory), or omit for an auto-generated name")),
+	}
+	opts = append(opts, renderToolOptions()...)
+	opts = append(opts, highlightToolOptions()...)
+	opts = append(opts, thumbnailToolOptions()...)
+
+	return mcp.NewTool("d2_save", opts...)
 }
 
 // GetHandler returns the tool handler function.
@@ -56,8 +70,14 @@ func (h *SaveHandler) Handle(ctx context.Context, request mcp.CallToolRequest) (
 
 	outputPath := mcp.ParseString(request, "path", "")
 
+	ctx, revision := trackRevision(ctx, diagramID)
+	renderOpts, err := withHighlight(ctx, h.diffUseCase, diagramID, request, parseRenderOptions(request))
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to compare with the baseline", err), nil
+	}
+
 	// Export the diagram.
-	reader, err := h.useCase.ExportDiagram(ctx, diagramID, format)
+	reader, err := h.useCase.ExportDiagram(ctx, diagramID, format, renderOpts)
 	if err != nil {
 		return mcp.NewToolResultErrorFromErr("Failed to export diagram", err), nil
 	}
@@ -68,28 +88,15 @@ func (h *SaveHandler) Handle(ctx context.Context, request mcp.CallToolRequest) (
 		return mcp.NewToolResultErrorFromErr("Failed to read exported output", err), nil
 	}
 
-	// Determine output path.
+	// Generate a filename when no path is given.
 	if outputPath == "" {
-		// Create output directory in temp.
-		outputDir := filepath.Join(os.TempDir(), "d2mcp_output")
-		if err := os.MkdirAll(outputDir, 0755); err != nil {
-			return mcp.NewToolResultErrorFromErr("Failed to create output directory", err), nil
-		}
-
-		// Generate filename.
 		timestamp := time.Now().Unix()
-		filename := fmt.Sprintf("%s_%d.%s", diagramID, timestamp, formatStr)
-		outputPath = filepath.Join(outputDir, filename)
-	} else {
-		// Ensure directory exists.
-		dir := filepath.Dir(outputPath)
-		if err := os.MkdirAll(dir, 0755); err != nil {
-			return mcp.NewToolResultErrorFromErr("Failed to create output directory", err), nil
-		}
+		outputPath = fmt.Sprintf("%s_%d.%s", url.PathEscape(diagramID), timestamp, fileExtension(format))
 	}
 
 	// Write to file.
-	if err := os.WriteFile(outputPath, data, 0644); err != nil {
+	outputPath, err = h.output.Write(outputPath, data)
+	if err != nil {
 		return mcp.NewToolResultErrorFromErr("Failed to write output file", err), nil
 	}
 
@@ -98,5 +105,21 @@ func (h *SaveHandler) Handle(ctx context.Context, request mcp.CallToolRequest) (
 	result += fmt.Sprintf("Format: %s\n", formatStr)
 	result += fmt.Sprintf("Size: %d bytes", len(data))
 
-	return mcp.NewToolResultText(result), nil
+	thumbnail, err := thumbnailContent(ctx, h.useCase, diagramID, request, renderOpts)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to render thumbnail", err), nil
+	}
+	if thumbnail != nil {
+		return withRevision(&mcp.CallToolResult{Content: []mcp.Content{mcp.NewTextContent(result), thumbnail}}, revision.Current), nil
+	}
+
+	return withRevision(mcp.NewToolResultText(result), revision.Current), nil
+}
+
+// fileExtension returns the file extension used for the given format.
+func fileExtension(format entity.ExportFormat) string {
+	if format == entity.FormatAnimatedSVG {
+		return "svg"
+	}
+	return string(format)
 }
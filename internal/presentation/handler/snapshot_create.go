@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/i2y/d2mcp/internal/usecase"
+)
+
+// SnapshotCreateHandler handles the d2_snapshot_create tool.
+type SnapshotCreateHandler struct {
+	useCase *usecase.SnapshotUseCase
+}
+
+// NewSnapshotCreateHandler creates a new snapshot create handler.
+func NewSnapshotCreateHandler(useCase *usecase.SnapshotUseCase) *SnapshotCreateHandler {
+	return &SnapshotCreateHandler{
+		useCase: useCase,
+	}
+}
+
+// GetTool returns the MCP tool definition.
+func (h *SnapshotCreateHandler) GetTool() mcp.Tool {
+	return mcp.NewTool(
+		"d2_snapshot_create",
+		mcp.WithDescription("Save the current state of a diagram as a named checkpoint, such as 'before-refactor'. Take a snapshot before a risky or large change so the diagram can be reverted to a known-good state with d2_snapshot_restore if the change is rejected. Taking a snapshot with an existing name replaces it."),
+		mcp.WithString("diagram_id", mcp.Description("ID of the diagram to snapshot"), mcp.Required()),
+		mcp.WithString("name", mcp.Description("Name of the snapshot (e.g., 'before-refactor')"), mcp.Required()),
+	)
+}
+
+// GetHandler returns the tool handler function.
+func (h *SnapshotCreateHandler) GetHandler() server.ToolHandlerFunc {
+	return h.Handle
+}
+
+// Handle processes the snapshot create request.
+func (h *SnapshotCreateHandler) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// Extract arguments.
+	diagramID := mcp.ParseString(request, "diagram_id", "")
+	name := mcp.ParseString(request, "name", "")
+
+	ctx, revision := trackRevision(ctx, diagramID)
+	snapshot, err := h.useCase.CreateSnapshot(ctx, diagramID, name)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to create snapshot", err), nil
+	}
+
+	if !snapshot.UpdatedAt.Equal(snapshot.CreatedAt) {
+		return withRevision(mcp.NewToolResultText(fmt.Sprintf("Snapshot '%s' of diagram '%s' replaced with the current state", snapshot.Name, diagramID)), revision.Current), nil
+	}
+	return withRevision(mcp.NewToolResultText(fmt.Sprintf("Snapshot '%s' of diagram '%s' created. Use d2_snapshot_restore to revert to it.", snapshot.Name, diagramID)), revision.Current), nil
+}
@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/i2y/d2mcp/internal/usecase"
+)
+
+// SnapshotDeleteHandler handles the d2_snapshot_delete tool.
+type SnapshotDeleteHandler struct {
+	useCase *usecase.SnapshotUseCase
+}
+
+// NewSnapshotDeleteHandler creates a new snapshot delete handler.
+func NewSnapshotDeleteHandler(useCase *usecase.SnapshotUseCase) *SnapshotDeleteHandler {
+	return &SnapshotDeleteHandler{
+		useCase: useCase,
+	}
+}
+
+// GetTool returns the MCP tool definition.
+func (h *SnapshotDeleteHandler) GetTool() mcp.Tool {
+	return mcp.NewTool(
+		"d2_snapshot_delete",
+		mcp.WithDescription("Delete a named snapshot of a diagram. The diagram itself is not changed."),
+		mcp.WithString("diagram_id", mcp.Description("ID of the diagram"), mcp.Required()),
+		mcp.WithString("name", mcp.Description("Name of the snapshot to delete"), mcp.Required()),
+	)
+}
+
+// GetHandler returns the tool handler function.
+func (h *SnapshotDeleteHandler) GetHandler() server.ToolHandlerFunc {
+	return h.Handle
+}
+
+// Handle processes the snapshot delete request.
+func (h *SnapshotDeleteHandler) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// Extract arguments.
+	diagramID := mcp.ParseString(request, "diagram_id", "")
+	name := mcp.ParseString(request, "name", "")
+
+	ctx, revision := trackRevision(ctx, diagramID)
+	if err := h.useCase.DeleteSnapshot(ctx, diagramID, name); err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to delete snapshot", err), nil
+	}
+
+	return withRevision(mcp.NewToolResultText(fmt.Sprintf("Snapshot '%s' deleted successfully", name)), revision.Current), nil
+}
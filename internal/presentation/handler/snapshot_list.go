@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/i2y/d2mcp/internal/usecase"
+)
+
+// SnapshotListHandler handles the d2_snapshot_list tool.
+type SnapshotListHandler struct {
+	useCase *usecase.SnapshotUseCase
+}
+
+// NewSnapshotListHandler creates a new snapshot list handler.
+func NewSnapshotListHandler(useCase *usecase.SnapshotUseCase) *SnapshotListHandler {
+	return &SnapshotListHandler{
+		useCase: useCase,
+	}
+}
+
+// snapshotSummary describes a snapshot in the d2_snapshot_list output.
+type snapshotSummary struct {
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Size      int       `json:"size"` // Length of the D2 source in bytes
+}
+
+// GetTool returns the MCP tool definition.
+func (h *SnapshotListHandler) GetTool() mcp.Tool {
+	return mcp.NewTool(
+		"d2_snapshot_list",
+		mcp.WithDescription("List the named snapshots of a diagram as JSON, oldest first, with the time each was created and last replaced. Use this to find a checkpoint to restore with d2_snapshot_restore."),
+		mcp.WithString("diagram_id", mcp.Description("ID of the diagram"), mcp.Required()),
+	)
+}
+
+// GetHandler returns the tool handler function.
+func (h *SnapshotListHandler) GetHandler() server.ToolHandlerFunc {
+	return h.Handle
+}
+
+// Handle processes the snapshot list request.
+func (h *SnapshotListHandler) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// Extract arguments.
+	diagramID := mcp.ParseString(request, "diagram_id", "")
+
+	ctx, revision := trackRevision(ctx, diagramID)
+	snapshots, err := h.useCase.ListSnapshots(ctx, diagramID)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to list snapshots", err), nil
+	}
+
+	if len(snapshots) == 0 {
+		return withRevision(mcp.NewToolResultText(fmt.Sprintf("Diagram '%s' has no snapshots", diagramID)), revision.Current), nil
+	}
+
+	summaries := make([]snapshotSummary, len(snapshots))
+	for i, snapshot := range snapshots {
+		summaries[i] = snapshotSummary{
+			Name:      snapshot.Name,
+			CreatedAt: snapshot.CreatedAt,
+			UpdatedAt: snapshot.UpdatedAt,
+			Size:      len(snapshot.Content),
+		}
+	}
+
+	jsonData, err := json.MarshalIndent(summaries, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError("Failed to format snapshot list"), nil
+	}
+
+	return withRevision(mcp.NewToolResultText(fmt.Sprintf("Snapshots of '%s':\n%s", diagramID, string(jsonData))), revision.Current), nil
+}
@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/i2y/d2mcp/internal/usecase"
+)
+
+// SnapshotRestoreHandler handles the d2_snapshot_restore tool.
+type SnapshotRestoreHandler struct {
+	useCase *usecase.SnapshotUseCase
+}
+
+// NewSnapshotRestoreHandler creates a new snapshot restore handler.
+func NewSnapshotRestoreHandler(useCase *usecase.SnapshotUseCase) *SnapshotRestoreHandler {
+	return &SnapshotRestoreHandler{
+		useCase: useCase,
+	}
+}
+
+// GetTool returns the MCP tool definition.
+func (h *SnapshotRestoreHandler) GetTool() mcp.Tool {
+	return mcp.NewTool(
+		"d2_snapshot_restore",
+		mcp.WithDescription("Revert a diagram to a named snapshot taken with d2_snapshot_create, discarding the changes made since. The snapshot is kept, so it can be restored again, and the restore itself can be reverted with d2_undo."),
+		mcp.WithString("diagram_id", mcp.Description("ID of the diagram to revert"), mcp.Required()),
+		withExpectedRevision(),
+		mcp.WithString("name", mcp.Description("Name of the snapshot to restore"), mcp.Required()),
+	)
+}
+
+// GetHandler returns the tool handler function.
+func (h *SnapshotRestoreHandler) GetHandler() server.ToolHandlerFunc {
+	return h.Handle
+}
+
+// Handle processes the snapshot restore request.
+func (h *SnapshotRestoreHandler) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// Extract arguments.
+	diagramID := mcp.ParseString(request, "diagram_id", "")
+
+	ctx, revision, err := expectRevision(ctx, request, diagramID)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Invalid expected_revision", err), nil
+	}
+	name := mcp.ParseString(request, "name", "")
+
+	snapshot, err := h.useCase.RestoreSnapshot(ctx, diagramID, name)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to restore snapshot", err), nil
+	}
+
+	return withRevision(mcp.NewToolResultText(fmt.Sprintf("Diagram '%s' restored to snapshot '%s' taken at %s", diagramID, snapshot.Name, snapshot.UpdatedAt.Format(time.RFC3339))), revision.Current), nil
+}
@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/i2y/d2mcp/internal/domain/entity"
+	"github.com/i2y/d2mcp/internal/usecase"
+)
+
+// UndoHandler handles the d2_undo tool.
+type UndoHandler struct {
+	useCase *usecase.OracleUseCase
+}
+
+// NewUndoHandler creates a new undo handler.
+func NewUndoHandler(useCase *usecase.OracleUseCase) *UndoHandler {
+	return &UndoHandler{
+		useCase: useCase,
+	}
+}
+
+// GetTool returns the MCP tool definition.
+func (h *UndoHandler) GetTool() mcp.Tool {
+	return mcp.NewTool(
+		"d2_undo",
+		mcp.WithDescription("Revert the most recent changes to a diagram. Every Oracle operation, batch, board change and d2_create of an existing diagram is one step; a batch is undone as a whole. Use this to back out of an edit that made the diagram worse instead of reversing it by hand. Undone changes can be reapplied with d2_redo until the diagram is changed again."),
+		mcp.WithString("diagram_id", mcp.Description("ID of the diagram to revert"), mcp.Required()),
+		withExpectedRevision(),
+		mcp.WithNumber("steps", mcp.Description("Number of changes to undo (default 1)")),
+	)
+}
+
+// GetHandler returns the tool handler function.
+func (h *UndoHandler) GetHandler() server.ToolHandlerFunc {
+	return h.Handle
+}
+
+// Handle processes the undo request.
+func (h *UndoHandler) Handle(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// Extract arguments.
+	diagramID := mcp.ParseString(request, "diagram_id", "")
+
+	ctx, revision, err := expectRevision(ctx, request, diagramID)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Invalid expected_revision", err), nil
+	}
+	steps := mcp.ParseInt(request, "steps", 1)
+
+	result, err := h.useCase.Undo(ctx, diagramID, steps)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to undo", err), nil
+	}
+
+	return withRevision(mcp.NewToolResultText(formatHistoryResult("Undid", diagramID, result)), revision.Current), nil
+}
+
+// formatHistoryResult describes the changes an undo or redo applied and what remains.
+func formatHistoryResult(verb, diagramID string, result *entity.HistoryResult) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %d changes in diagram %s:\n", verb, len(result.Changes), diagramID)
+	for _, change := range result.Changes {
+		fmt.Fprintf(&b, "- %s\n", change)
+	}
+	fmt.Fprintf(&b, "Changes that can be undone: %d\nChanges that can be redone: %d", result.UndoDepth, result.RedoDepth)
+	return b.String()
+}
@@ -0,0 +1,69 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/i2y/d2mcp/internal/domain/entity"
+	"github.com/i2y/d2mcp/internal/domain/repository"
+)
+
+// BoardUseCase implements business logic for managing diagram boards
+type BoardUseCase struct {
+	repo repository.BoardRepository
+}
+
+// NewBoardUseCase creates a new board use case
+func NewBoardUseCase(repo repository.BoardRepository) *BoardUseCase {
+	return &BoardUseCase{repo: repo}
+}
+
+// CreateBoard adds a new layer, scenario or step board
+func (uc *BoardUseCase) CreateBoard(ctx context.Context, diagramID string, parentPath []string, kind entity.BoardKind, name string) (*entity.Board, error) {
+	if diagramID == "" {
+		return nil, &ValidationError{Message: "diagram ID is required"}
+	}
+	if name == "" {
+		return nil, &ValidationError{Message: "board name is required"}
+	}
+	if kind.Keyword() == "" {
+		return nil, &ValidationError{Message: "board kind must be 'layer', 'scenario' or 'step'"}
+	}
+
+	return uc.repo.CreateBoard(ctx, diagramID, parentPath, kind, name)
+}
+
+// ListBoards returns the board tree of a diagram
+func (uc *BoardUseCase) ListBoards(ctx context.Context, diagramID string) ([]*entity.Board, error) {
+	if diagramID == "" {
+		return nil, &ValidationError{Message: "diagram ID is required"}
+	}
+
+	return uc.repo.ListBoards(ctx, diagramID)
+}
+
+// DeleteBoard removes a board and its nested boards
+func (uc *BoardUseCase) DeleteBoard(ctx context.Context, diagramID string, boardPath []string) error {
+	if diagramID == "" {
+		return &ValidationError{Message: "diagram ID is required"}
+	}
+	if len(boardPath) == 0 {
+		return &ValidationError{Message: "board path is required"}
+	}
+
+	return uc.repo.DeleteBoard(ctx, diagramID, boardPath)
+}
+
+// MoveBoard reorders a board among its siblings
+func (uc *BoardUseCase) MoveBoard(ctx context.Context, diagramID string, boardPath []string, index int) error {
+	if diagramID == "" {
+		return &ValidationError{Message: "diagram ID is required"}
+	}
+	if len(boardPath) == 0 {
+		return &ValidationError{Message: "board path is required"}
+	}
+	if index < 0 {
+		return &ValidationError{Message: "index cannot be negative"}
+	}
+
+	return uc.repo.MoveBoard(ctx, diagramID, boardPath, index)
+}
@@ -0,0 +1,585 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/i2y/d2mcp/internal/domain/entity"
+	"github.com/i2y/d2mcp/internal/domain/repository"
+)
+
+// BranchUseCase implements business logic for diagram branches
+type BranchUseCase struct {
+	repo     repository.OracleRepository
+	branches repository.BranchRepository
+}
+
+// NewBranchUseCase creates a new branch use case
+func NewBranchUseCase(repo repository.OracleRepository, branches repository.BranchRepository) *BranchUseCase {
+	return &BranchUseCase{repo: repo, branches: branches}
+}
+
+// CreateBranch forks a diagram into a new diagram
+func (uc *BranchUseCase) CreateBranch(ctx context.Context, diagramID, branchID string) (*entity.Branch, error) {
+	if diagramID == "" {
+		return nil, &ValidationError{Message: "diagram ID is required"}
+	}
+	if branchID == "" {
+		return nil, &ValidationError{Message: "branch ID is required"}
+	}
+	if branchID == diagramID {
+		return nil, &ValidationError{Message: "branch ID must differ from the diagram ID"}
+	}
+
+	return uc.branches.CreateBranch(ctx, diagramID, branchID)
+}
+
+// ListBranches returns the branches forked from a diagram
+func (uc *BranchUseCase) ListBranches(ctx context.Context, diagramID string) ([]*entity.Branch, error) {
+	if diagramID == "" {
+		return nil, &ValidationError{Message: "diagram ID is required"}
+	}
+
+	return uc.branches.ListBranches(ctx, diagramID)
+}
+
+// MergeBranch merges the changes made in a branch since its fork point into the
+// diagram it was forked from. The merge is three-way: changes made on only one
+// side are combined, and changes that contradict each other are reported as
+// conflicts. When there are conflicts nothing is applied. A non-nil
+// expectedRevision rejects the merge unless the diagram merged into is at that
+// revision.
+func (uc *BranchUseCase) MergeBranch(ctx context.Context, branchID string, expectedRevision *int64) (*entity.MergeResult, error) {
+	if branchID == "" {
+		return nil, &ValidationError{Message: "branch ID is required"}
+	}
+
+	branch, err := uc.branches.GetBranch(ctx, branchID)
+	if err != nil {
+		return nil, err
+	}
+	base, err := uc.repo.ParseGraph(ctx, branch.Base)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the fork point: %w", err)
+	}
+
+	// The operations are worked out from the diagram as read here, so they are
+	// only applied if it has not changed by the time they run.
+	revision := &repository.Revision{DiagramID: branch.DiagramID, Expected: expectedRevision}
+	ctx = repository.WithRevision(ctx, revision)
+	target, err := uc.repo.GetGraph(ctx, branch.DiagramID)
+	if err != nil {
+		return nil, err
+	}
+	if revision.Expected == nil {
+		read := revision.Current
+		revision.Expected = &read
+	}
+	source, err := uc.repo.GetGraph(ctx, branch.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &entity.MergeResult{
+		BranchID:  branch.ID,
+		DiagramID: branch.DiagramID,
+	}
+	ops, conflicts := mergeGraphs(base, target, source)
+	if len(conflicts) > 0 {
+		result.Conflicts = conflicts
+		result.Revision = revision.Current
+		return result, nil
+	}
+
+	for i := range ops {
+		ops[i].DiagramID = branch.DiagramID
+	}
+	if err := uc.branches.MergeBranch(ctx, branch.ID, ops, source.Content); err != nil {
+		return nil, err
+	}
+
+	result.Merged = true
+	result.Operations = len(ops)
+	result.Revision = revision.Current
+	return result, nil
+}
+
+// mergeGraphs works out the operations that bring the changes from base to
+// source into target, and the changes that conflict with changes from base to
+// target. Every board is merged, the root board first and then its layers,
+// scenarios and steps in order.
+func mergeGraphs(base, target, source *entity.DiagramGraph) ([]entity.OracleOperation, []*entity.MergeConflict) {
+	m := newMerge(nil, target, diffGraphs(base, target))
+	m.mergeChanges(diffGraphs(base, source))
+	m.mergeBoards(base, target, source)
+	return m.ops, m.conflicts
+}
+
+// merge collects the operations and conflicts of a three-way merge of a board.
+type merge struct {
+	boardPath     []string
+	target        *entity.DiagramGraph
+	targetChanges *entity.DiagramDiff
+	createdEdges  map[string]int // Edges created so far, by ID without the index
+	ops           []entity.OracleOperation
+	conflicts     []*entity.MergeConflict
+}
+
+func newMerge(boardPath []string, target *entity.DiagramGraph, targetChanges *entity.DiagramDiff) *merge {
+	return &merge{
+		boardPath:     boardPath,
+		target:        target,
+		targetChanges: targetChanges,
+		createdEdges:  map[string]int{},
+	}
+}
+
+// mergeChanges merges the changes the branch made to the board.
+func (m *merge) mergeChanges(changes *entity.DiagramDiff) {
+	for _, change := range changes.ChangedObjects {
+		m.mergeChange(change, m.targetChanges.ChangedObjects, !containsObject(m.targetChanges.RemovedObjects, change.ID))
+	}
+	for _, change := range changes.ChangedEdges {
+		m.mergeChange(change, m.targetChanges.ChangedEdges, !containsEdge(m.targetChanges.RemovedEdges, change.ID))
+	}
+	for _, obj := range changes.AddedObjects {
+		m.mergeAddedObject(obj)
+	}
+	for _, edge := range changes.AddedEdges {
+		m.mergeAddedEdge(edge)
+	}
+
+	// Remove edges before objects, and the last index of an edge or the deepest
+	// object first, so that the remaining IDs stay valid.
+	for i := len(changes.RemovedEdges) - 1; i >= 0; i-- {
+		id := changes.RemovedEdges[i].ID
+		if containsEdge(m.targetChanges.RemovedEdges, id) {
+			continue
+		}
+		m.mergeRemoval(id, findChange(m.targetChanges.ChangedEdges, id), nil)
+	}
+	for i := len(changes.RemovedObjects) - 1; i >= 0; i-- {
+		id := changes.RemovedObjects[i].ID
+		if containsObject(m.targetChanges.RemovedObjects, id) {
+			continue
+		}
+		m.mergeRemoval(id, findChange(m.targetChanges.ChangedObjects, id), m.addedInTarget(id))
+	}
+}
+
+// mergeBoards merges the boards nested in the board being merged, whose base,
+// target and source graphs are given. Operations cannot add or delete boards, so
+// a board added or deleted in the branch alone is a conflict.
+func (m *merge) mergeBoards(base, target, source *entity.DiagramGraph) {
+	// Scenarios inherit from the board they are declared in, and steps from the
+	// previous step. What a board inherits is merged with the board it comes from.
+	parentBase, parentTarget, parentSource := base, target, source
+
+	for _, board := range boardNames(base, source) {
+		path := append(append([]string{}, m.boardPath...), board.Name)
+		name := strings.Join(path, ".")
+		baseBoard := findBoard(base, board.Name)
+		targetBoard := findBoard(target, board.Name)
+		sourceBoard := findBoard(source, board.Name)
+
+		switch {
+		case sourceBoard == nil:
+			if targetBoard != nil {
+				m.boardConflict(board.Name, fmt.Sprintf("board %s was deleted in the branch; merges cannot delete boards, so delete it in the diagram with d2_board_delete and merge again", name))
+			}
+			continue
+		case baseBoard == nil && targetBoard == nil:
+			m.boardConflict(board.Name, fmt.Sprintf("board %s was added in the branch; merges cannot add boards, so create it in the diagram with d2_board_create and merge again", name))
+			continue
+		}
+
+		// A board added on both sides is merged like elements added on both sides.
+		inherits := board.Kind != entity.BoardLayer && baseBoard != nil
+		if baseBoard == nil {
+			baseBoard = &entity.DiagramGraph{}
+		}
+		changes := diffGraphs(baseBoard, sourceBoard)
+		if inherits {
+			changes = withoutInherited(changes, diffGraphs(parentBase, parentSource))
+		}
+
+		if targetBoard == nil {
+			if !changes.Empty() || boardsChanged(baseBoard, sourceBoard) {
+				m.boardConflict(board.Name, fmt.Sprintf("board %s was modified in the branch but deleted in the diagram", name))
+			}
+			continue
+		}
+
+		targetChanges := diffGraphs(baseBoard, targetBoard)
+		if inherits {
+			targetChanges = withoutInherited(targetChanges, diffGraphs(parentBase, parentTarget))
+		}
+		child := newMerge(path, targetBoard, targetChanges)
+		child.mergeChanges(changes)
+		for _, conflict := range child.conflicts {
+			conflict.BoardPath = path
+			conflict.Message = fmt.Sprintf("in board %s, %s", name, conflict.Message)
+		}
+		child.mergeBoards(baseBoard, targetBoard, sourceBoard)
+		m.ops = append(m.ops, child.ops...)
+		m.conflicts = append(m.conflicts, child.conflicts...)
+
+		if board.Kind == entity.BoardStep {
+			parentBase, parentTarget, parentSource = baseBoard, targetBoard, sourceBoard
+		}
+	}
+}
+
+// boardConflict reports a board that the merge cannot add or delete.
+func (m *merge) boardConflict(name, message string) {
+	m.conflicts = append(m.conflicts, &entity.MergeConflict{
+		Kind:      entity.ConflictBoard,
+		BoardPath: m.boardPath,
+		ElementID: name,
+		Message:   message,
+	})
+}
+
+// boardNames returns the boards of base and source, in the order of source
+// followed by the boards only base has.
+func boardNames(base, source *entity.DiagramGraph) []*entity.BoardGraph {
+	boards := append([]*entity.BoardGraph{}, source.Boards...)
+	for _, board := range base.Boards {
+		if findBoard(source, board.Name) == nil {
+			boards = append(boards, board)
+		}
+	}
+	return boards
+}
+
+// findBoard returns the graph of a board nested in graph, or nil.
+func findBoard(graph *entity.DiagramGraph, name string) *entity.DiagramGraph {
+	for _, board := range graph.Boards {
+		if board.Name == name {
+			return board.Graph
+		}
+	}
+	return nil
+}
+
+// boardsChanged reports whether any board nested in source differs from base.
+func boardsChanged(base, source *entity.DiagramGraph) bool {
+	if len(base.Boards) != len(source.Boards) {
+		return true
+	}
+	for _, board := range source.Boards {
+		baseBoard := findBoard(base, board.Name)
+		if baseBoard == nil || !diffGraphs(baseBoard, board.Graph).Empty() || boardsChanged(baseBoard, board.Graph) {
+			return true
+		}
+	}
+	return false
+}
+
+// withoutInherited removes from the changes to a scenario or step those it only
+// inherits from the board it builds on, which are merged there.
+func withoutInherited(changes, inherited *entity.DiagramDiff) *entity.DiagramDiff {
+	own := &entity.DiagramDiff{}
+	for _, obj := range changes.AddedObjects {
+		if !containsObject(inherited.AddedObjects, obj.ID) {
+			own.AddedObjects = append(own.AddedObjects, obj)
+		}
+	}
+	for _, obj := range changes.RemovedObjects {
+		if !containsObject(inherited.RemovedObjects, obj.ID) {
+			own.RemovedObjects = append(own.RemovedObjects, obj)
+		}
+	}
+	for _, edge := range changes.AddedEdges {
+		if !containsEdge(inherited.AddedEdges, edge.ID) {
+			own.AddedEdges = append(own.AddedEdges, edge)
+		}
+	}
+	for _, edge := range changes.RemovedEdges {
+		if !containsEdge(inherited.RemovedEdges, edge.ID) {
+			own.RemovedEdges = append(own.RemovedEdges, edge)
+		}
+	}
+	own.ChangedObjects = withoutInheritedChanges(changes.ChangedObjects, inherited.ChangedObjects)
+	own.ChangedEdges = withoutInheritedChanges(changes.ChangedEdges, inherited.ChangedEdges)
+	return own
+}
+
+// withoutInheritedChanges removes the attribute changes that match an inherited change.
+func withoutInheritedChanges(changes, inherited []*entity.ElementChange) []*entity.ElementChange {
+	var own []*entity.ElementChange
+	for _, change := range changes {
+		inheritedChange := findChange(inherited, change.ID)
+		var attributes []*entity.AttributeChange
+		for _, attribute := range change.Attributes {
+			if inheritedChange != nil {
+				if match := findAttribute(inheritedChange.Attributes, attribute.Name); match != nil && reflect.DeepEqual(match.After, attribute.After) {
+					continue
+				}
+			}
+			attributes = append(attributes, attribute)
+		}
+		if len(attributes) > 0 {
+			own = append(own, &entity.ElementChange{ID: change.ID, Attributes: attributes})
+		}
+	}
+	return own
+}
+
+// mergeChange applies the attribute changes of an element unless the target
+// changed the same attributes differently or deleted the element.
+func (m *merge) mergeChange(change *entity.ElementChange, targetChanges []*entity.ElementChange, existsInTarget bool) {
+	if !existsInTarget {
+		for _, attribute := range change.Attributes {
+			m.conflicts = append(m.conflicts, &entity.MergeConflict{
+				Kind:      entity.ConflictDeleteModify,
+				ElementID: change.ID,
+				Attribute: attribute.Name,
+				Base:      attribute.Before,
+				Branch:    attribute.After,
+				Message:   fmt.Sprintf("%s of %s was changed in the branch but %s was deleted in the diagram", attribute.Name, change.ID, change.ID),
+			})
+		}
+		return
+	}
+
+	targetChange := findChange(targetChanges, change.ID)
+	for _, attribute := range change.Attributes {
+		if targetChange != nil {
+			if targetAttribute := findAttribute(targetChange.Attributes, attribute.Name); targetAttribute != nil {
+				if !reflect.DeepEqual(targetAttribute.After, attribute.After) {
+					m.conflicts = append(m.conflicts, &entity.MergeConflict{
+						Kind:      entity.ConflictAttribute,
+						ElementID: change.ID,
+						Attribute: attribute.Name,
+						Base:      attribute.Before,
+						Target:    targetAttribute.After,
+						Branch:    attribute.After,
+						Message:   fmt.Sprintf("%s of %s was changed on both sides", attribute.Name, change.ID),
+					})
+				}
+				continue
+			}
+		}
+		m.setAttribute(change.ID, attribute.Name, attribute.After)
+	}
+}
+
+// mergeAddedObject creates an object added in the branch. An object added on
+// both sides merges when its attributes agree.
+func (m *merge) mergeAddedObject(obj *entity.GraphObject) {
+	if existing, exists := m.target.Objects[obj.ID]; exists {
+		m.mergeAddedOnBothSides(obj.ID, objectAttributes(existing), objectAttributes(obj))
+		return
+	}
+	for parent := obj.Parent; parent != ""; parent = m.parentOf(parent) {
+		if containsObject(m.targetChanges.RemovedObjects, parent) {
+			m.conflicts = append(m.conflicts, &entity.MergeConflict{
+				Kind:      entity.ConflictDeleteModify,
+				ElementID: parent,
+				Branch:    []string{obj.ID},
+				Message:   fmt.Sprintf("%s was added to %s in the branch but %s was deleted in the diagram", obj.ID, parent, parent),
+			})
+			return
+		}
+	}
+
+	m.ops = append(m.ops, entity.OracleOperation{Type: entity.OracleCreate, BoardPath: m.boardPath, Key: obj.ID})
+	for _, name := range sortedKeys(obj.Attributes) {
+		value := obj.Attributes[name]
+		if isDefaultAttribute(obj.ID, name, value) {
+			continue
+		}
+		m.setAttribute(obj.ID, name, value)
+	}
+}
+
+// mergeAddedEdge creates an edge added in the branch. An edge added on both sides
+// merges when its attributes agree.
+func (m *merge) mergeAddedEdge(edge *entity.GraphEdge) {
+	if existing, exists := m.target.Edges[edge.ID]; exists {
+		m.mergeAddedOnBothSides(edge.ID, edgeAttributes(existing), edgeAttributes(edge))
+		return
+	}
+	for _, endpoint := range []string{edge.From, edge.To} {
+		if containsObject(m.targetChanges.RemovedObjects, endpoint) {
+			m.conflicts = append(m.conflicts, &entity.MergeConflict{
+				Kind:      entity.ConflictMissingEndpoint,
+				ElementID: edge.ID,
+				Message:   fmt.Sprintf("%s was added in the branch but %s was deleted in the diagram", edge.ID, endpoint),
+			})
+			return
+		}
+	}
+
+	// The new edge comes after the target's edges between the same objects.
+	prefix := edgeIDPrefix(edge.ID)
+	index := m.createdEdges[prefix]
+	for id := range m.target.Edges {
+		if edgeIDPrefix(id) == prefix {
+			index++
+		}
+	}
+	m.createdEdges[prefix]++
+	id := fmt.Sprintf("%s[%d]", prefix, index)
+
+	m.ops = append(m.ops, entity.OracleOperation{Type: entity.OracleCreate, BoardPath: m.boardPath, Key: edge.Key()})
+	for _, name := range sortedKeys(edge.Attributes) {
+		m.setAttribute(id, name, edge.Attributes[name])
+	}
+}
+
+// mergeAddedOnBothSides reports the attributes of an element added on both sides
+// that disagree.
+func (m *merge) mergeAddedOnBothSides(id string, target, source map[string]interface{}) {
+	for _, change := range diffAttributes(target, source) {
+		m.conflicts = append(m.conflicts, &entity.MergeConflict{
+			Kind:      entity.ConflictAttribute,
+			ElementID: id,
+			Attribute: change.Name,
+			Target:    change.Before,
+			Branch:    change.After,
+			Message:   fmt.Sprintf("%s was added on both sides with a different %s", id, change.Name),
+		})
+	}
+}
+
+// mergeRemoval deletes an element removed in the branch unless the target
+// modified it. targetChange is the target's change to the element's attributes,
+// if any, and added lists the objects and connections the target added inside
+// or to it.
+func (m *merge) mergeRemoval(id string, targetChange *entity.ElementChange, added []string) {
+	if targetChange != nil {
+		for _, attribute := range targetChange.Attributes {
+			m.conflicts = append(m.conflicts, &entity.MergeConflict{
+				Kind:      entity.ConflictDeleteModify,
+				ElementID: id,
+				Attribute: attribute.Name,
+				Base:      attribute.Before,
+				Target:    attribute.After,
+				Message:   fmt.Sprintf("%s was deleted in the branch but its %s was changed in the diagram", id, attribute.Name),
+			})
+		}
+	}
+	if len(added) > 0 {
+		m.conflicts = append(m.conflicts, &entity.MergeConflict{
+			Kind:      entity.ConflictDeleteModify,
+			ElementID: id,
+			Target:    added,
+			Message:   fmt.Sprintf("%s was deleted in the branch but the diagram added %s to it", id, strings.Join(added, ", ")),
+		})
+	}
+	if targetChange != nil || len(added) > 0 {
+		return
+	}
+	m.ops = append(m.ops, entity.OracleOperation{Type: entity.OracleDelete, BoardPath: m.boardPath, Key: id})
+}
+
+// setAttribute adds the operation that sets an attribute, or deletes it when value is nil.
+func (m *merge) setAttribute(id, name string, value interface{}) {
+	key, ok := attributeKey(name)
+	if !ok {
+		return
+	}
+	if value == nil {
+		m.ops = append(m.ops, entity.OracleOperation{Type: entity.OracleDelete, BoardPath: m.boardPath, Key: id + "." + key})
+		return
+	}
+	text := fmt.Sprint(value)
+	m.ops = append(m.ops, entity.OracleOperation{Type: entity.OracleSet, BoardPath: m.boardPath, Key: id + "." + key, Value: &text})
+}
+
+// addedInTarget returns the IDs of the objects the target added inside an object
+// and of the connections it added to it.
+func (m *merge) addedInTarget(id string) []string {
+	var added []string
+	for _, obj := range m.targetChanges.AddedObjects {
+		if strings.HasPrefix(obj.ID, id+".") {
+			added = append(added, obj.ID)
+		}
+	}
+	for _, edge := range m.targetChanges.AddedEdges {
+		for _, endpoint := range []string{edge.From, edge.To} {
+			if endpoint == id || strings.HasPrefix(endpoint, id+".") {
+				added = append(added, edge.ID)
+				break
+			}
+		}
+	}
+	return added
+}
+
+// parentOf returns the parent of an object of the target.
+func (m *merge) parentOf(id string) string {
+	if obj, exists := m.target.Objects[id]; exists {
+		return obj.Parent
+	}
+	if i := strings.LastIndex(id, "."); i >= 0 {
+		return id[:i]
+	}
+	return ""
+}
+
+// attributeKey returns the D2 key of a graph attribute. Structural attributes,
+// which are part of the element's ID, have none.
+func attributeKey(name string) (string, bool) {
+	switch name {
+	case "parent", "srcArrow", "dstArrow":
+		return "", false
+	case "label", "shape", "icon", "near", "direction", "tooltip", "link", "width", "height":
+		return name, true
+	default:
+		return "style." + name, true
+	}
+}
+
+// isDefaultAttribute reports whether D2 gives a new object this attribute on its own.
+func isDefaultAttribute(id, name string, value interface{}) bool {
+	switch name {
+	case "label":
+		return value == id[strings.LastIndex(id, ".")+1:]
+	case "shape":
+		return value == "rectangle"
+	default:
+		return false
+	}
+}
+
+// edgeIDPrefix returns an edge ID without its index, e.g. (a -> b) for (a -> b)[0].
+func edgeIDPrefix(id string) string {
+	if i := strings.LastIndex(id, "["); i >= 0 {
+		return id[:i]
+	}
+	return id
+}
+
+func findChange(changes []*entity.ElementChange, id string) *entity.ElementChange {
+	for _, change := range changes {
+		if change.ID == id {
+			return change
+		}
+	}
+	return nil
+}
+
+func findAttribute(changes []*entity.AttributeChange, name string) *entity.AttributeChange {
+	for _, change := range changes {
+		if change.Name == name {
+			return change
+		}
+	}
+	return nil
+}
+
+func containsObject(objects []*entity.GraphObject, id string) bool {
+	i := sort.Search(len(objects), func(i int) bool { return objects[i].ID >= id })
+	return i < len(objects) && objects[i].ID == id
+}
+
+func containsEdge(edges []*entity.GraphEdge, id string) bool {
+	i := sort.Search(len(edges), func(i int) bool { return edges[i].ID >= id })
+	return i < len(edges) && edges[i].ID == id
+}
@@ -0,0 +1,240 @@
+package usecase
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/i2y/d2mcp/internal/domain/entity"
+)
+
+// mergeBase is the fork point shared by the merge tests.
+func mergeBase() *entity.DiagramGraph {
+	return &entity.DiagramGraph{
+		Objects: map[string]*entity.GraphObject{
+			"web": {ID: "web", Attributes: map[string]interface{}{"label": "web"}},
+			"api": {ID: "api", Attributes: map[string]interface{}{"label": "API"}},
+			"db":  {ID: "db", Attributes: map[string]interface{}{"label": "db"}},
+		},
+		Edges: map[string]*entity.GraphEdge{
+			"(web -> api)[0]": {ID: "(web -> api)[0]", From: "web", To: "api", Attributes: map[string]interface{}{"dstArrow": true}},
+		},
+	}
+}
+
+func TestMergeGraphs(t *testing.T) {
+	base := mergeBase()
+
+	target := mergeBase()
+	target.Objects["api"].Attributes["fill"] = "#fff"
+
+	source := mergeBase()
+	source.Objects["api"].Attributes["label"] = "Gateway"
+	source.Objects["cache"] = &entity.GraphObject{ID: "cache", Attributes: map[string]interface{}{"label": "cache", "shape": "cylinder"}}
+	source.Edges["(web -> api)[1]"] = &entity.GraphEdge{ID: "(web -> api)[1]", From: "web", To: "api", Attributes: map[string]interface{}{"dstArrow": true}}
+	delete(source.Objects, "db")
+
+	ops, conflicts := mergeGraphs(base, target, source)
+	if len(conflicts) != 0 {
+		t.Fatalf("mergeGraphs() conflicts = %v", conflicts)
+	}
+
+	want := []entity.OracleOperation{
+		{Type: entity.OracleSet, Key: "api.label", Value: stringPtr("Gateway")},
+		{Type: entity.OracleCreate, Key: "cache"},
+		{Type: entity.OracleSet, Key: "cache.shape", Value: stringPtr("cylinder")},
+		{Type: entity.OracleCreate, Key: "web -> api"},
+		{Type: entity.OracleDelete, Key: "db"},
+	}
+	if len(ops) != len(want) {
+		t.Fatalf("mergeGraphs() ops = %v, want %v", ops, want)
+	}
+	for i := range want {
+		if ops[i].Type != want[i].Type || ops[i].Key != want[i].Key || !equalValue(ops[i].Value, want[i].Value) {
+			t.Errorf("ops[%d] = %s %s %v, want %s %s %v", i, ops[i].Type, ops[i].Key, ops[i].Value, want[i].Type, want[i].Key, want[i].Value)
+		}
+	}
+}
+
+func TestMergeGraphs_Conflicts(t *testing.T) {
+	tests := []struct {
+		name      string
+		target    func(*entity.DiagramGraph)
+		source    func(*entity.DiagramGraph)
+		kind      entity.MergeConflictKind
+		elementID string
+		attribute string
+		base      interface{}
+		diagram   interface{}
+		branch    interface{}
+	}{
+		{
+			name:      "same attribute changed on both sides",
+			target:    func(g *entity.DiagramGraph) { g.Objects["api"].Attributes["label"] = "Edge" },
+			source:    func(g *entity.DiagramGraph) { g.Objects["api"].Attributes["label"] = "Gateway" },
+			kind:      entity.ConflictAttribute,
+			elementID: "api",
+			attribute: "label",
+			base:      "API",
+			diagram:   "Edge",
+			branch:    "Gateway",
+		},
+		{
+			name:      "modified in the branch, deleted in the diagram",
+			target:    func(g *entity.DiagramGraph) { delete(g.Objects, "db") },
+			source:    func(g *entity.DiagramGraph) { g.Objects["db"].Attributes["shape"] = "cylinder" },
+			kind:      entity.ConflictDeleteModify,
+			elementID: "db",
+			attribute: "shape",
+			branch:    "cylinder",
+		},
+		{
+			name:      "deleted in the branch, modified in the diagram",
+			target:    func(g *entity.DiagramGraph) { g.Objects["db"].Attributes["label"] = "Postgres" },
+			source:    func(g *entity.DiagramGraph) { delete(g.Objects, "db") },
+			kind:      entity.ConflictDeleteModify,
+			elementID: "db",
+			attribute: "label",
+			base:      "db",
+			diagram:   "Postgres",
+		},
+		{
+			name: "deleted in the branch, extended in the diagram",
+			target: func(g *entity.DiagramGraph) {
+				g.Objects["db.cache"] = &entity.GraphObject{ID: "db.cache", Parent: "db", Attributes: map[string]interface{}{"label": "cache"}}
+			},
+			source:    func(g *entity.DiagramGraph) { delete(g.Objects, "db") },
+			kind:      entity.ConflictDeleteModify,
+			elementID: "db",
+			diagram:   []string{"db.cache"},
+		},
+		{
+			name:   "connection to a shape deleted in the diagram",
+			target: func(g *entity.DiagramGraph) { delete(g.Objects, "db") },
+			source: func(g *entity.DiagramGraph) {
+				g.Edges["(api -> db)[0]"] = &entity.GraphEdge{ID: "(api -> db)[0]", From: "api", To: "db", Attributes: map[string]interface{}{"dstArrow": true}}
+			},
+			kind:      entity.ConflictMissingEndpoint,
+			elementID: "(api -> db)[0]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := mergeBase()
+			tt.target(target)
+			source := mergeBase()
+			tt.source(source)
+
+			_, conflicts := mergeGraphs(mergeBase(), target, source)
+			if len(conflicts) != 1 {
+				t.Fatalf("mergeGraphs() conflicts = %v, want 1", conflicts)
+			}
+			conflict := conflicts[0]
+			if conflict.Kind != tt.kind || conflict.ElementID != tt.elementID {
+				t.Errorf("mergeGraphs() conflict = %+v, want %s on %s", conflict, tt.kind, tt.elementID)
+			}
+			if conflict.Attribute != tt.attribute || !reflect.DeepEqual(conflict.Base, tt.base) ||
+				!reflect.DeepEqual(conflict.Target, tt.diagram) || !reflect.DeepEqual(conflict.Branch, tt.branch) {
+				t.Errorf("mergeGraphs() conflict %s = %v, %v, %v, want %s = %v, %v, %v",
+					conflict.Attribute, conflict.Base, conflict.Target, conflict.Branch, tt.attribute, tt.base, tt.diagram, tt.branch)
+			}
+		})
+	}
+}
+
+func TestMergeGraphs_Boards(t *testing.T) {
+	// withBoards gives a graph a layer net and a scenario outage, which inherits
+	// the objects of the graph.
+	withBoards := func(g *entity.DiagramGraph) *entity.DiagramGraph {
+		layer := &entity.DiagramGraph{Objects: map[string]*entity.GraphObject{
+			"lb": {ID: "lb", Attributes: map[string]interface{}{"label": "lb"}},
+		}}
+		scenario := &entity.DiagramGraph{Objects: map[string]*entity.GraphObject{}}
+		for id, obj := range g.Objects {
+			copied := *obj
+			copied.Attributes = map[string]interface{}{}
+			for name, value := range obj.Attributes {
+				copied.Attributes[name] = value
+			}
+			scenario.Objects[id] = &copied
+		}
+		g.Boards = []*entity.BoardGraph{
+			{Name: "net", Kind: entity.BoardLayer, Graph: layer},
+			{Name: "outage", Kind: entity.BoardScenario, Graph: scenario},
+		}
+		return g
+	}
+
+	base := withBoards(mergeBase())
+	target := withBoards(mergeBase())
+	source := withBoards(mergeBase())
+	source.Boards[0].Graph.Objects["firewall"] = &entity.GraphObject{ID: "firewall", Attributes: map[string]interface{}{"label": "firewall"}}
+	// A root object added in the branch shows up in the scenario by inheritance.
+	source.Objects["cache"] = &entity.GraphObject{ID: "cache", Attributes: map[string]interface{}{"label": "cache"}}
+	source.Boards[1].Graph.Objects["cache"] = &entity.GraphObject{ID: "cache", Attributes: map[string]interface{}{"label": "cache"}}
+	source.Boards[1].Graph.Objects["db"].Attributes["fill"] = "red"
+
+	ops, conflicts := mergeGraphs(base, target, source)
+	if len(conflicts) != 0 {
+		t.Fatalf("mergeGraphs() conflicts = %v", conflicts)
+	}
+
+	want := []entity.OracleOperation{
+		{Type: entity.OracleCreate, Key: "cache"},
+		{Type: entity.OracleCreate, BoardPath: []string{"net"}, Key: "firewall"},
+		{Type: entity.OracleSet, BoardPath: []string{"outage"}, Key: "db.style.fill", Value: stringPtr("red")},
+	}
+	if len(ops) != len(want) {
+		t.Fatalf("mergeGraphs() ops = %v, want %v", ops, want)
+	}
+	for i := range want {
+		if ops[i].Type != want[i].Type || ops[i].Key != want[i].Key || !equalValue(ops[i].Value, want[i].Value) ||
+			strings.Join(ops[i].BoardPath, ".") != strings.Join(want[i].BoardPath, ".") {
+			t.Errorf("ops[%d] = %s %v %s %v, want %s %v %s %v", i, ops[i].Type, ops[i].BoardPath, ops[i].Key, ops[i].Value, want[i].Type, want[i].BoardPath, want[i].Key, want[i].Value)
+		}
+	}
+
+	// Conflicts inside a board name the board.
+	target.Boards[0].Graph.Objects["lb"].Attributes["label"] = "LB"
+	source.Boards[0].Graph.Objects["lb"].Attributes["label"] = "Balancer"
+	_, conflicts = mergeGraphs(base, target, source)
+	if len(conflicts) != 1 || strings.Join(conflicts[0].BoardPath, ".") != "net" || conflicts[0].ElementID != "lb" {
+		t.Errorf("mergeGraphs() conflicts = %+v, want one on lb in net", conflicts)
+	}
+
+	// Boards added or deleted only in the branch cannot be merged.
+	added := withBoards(mergeBase())
+	added.Boards = append(added.Boards, &entity.BoardGraph{Name: "extra", Kind: entity.BoardLayer, Graph: &entity.DiagramGraph{}})
+	deleted := withBoards(mergeBase())
+	deleted.Boards = deleted.Boards[1:]
+	for name, source := range map[string]*entity.DiagramGraph{"extra": added, "net": deleted} {
+		_, conflicts := mergeGraphs(base, withBoards(mergeBase()), source)
+		if len(conflicts) != 1 || conflicts[0].Kind != entity.ConflictBoard || conflicts[0].ElementID != name {
+			t.Errorf("mergeGraphs() conflicts = %+v, want a board conflict on %s", conflicts, name)
+		}
+	}
+}
+
+func TestBranchUseCase_Validation(t *testing.T) {
+	uc := NewBranchUseCase(&mockOracleRepository{}, nil)
+	ctx := context.Background()
+
+	if _, err := uc.CreateBranch(ctx, "main", ""); err == nil {
+		t.Error("CreateBranch() without a branch ID should fail")
+	}
+	if _, err := uc.CreateBranch(ctx, "main", "main"); err == nil {
+		t.Error("CreateBranch() onto itself should fail")
+	}
+	if _, err := uc.MergeBranch(ctx, "", nil); err == nil {
+		t.Error("MergeBranch() without a branch ID should fail")
+	}
+}
+
+func equalValue(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -2,6 +2,7 @@ package usecase
 
 import (
 	"context"
+	"fmt"
 	"io"
 
 	"github.com/i2y/d2mcp/internal/domain/entity"
@@ -21,7 +22,7 @@ func NewDiagramUseCase(repo repository.DiagramRepository) *DiagramUseCase {
 }
 
 // RenderDiagram renders D2 text into a diagram.
-func (uc *DiagramUseCase) RenderDiagram(ctx context.Context, content string, format entity.ExportFormat, theme *entity.Theme) (io.Reader, error) {
+func (uc *DiagramUseCase) RenderDiagram(ctx context.Context, content string, format entity.ExportFormat, opts *entity.RenderOptions) (io.Reader, error) {
 	// Validate input.
 	if content == "" {
 		return nil, &ValidationError{Message: "content cannot be empty"}
@@ -32,7 +33,11 @@ func (uc *DiagramUseCase) RenderDiagram(ctx context.Context, content string, for
 		format = entity.FormatSVG
 	}
 
-	return uc.repo.Render(ctx, content, format, theme)
+	if err := validateRenderOptions(opts); err != nil {
+		return nil, err
+	}
+
+	return uc.repo.Render(ctx, content, format, opts)
 }
 
 // CreateDiagram creates a new diagram programmatically.
@@ -44,12 +49,15 @@ func (uc *DiagramUseCase) CreateDiagram(ctx context.Context, diagram *entity.Dia
 	if diagram.ID == "" {
 		return &ValidationError{Message: "diagram ID is required"}
 	}
+	if err := validateRenderOptions(diagram.Options); err != nil {
+		return err
+	}
 
 	return uc.repo.Create(ctx, diagram)
 }
 
 // ExportDiagram exports the diagram to the specified format.
-func (uc *DiagramUseCase) ExportDiagram(ctx context.Context, diagramID string, format entity.ExportFormat) (io.Reader, error) {
+func (uc *DiagramUseCase) ExportDiagram(ctx context.Context, diagramID string, format entity.ExportFormat, opts *entity.RenderOptions) (io.Reader, error) {
 	// Validate input.
 	if diagramID == "" {
 		return nil, &ValidationError{Message: "diagram ID is required"}
@@ -60,7 +68,43 @@ func (uc *DiagramUseCase) ExportDiagram(ctx context.Context, diagramID string, f
 		format = entity.FormatSVG
 	}
 
-	return uc.repo.Export(ctx, diagramID, format)
+	if err := validateRenderOptions(opts); err != nil {
+		return nil, err
+	}
+
+	return uc.repo.Export(ctx, diagramID, format, opts)
+}
+
+// ListDiagrams describes all stored diagrams, sorted by ID.
+func (uc *DiagramUseCase) ListDiagrams(ctx context.Context) ([]*entity.DiagramInfo, error) {
+	return uc.repo.ListDiagrams(ctx)
+}
+
+// DeleteDiagram removes a diagram along with its history, snapshots and branch record.
+func (uc *DiagramUseCase) DeleteDiagram(ctx context.Context, diagramID string) error {
+	if diagramID == "" {
+		return &ValidationError{Message: "diagram ID is required"}
+	}
+
+	return uc.repo.DeleteDiagram(ctx, diagramID)
+}
+
+// CloneDiagram stores a copy of a diagram under newID.
+func (uc *DiagramUseCase) CloneDiagram(ctx context.Context, diagramID, newID string) error {
+	if err := validateNewDiagramID(diagramID, newID); err != nil {
+		return err
+	}
+
+	return uc.repo.CloneDiagram(ctx, diagramID, newID)
+}
+
+// RenameDiagram moves a diagram to newID.
+func (uc *DiagramUseCase) RenameDiagram(ctx context.Context, diagramID, newID string) error {
+	if err := validateNewDiagramID(diagramID, newID); err != nil {
+		return err
+	}
+
+	return uc.repo.RenameDiagram(ctx, diagramID, newID)
 }
 
 // Create creates a diagram with the given ID and optional content.
@@ -72,3 +116,90 @@ func (uc *DiagramUseCase) Create(ctx context.Context, id string, content string)
 	}
 	return uc.CreateDiagram(ctx, diagram)
 }
+
+// validateNewDiagramID checks the arguments of operations that store a diagram under another ID.
+func validateNewDiagramID(diagramID, newID string) error {
+	if diagramID == "" {
+		return &ValidationError{Message: "diagram ID is required"}
+	}
+	if newID == "" {
+		return &ValidationError{Message: "new diagram ID is required"}
+	}
+	if newID == diagramID {
+		return &ValidationError{Message: "new diagram ID must differ from the diagram ID"}
+	}
+	return nil
+}
+
+// validateRenderOptions checks layout and render settings before they reach the renderer.
+func validateRenderOptions(opts *entity.RenderOptions) error {
+	if opts == nil {
+		return nil
+	}
+
+	if opts.Pad != nil && *opts.Pad < 0 {
+		return &ValidationError{Message: "pad cannot be negative"}
+	}
+	if opts.Scale != nil && *opts.Scale <= 0 {
+		return &ValidationError{Message: "scale must be greater than zero"}
+	}
+	if opts.Interval != nil && *opts.Interval <= 0 {
+		return &ValidationError{Message: "interval must be greater than zero"}
+	}
+	if err := validateRasterOptions(opts.Raster); err != nil {
+		return err
+	}
+
+	if opts.Layout == nil {
+		return nil
+	}
+
+	switch opts.Layout.Engine {
+	case "", entity.LayoutDagre, entity.LayoutELK:
+	default:
+		return &ValidationError{Message: fmt.Sprintf("unsupported layout engine: %s (use dagre or elk)", opts.Layout.Engine)}
+	}
+
+	switch opts.Layout.Direction {
+	case "", "up", "down", "left", "right":
+	default:
+		return &ValidationError{Message: fmt.Sprintf("invalid direction: %s (use up, down, left or right)", opts.Layout.Direction)}
+	}
+
+	switch opts.Layout.Routing {
+	case "", entity.RoutingOrthogonal, entity.RoutingSplines, entity.RoutingStraight:
+	default:
+		return &ValidationError{Message: fmt.Sprintf("invalid edge routing: %s (use orthogonal, splines or straight)", opts.Layout.Routing)}
+	}
+	if opts.Layout.Engine == entity.LayoutDagre && opts.Layout.Routing == entity.RoutingOrthogonal {
+		return &ValidationError{Message: "orthogonal edge routing requires the elk layout engine"}
+	}
+
+	if opts.Layout.NodeSpacing < 0 || opts.Layout.EdgeSpacing < 0 {
+		return &ValidationError{Message: "spacing cannot be negative"}
+	}
+
+	return nil
+}
+
+// validateRasterOptions checks the image size and converter requested for raster output.
+func validateRasterOptions(opts *entity.RasterOptions) error {
+	if opts == nil {
+		return nil
+	}
+
+	if opts.Width < 0 || opts.Height < 0 {
+		return &ValidationError{Message: "width and height cannot be negative"}
+	}
+	if opts.DPI < 0 {
+		return &ValidationError{Message: "dpi cannot be negative"}
+	}
+
+	switch opts.Backend {
+	case "", entity.RasterNative, entity.RasterExternal:
+	default:
+		return &ValidationError{Message: fmt.Sprintf("unsupported raster backend: %s (use native or external)", opts.Backend)}
+	}
+
+	return nil
+}
@@ -0,0 +1,212 @@
+package usecase
+
+import (
+	"context"
+	"reflect"
+	"sort"
+
+	"github.com/i2y/d2mcp/internal/domain/entity"
+	"github.com/i2y/d2mcp/internal/domain/repository"
+)
+
+// DiffUseCase implements structural comparison of diagram versions
+type DiffUseCase struct {
+	repo      repository.OracleRepository
+	snapshots repository.SnapshotRepository
+}
+
+// NewDiffUseCase creates a new diff use case
+func NewDiffUseCase(repo repository.OracleRepository, snapshots repository.SnapshotRepository) *DiffUseCase {
+	return &DiffUseCase{repo: repo, snapshots: snapshots}
+}
+
+// Diff compares two versions of a diagram board by board, object by object and
+// edge by edge. A version that names only a snapshot refers to a snapshot of the
+// other version's diagram, and an empty new version means the current state of
+// the old version's diagram.
+func (uc *DiffUseCase) Diff(ctx context.Context, from, to entity.DiagramVersion) (*entity.DiagramDiff, error) {
+	if from.DiagramID == "" && from.Content == "" {
+		return nil, &ValidationError{Message: "the version to compare from needs a diagram ID or D2 content"}
+	}
+	if to.DiagramID == "" && to.Content == "" {
+		if to.Snapshot == "" && from.Snapshot == "" {
+			return nil, &ValidationError{Message: "the version to compare to needs a diagram ID, a snapshot or D2 content"}
+		}
+		to.DiagramID = from.DiagramID
+	}
+	if from.Snapshot != "" && from.DiagramID == "" {
+		return nil, &ValidationError{Message: "a snapshot to compare from needs a diagram ID"}
+	}
+	if to.Snapshot != "" && to.DiagramID == "" {
+		return nil, &ValidationError{Message: "a snapshot to compare to needs a diagram ID"}
+	}
+
+	before, err := uc.graph(ctx, from)
+	if err != nil {
+		return nil, err
+	}
+	after, err := uc.graph(ctx, to)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := diffGraphs(before, after)
+	diffBoards(diff, nil, before, after)
+	diff.From = from
+	diff.To = to
+	return diff, nil
+}
+
+// graph loads the objects and edges of a diagram version.
+func (uc *DiffUseCase) graph(ctx context.Context, version entity.DiagramVersion) (*entity.DiagramGraph, error) {
+	switch {
+	case version.DiagramID == "":
+		return uc.repo.ParseGraph(ctx, version.Content)
+	case version.Snapshot != "":
+		snapshot, err := uc.snapshots.GetSnapshot(ctx, version.DiagramID, version.Snapshot)
+		if err != nil {
+			return nil, err
+		}
+		return uc.repo.ParseGraph(ctx, snapshot.Content)
+	default:
+		return uc.repo.GetGraph(ctx, version.DiagramID)
+	}
+}
+
+// diffGraphs compares two graphs. Objects and edges are matched by ID, and the
+// results are sorted by ID.
+func diffGraphs(before, after *entity.DiagramGraph) *entity.DiagramDiff {
+	diff := &entity.DiagramDiff{}
+
+	for _, id := range sortedKeys(before.Objects) {
+		old := before.Objects[id]
+		current, exists := after.Objects[id]
+		if !exists {
+			diff.RemovedObjects = append(diff.RemovedObjects, old)
+			continue
+		}
+		if changes := diffAttributes(objectAttributes(old), objectAttributes(current)); len(changes) > 0 {
+			diff.ChangedObjects = append(diff.ChangedObjects, &entity.ElementChange{ID: id, Attributes: changes})
+		}
+	}
+	for _, id := range sortedKeys(after.Objects) {
+		if _, exists := before.Objects[id]; !exists {
+			diff.AddedObjects = append(diff.AddedObjects, after.Objects[id])
+		}
+	}
+
+	for _, id := range sortedKeys(before.Edges) {
+		old := before.Edges[id]
+		current, exists := after.Edges[id]
+		if !exists {
+			diff.RemovedEdges = append(diff.RemovedEdges, old)
+			continue
+		}
+		if changes := diffAttributes(edgeAttributes(old), edgeAttributes(current)); len(changes) > 0 {
+			diff.ChangedEdges = append(diff.ChangedEdges, &entity.ElementChange{ID: id, Attributes: changes})
+		}
+	}
+	for _, id := range sortedKeys(after.Edges) {
+		if _, exists := before.Edges[id]; !exists {
+			diff.AddedEdges = append(diff.AddedEdges, after.Edges[id])
+		}
+	}
+
+	return diff
+}
+
+// diffBoards adds the boards nested in two graphs to diff, recursively. Boards are
+// matched by name. Changes a scenario or step only inherits from the board it
+// builds on are left out, since they are listed for that board.
+func diffBoards(diff *entity.DiagramDiff, boardPath []string, before, after *entity.DiagramGraph) {
+	parentBefore, parentAfter := before, after
+	for _, board := range boardNames(before, after) {
+		path := append(append([]string{}, boardPath...), board.Name)
+		beforeBoard := findBoard(before, board.Name)
+		afterBoard := findBoard(after, board.Name)
+
+		switch {
+		case afterBoard == nil:
+			diff.RemovedBoards = append(diff.RemovedBoards, &entity.Board{Name: board.Name, Kind: board.Kind, Path: path})
+			continue
+		case beforeBoard == nil:
+			diff.AddedBoards = append(diff.AddedBoards, &entity.Board{Name: board.Name, Kind: board.Kind, Path: path})
+			continue
+		}
+
+		changes := diffGraphs(beforeBoard, afterBoard)
+		if board.Kind != entity.BoardLayer {
+			changes = withoutInherited(changes, diffGraphs(parentBefore, parentAfter))
+		}
+		if !changes.Empty() {
+			diff.Boards = append(diff.Boards, &entity.BoardDiff{Path: path, Kind: board.Kind, Diff: changes})
+		}
+		diffBoards(diff, path, beforeBoard, afterBoard)
+
+		if board.Kind == entity.BoardStep {
+			parentBefore, parentAfter = beforeBoard, afterBoard
+		}
+	}
+}
+
+// objectAttributes flattens an object into attribute values keyed by name.
+func objectAttributes(obj *entity.GraphObject) map[string]interface{} {
+	attributes := map[string]interface{}{}
+	for name, value := range obj.Attributes {
+		attributes[name] = value
+	}
+	setAttribute(attributes, "label", obj.Label)
+	setAttribute(attributes, "shape", obj.Shape)
+	setAttribute(attributes, "parent", obj.Parent)
+	return attributes
+}
+
+// edgeAttributes flattens an edge into attribute values keyed by name.
+func edgeAttributes(edge *entity.GraphEdge) map[string]interface{} {
+	attributes := map[string]interface{}{}
+	for name, value := range edge.Attributes {
+		attributes[name] = value
+	}
+	setAttribute(attributes, "label", edge.Label)
+	return attributes
+}
+
+// setAttribute sets a string attribute, leaving it unset when the value is empty.
+func setAttribute(attributes map[string]interface{}, name, value string) {
+	if value != "" {
+		attributes[name] = value
+	}
+}
+
+// diffAttributes lists the attributes whose values differ, sorted by name.
+func diffAttributes(before, after map[string]interface{}) []*entity.AttributeChange {
+	names := make(map[string]bool, len(before)+len(after))
+	for name := range before {
+		names[name] = true
+	}
+	for name := range after {
+		names[name] = true
+	}
+
+	var changes []*entity.AttributeChange
+	for _, name := range sortedKeys(names) {
+		if !reflect.DeepEqual(before[name], after[name]) {
+			changes = append(changes, &entity.AttributeChange{
+				Name:   name,
+				Before: before[name],
+				After:  after[name],
+			})
+		}
+	}
+	return changes
+}
+
+// sortedKeys returns the keys of a map in order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,252 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/i2y/d2mcp/internal/domain/entity"
+)
+
+// mockSnapshotRepository is a mock implementation of SnapshotRepository
+type mockSnapshotRepository struct {
+	snapshots map[string]*entity.Snapshot
+}
+
+func (m *mockSnapshotRepository) CreateSnapshot(ctx context.Context, diagramID, name string) (*entity.Snapshot, error) {
+	return &entity.Snapshot{DiagramID: diagramID, Name: name}, nil
+}
+
+func (m *mockSnapshotRepository) ListSnapshots(ctx context.Context, diagramID string) ([]*entity.Snapshot, error) {
+	return nil, nil
+}
+
+func (m *mockSnapshotRepository) GetSnapshot(ctx context.Context, diagramID, name string) (*entity.Snapshot, error) {
+	snapshot, exists := m.snapshots[name]
+	if !exists {
+		return nil, errors.New("snapshot not found")
+	}
+	return snapshot, nil
+}
+
+func (m *mockSnapshotRepository) RestoreSnapshot(ctx context.Context, diagramID, name string) (*entity.Snapshot, error) {
+	return m.GetSnapshot(ctx, diagramID, name)
+}
+
+func (m *mockSnapshotRepository) DeleteSnapshot(ctx context.Context, diagramID, name string) error {
+	return nil
+}
+
+func TestDiffGraphs(t *testing.T) {
+	before := &entity.DiagramGraph{
+		Objects: map[string]*entity.GraphObject{
+			"web":   {ID: "web", Label: "web", Attributes: map[string]interface{}{"label": "web"}},
+			"api":   {ID: "api", Label: "API", Attributes: map[string]interface{}{"label": "API", "fill": "#fff"}},
+			"cache": {ID: "cache", Label: "cache", Attributes: map[string]interface{}{}},
+		},
+		Edges: map[string]*entity.GraphEdge{
+			"(web -> api)[0]":   {ID: "(web -> api)[0]", From: "web", To: "api", Attributes: map[string]interface{}{"dstArrow": true}},
+			"(api -> cache)[0]": {ID: "(api -> cache)[0]", From: "api", To: "cache", Attributes: map[string]interface{}{}},
+		},
+	}
+	after := &entity.DiagramGraph{
+		Objects: map[string]*entity.GraphObject{
+			"web": {ID: "web", Label: "web", Attributes: map[string]interface{}{"label": "web"}},
+			"api": {ID: "api", Label: "Gateway", Shape: "hexagon", Attributes: map[string]interface{}{"label": "Gateway", "shape": "hexagon"}},
+			"db":  {ID: "db", Label: "db", Shape: "cylinder", Attributes: map[string]interface{}{}},
+		},
+		Edges: map[string]*entity.GraphEdge{
+			"(web -> api)[0]": {ID: "(web -> api)[0]", From: "web", To: "api", Label: "HTTPS", Attributes: map[string]interface{}{"dstArrow": true, "label": "HTTPS"}},
+			"(api -> db)[0]":  {ID: "(api -> db)[0]", From: "api", To: "db", Attributes: map[string]interface{}{}},
+		},
+	}
+
+	diff := diffGraphs(before, after)
+
+	if len(diff.AddedObjects) != 1 || diff.AddedObjects[0].ID != "db" {
+		t.Errorf("AddedObjects = %v", diff.AddedObjects)
+	}
+	if len(diff.RemovedObjects) != 1 || diff.RemovedObjects[0].ID != "cache" {
+		t.Errorf("RemovedObjects = %v", diff.RemovedObjects)
+	}
+	if len(diff.ChangedObjects) != 1 || diff.ChangedObjects[0].ID != "api" {
+		t.Fatalf("ChangedObjects = %v", diff.ChangedObjects)
+	}
+
+	want := []entity.AttributeChange{
+		{Name: "fill", Before: "#fff", After: nil},
+		{Name: "label", Before: "API", After: "Gateway"},
+		{Name: "shape", Before: nil, After: "hexagon"},
+	}
+	changes := diff.ChangedObjects[0].Attributes
+	if len(changes) != len(want) {
+		t.Fatalf("api changes = %d, want %d", len(changes), len(want))
+	}
+	for i, change := range changes {
+		if *change != want[i] {
+			t.Errorf("api change %d = %+v, want %+v", i, *change, want[i])
+		}
+	}
+
+	if len(diff.AddedEdges) != 1 || diff.AddedEdges[0].ID != "(api -> db)[0]" {
+		t.Errorf("AddedEdges = %v", diff.AddedEdges)
+	}
+	if len(diff.RemovedEdges) != 1 || diff.RemovedEdges[0].ID != "(api -> cache)[0]" {
+		t.Errorf("RemovedEdges = %v", diff.RemovedEdges)
+	}
+	if len(diff.ChangedEdges) != 1 || len(diff.ChangedEdges[0].Attributes) != 1 || diff.ChangedEdges[0].Attributes[0].After != "HTTPS" {
+		t.Errorf("ChangedEdges = %v", diff.ChangedEdges)
+	}
+
+	if !diffGraphs(after, after).Empty() {
+		t.Error("diffGraphs() of a graph with itself should be empty")
+	}
+}
+
+func TestDiffUseCase_Diff(t *testing.T) {
+	current := &entity.DiagramGraph{Objects: map[string]*entity.GraphObject{
+		"a": {ID: "a", Label: "a", Attributes: map[string]interface{}{}},
+	}}
+
+	tests := []struct {
+		name    string
+		from    entity.DiagramVersion
+		to      entity.DiagramVersion
+		wantTo  entity.DiagramVersion
+		wantErr string
+	}{
+		{
+			name:   "snapshot to current state",
+			from:   entity.DiagramVersion{DiagramID: "test-diagram", Snapshot: "before"},
+			wantTo: entity.DiagramVersion{DiagramID: "test-diagram"},
+		},
+		{
+			name:   "two snapshots of one diagram",
+			from:   entity.DiagramVersion{DiagramID: "test-diagram", Snapshot: "before"},
+			to:     entity.DiagramVersion{Snapshot: "before"},
+			wantTo: entity.DiagramVersion{DiagramID: "test-diagram", Snapshot: "before"},
+		},
+		{
+			name:   "diagram to D2 content",
+			from:   entity.DiagramVersion{DiagramID: "test-diagram"},
+			to:     entity.DiagramVersion{Content: "a -> b"},
+			wantTo: entity.DiagramVersion{Content: "a -> b"},
+		},
+		{
+			name:    "missing from version",
+			to:      entity.DiagramVersion{DiagramID: "test-diagram"},
+			wantErr: "the version to compare from needs a diagram ID or D2 content",
+		},
+		{
+			name:    "diagram with itself",
+			from:    entity.DiagramVersion{DiagramID: "test-diagram"},
+			wantErr: "the version to compare to needs a diagram ID, a snapshot or D2 content",
+		},
+		{
+			name:    "snapshot of D2 content",
+			from:    entity.DiagramVersion{Content: "a"},
+			to:      entity.DiagramVersion{Snapshot: "before"},
+			wantErr: "a snapshot to compare to needs a diagram ID",
+		},
+		{
+			name:    "missing snapshot",
+			from:    entity.DiagramVersion{DiagramID: "test-diagram", Snapshot: "missing"},
+			wantErr: "snapshot not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mockOracleRepository{graphs: map[string]*entity.DiagramGraph{"test-diagram": current}}
+			snapshots := &mockSnapshotRepository{snapshots: map[string]*entity.Snapshot{
+				"before": {DiagramID: "test-diagram", Name: "before", Content: "a"},
+			}}
+			uc := NewDiffUseCase(repo, snapshots)
+
+			diff, err := uc.Diff(context.Background(), tt.from, tt.to)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("Diff() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Diff() error = %v", err)
+			}
+			if diff.From != tt.from || diff.To != tt.wantTo {
+				t.Errorf("Diff() versions = %+v, %+v", diff.From, diff.To)
+			}
+		})
+	}
+}
+
+func TestDiffBoards(t *testing.T) {
+	object := func(id string, attributes map[string]interface{}) *entity.GraphObject {
+		if attributes == nil {
+			attributes = map[string]interface{}{}
+		}
+		return &entity.GraphObject{ID: id, Label: id, Attributes: attributes}
+	}
+	graph := func(objects []*entity.GraphObject, boards ...*entity.BoardGraph) *entity.DiagramGraph {
+		g := &entity.DiagramGraph{Objects: map[string]*entity.GraphObject{}, Edges: map[string]*entity.GraphEdge{}, Boards: boards}
+		for _, obj := range objects {
+			g.Objects[obj.ID] = obj
+		}
+		return g
+	}
+
+	// The root board gains api, which the scenario inherits. The net layer gains
+	// a firewall and its nested details layer changes the router's shape; the
+	// old layer is removed and the new layer is added.
+	before := graph(
+		[]*entity.GraphObject{object("web", nil)},
+		&entity.BoardGraph{Name: "net", Kind: entity.BoardLayer, Graph: graph(
+			[]*entity.GraphObject{object("router", nil)},
+			&entity.BoardGraph{Name: "details", Kind: entity.BoardLayer, Graph: graph([]*entity.GraphObject{object("router", nil)})},
+		)},
+		&entity.BoardGraph{Name: "old", Kind: entity.BoardLayer, Graph: graph(nil)},
+		&entity.BoardGraph{Name: "outage", Kind: entity.BoardScenario, Graph: graph([]*entity.GraphObject{object("web", nil)})},
+	)
+	after := graph(
+		[]*entity.GraphObject{object("web", nil), object("api", nil)},
+		&entity.BoardGraph{Name: "net", Kind: entity.BoardLayer, Graph: graph(
+			[]*entity.GraphObject{object("router", nil), object("firewall", nil)},
+			&entity.BoardGraph{Name: "details", Kind: entity.BoardLayer, Graph: graph([]*entity.GraphObject{object("router", map[string]interface{}{"shape": "hexagon"})})},
+		)},
+		&entity.BoardGraph{Name: "outage", Kind: entity.BoardScenario, Graph: graph([]*entity.GraphObject{object("web", nil), object("api", nil)})},
+		&entity.BoardGraph{Name: "new", Kind: entity.BoardLayer, Graph: graph(nil)},
+	)
+
+	diff := diffGraphs(before, after)
+	diffBoards(diff, nil, before, after)
+
+	if len(diff.AddedObjects) != 1 || diff.AddedObjects[0].ID != "api" {
+		t.Errorf("AddedObjects = %v", diff.AddedObjects)
+	}
+	if len(diff.AddedBoards) != 1 || strings.Join(diff.AddedBoards[0].Path, ".") != "new" {
+		t.Errorf("AddedBoards = %v", diff.AddedBoards)
+	}
+	if len(diff.RemovedBoards) != 1 || strings.Join(diff.RemovedBoards[0].Path, ".") != "old" || diff.RemovedBoards[0].Kind != entity.BoardLayer {
+		t.Errorf("RemovedBoards = %v", diff.RemovedBoards)
+	}
+
+	// The scenario only inherits api, so it has no changes of its own.
+	if len(diff.Boards) != 2 {
+		t.Fatalf("Boards = %d, want 2", len(diff.Boards))
+	}
+	net := diff.Boards[0]
+	if strings.Join(net.Path, ".") != "net" || len(net.Diff.AddedObjects) != 1 || net.Diff.AddedObjects[0].ID != "firewall" {
+		t.Errorf("Boards[0] = %v %+v", net.Path, net.Diff)
+	}
+	details := diff.Boards[1]
+	if strings.Join(details.Path, ".") != "net.details" || len(details.Diff.ChangedObjects) != 1 || details.Diff.ChangedObjects[0].ID != "router" {
+		t.Errorf("Boards[1] = %v %+v", details.Path, details.Diff)
+	}
+
+	unchanged := diffGraphs(after, after)
+	diffBoards(unchanged, nil, after, after)
+	if !unchanged.Empty() {
+		t.Error("diffBoards() of a graph with itself should be empty")
+	}
+}
@@ -129,6 +129,27 @@ func (uc *OracleUseCase) LoadDiagram(ctx context.Context, diagramID string, cont
 	return uc.repo.LoadDiagram(ctx, diagramID, content)
 }
 
+// LoadFile loads a diagram from a D2 file, resolving its relative imports within root
+func (uc *OracleUseCase) LoadFile(ctx context.Context, diagramID, path, root string) error {
+	if diagramID == "" {
+		return &ValidationError{Message: "diagram ID is required"}
+	}
+	if path == "" {
+		return &ValidationError{Message: "file path is required"}
+	}
+
+	return uc.repo.LoadFile(ctx, diagramID, path, root)
+}
+
+// GetGraph retrieves the objects and edges of a diagram's root board
+func (uc *OracleUseCase) GetGraph(ctx context.Context, diagramID string) (*entity.DiagramGraph, error) {
+	if diagramID == "" {
+		return nil, &ValidationError{Message: "diagram ID is required"}
+	}
+
+	return uc.repo.GetGraph(ctx, diagramID)
+}
+
 // SerializeDiagram converts the current graph state back to D2 text
 func (uc *OracleUseCase) SerializeDiagram(ctx context.Context, diagramID string) (string, error) {
 	if diagramID == "" {
@@ -138,6 +159,15 @@ func (uc *OracleUseCase) SerializeDiagram(ctx context.Context, diagramID string)
 	return uc.repo.SerializeDiagram(ctx, diagramID)
 }
 
+// SerializeBoard converts a single layer, scenario or step board back to D2 text
+func (uc *OracleUseCase) SerializeBoard(ctx context.Context, diagramID string, boardPath []string) (string, error) {
+	if diagramID == "" {
+		return "", &ValidationError{Message: "diagram ID is required"}
+	}
+
+	return uc.repo.SerializeBoard(ctx, diagramID, boardPath)
+}
+
 // ExecuteOperation executes a single Oracle operation based on its type
 func (uc *OracleUseCase) ExecuteOperation(ctx context.Context, op *entity.OracleOperation) (*entity.OracleResult, error) {
 	switch op.Type {
@@ -155,3 +185,68 @@ func (uc *OracleUseCase) ExecuteOperation(ctx context.Context, op *entity.Oracle
 		return nil, fmt.Errorf("unknown operation type: %s", op.Type)
 	}
 }
+
+// ExecuteBatch applies operations to a diagram in order as a single change. Either
+// all operations are applied or none are.
+func (uc *OracleUseCase) ExecuteBatch(ctx context.Context, diagramID string, ops []entity.OracleOperation) ([]*entity.OracleResult, error) {
+	if diagramID == "" {
+		return nil, &ValidationError{Message: "diagram ID is required"}
+	}
+	if len(ops) == 0 {
+		return nil, &ValidationError{Message: "at least one operation is required"}
+	}
+
+	for i := range ops {
+		ops[i].DiagramID = diagramID
+		if err := validateOperation(&ops[i]); err != nil {
+			return nil, &ValidationError{Message: fmt.Sprintf("operation %d: %s", i+1, err.Error())}
+		}
+	}
+
+	return uc.repo.ExecuteBatch(ctx, diagramID, ops)
+}
+
+// Undo reverts the most recent changes to a diagram
+func (uc *OracleUseCase) Undo(ctx context.Context, diagramID string, steps int) (*entity.HistoryResult, error) {
+	if diagramID == "" {
+		return nil, &ValidationError{Message: "diagram ID is required"}
+	}
+	if steps <= 0 {
+		return nil, &ValidationError{Message: "steps must be greater than zero"}
+	}
+
+	return uc.repo.Undo(ctx, diagramID, steps)
+}
+
+// Redo reapplies the most recently undone changes to a diagram
+func (uc *OracleUseCase) Redo(ctx context.Context, diagramID string, steps int) (*entity.HistoryResult, error) {
+	if diagramID == "" {
+		return nil, &ValidationError{Message: "diagram ID is required"}
+	}
+	if steps <= 0 {
+		return nil, &ValidationError{Message: "steps must be greater than zero"}
+	}
+
+	return uc.repo.Redo(ctx, diagramID, steps)
+}
+
+// validateOperation checks the fields an operation of its type needs.
+func validateOperation(op *entity.OracleOperation) error {
+	switch op.Type {
+	case entity.OracleCreate, entity.OracleSet, entity.OracleDelete:
+	case entity.OracleMove:
+		if op.NewKey == nil || *op.NewKey == "" {
+			return &ValidationError{Message: "new key is required"}
+		}
+	case entity.OracleRename:
+		if op.NewKey == nil || *op.NewKey == "" {
+			return &ValidationError{Message: "new name is required"}
+		}
+	default:
+		return &ValidationError{Message: fmt.Sprintf("unknown operation type: %s", op.Type)}
+	}
+	if op.Key == "" {
+		return &ValidationError{Message: "element key is required"}
+	}
+	return nil
+}
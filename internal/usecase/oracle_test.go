@@ -14,6 +14,7 @@ type mockOracleRepository struct {
 	// Control behavior
 	shouldFail bool
 	failMsg    string
+	graphs     map[string]*entity.DiagramGraph // Returned by GetGraph when set
 
 	// Track calls
 	createElementCalled bool
@@ -25,7 +26,9 @@ type mockOracleRepository struct {
 	getEdgeCalled       bool
 	getChildrenCalled   bool
 	loadDiagramCalled   bool
+	loadFileCalled      bool
 	serializeCalled     bool
+	executeBatchCalled  bool
 
 	// Mock data
 	mockObject   *entity.GraphObject
@@ -33,7 +36,7 @@ type mockOracleRepository struct {
 	mockChildren []string
 }
 
-func (m *mockOracleRepository) Render(ctx context.Context, content string, format entity.ExportFormat, theme *entity.Theme) (io.Reader, error) {
+func (m *mockOracleRepository) Render(ctx context.Context, content string, format entity.ExportFormat, opts *entity.RenderOptions) (io.Reader, error) {
 	return nil, nil
 }
 
@@ -41,10 +44,26 @@ func (m *mockOracleRepository) Create(ctx context.Context, diagram *entity.Diagr
 	return nil
 }
 
-func (m *mockOracleRepository) Export(ctx context.Context, diagramID string, format entity.ExportFormat) (io.Reader, error) {
+func (m *mockOracleRepository) Export(ctx context.Context, diagramID string, format entity.ExportFormat, opts *entity.RenderOptions) (io.Reader, error) {
 	return nil, nil
 }
 
+func (m *mockOracleRepository) ListDiagrams(ctx context.Context) ([]*entity.DiagramInfo, error) {
+	return nil, nil
+}
+
+func (m *mockOracleRepository) DeleteDiagram(ctx context.Context, diagramID string) error {
+	return nil
+}
+
+func (m *mockOracleRepository) CloneDiagram(ctx context.Context, diagramID, newID string) error {
+	return nil
+}
+
+func (m *mockOracleRepository) RenameDiagram(ctx context.Context, diagramID, newID string) error {
+	return nil
+}
+
 func (m *mockOracleRepository) CreateElement(ctx context.Context, diagramID string, boardPath []string, key string) (*entity.OracleResult, error) {
 	m.createElementCalled = true
 	if m.shouldFail {
@@ -137,6 +156,49 @@ func (m *mockOracleRepository) GetChildren(ctx context.Context, diagramID string
 	return []string{"child1", "child2"}, nil
 }
 
+func (m *mockOracleRepository) ExecuteBatch(ctx context.Context, diagramID string, ops []entity.OracleOperation) ([]*entity.OracleResult, error) {
+	m.executeBatchCalled = true
+	if m.shouldFail {
+		return nil, errors.New(m.failMsg)
+	}
+	results := make([]*entity.OracleResult, len(ops))
+	for i := range ops {
+		results[i] = &entity.OracleResult{Success: true, NewKey: ops[i].Key}
+	}
+	return results, nil
+}
+
+func (m *mockOracleRepository) Undo(ctx context.Context, diagramID string, steps int) (*entity.HistoryResult, error) {
+	if m.shouldFail {
+		return nil, errors.New(m.failMsg)
+	}
+	return &entity.HistoryResult{Changes: make([]string, steps)}, nil
+}
+
+func (m *mockOracleRepository) Redo(ctx context.Context, diagramID string, steps int) (*entity.HistoryResult, error) {
+	if m.shouldFail {
+		return nil, errors.New(m.failMsg)
+	}
+	return &entity.HistoryResult{Changes: make([]string, steps)}, nil
+}
+
+func (m *mockOracleRepository) ParseGraph(ctx context.Context, content string) (*entity.DiagramGraph, error) {
+	if m.shouldFail {
+		return nil, errors.New(m.failMsg)
+	}
+	return &entity.DiagramGraph{Content: content}, nil
+}
+
+func (m *mockOracleRepository) GetGraph(ctx context.Context, diagramID string) (*entity.DiagramGraph, error) {
+	if m.shouldFail {
+		return nil, errors.New(m.failMsg)
+	}
+	if graph, exists := m.graphs[diagramID]; exists {
+		return graph, nil
+	}
+	return &entity.DiagramGraph{ID: diagramID}, nil
+}
+
 func (m *mockOracleRepository) LoadDiagram(ctx context.Context, diagramID string, content string) error {
 	m.loadDiagramCalled = true
 	if m.shouldFail {
@@ -145,6 +207,14 @@ func (m *mockOracleRepository) LoadDiagram(ctx context.Context, diagramID string
 	return nil
 }
 
+func (m *mockOracleRepository) LoadFile(ctx context.Context, diagramID, path, root string) error {
+	m.loadFileCalled = true
+	if m.shouldFail {
+		return errors.New(m.failMsg)
+	}
+	return nil
+}
+
 func (m *mockOracleRepository) SerializeDiagram(ctx context.Context, diagramID string) (string, error) {
 	m.serializeCalled = true
 	if m.shouldFail {
@@ -153,6 +223,14 @@ func (m *mockOracleRepository) SerializeDiagram(ctx context.Context, diagramID s
 	return "serialized content", nil
 }
 
+func (m *mockOracleRepository) SerializeBoard(ctx context.Context, diagramID string, boardPath []string) (string, error) {
+	m.serializeCalled = true
+	if m.shouldFail {
+		return "", errors.New(m.failMsg)
+	}
+	return "serialized board", nil
+}
+
 func TestOracleUseCase_CreateElement(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -446,6 +524,147 @@ func TestOracleUseCase_ExecuteOperation(t *testing.T) {
 	}
 }
 
+func TestOracleUseCase_ExecuteBatch(t *testing.T) {
+	newName := "backend"
+
+	tests := []struct {
+		name      string
+		diagramID string
+		ops       []entity.OracleOperation
+		wantErr   bool
+		errMsg    string
+	}{
+		{
+			name:      "valid batch",
+			diagramID: "test-diagram",
+			ops: []entity.OracleOperation{
+				{Type: entity.OracleCreate, Key: "server"},
+				{Type: entity.OracleRename, Key: "server", NewKey: &newName},
+			},
+			wantErr: false,
+		},
+		{
+			name:      "missing diagram ID",
+			diagramID: "",
+			ops:       []entity.OracleOperation{{Type: entity.OracleCreate, Key: "server"}},
+			wantErr:   true,
+			errMsg:    "diagram ID is required",
+		},
+		{
+			name:      "no operations",
+			diagramID: "test-diagram",
+			wantErr:   true,
+			errMsg:    "at least one operation is required",
+		},
+		{
+			name:      "missing key",
+			diagramID: "test-diagram",
+			ops: []entity.OracleOperation{
+				{Type: entity.OracleCreate, Key: "server"},
+				{Type: entity.OracleDelete},
+			},
+			wantErr: true,
+			errMsg:  "operation 2: element key is required",
+		},
+		{
+			name:      "rename without new name",
+			diagramID: "test-diagram",
+			ops:       []entity.OracleOperation{{Type: entity.OracleRename, Key: "server"}},
+			wantErr:   true,
+			errMsg:    "operation 1: new name is required",
+		},
+		{
+			name:      "unknown operation type",
+			diagramID: "test-diagram",
+			ops:       []entity.OracleOperation{{Type: "copy", Key: "server"}},
+			wantErr:   true,
+			errMsg:    "operation 1: unknown operation type: copy",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := &mockOracleRepository{}
+			uc := NewOracleUseCase(mockRepo)
+
+			results, err := uc.ExecuteBatch(context.Background(), tt.diagramID, tt.ops)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ExecuteBatch() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				if err.Error() != tt.errMsg {
+					t.Errorf("ExecuteBatch() error = %v, want %v", err, tt.errMsg)
+				}
+				if mockRepo.executeBatchCalled {
+					t.Error("ExecuteBatch() called the repository for an invalid batch")
+				}
+				return
+			}
+			if len(results) != len(tt.ops) {
+				t.Errorf("ExecuteBatch() returned %d results, want %d", len(results), len(tt.ops))
+			}
+		})
+	}
+}
+
+func TestOracleUseCase_UndoRedo(t *testing.T) {
+	tests := []struct {
+		name      string
+		diagramID string
+		steps     int
+		wantErr   bool
+		errMsg    string
+	}{
+		{
+			name:      "valid steps",
+			diagramID: "test-diagram",
+			steps:     2,
+			wantErr:   false,
+		},
+		{
+			name:      "missing diagram ID",
+			diagramID: "",
+			steps:     1,
+			wantErr:   true,
+			errMsg:    "diagram ID is required",
+		},
+		{
+			name:      "zero steps",
+			diagramID: "test-diagram",
+			steps:     0,
+			wantErr:   true,
+			errMsg:    "steps must be greater than zero",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc := NewOracleUseCase(&mockOracleRepository{})
+
+			for name, call := range map[string]func(context.Context, string, int) (*entity.HistoryResult, error){
+				"Undo": uc.Undo,
+				"Redo": uc.Redo,
+			} {
+				result, err := call(context.Background(), tt.diagramID, tt.steps)
+				if (err != nil) != tt.wantErr {
+					t.Errorf("%s() error = %v, wantErr %v", name, err, tt.wantErr)
+					continue
+				}
+				if tt.wantErr {
+					if err.Error() != tt.errMsg {
+						t.Errorf("%s() error = %v, want %v", name, err, tt.errMsg)
+					}
+					continue
+				}
+				if len(result.Changes) != tt.steps {
+					t.Errorf("%s() returned %d changes, want %d", name, len(result.Changes), tt.steps)
+				}
+			}
+		})
+	}
+}
+
 func TestOracleUseCase_LoadAndSerialize(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -488,6 +707,33 @@ func TestOracleUseCase_LoadAndSerialize(t *testing.T) {
 		})
 	}
 
+	// Test LoadFile
+	fileTests := []struct {
+		name      string
+		diagramID string
+		path      string
+		wantErr   bool
+	}{
+		{name: "valid file", diagramID: "test", path: "docs/arch.d2"},
+		{name: "empty diagram ID", diagramID: "", path: "docs/arch.d2", wantErr: true},
+		{name: "empty path", diagramID: "test", path: "", wantErr: true},
+	}
+
+	for _, tt := range fileTests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := &mockOracleRepository{}
+			uc := NewOracleUseCase(mockRepo)
+
+			err := uc.LoadFile(context.Background(), tt.diagramID, tt.path, "")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("LoadFile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !mockRepo.loadFileCalled {
+				t.Error("LoadFile() repository method not called")
+			}
+		})
+	}
+
 	// Test SerializeDiagram
 	t.Run("serialize diagram", func(t *testing.T) {
 		mockRepo := &mockOracleRepository{}
@@ -0,0 +1,65 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/i2y/d2mcp/internal/domain/entity"
+	"github.com/i2y/d2mcp/internal/domain/repository"
+)
+
+// SnapshotUseCase implements business logic for diagram snapshots
+type SnapshotUseCase struct {
+	repo repository.SnapshotRepository
+}
+
+// NewSnapshotUseCase creates a new snapshot use case
+func NewSnapshotUseCase(repo repository.SnapshotRepository) *SnapshotUseCase {
+	return &SnapshotUseCase{repo: repo}
+}
+
+// CreateSnapshot saves the current state of a diagram under a name
+func (uc *SnapshotUseCase) CreateSnapshot(ctx context.Context, diagramID, name string) (*entity.Snapshot, error) {
+	if err := validateSnapshot(diagramID, name); err != nil {
+		return nil, err
+	}
+
+	return uc.repo.CreateSnapshot(ctx, diagramID, name)
+}
+
+// ListSnapshots returns the snapshots of a diagram
+func (uc *SnapshotUseCase) ListSnapshots(ctx context.Context, diagramID string) ([]*entity.Snapshot, error) {
+	if diagramID == "" {
+		return nil, &ValidationError{Message: "diagram ID is required"}
+	}
+
+	return uc.repo.ListSnapshots(ctx, diagramID)
+}
+
+// RestoreSnapshot reverts a diagram to a snapshot
+func (uc *SnapshotUseCase) RestoreSnapshot(ctx context.Context, diagramID, name string) (*entity.Snapshot, error) {
+	if err := validateSnapshot(diagramID, name); err != nil {
+		return nil, err
+	}
+
+	return uc.repo.RestoreSnapshot(ctx, diagramID, name)
+}
+
+// DeleteSnapshot removes a snapshot
+func (uc *SnapshotUseCase) DeleteSnapshot(ctx context.Context, diagramID, name string) error {
+	if err := validateSnapshot(diagramID, name); err != nil {
+		return err
+	}
+
+	return uc.repo.DeleteSnapshot(ctx, diagramID, name)
+}
+
+// validateSnapshot checks the arguments that name a snapshot.
+func validateSnapshot(diagramID, name string) error {
+	if diagramID == "" {
+		return &ValidationError{Message: "diagram ID is required"}
+	}
+	if name == "" {
+		return &ValidationError{Message: "snapshot name is required"}
+	}
+	return nil
+}